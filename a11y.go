@@ -0,0 +1,245 @@
+package htmlutil
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// A11yRule identifies an accessibility check of AuditAccessibility().
+type A11yRule string
+
+const (
+	// A11yImageAlt flags img elements without an alt attribute that are not
+	// marked as decorative.
+	A11yImageAlt A11yRule = "image-alt"
+
+	// A11yFieldLabel flags form fields without a label.
+	A11yFieldLabel A11yRule = "field-label"
+
+	// A11yControlName flags buttons and links without accessible text.
+	A11yControlName A11yRule = "control-name"
+
+	// A11yTableHeaders flags tables without th cells.
+	A11yTableHeaders A11yRule = "table-headers"
+
+	// A11yPositiveTabindex flags elements with a positive tabindex, which
+	// overrides the natural tab order.
+	A11yPositiveTabindex A11yRule = "positive-tabindex"
+)
+
+// A11yIssue is an accessibility problem found by AuditAccessibility().
+type A11yIssue struct {
+	Rule    A11yRule
+	Message string
+	Node    *html.Node
+}
+
+// AuditAccessibility runs all the accessibility checks on the provided
+// document and returns the issues found, grouped by rule in the order of the
+// rule constants, and in document order within a rule. Like the checks
+// themselves, it skips the content of template elements, which isn't
+// rendered.
+func AuditAccessibility(doc *html.Node) []A11yIssue {
+	issues := []A11yIssue{}
+
+	add := func(rule A11yRule, message string, nodes []*html.Node) {
+		for _, n := range nodes {
+			issues = append(issues, A11yIssue{Rule: rule, Message: message, Node: n})
+		}
+	}
+
+	add(A11yImageAlt, "image has no alt attribute", ImagesMissingAlt(doc))
+	add(A11yFieldLabel, "form field has no label", FieldsMissingLabel(doc))
+	add(A11yControlName, "button or link has no accessible text", ControlsMissingName(doc))
+	add(A11yTableHeaders, "table has no header cells", TablesMissingHeaders(doc))
+	add(A11yPositiveTabindex, "element has a positive tabindex", PositiveTabindexElements(doc))
+
+	return issues
+}
+
+// ImagesMissingAlt returns the img elements within the provided document that
+// have no alt attribute. Images with an empty alt attribute, with a role of
+// "presentation" or "none", hidden with aria-hidden="true", or named by
+// aria-label or aria-labelledby are not returned.
+func ImagesMissingAlt(doc *html.Node) []*html.Node {
+	a := newA11yAuditor(doc)
+	return renderedHtmlNodesFunc(doc, func(n *html.Node) bool {
+		if !isHtmlElement(n, "img") {
+			return false
+		}
+		if _, ok := GetAttr(n, "alt"); ok {
+			return false
+		}
+		return !isDecorative(n) && !a.hasAriaName(n)
+	})
+}
+
+// FieldsMissingLabel returns the input, select, and textarea elements within
+// the provided document that have no label, either a label element wrapping
+// them or referencing their id with its for attribute, or an aria-label,
+// aria-labelledby, or title attribute. Hidden inputs and inputs labeled by
+// their own value or alt, such as submit buttons, are not returned.
+func FieldsMissingLabel(doc *html.Node) []*html.Node {
+	a := newA11yAuditor(doc)
+	return renderedHtmlNodesFunc(doc, func(n *html.Node) bool {
+		if !isHtmlElement(n, "input", "select", "textarea") {
+			return false
+		}
+		if n.Data == "input" {
+			switch strings.ToLower(GetAttrOr(n, "type", "text")) {
+			case "hidden", "submit", "reset", "button", "image":
+				return false
+			}
+		}
+
+		if id := GetAttrOr(n, "id", ""); id != "" && a.labelFor[id] {
+			return false
+		}
+		if Closest(n.Parent, "label", "", "") != nil {
+			return false
+		}
+		return !a.hasAriaName(n) && strings.TrimSpace(GetAttrOr(n, "title", "")) == ""
+	})
+}
+
+// ControlsMissingName returns the button elements, links with an href, and
+// button-like inputs within the provided document that have no accessible
+// text: no text content, no alt text on images within them, and no
+// aria-label, aria-labelledby, or title attribute. Submit and reset inputs are
+// never returned since browsers give them a default label.
+func ControlsMissingName(doc *html.Node) []*html.Node {
+	a := newA11yAuditor(doc)
+	return renderedHtmlNodesFunc(doc, func(n *html.Node) bool {
+		switch {
+		case isHtmlElement(n, "button"):
+		case isHtmlElement(n, "a"):
+			if _, ok := GetAttr(n, "href"); !ok {
+				return false
+			}
+		case isHtmlElement(n, "input"):
+			switch strings.ToLower(GetAttrOr(n, "type", "")) {
+			case "button":
+				if strings.TrimSpace(GetAttrOr(n, "value", "")) != "" {
+					return false
+				}
+			case "image":
+				if strings.TrimSpace(GetAttrOr(n, "alt", "")) != "" {
+					return false
+				}
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+
+		if a.hasAriaName(n) || strings.TrimSpace(GetAttrOr(n, "title", "")) != "" {
+			return false
+		}
+		return !hasAccessibleContent(n)
+	})
+}
+
+// TablesMissingHeaders returns the table elements within the provided
+// document that have no th cells of their own, not counting nested tables.
+// Layout tables with a role of "presentation" or "none" are not returned.
+func TablesMissingHeaders(doc *html.Node) []*html.Node {
+	return renderedHtmlNodesFunc(doc, func(n *html.Node) bool {
+		if !isHtmlElement(n, "table") || isDecorative(n) {
+			return false
+		}
+
+		for _, tr := range tableRows(n) {
+			if len(childElementsByTag(tr, "th")) > 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// PositiveTabindexElements returns the elements within the provided document
+// with a tabindex attribute greater than 0.
+func PositiveTabindexElements(doc *html.Node) []*html.Node {
+	return renderedHtmlNodesFunc(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return false
+		}
+		tabindex, err := strconv.Atoi(strings.TrimSpace(GetAttrOr(n, "tabindex", "")))
+		return err == nil && tabindex > 0
+	})
+}
+
+// a11yAuditor holds the document-wide state of the accessibility checks.
+type a11yAuditor struct {
+	ids      IdIndex
+	labelFor map[string]bool
+}
+
+func newA11yAuditor(doc *html.Node) *a11yAuditor {
+	a := &a11yAuditor{
+		ids:      BuildIdIndex(doc),
+		labelFor: make(map[string]bool),
+	}
+
+	for _, label := range NewMatcherWithOptions("label", "for", "", MatchOptions{SkipTemplateContent: true}).FindAll(doc) {
+		a.labelFor[GetAttrOr(label, "for", "")] = true
+	}
+
+	return a
+}
+
+// hasAriaName reports whether n is named by a non-empty aria-label attribute,
+// or by an aria-labelledby attribute referencing an element with text.
+func (a *a11yAuditor) hasAriaName(n *html.Node) bool {
+	if strings.TrimSpace(GetAttrOr(n, "aria-label", "")) != "" {
+		return true
+	}
+
+	for _, id := range splitHtmlTokens(GetAttrOr(n, "aria-labelledby", "")) {
+		if label := a.ids.Lookup(id); label != nil && GetNormalizedText(label) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAccessibleContent reports whether n has text content, or an image with
+// alt text within it.
+func hasAccessibleContent(n *html.Node) bool {
+	if GetNormalizedText(n) != "" {
+		return true
+	}
+
+	_, err := FindFirstHtmlNodeFunc(n, func(c *html.Node) bool {
+		return isHtmlElement(c, "img") && strings.TrimSpace(GetAttrOr(c, "alt", "")) != ""
+	})
+	return err == nil
+}
+
+// isDecorative reports whether n has a role of "presentation" or "none", or is
+// hidden with aria-hidden="true".
+func isDecorative(n *html.Node) bool {
+	switch strings.ToLower(strings.TrimSpace(GetAttrOr(n, "role", ""))) {
+	case "presentation", "none":
+		return true
+	}
+	return strings.EqualFold(strings.TrimSpace(GetAttrOr(n, "aria-hidden", "")), "true")
+}
+
+// isHtmlElement reports whether n is an HTML element with one of the provided
+// tags.
+func isHtmlElement(n *html.Node, tags ...string) bool {
+	if n == nil || n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+
+	for _, tag := range tags {
+		if n.Data == tag {
+			return true
+		}
+	}
+	return false
+}
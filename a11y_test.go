@@ -0,0 +1,116 @@
+package htmlutil
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestA11yRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  func(*html.Node) []*html.Node
+		markup string
+		want   []string
+	}{
+		{
+			name:  "images missing alt",
+			check: ImagesMissingAlt,
+			markup: `<img id="bad"><img id="alt" alt="A cat"><img id="empty" alt="">` +
+				`<img id="presentation" role="presentation"><img id="none" role=" NONE "><img id="hidden" aria-hidden="true">` +
+				`<img id="label" aria-label="A dog"><img id="blank-label" aria-label="  ">` +
+				`<span id="name">A bird</span><img id="labelledby" aria-labelledby="missing name">` +
+				`<span id="empty-name"> </span><img id="empty-labelledby" aria-labelledby="empty-name">` +
+				`<template><img id="template"></template><svg><image id="svg"></image></svg>`,
+			want: []string{"bad", "blank-label", "empty-labelledby"},
+		},
+		{
+			name:  "fields missing label",
+			check: FieldsMissingLabel,
+			markup: `<input id="bad"><select id="bad-select"></select><textarea id="bad-textarea"></textarea>` +
+				`<label for="for">Name</label><input id="for">` +
+				`<label>Email <span><input id="wrapped"></span></label>` +
+				`<input id="label" aria-label="Search"><span id="l">Phone</span><input id="labelledby" aria-labelledby="l">` +
+				`<input id="title" title="Age"><input id="blank-title" title=" ">` +
+				`<input type="hidden" id="hidden"><input type="submit" id="submit"><input type="IMAGE" id="image">` +
+				`<input type="checkbox" id="checkbox"><label for="">Empty</label><input id="">` +
+				`<template><label for="in-template">T</label></template><input id="in-template">`,
+			want: []string{"bad", "bad-select", "bad-textarea", "blank-title", "checkbox", "", "in-template"},
+		},
+		{
+			name:  "controls missing name",
+			check: ControlsMissingName,
+			markup: `<button id="bad"></button><button id="text">Save</button><button id="space"> </button>` +
+				`<a id="bad-link" href="/"><img src="x.png"></a><a id="img-alt" href="/"><img src="x.png" alt="Home"></a>` +
+				`<a id="anchor"></a><a id="label" href="/" aria-label="Close"></a><a id="title" href="/" title="Close"></a>` +
+				`<span id="n">Next</span><button id="labelledby" aria-labelledby="n"></button>` +
+				`<input type="button" id="bad-input"><input type="button" id="value" value="Go">` +
+				`<input type="image" id="bad-image" src="go.png"><input type="image" id="image-alt" alt="Go" src="go.png">` +
+				`<input type="submit" id="submit"><input type="reset" id="reset">`,
+			want: []string{"bad", "space", "bad-link", "bad-input", "bad-image"},
+		},
+		{
+			name:  "tables missing headers",
+			check: TablesMissingHeaders,
+			markup: `<table id="bad"><tr><td>1</td></tr></table>` +
+				`<table id="headers"><thead><tr><th>A</th></tr></thead><tbody><tr><td>1</td></tr></tbody></table>` +
+				`<table id="row-header"><tr><th scope="row">A</th><td>1</td></tr></table>` +
+				`<table id="layout" role="presentation"><tr><td>1</td></tr></table>` +
+				`<table id="outer"><tr><td><table id="inner"><tr><th>A</th></tr></table></td></tr></table>` +
+				`<table id="empty"></table>`,
+			want: []string{"bad", "outer", "empty"},
+		},
+		{
+			name:  "positive tabindex",
+			check: PositiveTabindexElements,
+			markup: `<div id="one" tabindex="1"></div><div id="zero" tabindex="0"></div><div id="negative" tabindex="-1"></div>` +
+				`<a id="spaces" href="/" tabindex=" 3 "></a><div id="invalid" tabindex="x"></div><div id="empty" tabindex=""></div>` +
+				`<template><div id="template" tabindex="2"></div></template>`,
+			want: []string{"one", "spaces"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := nodeNames(tt.check(mustParse(t, tt.markup)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuditAccessibility(t *testing.T) {
+	doc := mustParse(t, `<div id="tab" tabindex="2"><img id="img"></div>`+
+		`<table id="table"><tr><td><input id="field"></td></tr></table>`+
+		`<img id="img2"><button id="button"></button>`)
+
+	issues := AuditAccessibility(doc)
+
+	type issue struct {
+		rule A11yRule
+		id   string
+	}
+	var got []issue
+	for _, is := range issues {
+		if is.Message == "" {
+			t.Errorf("%s: empty message", is.Rule)
+		}
+		got = append(got, issue{is.Rule, GetAttrOr(is.Node, "id", "")})
+	}
+
+	want := []issue{
+		{A11yImageAlt, "img"},
+		{A11yImageAlt, "img2"},
+		{A11yFieldLabel, "field"},
+		{A11yControlName, "button"},
+		{A11yTableHeaders, "table"},
+		{A11yPositiveTabindex, "tab"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := AuditAccessibility(mustParse(t, `<p>Fine</p>`)); got == nil || len(got) != 0 {
+		t.Errorf("got %#v, want an empty slice", got)
+	}
+}
@@ -0,0 +1,268 @@
+package htmlutil
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetAttr returns the value of the first attribute of the provided node with
+// the provided key and no namespace. The boolean result reports whether such an
+// attribute was found.
+//
+// If the node is nil, the attribute is reported as not found.
+func GetAttr(n *html.Node, key string) (string, bool) {
+	return GetAttrNS(n, "", key)
+}
+
+// GetAttrOr is a convenience function for GetAttr() that returns fallback if
+// the attribute is not found.
+func GetAttrOr(n *html.Node, key string, fallback string) string {
+	return GetAttrNSOr(n, "", key, fallback)
+}
+
+// GetAttrNS returns the value of the first attribute of the provided node with
+// the provided namespace and key. Foreign attributes such as xlink:href are
+// stored by the parser with a namespace of "xlink" and a key of "href".
+//
+// If the node is nil, the attribute is reported as not found.
+func GetAttrNS(n *html.Node, namespace string, key string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+
+	for _, a := range n.Attr {
+		if a.Namespace == namespace && a.Key == key {
+			return a.Val, true
+		}
+	}
+
+	return "", false
+}
+
+// GetAttrNSOr is a convenience function for GetAttrNS() that returns fallback
+// if the attribute is not found.
+func GetAttrNSOr(n *html.Node, namespace string, key string, fallback string) string {
+	if val, ok := GetAttrNS(n, namespace, key); ok {
+		return val
+	}
+
+	return fallback
+}
+
+// SetHtmlAttr sets the attribute of the provided node with the provided key to
+// value. If the attribute already exists, the first occurrence is updated in
+// place and any further occurrences are removed. Otherwise a new attribute is
+// appended. Nothing happens if the node is nil.
+func SetHtmlAttr(n *html.Node, key string, value string) {
+	if n == nil {
+		return
+	}
+
+	found := false
+	attrs := n.Attr[:0]
+
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			// Collapse duplicate keys into the first occurrence
+			if found {
+				continue
+			}
+			a.Val = value
+			found = true
+		}
+		attrs = append(attrs, a)
+	}
+
+	if !found {
+		attrs = append(attrs, html.Attribute{Key: key, Val: value})
+	}
+
+	n.Attr = attrs
+}
+
+// SetHtmlAttrs is a convenience function for SetHtmlAttr() that sets each of
+// the provided attributes. New attributes are appended in key order.
+func SetHtmlAttrs(n *html.Node, attrs map[string]string) {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		SetHtmlAttr(n, key, attrs[key])
+	}
+}
+
+// SetAttrOnMatches sets the attribute newKey to newValue on the HTML nodes
+// matching the provided tag, attribute, and attribute value up to the provided
+// count, using the same criteria as GetHtmlNodes().
+//
+// If the count is -1, all matching nodes will be updated.
+func SetAttrOnMatches(root *html.Node, tag string, attr string, attrValue string, newKey string, newValue string, count int) {
+	for _, n := range GetHtmlNodes(root, tag, attr, attrValue, count, false) {
+		SetHtmlAttr(n, newKey, newValue)
+	}
+}
+
+// GetAllHtmlNodesByAttrs is a convenience function for GetHtmlNodesByAttrs()
+// that returns all matching HTML nodes.
+func GetAllHtmlNodesByAttrs(n *html.Node, tag string, attrs map[string]string) []*html.Node {
+	return GetHtmlNodesByAttrs(n, tag, attrs, -1)
+}
+
+// FindFirstHtmlNodeByAttrs is a convenience function for GetHtmlNodesByAttrs()
+// that returns the first matching HTML node, or ErrNodeNotFound if there is
+// none.
+func FindFirstHtmlNodeByAttrs(n *html.Node, tag string, attrs map[string]string) (*html.Node, error) {
+	return firstHtmlNode(GetHtmlNodesByAttrs(n, tag, attrs, 1))
+}
+
+// GetFirstHtmlNodeByAttrs is a convenience function for GetHtmlNodesByAttrs()
+// that returns the first matching HTML node, or an empty node if there is
+// none.
+func GetFirstHtmlNodeByAttrs(n *html.Node, tag string, attrs map[string]string) *html.Node {
+	return orEmptyHtmlNode(FindFirstHtmlNodeByAttrs(n, tag, attrs))
+}
+
+// GetHtmlNodesByAttrs returns the HTML nodes found within the provided node
+// that have every attribute in attrs, up to the provided count.
+//
+// Each key of attrs must be present on a node with the associated value, or
+// with any value if the associated value is empty. When a node has several
+// attributes with the same key, the pair is satisfied if any of them has the
+// value. A nil or empty map matches every element with the provided tag.
+//
+// The tag is optional. If it is empty, it will not be used as search criteria.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesByAttrs(n *html.Node, tag string, attrs map[string]string, count int) []*html.Node {
+	m := NewMatcher(tag, "", "")
+	return GetHtmlNodesFunc(n, func(n *html.Node) bool {
+		return htmlNodeHasAttrs(n, m, attrs)
+	}, count)
+}
+
+// RemoveAllHtmlNodesByAttrs is a convenience function for
+// RemoveHtmlNodesByAttrs() that removes all matching HTML nodes.
+func RemoveAllHtmlNodesByAttrs(n *html.Node, tag string, attrs map[string]string) {
+	RemoveHtmlNodesByAttrs(n, tag, attrs, -1)
+}
+
+// RemoveFirstHtmlNodeByAttrs is a convenience function for
+// RemoveHtmlNodesByAttrs() that removes the first matching HTML node.
+func RemoveFirstHtmlNodeByAttrs(n *html.Node, tag string, attrs map[string]string) {
+	RemoveHtmlNodesByAttrs(n, tag, attrs, 1)
+}
+
+// RemoveHtmlNodesByAttrs removes the HTML nodes found within the provided
+// node that have every attribute in attrs, using the same criteria as
+// GetHtmlNodesByAttrs(), up to the provided count. Matching nodes without a
+// parent are skipped.
+//
+// If the count is -1, all nodes meeting the criteria will be removed.
+func RemoveHtmlNodesByAttrs(n *html.Node, tag string, attrs map[string]string, count int) {
+	RemoveNodes(GetHtmlNodesByAttrs(n, tag, attrs, count))
+}
+
+// htmlNodeHasAttrs reports whether n is an element matching the tag of m and
+// has every attribute in attrs.
+func htmlNodeHasAttrs(n *html.Node, m *Matcher, attrs map[string]string) bool {
+	if !m.Match(n) {
+		return false
+	}
+
+	for key, val := range attrs {
+		found := false
+		for _, a := range n.Attr {
+			if a.Namespace == "" && a.Key == key && (val == "" || a.Val == val) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AttrCollision tells RenameHtmlAttr() what to do when an element already has
+// an attribute with the new key.
+type AttrCollision int
+
+const (
+	// AttrCollisionSkip leaves both attributes of the element as they are.
+	AttrCollisionSkip AttrCollision = iota
+
+	// AttrCollisionOverwrite removes the existing attribute, so the renamed
+	// one replaces it.
+	AttrCollisionOverwrite
+
+	// AttrCollisionMerge appends the whitespace-separated tokens of the
+	// renamed attribute that the existing attribute doesn't have to it, and
+	// removes the renamed attribute, as suits class-like attributes.
+	AttrCollisionMerge
+)
+
+// RenameHtmlAttr renames the fromKey attribute of the HTML nodes found within
+// the provided node with the provided tag, up to the provided count, to toKey,
+// keeping its value and its position among the attributes of the element. It
+// returns the number of elements whose attributes changed.
+//
+// The collision mode controls what happens when an element already has a
+// toKey attribute. Only attributes without a namespace are renamed.
+//
+// The tag is optional. If it is empty, it will not be used as search criteria.
+//
+// If the count is -1, all matching elements will be processed.
+func RenameHtmlAttr(n *html.Node, tag string, fromKey string, toKey string, count int, collision AttrCollision) int {
+	if fromKey == "" || toKey == "" || fromKey == toKey {
+		return 0
+	}
+
+	// Only count the elements holding an attribute that can be renamed, so
+	// that namespaced attributes with the same key don't use up the count
+	m := NewMatcher(tag, "", "")
+	renamed := 0
+	for _, node := range GetHtmlNodesFunc(n, func(x *html.Node) bool {
+		return m.Match(x) && attrIndex(x, fromKey) >= 0
+	}, count) {
+		from := attrIndex(node, fromKey)
+		to := attrIndex(node, toKey)
+
+		switch {
+		case to < 0:
+			node.Attr[from].Key = toKey
+		case collision == AttrCollisionOverwrite:
+			node.Attr[from].Key = toKey
+			node.Attr = append(node.Attr[:to], node.Attr[to+1:]...)
+		case collision == AttrCollisionMerge:
+			tokens := splitHtmlTokens(node.Attr[to].Val)
+			for _, token := range splitHtmlTokens(node.Attr[from].Val) {
+				if !slices.Contains(tokens, token) {
+					tokens = append(tokens, token)
+				}
+			}
+			node.Attr[to].Val = strings.Join(tokens, " ")
+			node.Attr = append(node.Attr[:from], node.Attr[from+1:]...)
+		default:
+			continue
+		}
+		renamed++
+	}
+
+	return renamed
+}
+
+// attrIndex returns the index of the first attribute of n with the provided
+// key and no namespace, or -1 if there is none.
+func attrIndex(n *html.Node, key string) int {
+	return slices.IndexFunc(n.Attr, func(a html.Attribute) bool {
+		return a.Namespace == "" && a.Key == key
+	})
+}
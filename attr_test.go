@@ -0,0 +1,331 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// attrString formats the attributes of a node as "key=value" pairs in order,
+// prefixing namespaced keys with their namespace.
+func attrString(n *html.Node) string {
+	parts := make([]string, 0, len(n.Attr))
+	for _, a := range n.Attr {
+		key := a.Key
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + key
+		}
+		parts = append(parts, key+"="+a.Val)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestGetAttr(t *testing.T) {
+	doc := mustParse(t, `<a id="dup" href="/first" href="/second" title="">x</a>`+
+		`<svg><use xlink:href="#icon" href="#plain"></use></svg>`)
+	a := GetFirstHtmlNode(doc, "a", "", "")
+	use := GetFirstHtmlNode(doc, "use", "", "")
+
+	tests := []struct {
+		name      string
+		n         *html.Node
+		namespace string
+		key       string
+		want      string
+		wantOK    bool
+	}{
+		{"first of duplicate keys", a, "", "href", "/first", true},
+		{"empty value", a, "", "title", "", true},
+		{"missing", a, "", "rel", "", false},
+		{"case-sensitive key", a, "", "HREF", "", false},
+		{"nil node", nil, "", "href", "", false},
+		{"namespaced", use, "xlink", "href", "#icon", true},
+		{"plain next to namespaced", use, "", "href", "#plain", true},
+		{"wrong namespace", use, "xml", "href", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GetAttrNS(tt.n, tt.namespace, tt.key)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("GetAttrNS: got %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+
+			wantOr := tt.want
+			if !tt.wantOK {
+				wantOr = "fallback"
+			}
+			if got := GetAttrNSOr(tt.n, tt.namespace, tt.key, "fallback"); got != wantOr {
+				t.Errorf("GetAttrNSOr: got %q, want %q", got, wantOr)
+			}
+
+			if tt.namespace == "" {
+				if got, ok := GetAttr(tt.n, tt.key); got != tt.want || ok != tt.wantOK {
+					t.Errorf("GetAttr: got %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+				}
+				if got := GetAttrOr(tt.n, tt.key, "fallback"); got != wantOr {
+					t.Errorf("GetAttrOr: got %q, want %q", got, wantOr)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAttrIgnoresNamespacedAttributes(t *testing.T) {
+	doc := mustParse(t, `<svg><a xlink:href="#x"></a></svg>`)
+	a := GetFirstHtmlNode(doc, "a", "", "")
+
+	if got, ok := GetAttr(a, "href"); ok {
+		t.Errorf("got %q, want xlink:href to be ignored without a namespace", got)
+	}
+}
+
+func TestSetHtmlAttr(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		key   string
+		value string
+		want  string
+	}{
+		{"update in place", `<p id="a" class="x" title="t"></p>`, "class", "y", "id=a class=y title=t"},
+		{"append", `<p id="a" title="t"></p>`, "class", "y", "id=a title=t class=y"},
+		{"collapse duplicates", `<p class="x" id="a" class="z" title="t"></p>`, "class", "y", "class=y id=a title=t"},
+		{"empty value", `<p id="a"></p>`, "hidden", "", "id=a hidden="},
+		{"namespaced untouched", `<svg><a xlink:href="#x" id="a"></a></svg>`, "href", "#y", "xlink:href=#x id=a href=#y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+			n := GetAllHtmlNodes(doc, "", "id", "a")[0]
+
+			SetHtmlAttr(n, tt.key, tt.value)
+			if got := attrString(n); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetHtmlAttrNil(t *testing.T) {
+	// Must not panic
+	SetHtmlAttr(nil, "id", "a")
+	SetHtmlAttrs(nil, map[string]string{"id": "a"})
+}
+
+func TestSetHtmlAttrs(t *testing.T) {
+	doc := mustParse(t, `<p title="t" id="a" class="x"></p>`)
+	n := GetFirstHtmlNode(doc, "p", "", "")
+
+	SetHtmlAttrs(n, map[string]string{"lang": "en", "class": "y", "dir": "ltr"})
+	if got, want := attrString(n), "title=t id=a class=y dir=ltr lang=en"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSetAttrOnMatches(t *testing.T) {
+	doc := mustParse(t, `<a href="/a" id="1">a</a><a id="2">b</a><a href="/c" id="3">c</a><a href="/d" id="4">d</a>`)
+
+	SetAttrOnMatches(doc, "a", "href", "", "rel", "nofollow", 2)
+
+	var got []string
+	for _, a := range GetAllHtmlNodes(doc, "a", "", "") {
+		got = append(got, attrString(a))
+	}
+	want := []string{"href=/a id=1 rel=nofollow", "id=2", "href=/c id=3 rel=nofollow", "href=/d id=4"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenameHtmlAttr(t *testing.T) {
+	const markup = `<div id="a" data-class="x y" title="t"></div>` +
+		`<div id="b" class="y z" data-class="x y" title="t"></div>` +
+		`<p id="c" data-class="p"></p>` +
+		`<div id="d" class="solo"></div>`
+
+	tests := []struct {
+		name      string
+		tag       string
+		count     int
+		collision AttrCollision
+		renamed   int
+		want      map[string]string
+	}{
+		{
+			name:      "skip",
+			tag:       "div",
+			count:     -1,
+			collision: AttrCollisionSkip,
+			renamed:   1,
+			want: map[string]string{
+				"a": "id=a class=x y title=t",
+				"b": "id=b class=y z data-class=x y title=t",
+				"c": "id=c data-class=p",
+			},
+		},
+		{
+			name:      "overwrite",
+			tag:       "div",
+			count:     -1,
+			collision: AttrCollisionOverwrite,
+			renamed:   2,
+			want: map[string]string{
+				"a": "id=a class=x y title=t",
+				"b": "id=b class=x y title=t",
+				"c": "id=c data-class=p",
+			},
+		},
+		{
+			name:      "merge",
+			tag:       "",
+			count:     -1,
+			collision: AttrCollisionMerge,
+			renamed:   3,
+			want: map[string]string{
+				"a": "id=a class=x y title=t",
+				"b": "id=b class=y z x title=t",
+				"c": "id=c class=p",
+			},
+		},
+		{
+			name:      "count",
+			tag:       "",
+			count:     2,
+			collision: AttrCollisionMerge,
+			renamed:   2,
+			want: map[string]string{
+				"a": "id=a class=x y title=t",
+				"b": "id=b class=y z x title=t",
+				"c": "id=c data-class=p",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, markup)
+		if got := RenameHtmlAttr(doc, tt.tag, "data-class", "class", tt.count, tt.collision); got != tt.renamed {
+			t.Errorf("%s: got %d renamed, want %d", tt.name, got, tt.renamed)
+		}
+		for id, want := range tt.want {
+			if got := attrString(GetElementById(doc, id)); got != want {
+				t.Errorf("%s: %s: got %q, want %q", tt.name, id, got, want)
+			}
+		}
+		if got := attrString(GetElementById(doc, "d")); got != "id=d class=solo" {
+			t.Errorf("%s: d: got %q, want it unchanged", tt.name, got)
+		}
+	}
+}
+
+func TestRenameHtmlAttrNamespaced(t *testing.T) {
+	doc := mustParse(t, `<svg><use id="svg" xlink:href="#icon"></use></svg><img id="img" data-src="a.png" alt="">`)
+
+	if got := RenameHtmlAttr(doc, "", "href", "src", 1, AttrCollisionSkip); got != 0 {
+		t.Errorf("got %d renamed, want namespaced attributes left alone", got)
+	}
+	if got := RenameHtmlAttr(doc, "", "data-src", "src", 1, AttrCollisionSkip); got != 1 {
+		t.Errorf("got %d renamed, want 1", got)
+	}
+	if got := attrString(GetElementById(doc, "img")); got != "id=img src=a.png alt=" {
+		t.Errorf("got %q, want the position kept", got)
+	}
+
+	// A namespaced attribute with the target key is not a collision
+	doc = mustParse(t, `<svg><a id="a" xlink:href="/x" data-href="/y"></a></svg>`)
+	if got := RenameHtmlAttr(doc, "a", "data-href", "href", -1, AttrCollisionSkip); got != 1 {
+		t.Errorf("got %d renamed, want 1", got)
+	}
+	if got := attrString(GetElementById(doc, "a")); got != "id=a xlink:href=/x href=/y" {
+		t.Errorf("got %q", got)
+	}
+
+	// Namespaced attributes with the source key don't use up the count
+	doc = mustParse(t, `<svg><a id="s" xlink:href="/x"></a></svg><a id="h" href="/y"></a>`)
+	if got := RenameHtmlAttr(doc, "a", "href", "data-href", 1, AttrCollisionSkip); got != 1 {
+		t.Errorf("got %d renamed, want 1", got)
+	}
+	if got := attrString(GetElementById(doc, "h")); got != "id=h data-href=/y" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRenameHtmlAttrNoop(t *testing.T) {
+	doc := mustParse(t, `<p id="p" a="1" b="2"></p>`)
+
+	for _, keys := range [][2]string{{"", "b"}, {"a", ""}, {"a", "a"}, {"missing", "c"}} {
+		if got := RenameHtmlAttr(doc, "", keys[0], keys[1], -1, AttrCollisionOverwrite); got != 0 {
+			t.Errorf("%q to %q: got %d renamed", keys[0], keys[1], got)
+		}
+	}
+	if got := attrString(GetElementById(doc, "p")); got != "id=p a=1 b=2" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGetHtmlNodesByAttrs(t *testing.T) {
+	doc := mustParse(t, `<div id="a" class="x" role="main">`+
+		`<p id="b" class="x" data-v="1" data-v="2">one</p>`+
+		`<p id="c" class="y" data-v="">two</p>`+
+		`<span id="d" class="x" role="note"></span>`+
+		`<svg><a id="e" xlink:href="#x"></a></svg></div>`)
+
+	tests := []struct {
+		name  string
+		tag   string
+		attrs map[string]string
+		count int
+		want  []string
+	}{
+		{"value", "", map[string]string{"class": "x"}, -1, []string{"a", "b", "d"}},
+		{"every pair", "", map[string]string{"class": "x", "role": ""}, -1, []string{"a", "d"}},
+		{"tag", "p", map[string]string{"class": "x"}, -1, []string{"b"}},
+		{"count", "", map[string]string{"class": "x"}, 2, []string{"a", "b"}},
+		{"any value", "", map[string]string{"data-v": ""}, -1, []string{"b", "c"}},
+		{"first duplicate", "", map[string]string{"data-v": "1"}, -1, []string{"b"}},
+		{"second duplicate", "", map[string]string{"data-v": "2"}, -1, []string{"b"}},
+		{"duplicate values", "", map[string]string{"data-v": "3"}, -1, nil},
+		{"missing", "", map[string]string{"class": "x", "lang": ""}, -1, nil},
+		{"namespaced", "", map[string]string{"href": ""}, -1, nil},
+		{"nil tag", "p", nil, -1, []string{"b", "c"}},
+		{"empty tag", "span", map[string]string{}, -1, []string{"d"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, n := range GetHtmlNodesByAttrs(doc, tt.tag, tt.attrs, tt.count) {
+			got = append(got, GetAttrOr(n, "id", ""))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// A nil or empty map without a tag matches every element
+	all := len(GetAllHtmlNodes(doc, "", "", ""))
+	for _, attrs := range []map[string]string{nil, {}} {
+		if got := GetHtmlNodesByAttrs(doc, "", attrs, -1); len(got) != all {
+			t.Errorf("%v: got %d nodes, want %d", attrs, len(got), all)
+		}
+	}
+
+	if got := GetFirstHtmlNodeByAttrs(doc, "", map[string]string{"role": "note"}); GetAttrOr(got, "id", "") != "d" {
+		t.Errorf("GetFirstHtmlNodeByAttrs: got %v", got)
+	}
+	if _, err := FindFirstHtmlNodeByAttrs(doc, "", map[string]string{"role": "none"}); err != ErrNodeNotFound {
+		t.Errorf("FindFirstHtmlNodeByAttrs: got %v, want ErrNodeNotFound", err)
+	}
+}
+
+func TestRemoveHtmlNodesByAttrs(t *testing.T) {
+	doc := mustParse(t, `<div class="ad"><p class="ad">nested</p></div><p class="ad" data-x="1">a</p><p>b</p>`)
+
+	RemoveAllHtmlNodesByAttrs(doc, "", map[string]string{"class": "ad"})
+	checkSiblings(t, doc)
+
+	if got := bodyHtml(t, doc); got != `<p>b</p>` {
+		t.Errorf("got %q", got)
+	}
+}
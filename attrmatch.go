@@ -0,0 +1,102 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// AttrMatchKind is the way an AttrMatch compares attribute values.
+type AttrMatchKind int
+
+const (
+	// AttrMatchExact matches values equal to the value of the AttrMatch, like
+	// the CSS [attr=value] selector.
+	AttrMatchExact AttrMatchKind = iota
+
+	// AttrMatchPrefix matches values starting with the value of the AttrMatch,
+	// like the CSS [attr^=value] selector.
+	AttrMatchPrefix
+
+	// AttrMatchSuffix matches values ending with the value of the AttrMatch,
+	// like the CSS [attr$=value] selector.
+	AttrMatchSuffix
+
+	// AttrMatchContains matches values containing the value of the AttrMatch,
+	// like the CSS [attr*=value] selector.
+	AttrMatchContains
+
+	// AttrMatchWordToken matches values with a whitespace-separated token
+	// equal to the value of the AttrMatch, like the CSS [attr~=value]
+	// selector.
+	AttrMatchWordToken
+)
+
+// AttrMatch describes how an attribute value must compare to Value to match.
+// An empty Value matches any attribute value, whatever the kind.
+type AttrMatch struct {
+	Kind  AttrMatchKind
+	Value string
+
+	// CaseInsensitive compares values case-insensitively.
+	CaseInsensitive bool
+}
+
+// Matches reports whether the provided attribute value matches.
+func (m AttrMatch) Matches(value string) bool {
+	if m.CaseInsensitive {
+		m.Value = strings.ToLower(m.Value)
+	}
+	return m.matchesLowered(value)
+}
+
+// matchesLowered is like Matches() but expects Value to be lowercased already
+// if the comparison is case-insensitive, as it is in a Matcher, so that only
+// the candidate value is lowercased.
+func (m AttrMatch) matchesLowered(value string) bool {
+	if m.Value == "" {
+		return true
+	}
+
+	want := m.Value
+	if m.CaseInsensitive {
+		value = strings.ToLower(value)
+	}
+
+	switch m.Kind {
+	case AttrMatchExact:
+		return value == want
+	case AttrMatchPrefix:
+		return strings.HasPrefix(value, want)
+	case AttrMatchSuffix:
+		return strings.HasSuffix(value, want)
+	case AttrMatchContains:
+		return strings.Contains(value, want)
+	case AttrMatchWordToken:
+		for _, token := range splitHtmlTokens(value) {
+			if token == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetAllHtmlNodesByAttrMatch is a convenience function for
+// GetHtmlNodesByAttrMatch() that returns all matching HTML nodes.
+func GetAllHtmlNodesByAttrMatch(n *html.Node, tag string, key string, match AttrMatch) []*html.Node {
+	return GetHtmlNodesByAttrMatch(n, tag, key, match, -1)
+}
+
+// GetHtmlNodesByAttrMatch returns the HTML nodes found within the provided
+// node that have an attribute with the provided key whose value satisfies the
+// provided AttrMatch, up to the provided count.
+//
+// The tag and key are optional. If they are empty, they will not be used as
+// search criteria, so an empty key matches any attribute whose value matches.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesByAttrMatch(n *html.Node, tag string, key string, match AttrMatch, count int) []*html.Node {
+	m := newAttrMatcher(tag, key, match, MatchOptions{})
+	return m.Find(n, count)
+}
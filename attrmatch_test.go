@@ -0,0 +1,104 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttrMatchMatches(t *testing.T) {
+	tests := []struct {
+		match AttrMatch
+		value string
+		want  bool
+	}{
+		{AttrMatch{Kind: AttrMatchExact, Value: "a"}, "a", true},
+		{AttrMatch{Kind: AttrMatchExact, Value: "a"}, "ab", false},
+		{AttrMatch{Kind: AttrMatchExact, Value: "a"}, "A", false},
+		{AttrMatch{Kind: AttrMatchExact, Value: "a", CaseInsensitive: true}, "A", true},
+		{AttrMatch{Kind: AttrMatchPrefix, Value: "https://"}, "https://example.com", true},
+		{AttrMatch{Kind: AttrMatchPrefix, Value: "https://"}, "http://example.com", false},
+		{AttrMatch{Kind: AttrMatchPrefix, Value: "HTTPS://", CaseInsensitive: true}, "https://example.com", true},
+		{AttrMatch{Kind: AttrMatchSuffix, Value: ".webp"}, "/img/a.webp", true},
+		{AttrMatch{Kind: AttrMatchSuffix, Value: ".webp"}, "/img/a.webp?x=1", false},
+		{AttrMatch{Kind: AttrMatchSuffix, Value: ".webp", CaseInsensitive: true}, "/img/A.WEBP", true},
+		{AttrMatch{Kind: AttrMatchContains, Value: "col-"}, "row col-md-6", true},
+		{AttrMatch{Kind: AttrMatchContains, Value: "col-"}, "column", false},
+		{AttrMatch{Kind: AttrMatchWordToken, Value: "b"}, "a b c", true},
+		{AttrMatch{Kind: AttrMatchWordToken, Value: "b"}, "a\tb\nc", true},
+		{AttrMatch{Kind: AttrMatchWordToken, Value: "b"}, "abc", false},
+		{AttrMatch{Kind: AttrMatchWordToken, Value: "b"}, "a bc", false},
+		{AttrMatch{Kind: AttrMatchWordToken, Value: "B", CaseInsensitive: true}, "a b", true},
+		{AttrMatch{Kind: AttrMatchWordToken, Value: "a b"}, "a b", false},
+		{AttrMatch{Kind: AttrMatchExact}, "anything", true},
+		{AttrMatch{Kind: AttrMatchWordToken}, "", true},
+		{AttrMatch{Kind: AttrMatchKind(99), Value: "a"}, "a", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.match.Matches(tt.value); got != tt.want {
+			t.Errorf("%+v.Matches(%q): got %v, want %v", tt.match, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetHtmlNodesByAttrMatch(t *testing.T) {
+	doc := mustParse(t, `<a id="a1" href="https://example.com/">1</a>`+
+		`<a id="a2" href="http://example.com/">2</a>`+
+		`<img id="i1" src="/a.webp" class="col-6 wide">`+
+		`<img id="i2" src="/b.png" class="col wide">`+
+		`<div id="d1" class="row col-md-6"></div>`)
+
+	tests := []struct {
+		name  string
+		tag   string
+		key   string
+		match AttrMatch
+		count int
+		want  string
+	}{
+		{"prefix", "a", "href", AttrMatch{Kind: AttrMatchPrefix, Value: "https://"}, -1, "a1"},
+		{"suffix", "img", "src", AttrMatch{Kind: AttrMatchSuffix, Value: ".webp"}, -1, "i1"},
+		{"contains any tag", "", "class", AttrMatch{Kind: AttrMatchContains, Value: "col-"}, -1, "i1,d1"},
+		{"word token", "", "class", AttrMatch{Kind: AttrMatchWordToken, Value: "wide"}, -1, "i1,i2"},
+		{"word token not substring", "", "class", AttrMatch{Kind: AttrMatchWordToken, Value: "col"}, -1, "i2"},
+		{"any key", "", "", AttrMatch{Kind: AttrMatchContains, Value: "example"}, -1, "a1,a2"},
+		{"count", "", "id", AttrMatch{Kind: AttrMatchPrefix, Value: "i"}, 1, "i1"},
+		{"empty value", "img", "class", AttrMatch{Kind: AttrMatchSuffix}, -1, "i1,i2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeNames(GetHtmlNodesByAttrMatch(doc, tt.tag, tt.key, tt.match, tt.count))
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if tt.count < 0 {
+				all := nodeNames(GetAllHtmlNodesByAttrMatch(doc, tt.tag, tt.key, tt.match))
+				if strings.Join(all, ",") != tt.want {
+					t.Errorf("GetAllHtmlNodesByAttrMatch: got %q, want %q", all, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetHtmlNodesAgreesWithAttrMatch(t *testing.T) {
+	doc := mustParse(t, largePage(5))
+
+	for _, q := range []struct{ tag, attr, attrValue string }{
+		{"a", "href", "/wiki/Link_1_1"},
+		{"", "class", "section"},
+		{"div", "", "s3"},
+		{"", "title", ""},
+	} {
+		exact := GetHtmlNodesByAttrMatch(doc, q.tag, q.attr, AttrMatch{Kind: AttrMatchExact, Value: q.attrValue}, -1)
+		if got := GetAllHtmlNodes(doc, q.tag, q.attr, q.attrValue); strings.Join(nodeNames(got), ",") != strings.Join(nodeNames(exact), ",") {
+			t.Errorf("%v: GetAllHtmlNodes got %q, AttrMatchExact got %q", q, nodeNames(got), nodeNames(exact))
+		}
+
+		contains := GetHtmlNodesByAttrMatch(doc, q.tag, q.attr, AttrMatch{Kind: AttrMatchContains, Value: q.attrValue}, -1)
+		if got := GetAllHtmlNodesAllowAttrSubstring(doc, q.tag, q.attr, q.attrValue); strings.Join(nodeNames(got), ",") != strings.Join(nodeNames(contains), ",") {
+			t.Errorf("%v: GetAllHtmlNodesAllowAttrSubstring got %q, AttrMatchContains got %q", q, nodeNames(got), nodeNames(contains))
+		}
+	}
+}
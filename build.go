@@ -0,0 +1,89 @@
+package htmlutil
+
+import (
+	"errors"
+	"sort"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// NewElement returns a detached HTML element with the provided tag,
+// attributes, and children. The DataAtom of the element is set from the tag,
+// and the attributes are added in sorted key order so the output is
+// deterministic.
+//
+// Like html.Node.AppendChild, NewElement panics if one of the children is
+// nil or already attached to a tree; use AppendChildren() to get an error
+// instead.
+func NewElement(tag string, attrs map[string]string, children ...*html.Node) *html.Node {
+	n := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Lookup([]byte(tag)),
+		Data:     tag,
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		n.Attr = append(n.Attr, html.Attribute{Key: key, Val: attrs[key]})
+	}
+
+	if err := AppendChildren(n, children...); err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// NewText returns a detached text node with the provided text, which is
+// escaped when rendered.
+func NewText(s string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: s}
+}
+
+// NewComment returns a detached comment node with the provided data.
+func NewComment(s string) *html.Node {
+	return &html.Node{Type: html.CommentNode, Data: s}
+}
+
+// AppendChildren appends the provided children to parent, in order. An error
+// is returned, and nothing is appended, if a child is nil, is already attached
+// to a tree, is listed twice, or is parent or one of its ancestors.
+func AppendChildren(parent *html.Node, children ...*html.Node) error {
+	if parent == nil {
+		return errors.New("htmlutil: cannot append children to a nil node")
+	}
+
+	seen := make(map[*html.Node]bool, len(children))
+	for _, c := range children {
+		if err := checkDetached(c); err != nil {
+			return err
+		}
+		if seen[c] {
+			return errors.New("htmlutil: node appended twice")
+		}
+		if c == parent || IsDescendantOf(parent, c) {
+			return errors.New("htmlutil: cannot insert a node into itself or its descendants")
+		}
+		seen[c] = true
+	}
+
+	for _, c := range children {
+		parent.AppendChild(c)
+	}
+	return nil
+}
+
+// checkDetached returns an error if n is nil or attached to a tree.
+func checkDetached(n *html.Node) error {
+	if n == nil {
+		return errors.New("htmlutil: nil node")
+	}
+	if n.Parent != nil || n.PrevSibling != nil || n.NextSibling != nil {
+		return errors.New("htmlutil: node is already attached, detach or clone it first")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestBuildFigure(t *testing.T) {
+	figure := NewElement("figure", map[string]string{"class": "photo"},
+		NewElement("img", map[string]string{"src": "/cat.jpg", "alt": "A cat", "width": "640"}),
+		NewElement("figcaption", nil, NewText("Tom & Jerry <3")),
+		NewComment(" credits "),
+	)
+
+	want := `<figure class="photo"><img alt="A cat" src="/cat.jpg" width="640"/>` +
+		`<figcaption>Tom &amp; Jerry &lt;3</figcaption><!-- credits --></figure>`
+	if got := mustRender(t, figure); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	if figure.DataAtom != atom.Figure || figure.FirstChild.DataAtom != atom.Img || figure.LastChild.Type != html.CommentNode {
+		t.Errorf("got atoms %v %v", figure.DataAtom, figure.FirstChild.DataAtom)
+	}
+	checkSiblings(t, figure)
+
+	// The built tree survives a round trip through the parser
+	doc := mustParse(t, "<body>"+want)
+	if got := bodyHtml(t, doc); got != want {
+		t.Errorf("reparsed: got %s", got)
+	}
+}
+
+func TestNewElementCustomTag(t *testing.T) {
+	n := NewElement("my-widget", map[string]string{"data-x": "1"})
+	if n.DataAtom != 0 || n.Data != "my-widget" {
+		t.Errorf("got atom %v data %q", n.DataAtom, n.Data)
+	}
+
+	if got := mustRender(t, n); got != `<my-widget data-x="1"></my-widget>` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestNewElementPanics(t *testing.T) {
+	attached := NewElement("span", nil)
+	NewElement("div", nil, attached)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewElement did not panic for an attached child")
+		}
+	}()
+	NewElement("p", nil, attached)
+}
+
+func TestAppendChildren(t *testing.T) {
+	parent := NewElement("ul", nil, NewElement("li", nil, NewText("a")))
+	b, c := NewElement("li", nil, NewText("b")), NewElement("li", nil, NewText("c"))
+
+	if err := AppendChildren(parent, b, c); err != nil {
+		t.Fatalf("AppendChildren: %v", err)
+	}
+	if got := mustRender(t, parent); got != `<ul><li>a</li><li>b</li><li>c</li></ul>` {
+		t.Errorf("got %s", got)
+	}
+	checkSiblings(t, parent)
+
+	if err := AppendChildren(parent); err != nil {
+		t.Errorf("no children: %v", err)
+	}
+}
+
+func TestAppendChildrenErrors(t *testing.T) {
+	doc := mustParse(t, `<div id="outer"><p id="inner"><span id="leaf">x</span></p></div><p id="sibling"></p>`)
+	outer, inner, leaf := GetElementById(doc, "outer"), GetElementById(doc, "inner"), GetElementById(doc, "leaf")
+	before := mustRender(t, doc)
+
+	detached := NewElement("em", nil)
+	prevOnly := NewElement("b", nil)
+	prevOnly.PrevSibling = NewElement("i", nil)
+
+	tests := []struct {
+		name     string
+		parent   *html.Node
+		children []*html.Node
+		want     string
+	}{
+		{"nil parent", nil, []*html.Node{detached}, "nil node"},
+		{"nil child", leaf, []*html.Node{detached, nil}, "nil node"},
+		{"attached child", leaf, []*html.Node{detached, GetElementById(doc, "sibling")}, "already attached"},
+		{"sibling pointer only", leaf, []*html.Node{prevOnly}, "already attached"},
+		{"listed twice", leaf, []*html.Node{detached, detached}, "twice"},
+		{"into itself", doc, []*html.Node{doc}, "itself"},
+	}
+
+	for _, tt := range tests {
+		err := AppendChildren(tt.parent, tt.children...)
+		if err == nil || !strings.HasPrefix(err.Error(), "htmlutil: ") || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error about %q", tt.name, err, tt.want)
+		}
+	}
+
+	// The ancestors of parent are attached, so they are rejected before the
+	// cycle check
+	if err := AppendChildren(leaf, outer); err == nil {
+		t.Error("ancestor: got no error")
+	}
+	if err := AppendChildren(leaf, inner); err == nil {
+		t.Error("parent: got no error")
+	}
+
+	if got := mustRender(t, doc); got != before {
+		t.Errorf("document modified:\ngot  %s\nwant %s", got, before)
+	}
+	if detached.Parent != nil {
+		t.Error("a valid child was appended along with an invalid one")
+	}
+}
+
+func TestAppendChildrenDetachedAncestor(t *testing.T) {
+	root := NewElement("div", nil)
+	child := NewElement("p", nil)
+	if err := AppendChildren(root, child); err != nil {
+		t.Fatal(err)
+	}
+
+	// root is detached, but child is its descendant
+	if err := AppendChildren(child, root); err == nil || !strings.Contains(err.Error(), "itself") {
+		t.Errorf("got %v, want a cycle error", err)
+	}
+	checkSiblings(t, root)
+}
@@ -0,0 +1,173 @@
+package htmlutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// charsetPrescanLength is the number of bytes searched for a meta charset
+// declaration, as specified by the HTML spec.
+const charsetPrescanLength = 1024
+
+// DetectCharset returns the canonical name of the encoding of an HTML
+// document whose first bytes are head, such as "utf-8" or "shift_jis", as
+// determined by the encoding sniffing algorithm of the HTML spec: a byte
+// order mark, then the provided hint, then a meta charset declaration within
+// the first 1024 bytes. UTF-8 is returned if none of them names an encoding.
+//
+// The hint is optional. It is either a Content-Type such as
+// "text/html; charset=windows-1251" or a bare charset label such as
+// "windows-1251", and results in an error if it names an unsupported
+// encoding.
+func DetectCharset(head []byte, contentTypeHint string) (string, error) {
+	label := charsetHintLabel(contentTypeHint)
+	if label != "" && lookupCharset(label) == "" {
+		return "", fmt.Errorf("htmlutil: unsupported charset %q", label)
+	}
+
+	return sniffCharset(head, label), nil
+}
+
+// ParseReaderWithCharset parses an HTML document from r, decoding it to UTF-8
+// from the encoding detected by DetectCharset() using the provided hint, and
+// returns the document along with the canonical name of the encoding.
+func ParseReaderWithCharset(r io.Reader, contentTypeHint string) (*html.Node, string, error) {
+	label := charsetHintLabel(contentTypeHint)
+	if label != "" && lookupCharset(label) == "" {
+		return nil, "", fmt.Errorf("htmlutil: unsupported charset %q", label)
+	}
+
+	return parseDecoded(r, label)
+}
+
+// parseDecoded parses an HTML document from r, decoding it from the encoding
+// sniffed from its first bytes and the provided charset label, which is
+// ignored if it isn't supported.
+func parseDecoded(r io.Reader, label string) (*html.Node, string, error) {
+	br := bufio.NewReaderSize(r, charsetPrescanLength)
+	head, err := br.Peek(charsetPrescanLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+
+	name := sniffCharset(head, label)
+
+	// Decoders differ in whether they strip the byte order mark, so strip it
+	// beforehand
+	if n := bomLength(head); n > 0 {
+		br.Discard(n)
+	}
+
+	decoded, err := charset.NewReaderLabel(name, br)
+	if err != nil {
+		return nil, "", err
+	}
+
+	doc, err := html.Parse(decoded)
+	if err != nil {
+		return nil, "", err
+	}
+	return doc, name, nil
+}
+
+// charsetHintLabel returns the charset label of a hint passed to
+// DetectCharset(), which is either a Content-Type or a bare label.
+func charsetHintLabel(hint string) string {
+	if strings.ContainsAny(hint, "/;=") {
+		return charsetFromContentType(hint)
+	}
+	return strings.TrimSpace(hint)
+}
+
+// charsetFromContentType returns the charset parameter of a Content-Type, or
+// an empty string.
+func charsetFromContentType(contentType string) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		return params["charset"]
+	}
+	return ""
+}
+
+// sniffCharset implements DetectCharset(), ignoring the provided charset label
+// if it isn't supported.
+func sniffCharset(head []byte, label string) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xef\xbb\xbf")):
+		return "utf-8"
+	case bytes.HasPrefix(head, []byte("\xfe\xff")):
+		return "utf-16be"
+	case bytes.HasPrefix(head, []byte("\xff\xfe")):
+		return "utf-16le"
+	}
+
+	if name := lookupCharset(label); name != "" {
+		return name
+	}
+
+	if name := prescanMetaCharset(head); name != "" {
+		// A document can't declare itself as UTF-16, since the declaration
+		// could not have been read in that case
+		if strings.HasPrefix(name, "utf-16") {
+			return "utf-8"
+		}
+		if name == "x-user-defined" {
+			return "windows-1252"
+		}
+		return name
+	}
+
+	return "utf-8"
+}
+
+// bomLength returns the length of the byte order mark at the start of head,
+// or 0 if it has none.
+func bomLength(head []byte) int {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xef\xbb\xbf")):
+		return 3
+	case bytes.HasPrefix(head, []byte("\xfe\xff")), bytes.HasPrefix(head, []byte("\xff\xfe")):
+		return 2
+	}
+	return 0
+}
+
+// prescanMetaCharset returns the canonical name of the encoding declared by
+// a meta element within the first 1024 bytes of head, or an empty string.
+func prescanMetaCharset(head []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(head[:min(len(head), charsetPrescanLength)]))
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if t := z.Token(); t.Data == "meta" {
+				meta := &html.Node{Type: html.ElementNode, Data: t.Data, Attr: t.Attr}
+				if name := lookupCharset(metaCharset(meta)); name != "" {
+					return name
+				}
+			}
+		}
+	}
+}
+
+// lookupCharset returns the canonical name of the encoding with the provided
+// label, or an empty string if it isn't supported.
+func lookupCharset(label string) string {
+	label = strings.Trim(strings.TrimSpace(label), `"'`)
+	if label == "" {
+		return ""
+	}
+
+	if e, name := charset.Lookup(label); e != nil {
+		return strings.ToLower(name)
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package htmlutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encodeFixture encodes a UTF-8 document with the provided encoding.
+func encodeFixture(t testing.TB, e encoding.Encoding, s string) []byte {
+	t.Helper()
+
+	b, err := e.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("encoding fixture: %v", err)
+	}
+	return b
+}
+
+var charsetFixtures = []struct {
+	name     string
+	encoding encoding.Encoding
+	charset  string
+	text     string
+}{
+	{"windows-1251", charmap.Windows1251, "windows-1251", "Съешь же ещё этих мягких французских булок"},
+	{"shift_jis", japanese.ShiftJIS, "shift_jis", "いろはにほへと、ちりぬるを。漢字とカタカナ"},
+	{"euc-kr", korean.EUCKR, "euc-kr", "다람쥐 헌 쳇바퀴에 타고파"},
+	{"iso-8859-2", charmap.ISO8859_2, "iso-8859-2", "Příliš žluťoučký kůň úpěl ďábelské ódy"},
+}
+
+func TestParseReaderWithCharsetMeta(t *testing.T) {
+	for _, tt := range charsetFixtures {
+		markup := `<!DOCTYPE html><html><head><meta charset="` + tt.charset + `"><title>` + tt.text + `</title></head>` +
+			`<body><p>` + tt.text + `</p></body></html>`
+		data := encodeFixture(t, tt.encoding, markup)
+		if bytes.Contains(data, []byte(tt.text)) {
+			t.Fatalf("%s: fixture is UTF-8", tt.name)
+		}
+
+		doc, name, err := ParseReaderWithCharset(bytes.NewReader(data), "")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if name != tt.charset {
+			t.Errorf("%s: got charset %q", tt.name, name)
+		}
+		if got := GetText(GetFirstHtmlNode(doc, "p", "", "")); got != tt.text {
+			t.Errorf("%s: got text %q, want %q", tt.name, got, tt.text)
+		}
+		if got := GetText(GetFirstHtmlNode(doc, "title", "", "")); got != tt.text {
+			t.Errorf("%s: got title %q, want %q", tt.name, got, tt.text)
+		}
+	}
+}
+
+func TestParseReaderWithCharsetHint(t *testing.T) {
+	for _, tt := range charsetFixtures {
+		// The hint wins over a wrong declaration
+		data := encodeFixture(t, tt.encoding, `<meta http-equiv="Content-Type" content="text/html; charset=utf-8"><p>`+tt.text+`</p>`)
+
+		for _, hint := range []string{"text/html; charset=" + strings.ToUpper(tt.charset), tt.charset} {
+			doc, name, err := ParseReaderWithCharset(bytes.NewReader(data), hint)
+			if err != nil {
+				t.Errorf("%s: %q: %v", tt.name, hint, err)
+				continue
+			}
+			if name != tt.charset {
+				t.Errorf("%s: %q: got charset %q", tt.name, hint, name)
+			}
+			if got := GetText(GetFirstHtmlNode(doc, "p", "", "")); got != tt.text {
+				t.Errorf("%s: %q: got text %q, want %q", tt.name, hint, got, tt.text)
+			}
+		}
+	}
+}
+
+func TestParseReaderWithCharsetBOM(t *testing.T) {
+	const text = "Grüße, 世界"
+	markup := `<meta charset="windows-1251"><p>` + text + `</p>`
+
+	tests := []struct {
+		name     string
+		encoding encoding.Encoding
+		charset  string
+	}{
+		{"utf-8", unicode.UTF8BOM, "utf-8"},
+		{"utf-16le", unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "utf-16le"},
+		{"utf-16be", unicode.UTF16(unicode.BigEndian, unicode.UseBOM), "utf-16be"},
+	}
+
+	for _, tt := range tests {
+		data := encodeFixture(t, tt.encoding, markup)
+
+		// The byte order mark wins over both the hint and the declaration
+		doc, name, err := ParseReaderWithCharset(bytes.NewReader(data), "shift_jis")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if name != tt.charset {
+			t.Errorf("%s: got charset %q", tt.name, name)
+		}
+		if got := GetText(GetFirstHtmlNode(doc, "body", "", "")); got != text {
+			t.Errorf("%s: got text %q, want %q without the byte order mark", tt.name, got, text)
+		}
+	}
+}
+
+func TestDetectCharset(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		hint string
+		want string
+	}{
+		{"default", `<p>x</p>`, "", "utf-8"},
+		{"meta charset", `<meta charset="Shift_JIS">`, "", "shift_jis"},
+		{"meta charset label", `<meta charset=latin1>`, "", "windows-1252"},
+		{"http-equiv", `<meta http-equiv="content-type" content="text/html; charset=koi8-r">`, "", "koi8-r"},
+		{"unknown meta charset", `<meta charset="bogus"><meta charset="euc-kr">`, "", "euc-kr"},
+		{"utf-16 declaration", `<meta charset="utf-16le">`, "", "utf-8"},
+		{"x-user-defined", `<meta charset="x-user-defined">`, "", "windows-1252"},
+		{"beyond the prescan", strings.Repeat(" ", 1024) + `<meta charset="shift_jis">`, "", "utf-8"},
+		{"content type hint", `<meta charset="shift_jis">`, "text/html; charset=windows-1251", "windows-1251"},
+		{"hint without charset", `<meta charset="shift_jis">`, "text/html", "shift_jis"},
+		{"bare hint", `<p>x</p>`, " EUC-JP ", "euc-jp"},
+		{"bom", "\xef\xbb\xbf<meta charset=\"shift_jis\">", "windows-1251", "utf-8"},
+	}
+
+	for _, tt := range tests {
+		got, err := DetectCharset([]byte(tt.head), tt.hint)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCharsetUnsupportedHint(t *testing.T) {
+	for _, hint := range []string{"bogus", "text/html; charset=bogus"} {
+		if _, err := DetectCharset([]byte(`<p>x</p>`), hint); err == nil || !strings.Contains(err.Error(), `"bogus"`) {
+			t.Errorf("DetectCharset %q: got %v, want an unsupported charset error", hint, err)
+		}
+		if _, _, err := ParseReaderWithCharset(strings.NewReader(`<p>x</p>`), hint); err == nil {
+			t.Errorf("ParseReaderWithCharset %q: got no error", hint)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package htmlutil
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetHtmlNodesByClass returns the HTML nodes found within the provided node
+// that have the provided class, up to the provided count.
+//
+// The tag is optional. If it is empty, it will not be used as search criteria.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesByClass(n *html.Node, tag string, class string, count int) []*html.Node {
+	return GetHtmlNodesFunc(n, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && (tag == "" || n.Data == tag) && HasClass(n, class)
+	}, count)
+}
+
+// HasClass reports whether the class attribute of the provided node contains
+// the provided class as one of its whitespace-separated tokens. As in CSS, the
+// comparison is case-sensitive.
+func HasClass(n *html.Node, class string) bool {
+	if n == nil || class == "" {
+		return false
+	}
+
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == "class" {
+			for _, token := range splitHtmlTokens(a.Val) {
+				if token == class {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// splitHtmlTokens splits s into tokens separated by ASCII whitespace as
+// defined by the HTML spec.
+func splitHtmlTokens(s string) []string {
+	return strings.FieldsFunc(s, isHtmlSpace)
+}
+
+// isHtmlSpace reports whether r is ASCII whitespace as defined by the HTML
+// spec.
+func isHtmlSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+// AddClass adds the provided classes to the class attribute of the provided
+// node, creating the attribute if needed. Classes the node already has are not
+// added again, duplicate tokens are removed, and the order of existing tokens
+// is preserved.
+func AddClass(n *html.Node, classes ...string) {
+	setClassTokens(n, func(tokens []string) []string {
+		for _, class := range classes {
+			for _, token := range splitHtmlTokens(class) {
+				if !slices.Contains(tokens, token) {
+					tokens = append(tokens, token)
+				}
+			}
+		}
+		return tokens
+	})
+}
+
+// RemoveClass removes the provided classes from the class attribute of the
+// provided node. As with AddClass(), each class can hold several
+// whitespace-separated tokens. The class attribute is removed if no classes
+// are left.
+func RemoveClass(n *html.Node, classes ...string) {
+	var removed []string
+	for _, class := range classes {
+		removed = append(removed, splitHtmlTokens(class)...)
+	}
+
+	setClassTokens(n, func(tokens []string) []string {
+		return slices.DeleteFunc(tokens, func(token string) bool {
+			return slices.Contains(removed, token)
+		})
+	})
+}
+
+// ToggleClass adds the provided class to the provided node if it doesn't have
+// it, and removes it otherwise. It reports whether the node has the class
+// afterwards.
+func ToggleClass(n *html.Node, class string) bool {
+	if n == nil {
+		return false
+	}
+	if HasClass(n, class) {
+		RemoveClass(n, class)
+		return false
+	}
+
+	AddClass(n, class)
+	return true
+}
+
+// AddClassOnMatches adds the provided classes to the HTML nodes matching the
+// provided tag, attribute, and attribute value, using the same criteria as
+// GetHtmlNodes().
+func AddClassOnMatches(root *html.Node, tag string, attr string, attrValue string, classes ...string) {
+	for _, n := range GetAllHtmlNodes(root, tag, attr, attrValue) {
+		AddClass(n, classes...)
+	}
+}
+
+// RemoveClassOnMatches removes the provided classes from the HTML nodes
+// matching the provided tag, attribute, and attribute value, using the same
+// criteria as GetHtmlNodes().
+func RemoveClassOnMatches(root *html.Node, tag string, attr string, attrValue string, classes ...string) {
+	for _, n := range GetAllHtmlNodes(root, tag, attr, attrValue) {
+		RemoveClass(n, classes...)
+	}
+}
+
+// setClassTokens replaces the class tokens of n with the result of update,
+// which is passed the current tokens without duplicates. The class attribute
+// is only rewritten if the tokens change, and is removed if none are left.
+// Nothing happens if n is nil.
+func setClassTokens(n *html.Node, update func(tokens []string) []string) {
+	if n == nil {
+		return
+	}
+
+	val, hasClass := GetAttr(n, "class")
+
+	var tokens []string
+	for _, token := range splitHtmlTokens(val) {
+		if !slices.Contains(tokens, token) {
+			tokens = append(tokens, token)
+		}
+	}
+	unchanged := len(tokens) == len(splitHtmlTokens(val))
+
+	before := slices.Clone(tokens)
+	tokens = update(tokens)
+	if unchanged && slices.Equal(before, tokens) {
+		return
+	}
+
+	if len(tokens) == 0 {
+		if hasClass {
+			n.Attr = slices.DeleteFunc(n.Attr, func(a html.Attribute) bool {
+				return a.Namespace == "" && a.Key == "class"
+			})
+		}
+		return
+	}
+
+	SetHtmlAttr(n, "class", strings.Join(tokens, " "))
+}
@@ -0,0 +1,115 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// classAttr returns the class attribute of n, or "<none>" if it has none.
+func classAttr(n *html.Node) string {
+	return GetAttrOr(n, "class", "<none>")
+}
+
+func TestAddClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		classes []string
+		want    string
+	}{
+		{"no attribute", `<p>`, []string{"a"}, "a"},
+		{"empty attribute", `<p class="">`, []string{"a"}, "a"},
+		{"append", `<p class="a b">`, []string{"c"}, "a b c"},
+		{"already present", `<p class="a b">`, []string{"b"}, "a b"},
+		{"several", `<p class="a">`, []string{"b", "a", "c"}, "a b c"},
+		{"tokens in one argument", `<p class="a">`, []string{"b  c", "\td"}, "a b c d"},
+		{"existing duplicates", `<p class="a b a">`, []string{"c"}, "a b c"},
+		{"tabs and newlines", "<p class=\"a\tb\nc\">", []string{"d"}, "a b c d"},
+		{"unchanged whitespace kept", "<p class=\"a\tb\">", []string{"a"}, "a\tb"},
+		{"duplicates fixed on write", `<p class="a a">`, []string{"a"}, "a"},
+		{"nothing", `<p class="a">`, nil, "a"},
+		{"empty class", `<p>`, []string{""}, "<none>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := GetFirstHtmlNode(mustParse(t, tt.doc), "p", "", "")
+			AddClass(p, tt.classes...)
+			if got := classAttr(p); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		classes []string
+		want    string
+	}{
+		{"middle", `<p class="a b c">`, []string{"b"}, "a c"},
+		{"several", `<p class="a b c">`, []string{"c", "a"}, "b"},
+		{"tokens in one argument", `<p class="a b c">`, []string{"a c"}, "b"},
+		{"last removes attribute", `<p class="a" id="x">`, []string{"a"}, "<none>"},
+		{"duplicates", `<p class="a b a">`, []string{"a"}, "b"},
+		{"missing", `<p class="a">`, []string{"b"}, "a"},
+		{"no attribute", `<p>`, []string{"a"}, "<none>"},
+		{"empty attribute", `<p class="">`, []string{"a"}, ""},
+		{"tabs and newlines", "<p class=\"a\tb\nc\">", []string{"b"}, "a c"},
+		{"case-sensitive", `<p class="A">`, []string{"a"}, "A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := GetFirstHtmlNode(mustParse(t, tt.doc), "p", "", "")
+			RemoveClass(p, tt.classes...)
+			if got := classAttr(p); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggleClass(t *testing.T) {
+	p := GetFirstHtmlNode(mustParse(t, `<p class="a">`), "p", "", "")
+
+	if !ToggleClass(p, "b") || classAttr(p) != "a b" {
+		t.Errorf("toggle on: got %q", classAttr(p))
+	}
+	if ToggleClass(p, "a") || classAttr(p) != "b" {
+		t.Errorf("toggle off: got %q", classAttr(p))
+	}
+	if ToggleClass(p, "b") || classAttr(p) != "<none>" {
+		t.Errorf("toggle last off: got %q", classAttr(p))
+	}
+}
+
+func TestClassHelpersNil(t *testing.T) {
+	// Must not panic
+	AddClass(nil, "a")
+	RemoveClass(nil, "a")
+	if ToggleClass(nil, "a") {
+		t.Error("ToggleClass(nil) reported the class as set")
+	}
+}
+
+func TestClassOnMatches(t *testing.T) {
+	doc := mustParse(t, `<table id="t1"></table><table id="t2" class="old"></table><div class="old"></div>`)
+
+	AddClassOnMatches(doc, "table", "", "", "table-striped")
+	RemoveClassOnMatches(doc, "", "class", "", "old")
+
+	var got []string
+	for _, id := range []string{"t1", "t2"} {
+		got = append(got, classAttr(GetFirstHtmlNode(doc, "", "id", id)))
+	}
+	got = append(got, classAttr(GetFirstHtmlNode(doc, "div", "", "")))
+
+	if want := []string{"table-striped", "table-striped", "<none>"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
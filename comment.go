@@ -0,0 +1,49 @@
+package htmlutil
+
+import (
+	"regexp"
+
+	"golang.org/x/net/html"
+)
+
+// GetAllCommentNodes is a convenience function for GetCommentNodes() that
+// returns all comment nodes.
+func GetAllCommentNodes(n *html.Node) []*html.Node {
+	return GetCommentNodes(n, -1)
+}
+
+// GetCommentNodes returns the comment nodes found within the provided node, in
+// document order, up to the provided count. Text looking like a comment inside
+// script and style elements is raw text rather than a comment node, so it is
+// never returned.
+//
+// If the count is -1, all comment nodes will be returned.
+func GetCommentNodes(n *html.Node, count int) []*html.Node {
+	return GetHtmlNodesFunc(n, func(n *html.Node) bool {
+		return n.Type == html.CommentNode
+	}, count)
+}
+
+// GetCommentNodesMatching is like GetCommentNodes() but only returns the
+// comments whose data, the text between "<!--" and "-->", matches the provided
+// regular expression.
+func GetCommentNodesMatching(n *html.Node, re *regexp.Regexp, count int) []*html.Node {
+	return GetHtmlNodesFunc(n, func(n *html.Node) bool {
+		return n.Type == html.CommentNode && re.MatchString(n.Data)
+	}, count)
+}
+
+// RemoveAllCommentNodes is a convenience function for RemoveCommentNodes()
+// that removes all comment nodes.
+func RemoveAllCommentNodes(n *html.Node) {
+	RemoveCommentNodes(n, -1)
+}
+
+// RemoveCommentNodes removes the comment nodes found within the provided node
+// up to the provided count. Unlike RemoveHtmlComments(), comments inside pre
+// and textarea elements are removed too.
+//
+// If the count is -1, all comment nodes will be removed.
+func RemoveCommentNodes(n *html.Node, count int) {
+	RemoveNodes(GetCommentNodes(n, count))
+}
@@ -0,0 +1,248 @@
+package htmlutil
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// CompareOptions controls which differences NodesEqual() and
+// FirstDifference() ignore, and which normalizations CanonicalizeHtmlNode()
+// applies.
+type CompareOptions struct {
+	// IgnoreAttrOrder compares attributes as sets rather than lists.
+	IgnoreAttrOrder bool
+
+	// IgnoreWhitespace ignores text nodes consisting solely of whitespace,
+	// except inside pre, textarea, and other elements where whitespace is
+	// significant.
+	IgnoreWhitespace bool
+
+	// IgnoreComments ignores comment nodes.
+	IgnoreComments bool
+
+	// LowercaseAttrs lists the attributes whose values are compared
+	// case-insensitively, such as "type" or "method".
+	LowercaseAttrs []string
+}
+
+// NodeDifference describes the first point where two trees diverge.
+type NodeDifference struct {
+	// Path locates the differing nodes, as in "html/body/div[2]/p[1]". Each
+	// element is named by its tag followed by its 1-based position among its
+	// sibling elements with the same tag, except for html, head, and body,
+	// and text and comment nodes are named "text()" and "comment()".
+	Path string
+
+	// Reason describes the difference.
+	Reason string
+
+	// A and B are the differing nodes, either of which is nil when a node
+	// has no counterpart.
+	A *html.Node
+	B *html.Node
+}
+
+// String returns the path and reason of the difference.
+func (d NodeDifference) String() string {
+	return d.Path + ": " + d.Reason
+}
+
+// NodesEqual reports whether the trees rooted at a and b are equal, ignoring
+// the differences allowed by the provided options.
+func NodesEqual(a *html.Node, b *html.Node, opts CompareOptions) bool {
+	return FirstDifference(a, b, opts) == nil
+}
+
+// FirstDifference returns the first point, in document order, where the
+// trees rooted at a and b diverge, ignoring the differences allowed by the
+// provided options, or nil if they are equal.
+func FirstDifference(a *html.Node, b *html.Node, opts CompareOptions) *NodeDifference {
+	if a == nil || b == nil {
+		if a == b {
+			return nil
+		}
+		return &NodeDifference{Reason: "one of the nodes is nil", A: a, B: b}
+	}
+
+	return compareHtmlNodes(a, b, rootPath(a), opts)
+}
+
+// CanonicalizeHtmlNode applies the normalizations of the provided options to
+// the provided node and its descendants in place: attributes are sorted by
+// namespace and key if attribute order is ignored, whitespace-only text nodes
+// and comments are removed if they are ignored, and the values of the listed
+// attributes are lowercased. Rendering or hashing canonicalized trees gives
+// the same result for trees that NodesEqual() considers equal.
+func CanonicalizeHtmlNode(n *html.Node, opts CompareOptions) {
+	var removed []*html.Node
+
+	Walk(n, func(c *html.Node) WalkAction {
+		if c.Type == html.ElementNode {
+			c.Attr = canonicalAttrs(c.Attr, opts)
+		}
+		if c != n && ignoredInComparison(c, opts) {
+			removed = append(removed, c)
+		}
+		return WalkContinue
+	})
+
+	RemoveNodes(removed)
+}
+
+func compareHtmlNodes(a *html.Node, b *html.Node, path string, opts CompareOptions) *NodeDifference {
+	diff := func(reason string, args ...any) *NodeDifference {
+		return &NodeDifference{Path: path, Reason: fmt.Sprintf(reason, args...), A: a, B: b}
+	}
+
+	if a.Type != b.Type {
+		return diff("%s vs %s", describeHtmlNode(a), describeHtmlNode(b))
+	}
+
+	switch a.Type {
+	case html.ElementNode:
+		if a.Data != b.Data || a.Namespace != b.Namespace {
+			return diff("%s vs %s", describeHtmlNode(a), describeHtmlNode(b))
+		}
+		if attrsA, attrsB := canonicalAttrs(a.Attr, opts), canonicalAttrs(b.Attr, opts); !slices.Equal(attrsA, attrsB) {
+			return diff("attributes %s vs %s", formatAttrs(attrsA), formatAttrs(attrsB))
+		}
+	case html.TextNode, html.CommentNode, html.DoctypeNode:
+		if a.Data != b.Data {
+			return diff("%q vs %q", a.Data, b.Data)
+		}
+	}
+
+	childrenA, childrenB := comparedChildren(a, opts), comparedChildren(b, opts)
+	paths := childPaths(childrenA)
+	for i := 0; i < len(childrenA) || i < len(childrenB); i++ {
+		switch {
+		case i >= len(childrenA):
+			return &NodeDifference{Path: joinPath(path, childPaths(childrenB)[i]), Reason: "missing in first tree", B: childrenB[i]}
+		case i >= len(childrenB):
+			return &NodeDifference{Path: joinPath(path, paths[i]), Reason: "missing in second tree", A: childrenA[i]}
+		}
+
+		if d := compareHtmlNodes(childrenA[i], childrenB[i], joinPath(path, paths[i]), opts); d != nil {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// comparedChildren returns the children of n that are not ignored by opts.
+func comparedChildren(n *html.Node, opts CompareOptions) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !ignoredInComparison(c, opts) {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// ignoredInComparison reports whether n is a node ignored by opts.
+func ignoredInComparison(n *html.Node, opts CompareOptions) bool {
+	switch n.Type {
+	case html.CommentNode:
+		return opts.IgnoreComments
+	case html.TextNode:
+		if !opts.IgnoreWhitespace || strings.TrimFunc(n.Data, isHtmlSpace) != "" {
+			return false
+		}
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.Type == html.ElementNode && preservesWhitespace(p) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// canonicalAttrs returns a copy of attrs normalized as specified by opts.
+func canonicalAttrs(attrs []html.Attribute, opts CompareOptions) []html.Attribute {
+	canonical := slices.Clone(attrs)
+	for i, a := range canonical {
+		if a.Namespace == "" && slices.Contains(opts.LowercaseAttrs, a.Key) {
+			canonical[i].Val = strings.ToLower(a.Val)
+		}
+	}
+
+	if opts.IgnoreAttrOrder {
+		slices.SortStableFunc(canonical, compareAttrs)
+	}
+	return canonical
+}
+
+// compareAttrs orders attributes by namespace, key, and value.
+func compareAttrs(a html.Attribute, b html.Attribute) int {
+	if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Key, b.Key); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Val, b.Val)
+}
+
+// formatAttrs formats attributes as they would appear in a start tag.
+func formatAttrs(attrs []html.Attribute) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = qualifiedAttrKey(a) + "=" + strconv.Quote(a.Val)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// rootPath returns the path segment of the root of a comparison: nothing for
+// a document node, and the tag of an element.
+func rootPath(n *html.Node) string {
+	if n.Type == html.ElementNode {
+		return n.Data
+	}
+	return ""
+}
+
+// childPaths returns the path segments of the provided siblings.
+func childPaths(siblings []*html.Node) []string {
+	paths := make([]string, len(siblings))
+	counts := make(map[string]int)
+
+	for i, n := range siblings {
+		var name string
+		switch n.Type {
+		case html.ElementNode:
+			name = n.Data
+		case html.TextNode:
+			name = "text()"
+		case html.CommentNode:
+			name = "comment()"
+		case html.DoctypeNode:
+			name = "doctype()"
+		default:
+			name = "node()"
+		}
+
+		counts[name]++
+		if isHtmlElement(n, "html", "head", "body") {
+			paths[i] = name
+		} else {
+			paths[i] = name + "[" + strconv.Itoa(counts[name]) + "]"
+		}
+	}
+
+	return paths
+}
+
+// joinPath appends a segment to a path.
+func joinPath(path string, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "/" + segment
+}
@@ -0,0 +1,173 @@
+package htmlutil
+
+import (
+	"testing"
+)
+
+func TestNodesEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		opts CompareOptions
+		want bool
+	}{
+		{"identical", `<p class="x">a</p>`, `<p class="x">a</p>`, CompareOptions{}, true},
+		{"formatting", `<p class="x">a</p>`, `<p class='x'>a</p >`, CompareOptions{}, true},
+		{"attribute order", `<a href="/" class="x">a</a>`, `<a class="x" href="/">a</a>`, CompareOptions{}, false},
+		{"attribute order ignored", `<a href="/" class="x">a</a>`, `<a class="x" href="/">a</a>`, CompareOptions{IgnoreAttrOrder: true}, true},
+		{"attribute values", `<a class="x">a</a>`, `<a class="y">a</a>`, CompareOptions{IgnoreAttrOrder: true}, false},
+		{"whitespace", "<div>\n  <p>a</p>\n</div>", `<div><p>a</p></div>`, CompareOptions{}, false},
+		{"whitespace ignored", "<div>\n  <p>a</p>\n</div>", `<div><p>a</p></div>`, CompareOptions{IgnoreWhitespace: true}, true},
+		{"whitespace in text kept", `<p>a b</p>`, `<p>a  b</p>`, CompareOptions{IgnoreWhitespace: true}, false},
+		{"whitespace in pre kept", "<pre><b>a</b> <b>b</b></pre>", "<pre><b>a</b><b>b</b></pre>", CompareOptions{IgnoreWhitespace: true}, false},
+		{"comments", `<p>a<!--x--></p>`, `<p>a</p>`, CompareOptions{}, false},
+		{"comments ignored", `<p><!--x-->a<!--y--></p>`, `<p>a</p>`, CompareOptions{IgnoreComments: true}, true},
+		{"lowercase attributes", `<input type="TEXT" name="Q">`, `<input type="text" name="Q">`, CompareOptions{LowercaseAttrs: []string{"type"}}, true},
+		{"lowercase other attributes", `<input type="text" name="Q">`, `<input type="text" name="q">`, CompareOptions{LowercaseAttrs: []string{"type"}}, false},
+		{"tags", `<p>a</p>`, `<div>a</div>`, CompareOptions{}, false},
+		{"text", `<p>a</p>`, `<p>b</p>`, CompareOptions{}, false},
+		{"doctype", `<!DOCTYPE html><p>a</p>`, `<p>a</p>`, CompareOptions{}, false},
+		{"namespaces", `<svg><a></a></svg>`, `<a></a>`, CompareOptions{IgnoreWhitespace: true}, false},
+		{
+			"all options",
+			"<!DOCTYPE html><html><head></head><body>\n<div id=\"a\" class=\"b\"><!-- c -->\n<input type=\"CHECKBOX\"></div></body></html>",
+			`<!DOCTYPE html><div class="b" id="a"><input type="checkbox"></div>`,
+			CompareOptions{IgnoreAttrOrder: true, IgnoreWhitespace: true, IgnoreComments: true, LowercaseAttrs: []string{"type"}},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+		if got := NodesEqual(a, b, tt.opts); got != tt.want {
+			t.Errorf("%s: got %v, want %v (difference %v)", tt.name, got, tt.want, FirstDifference(a, b, tt.opts))
+		}
+		if got := NodesEqual(b, a, tt.opts); got != tt.want {
+			t.Errorf("%s: reversed: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNodesEqualNil(t *testing.T) {
+	doc := mustParse(t, `<p>a</p>`)
+
+	if !NodesEqual(nil, nil, CompareOptions{}) {
+		t.Error("nil trees are not equal")
+	}
+	if NodesEqual(doc, nil, CompareOptions{}) || NodesEqual(nil, doc, CompareOptions{}) {
+		t.Error("nil tree equal to a document")
+	}
+}
+
+func TestFirstDifference(t *testing.T) {
+	const base = `<div><p>a</p></div><div><p>b</p><p id="x" class="y">c</p></div>`
+
+	tests := []struct {
+		name       string
+		b          string
+		opts       CompareOptions
+		wantPath   string
+		wantReason string
+	}{
+		{
+			name:       "text",
+			b:          `<div><p>a</p></div><div><p>b</p><p id="x" class="y">changed</p></div>`,
+			wantPath:   "html/body/div[2]/p[2]/text()[1]",
+			wantReason: `"c" vs "changed"`,
+		},
+		{
+			name:       "attributes",
+			b:          `<div><p>a</p></div><div><p>b</p><p class="y" id="x">c</p></div>`,
+			wantPath:   "html/body/div[2]/p[2]",
+			wantReason: `attributes [id="x" class="y"] vs [class="y" id="x"]`,
+		},
+		{
+			name:       "sorted attributes",
+			b:          `<div><p>a</p></div><div><p>b</p><p class="z" id="x">c</p></div>`,
+			opts:       CompareOptions{IgnoreAttrOrder: true},
+			wantPath:   "html/body/div[2]/p[2]",
+			wantReason: `attributes [class="y" id="x"] vs [class="z" id="x"]`,
+		},
+		{
+			name:       "missing in second tree",
+			b:          `<div><p>a</p></div><div><p>b</p></div>`,
+			wantPath:   "html/body/div[2]/p[2]",
+			wantReason: "missing in second tree",
+		},
+		{
+			name:       "missing in first tree",
+			b:          base + `<!--extra-->`,
+			wantPath:   "html/body/comment()[1]",
+			wantReason: "missing in first tree",
+		},
+		{
+			name:     "tag",
+			b:        `<div><p>a</p></div><section><p>b</p></section>`,
+			wantPath: "html/body/div[2]",
+		},
+	}
+
+	a := mustParse(t, base)
+	for _, tt := range tests {
+		d := FirstDifference(a, mustParse(t, tt.b), tt.opts)
+		if d == nil {
+			t.Errorf("%s: got no difference", tt.name)
+			continue
+		}
+		if d.Path != tt.wantPath || (tt.wantReason != "" && d.Reason != tt.wantReason) {
+			t.Errorf("%s: got %s, want %s: %s", tt.name, d, tt.wantPath, tt.wantReason)
+		}
+		if d.A == nil && d.B == nil {
+			t.Errorf("%s: got no nodes", tt.name)
+		}
+	}
+
+	if d := FirstDifference(a, mustParse(t, base), CompareOptions{}); d != nil {
+		t.Errorf("equal trees: got %s", d)
+	}
+}
+
+func TestFirstDifferenceElementRoot(t *testing.T) {
+	a := GetFirstHtmlNode(mustParse(t, `<ul><li>a</li><li>b</li></ul>`), "ul", "", "")
+	b := GetFirstHtmlNode(mustParse(t, `<ul><li>a</li><li>c</li></ul>`), "ul", "", "")
+
+	d := FirstDifference(a, b, CompareOptions{})
+	if d == nil || d.Path != "ul/li[2]/text()[1]" {
+		t.Errorf("got %v, want a path starting at the root element", d)
+	}
+}
+
+func TestCanonicalizeHtmlNode(t *testing.T) {
+	opts := CompareOptions{IgnoreAttrOrder: true, IgnoreWhitespace: true, IgnoreComments: true, LowercaseAttrs: []string{"method"}}
+	a := mustParse(t, "<form method=\"POST\" action=\"/a\">\n  <!-- fields -->\n  <input name=\"q\" type=\"text\">\n  <pre> </pre>\n</form>")
+	b := mustParse(t, `<form action="/a" method="post"><input type="text" name="q"><pre> </pre></form>`)
+
+	CanonicalizeHtmlNode(a, opts)
+	CanonicalizeHtmlNode(b, opts)
+	checkSiblings(t, a)
+
+	want := `<html><head></head><body><form action="/a" method="post"><input name="q" type="text"/><pre> </pre></form></body></html>`
+	if got := mustRender(t, a); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if got := mustRender(t, b); got != want {
+		t.Errorf("second tree: got  %s\nwant %s", got, want)
+	}
+
+	// Canonicalized trees are equal without options
+	if d := FirstDifference(a, b, CompareOptions{}); d != nil {
+		t.Errorf("got difference %s", d)
+	}
+}
+
+func TestCanonicalizeHtmlNodeNoOptions(t *testing.T) {
+	markup := "<div b=\"2\" a=\"1\">\n<!--c--> <p>x</p></div>"
+	doc := mustParse(t, markup)
+	before := mustRender(t, doc)
+
+	CanonicalizeHtmlNode(doc, CompareOptions{})
+	if got := mustRender(t, doc); got != before {
+		t.Errorf("got %s, want the tree unchanged", got)
+	}
+}
@@ -0,0 +1,286 @@
+package htmlutil
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultPenalizedNames lists the class and id substrings that
+// ExtractMainContent() penalizes when ContentOptions.PenalizedNames is nil.
+var DefaultPenalizedNames = []string{
+	"comment", "sidebar", "share", "social", "related", "footer", "nav",
+	"menu", "promo", "sponsor", "advert", "banner", "widget",
+}
+
+// ContentOptions controls the heuristics of ExtractMainContent().
+type ContentOptions struct {
+	// MinTextLength is the number of characters of text an article or main
+	// element must have to be used as the main content without scoring. If
+	// 0, 200 is used.
+	MinTextLength int
+
+	// MaxLinkDensity is the fraction of the text of a candidate that may be
+	// link text. Candidates above it are discarded. If 0, 0.5 is used.
+	MaxLinkDensity float64
+
+	// PenalizedNames lists the substrings of class and id attributes, compared
+	// case-insensitively, that make an element unlikely to be the main
+	// content. If nil, DefaultPenalizedNames is used.
+	PenalizedNames []string
+}
+
+// ExtractMainContent heuristically locates the element holding the main
+// content of the provided document, such as the text of an article, and
+// returns a detached clone of it, leaving the document untouched.
+//
+// An article element, or failing that a main element, with enough text and
+// no penalized class or id is preferred. Otherwise, the parents and
+// grandparents of paragraphs are scored by the number, length, and commas of
+// their paragraphs, reduced by their link density and by penalized class or
+// id names, and the best candidate wins. The nav, aside, footer, header, form,
+// script, and style elements within the winner are removed from the clone.
+//
+// If no candidate is found, ErrNodeNotFound is returned.
+func ExtractMainContent(doc *html.Node, opts ContentOptions) (*html.Node, error) {
+	if opts.MinTextLength == 0 {
+		opts.MinTextLength = 200
+	}
+	if opts.MaxLinkDensity == 0 {
+		opts.MaxLinkDensity = 0.5
+	}
+	if opts.PenalizedNames == nil {
+		opts.PenalizedNames = DefaultPenalizedNames
+	}
+
+	best := semanticMainContent(doc, opts)
+	if best == nil {
+		best = scoredMainContent(doc, opts)
+	}
+	if best == nil {
+		return nil, ErrNodeNotFound
+	}
+
+	content := CloneHtmlNode(best)
+	RemoveNodes(GetAllHtmlNodesFunc(content, func(n *html.Node) bool {
+		if n == content || n.Type != html.ElementNode || n.Namespace != "" {
+			return false
+		}
+		switch n.Data {
+		case "nav", "aside", "footer", "header", "form", "script", "style":
+			return true
+		}
+		return false
+	}))
+
+	return content, nil
+}
+
+// semanticMainContent returns the article element, or failing that the main
+// element, with the longest text, if it is long enough and not penalized.
+func semanticMainContent(doc *html.Node, opts ContentOptions) *html.Node {
+	for _, tag := range []string{"article", "main"} {
+		var best *html.Node
+		bestLength := 0
+
+		for _, n := range GetAllHtmlNodes(doc, tag, "", "") {
+			if hasPenalizedName(n, opts.PenalizedNames) {
+				continue
+			}
+			if length := textLength(n); length >= opts.MinTextLength && length > bestLength {
+				best, bestLength = n, length
+			}
+		}
+
+		if best != nil {
+			return best
+		}
+	}
+	return nil
+}
+
+// scoredMainContent returns the candidate with the best score according to
+// its paragraphs, or nil if no paragraph has enough text.
+func scoredMainContent(doc *html.Node, opts ContentOptions) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	for _, p := range GetAllHtmlNodesFunc(doc, isContentParagraph) {
+		text := GetNormalizedText(p)
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			continue
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(length)/100, 3)
+		addScore(p.Parent, score)
+		if p.Parent != nil {
+			addScore(p.Parent.Parent, score/2)
+		}
+	}
+
+	var best *html.Node
+	bestScore := 0.0
+
+	for _, n := range candidates {
+		density := linkDensity(n)
+		if density > opts.MaxLinkDensity {
+			continue
+		}
+
+		score := scores[n] * (1 - density)
+		if hasPenalizedName(n, opts.PenalizedNames) {
+			score -= 25
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+
+	return best
+}
+
+// isContentParagraph reports whether n is a p or pre element.
+func isContentParagraph(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.Namespace == "" && (n.Data == "p" || n.Data == "pre")
+}
+
+// linkDensity returns the fraction of the text of n that is within links.
+func linkDensity(n *html.Node) float64 {
+	total := textLength(n)
+	if total == 0 {
+		return 0
+	}
+
+	links := 0
+	for _, a := range GetAllHtmlNodes(n, "a", "", "") {
+		links += textLength(a)
+	}
+	return float64(links) / float64(total)
+}
+
+// textLength returns the number of characters of the normalized text of n.
+func textLength(n *html.Node) int {
+	return utf8.RuneCountInString(GetNormalizedText(n))
+}
+
+// hasPenalizedName reports whether the class or id attribute of n contains
+// one of the provided names, compared case-insensitively.
+func hasPenalizedName(n *html.Node, names []string) bool {
+	return attrContainsAny(n, []string{"class", "id"}, names)
+}
+
+// attrContainsAny reports whether one of the provided attributes of n
+// contains one of the provided substrings, compared case-insensitively.
+func attrContainsAny(n *html.Node, keys []string, substrings []string) bool {
+	for _, key := range keys {
+		val := strings.ToLower(GetAttrOr(n, key, ""))
+		if val == "" {
+			continue
+		}
+		for _, s := range substrings {
+			if s != "" && strings.Contains(val, strings.ToLower(s)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BoilerplateOptions controls which elements StripBoilerplate() removes.
+type BoilerplateOptions struct {
+	// Tags lists the elements that are removed, such as nav or footer.
+	Tags []string
+
+	// Roles lists the values of the role attribute, compared
+	// case-insensitively, of the elements that are removed.
+	Roles []string
+
+	// Names lists the substrings of class and id attributes, compared
+	// case-insensitively, of the elements that are removed.
+	Names []string
+
+	// MaxTextFraction is the largest fraction of the text of the provided
+	// node that a matching element may contain and still be removed, which
+	// keeps a site wrapping everything in <div class="ad-wrapper"> from losing
+	// its content. If 0, the fraction is not checked.
+	MaxTextFraction float64
+}
+
+// DefaultBoilerplateOptions returns BoilerplateOptions removing navigation,
+// footers, asides, banners, ads, cookie notices, newsletter forms, and social
+// widgets, as long as they hold at most half of the text.
+func DefaultBoilerplateOptions() BoilerplateOptions {
+	return BoilerplateOptions{
+		Tags:  []string{"nav", "footer", "aside"},
+		Roles: []string{"banner", "navigation", "complementary", "contentinfo"},
+		Names: []string{
+			"advert", "ad-slot", "cookie", "newsletter", "share", "social",
+			"sponsor", "subscribe", "popup", "related",
+		},
+		MaxTextFraction: 0.5,
+	}
+}
+
+// StripBoilerplate removes the elements within the provided node matching the
+// tags, roles, or class and id names of the provided options, along with their
+// descendants, and returns the number of subtrees removed. The html, head, and
+// body elements are never removed.
+func StripBoilerplate(n *html.Node, opts BoilerplateOptions) int {
+	tags := stringSet(opts.Tags)
+	total := textLength(n)
+
+	removed := 0
+	Walk(n, func(c *html.Node) WalkAction {
+		if c == n || c.Parent == nil || !isBoilerplate(c, tags, opts) {
+			return WalkContinue
+		}
+		if opts.MaxTextFraction > 0 && total > 0 && float64(textLength(c)) > opts.MaxTextFraction*float64(total) {
+			return WalkContinue
+		}
+
+		c.Parent.RemoveChild(c)
+		removed++
+		return WalkSkipChildren
+	})
+
+	return removed
+}
+
+// isBoilerplate reports whether n matches the tags, roles, or names of the
+// provided options.
+func isBoilerplate(n *html.Node, tags map[string]bool, opts BoilerplateOptions) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if n.Namespace == "" {
+		switch n.Data {
+		case "html", "head", "body":
+			return false
+		}
+	}
+
+	if tags[n.Data] {
+		return true
+	}
+
+	role := strings.ToLower(strings.TrimSpace(GetAttrOr(n, "role", "")))
+	for _, r := range opts.Roles {
+		if role != "" && role == strings.ToLower(r) {
+			return true
+		}
+	}
+
+	return attrContainsAny(n, []string{"class", "id"}, opts.Names)
+}
@@ -0,0 +1,146 @@
+package htmlutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const articleBody = `The council approved the new bridge on Tuesday, after three years of debate, ` +
+	`ending a dispute that had divided the town. Construction starts in the spring, and the ` +
+	`old ferry will keep running until the bridge opens, the mayor said.`
+
+var contentFixtures = []struct {
+	name   string
+	markup string
+}{
+	{
+		name: "article element",
+		markup: `<html><head><title>News</title></head><body>` +
+			`<header><nav><a href="/">Home</a> <a href="/world">World</a> <a href="/sport">Sport</a></nav></header>` +
+			`<article><header><h1>Bridge approved</h1><nav><a href="/tags/town">Town news</a></nav></header>` +
+			`<p>` + articleBody + `</p>` +
+			`<p>Residents will be able to comment on the design, chosen among four proposals, until May.</p>` +
+			`<aside>Read also: a history of the ferry</aside>` +
+			`<form><input name="email"> Subscribe to our newsletter</form>` +
+			`<footer>Published by the town desk</footer></article>` +
+			`<footer>Copyright the Town Gazette</footer></body></html>`,
+	},
+	{
+		name: "main element",
+		markup: `<html><body><nav><ul><li><a href="/">Home</a></li><li><a href="/a">Archive</a></li></ul></nav>` +
+			`<main><h1>Bridge approved</h1><p>` + articleBody + `</p><script>track()</script></main>` +
+			`<aside class="sidebar"><p>Popular: ten facts about rivers that will surprise you, we promise.</p></aside>` +
+			`</body></html>`,
+	},
+	{
+		name: "scored blog post",
+		markup: `<html><body><div id="page">` +
+			`<div class="menu"><a href="/">Home</a> | <a href="/about">About</a> | <a href="/contact">Contact</a></div>` +
+			`<div class="post"><h2>Bridge approved</h2>` +
+			`<p>` + articleBody + `</p>` +
+			`<p>The design, a steel arch, was chosen among four proposals, and it will carry two lanes, a bike path, and a footpath.</p>` +
+			`<p>Funding comes from the region, the state, and a bond, which voters approved last year by a wide margin.</p>` +
+			`</div>` +
+			`<div id="comments"><p>Great news, finally, after all these years of waiting for it!</p>` +
+			`<p>I will miss the ferry, honestly, it was charming and slow.</p></div>` +
+			`<div class="share"><a href="/s/1">Share this post on your favourite network</a></div>` +
+			`</div></body></html>`,
+	},
+	{
+		name: "short article falls back to scoring",
+		markup: `<html><body><article><p>Teaser text only.</p></article>` +
+			`<div class="story"><p>` + articleBody + `</p><p>More details, quotes, and reactions follow below.</p></div>` +
+			`<div class="links"><p><a href="/1">Link one, which is long enough to count</a> <a href="/2">and another link here</a></p></div>` +
+			`</body></html>`,
+	},
+	{
+		name: "penalized article",
+		markup: `<html><body><article class="comment-list"><p>` + strings.Repeat("A reader comment, with some words. ", 10) + `</p></article>` +
+			`<article><p>` + articleBody + `</p></article></body></html>`,
+	},
+}
+
+func TestExtractMainContent(t *testing.T) {
+	boilerplate := []string{"Home", "Town news", "Read also", "Subscribe", "Published by", "Copyright",
+		"Popular", "Archive", "track()", "Great news", "Share this", "Teaser", "Link one", "A reader comment"}
+
+	for _, tt := range contentFixtures {
+		doc := mustParse(t, tt.markup)
+		before := mustRender(t, doc)
+
+		content, err := ExtractMainContent(doc, ContentOptions{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if content.Parent != nil || content.PrevSibling != nil || content.NextSibling != nil {
+			t.Errorf("%s: content is not detached", tt.name)
+		}
+		if mustRender(t, doc) != before {
+			t.Errorf("%s: document was modified", tt.name)
+		}
+
+		text := GetNormalizedText(content)
+		if !strings.Contains(text, articleBody) {
+			t.Errorf("%s: got %q, want the article body", tt.name, text)
+		}
+		for _, s := range boilerplate {
+			if strings.Contains(text, s) {
+				t.Errorf("%s: got %q, want no %q", tt.name, text, s)
+			}
+		}
+	}
+}
+
+func TestExtractMainContentOptions(t *testing.T) {
+	markup := `<html><body><article><p>Short article.</p></article>` +
+		`<div class="sidebar"><p>` + articleBody + `</p></div></body></html>`
+
+	tests := []struct {
+		name string
+		opts ContentOptions
+		want string
+	}{
+		{"penalized candidate", ContentOptions{}, "Short article." + articleBody},
+		{"min text length", ContentOptions{MinTextLength: 5}, "Short article."},
+		{"no penalized names", ContentOptions{PenalizedNames: []string{}}, articleBody},
+		{"custom penalized names", ContentOptions{MinTextLength: 5, PenalizedNames: []string{"ARTICLE"}}, "Short article."},
+	}
+
+	for _, tt := range tests {
+		content, err := ExtractMainContent(mustParse(t, markup), tt.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := GetNormalizedText(content); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractMainContentLinkDensity(t *testing.T) {
+	markup := `<html><body><div id="links"><p><a href="/a">` + articleBody + `</a> and a little text</p></div></body></html>`
+
+	if _, err := ExtractMainContent(mustParse(t, markup), ContentOptions{}); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("got %v, want ErrNodeNotFound for a link list", err)
+	}
+
+	content, err := ExtractMainContent(mustParse(t, markup), ContentOptions{MaxLinkDensity: 1})
+	if err != nil {
+		t.Fatalf("MaxLinkDensity 1: %v", err)
+	}
+	if !strings.Contains(GetNormalizedText(content), articleBody) {
+		t.Errorf("MaxLinkDensity 1: got %q", GetNormalizedText(content))
+	}
+}
+
+func TestExtractMainContentNotFound(t *testing.T) {
+	for _, markup := range []string{``, `<p>Too short.</p>`, `<nav><a href="/">Home</a></nav>`} {
+		if _, err := ExtractMainContent(mustParse(t, markup), ContentOptions{}); !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("%q: got %v, want ErrNodeNotFound", markup, err)
+		}
+	}
+}
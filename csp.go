@@ -0,0 +1,70 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// NonceOptions controls which elements ApplyCSPNonce() modifies.
+type NonceOptions struct {
+	// IncludeExternal also sets the nonce on scripts with a src attribute and
+	// on <link rel="stylesheet"> elements, for policies that allow external
+	// resources by nonce rather than by host.
+	IncludeExternal bool
+}
+
+// ApplyCSPNonce sets the nonce attribute of the inline script and style
+// elements of the provided document to the provided nonce, replacing any
+// nonce they already have, so that a Content-Security-Policy with a nonce
+// source allows them. It returns the number of elements whose nonce was set.
+// Nothing is modified if the nonce is empty.
+//
+// Style attributes and event handler attributes such as onclick can't be
+// allowed by a nonce. Use CountInlineStyleAttrs() to find out whether a
+// policy without 'unsafe-inline' will break the document's inline styles.
+func ApplyCSPNonce(doc *html.Node, nonce string, opts NonceOptions) int {
+	if nonce == "" {
+		return 0
+	}
+
+	count := 0
+	Walk(doc, func(n *html.Node) WalkAction {
+		if n.Type != html.ElementNode {
+			return WalkContinue
+		}
+
+		apply := false
+		switch n.Data {
+		case "script":
+			_, external := GetAttr(n, "src")
+			apply = !external || opts.IncludeExternal
+		case "style":
+			apply = true
+		case "link":
+			apply = opts.IncludeExternal && n.Namespace == "" && hasRelToken(n, "stylesheet")
+		}
+
+		if apply {
+			SetHtmlAttr(n, "nonce", nonce)
+			count++
+		}
+		return WalkContinue
+	})
+
+	return count
+}
+
+// CountInlineStyleAttrs returns the number of elements within the provided
+// node that have a style attribute, which a Content-Security-Policy without
+// 'unsafe-inline' in style-src blocks even when a nonce is used.
+func CountInlineStyleAttrs(n *html.Node) int {
+	count := 0
+
+	Walk(n, func(n *html.Node) WalkAction {
+		if _, ok := GetAttr(n, "style"); ok && n.Type == html.ElementNode {
+			count++
+		}
+		return WalkContinue
+	})
+
+	return count
+}
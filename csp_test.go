@@ -0,0 +1,94 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const cspDoc = `<!DOCTYPE html><html><head>` +
+	`<script>var a = 1;</script><script src="/app.js"></script>` +
+	`<style>p { color: red }</style><link rel="stylesheet" href="/site.css"><link rel="icon" href="/i.png">` +
+	`<script nonce="stale" nonce="duplicate">var b = 2;</script></head>` +
+	`<body><p style="margin: 0">x</p><div style="">y</div><svg><style>circle { fill: red }</style></svg>` +
+	`<template><script>var c = 3;</script></template><script type="application/ld+json">{}</script></body></html>`
+
+func TestApplyCSPNonce(t *testing.T) {
+	tests := []struct {
+		name string
+		opts NonceOptions
+		want int
+	}{
+		{"inline", NonceOptions{}, 6},
+		{"external", NonceOptions{IncludeExternal: true}, 8},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, cspDoc)
+
+		if got := ApplyCSPNonce(doc, "n0nce", tt.opts); got != tt.want {
+			t.Errorf("%s: got %d elements, want %d", tt.name, got, tt.want)
+		}
+
+		for _, n := range GetAllHtmlNodes(doc, "", "", "") {
+			nonce, ok := GetAttr(n, "nonce")
+			_, external := GetAttr(n, "src")
+			wantNonce := n.Data == "style" ||
+				(n.Data == "script" && (!external || tt.opts.IncludeExternal)) ||
+				(n.Data == "link" && tt.opts.IncludeExternal && GetAttrOr(n, "rel", "") == "stylesheet")
+			if ok != wantNonce || (ok && nonce != "n0nce") {
+				t.Errorf("%s: %s: got nonce %q, %v, want %v", tt.name, mustRender(t, n), nonce, ok, wantNonce)
+			}
+		}
+	}
+}
+
+func TestApplyCSPNonceIdempotent(t *testing.T) {
+	for _, opts := range []NonceOptions{{}, {IncludeExternal: true}} {
+		doc := mustParse(t, cspDoc)
+		first := ApplyCSPNonce(doc, "first", opts)
+		firstHtml := mustRender(t, doc)
+
+		// A second nonce replaces the first on the same elements, without
+		// duplicating the attribute
+		second := ApplyCSPNonce(doc, "second", opts)
+		if first != second {
+			t.Errorf("%+v: got %d elements the second time, want %d", opts, second, first)
+		}
+		got := mustRender(t, doc)
+		if got != strings.ReplaceAll(firstHtml, `nonce="first"`, `nonce="second"`) {
+			t.Errorf("%+v: got  %s\nwant the first rendering with the second nonce", opts, got)
+		}
+		if strings.Contains(got, "stale") || strings.Contains(got, "duplicate") {
+			t.Errorf("%+v: stale nonce kept in %s", opts, got)
+		}
+
+		// The same nonce leaves the document as it is
+		ApplyCSPNonce(doc, "second", opts)
+		if again := mustRender(t, doc); again != got {
+			t.Errorf("%+v: got  %s\nwant %s", opts, again, got)
+		}
+	}
+}
+
+func TestApplyCSPNonceEmpty(t *testing.T) {
+	doc := mustParse(t, cspDoc)
+	before := mustRender(t, doc)
+
+	if got := ApplyCSPNonce(doc, "", NonceOptions{IncludeExternal: true}); got != 0 {
+		t.Errorf("got %d elements", got)
+	}
+	if got := mustRender(t, doc); got != before {
+		t.Errorf("got %s, want the document unchanged", got)
+	}
+}
+
+func TestCountInlineStyleAttrs(t *testing.T) {
+	doc := mustParse(t, cspDoc)
+
+	if got := CountInlineStyleAttrs(doc); got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+	if got := CountInlineStyleAttrs(GetFirstHtmlNode(doc, "head", "", "")); got != 0 {
+		t.Errorf("head: got %d, want 0", got)
+	}
+}
@@ -0,0 +1,103 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetDataAttrs returns the data-* attributes of the provided node, keyed by
+// their names as in the DOM's dataset: without the "data-" prefix, and with
+// each hyphen followed by a lowercase ASCII letter removed and the letter
+// uppercased, so data-x-y-z has the key "xYZ".
+//
+// As in the DOM, attributes whose names contain uppercase ASCII letters are
+// skipped. The parser lowercases attribute names, so these only occur in
+// trees built by hand. If several attributes have the same key, the first one
+// is kept.
+func GetDataAttrs(n *html.Node) map[string]string {
+	attrs := make(map[string]string)
+	if n == nil {
+		return attrs
+	}
+
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !strings.HasPrefix(a.Key, "data-") || strings.ContainsFunc(a.Key, isASCIIUpper) {
+			continue
+		}
+
+		key := datasetKey(strings.TrimPrefix(a.Key, "data-"))
+		if _, ok := attrs[key]; !ok {
+			attrs[key] = a.Val
+		}
+	}
+
+	return attrs
+}
+
+// GetDataAttr returns the value of the data-* attribute of the provided node
+// with the provided name, which can be given as the attribute name
+// ("data-user-id"), without its prefix ("user-id"), or as a dataset key
+// ("userId"). The boolean result reports whether the attribute was found.
+func GetDataAttr(n *html.Node, name string) (string, bool) {
+	return GetAttr(n, dataAttrKey(name))
+}
+
+// FindNodesWithDataAttr returns the element nodes found within the provided
+// node that have the data-* attribute with the provided name, given in any of
+// the forms accepted by GetDataAttr(), up to the provided count.
+//
+// If the count is -1, all nodes will be returned.
+func FindNodesWithDataAttr(root *html.Node, name string, count int) []*html.Node {
+	key := dataAttrKey(name)
+
+	return GetHtmlNodesFunc(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return false
+		}
+		_, ok := GetAttr(n, key)
+		return ok
+	}, count)
+}
+
+// datasetKey converts the name of a data-* attribute without its prefix to a
+// dataset key, as the DOM does.
+func datasetKey(name string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(name); i++ {
+		if name[i] == '-' && i+1 < len(name) && 'a' <= name[i+1] && name[i+1] <= 'z' {
+			sb.WriteByte(name[i+1] - 'a' + 'A')
+			i++
+			continue
+		}
+		sb.WriteByte(name[i])
+	}
+
+	return sb.String()
+}
+
+// dataAttrKey returns the attribute name for a data-* attribute name given in
+// any of the forms accepted by GetDataAttr().
+func dataAttrKey(name string) string {
+	if strings.HasPrefix(name, "data-") {
+		return name
+	}
+
+	var sb strings.Builder
+	sb.WriteString("data-")
+	for i := 0; i < len(name); i++ {
+		if isASCIIUpper(rune(name[i])) {
+			sb.WriteByte('-')
+			sb.WriteByte(name[i] - 'A' + 'a')
+			continue
+		}
+		sb.WriteByte(name[i])
+	}
+
+	return sb.String()
+}
+
+func isASCIIUpper(r rune) bool {
+	return 'A' <= r && r <= 'Z'
+}
@@ -0,0 +1,93 @@
+package htmlutil
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDatasetKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"x", "x"},
+		{"x-y-z", "xYZ"},
+		{"user-id", "userId"},
+		{"x-", "x-"},
+		{"x--y", "x-Y"},
+		{"-x", "X"},
+		{"x-1", "x-1"},
+		{"x-_y", "x-_y"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := datasetKey(tt.name); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetDataAttrs(t *testing.T) {
+	doc := mustParse(t, `<div data-x-y-z="1" data-User-ID="2" data-a="first" data-a="second" data-trailing-="3" data-double--hyphen="4" data-="5" id="d"></div>`)
+	div := GetFirstHtmlNode(doc, "div", "", "")
+
+	// Hand-built uppercase and namespaced attributes are skipped
+	div.Attr = append(div.Attr,
+		html.Attribute{Key: "data-Upper", Val: "6"},
+		html.Attribute{Namespace: "xlink", Key: "data-ns", Val: "7"})
+
+	want := map[string]string{
+		"xYZ":           "1",
+		"userId":        "2",
+		"a":             "first",
+		"trailing-":     "3",
+		"double-Hyphen": "4",
+		"":              "5",
+	}
+	if got := GetDataAttrs(div); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+
+	if got := GetDataAttrs(nil); got == nil || len(got) != 0 {
+		t.Errorf("nil node: got %v", got)
+	}
+}
+
+func TestGetDataAttr(t *testing.T) {
+	doc := mustParse(t, `<p data-user-id="42" data-x-y-z="xyz" data-a="first" data-a="second"></p><p data-userid="other"></p><p data-user-id=""></p>`)
+	p := GetFirstHtmlNode(doc, "p", "", "")
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"data-user-id", "42", true},
+		{"user-id", "42", true},
+		{"userId", "42", true},
+		{"xYZ", "xyz", true},
+		{"x-y-z", "xyz", true},
+		{"a", "first", true},
+		{"userid", "", false},
+		{"missing", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := GetDataAttr(p, tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%q: got %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	for _, name := range []string{"data-user-id", "user-id", "userId"} {
+		if got := FindNodesWithDataAttr(doc, name, -1); len(got) != 2 {
+			t.Errorf("FindNodesWithDataAttr %q: got %d nodes, want 2", name, len(got))
+		}
+	}
+	if got := FindNodesWithDataAttr(doc, "userId", 1); len(got) != 1 || got[0] != p {
+		t.Errorf("FindNodesWithDataAttr with count: got %v", nodeNames(got))
+	}
+}
@@ -0,0 +1,240 @@
+package htmlutil
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ChangeKind is the kind of a Change reported by DiffHtmlNodes().
+type ChangeKind int
+
+const (
+	// NodeAdded reports a node present only in the second tree.
+	NodeAdded ChangeKind = iota
+
+	// NodeRemoved reports a node present only in the first tree.
+	NodeRemoved
+
+	// TextChanged reports a text or comment node whose content changed.
+	TextChanged
+
+	// AttrChanged reports an element whose attributes changed.
+	AttrChanged
+)
+
+// String returns the name of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case NodeAdded:
+		return "added"
+	case NodeRemoved:
+		return "removed"
+	case TextChanged:
+		return "text changed"
+	case AttrChanged:
+		return "attributes changed"
+	}
+	return "unknown"
+}
+
+// Change is a difference between two trees reported by DiffHtmlNodes().
+type Change struct {
+	Kind ChangeKind
+
+	// Path locates the changed node, in the same format as
+	// NodeDifference.Path. Removed nodes are located in the first tree, and
+	// other changes in the second tree.
+	Path string
+
+	// Old and New are snippets of the node before and after the change: the
+	// rendered node for added and removed nodes, the content for text
+	// changes, and the start tag for attribute changes. Old is empty for
+	// added nodes and New is empty for removed nodes.
+	Old string
+	New string
+
+	// OldNode and NewNode are the changed nodes in the first and second
+	// trees, either of which is nil for added and removed nodes.
+	OldNode *html.Node
+	NewNode *html.Node
+}
+
+// String returns the kind and path of the change.
+func (c Change) String() string {
+	return c.Path + ": " + c.Kind.String()
+}
+
+// DiffOptions controls which differences DiffHtmlNodes() reports.
+type DiffOptions struct {
+	// IgnoreAttrs lists the attributes whose presence and values are not
+	// compared, such as "nonce" or a CSRF token.
+	IgnoreAttrs []string
+
+	// IgnoreWhitespace ignores whitespace-only text nodes, and compares text
+	// with its whitespace collapsed, except inside pre, textarea, and other
+	// elements where whitespace is significant.
+	IgnoreWhitespace bool
+
+	// IgnoreComments ignores comment nodes.
+	IgnoreComments bool
+
+	// Lookahead is the number of siblings searched for a matching node
+	// before a pair of nodes is reported as a removal and an addition. If 0,
+	// 3 siblings are searched.
+	Lookahead int
+}
+
+// DiffHtmlNodes returns the changes turning the tree rooted at a into the
+// tree rooted at b, in document order. Attribute order is never significant.
+//
+// Children are matched pairwise by node type and tag. When two children
+// don't match, the following siblings are searched for a match so that an
+// inserted or deleted node is reported as such instead of shifting every
+// following sibling. The result is not guaranteed to be the smallest set of
+// changes.
+func DiffHtmlNodes(a *html.Node, b *html.Node, opts DiffOptions) []Change {
+	d := differ{opts: opts, compare: CompareOptions{IgnoreWhitespace: opts.IgnoreWhitespace, IgnoreComments: opts.IgnoreComments}}
+	if d.opts.Lookahead <= 0 {
+		d.opts.Lookahead = 3
+	}
+
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		d.added(b, rootPath(b))
+	case b == nil:
+		d.removed(a, rootPath(a))
+	case !diffNodesMatch(a, b):
+		d.removed(a, rootPath(a))
+		d.added(b, rootPath(b))
+	default:
+		d.diff(a, b, rootPath(b))
+	}
+
+	return d.changes
+}
+
+// differ accumulates the changes found by DiffHtmlNodes().
+type differ struct {
+	opts    DiffOptions
+	compare CompareOptions
+	changes []Change
+}
+
+// diff compares two matching nodes and their descendants.
+func (d *differ) diff(a *html.Node, b *html.Node, path string) {
+	switch a.Type {
+	case html.ElementNode:
+		if !d.attrsEqual(a, b) {
+			d.changes = append(d.changes, Change{Kind: AttrChanged, Path: path, Old: startTag(a), New: startTag(b), OldNode: a, NewNode: b})
+		}
+	case html.TextNode, html.CommentNode:
+		if d.normalizeText(a) != d.normalizeText(b) {
+			d.changes = append(d.changes, Change{Kind: TextChanged, Path: path, Old: a.Data, New: b.Data, OldNode: a, NewNode: b})
+		}
+	}
+
+	childrenA, childrenB := comparedChildren(a, d.compare), comparedChildren(b, d.compare)
+	pathsA, pathsB := childPaths(childrenA), childPaths(childrenB)
+
+	i, j := 0, 0
+	for i < len(childrenA) && j < len(childrenB) {
+		if diffNodesMatch(childrenA[i], childrenB[j]) {
+			d.diff(childrenA[i], childrenB[j], joinPath(path, pathsB[j]))
+			i++
+			j++
+			continue
+		}
+
+		// Prefer the nearest match, treating the skipped nodes as added or
+		// removed
+		skipA, skipB := d.lookahead(childrenA[i:], childrenB[j:])
+		if skipA == 0 && skipB == 0 {
+			skipA, skipB = 1, 1
+		}
+		for range skipA {
+			d.removed(childrenA[i], joinPath(path, pathsA[i]))
+			i++
+		}
+		for range skipB {
+			d.added(childrenB[j], joinPath(path, pathsB[j]))
+			j++
+		}
+	}
+
+	for ; i < len(childrenA); i++ {
+		d.removed(childrenA[i], joinPath(path, pathsA[i]))
+	}
+	for ; j < len(childrenB); j++ {
+		d.added(childrenB[j], joinPath(path, pathsB[j]))
+	}
+}
+
+// lookahead returns the number of nodes to skip at the start of a or b so
+// that their first nodes match, or zeros if no match is found within the
+// lookahead distance.
+func (d *differ) lookahead(a []*html.Node, b []*html.Node) (int, int) {
+	for k := 1; k <= d.opts.Lookahead; k++ {
+		if k < len(b) && diffNodesMatch(a[0], b[k]) {
+			return 0, k
+		}
+		if k < len(a) && diffNodesMatch(a[k], b[0]) {
+			return k, 0
+		}
+	}
+	return 0, 0
+}
+
+func (d *differ) added(n *html.Node, path string) {
+	d.changes = append(d.changes, Change{Kind: NodeAdded, Path: path, New: renderSnippet(n), NewNode: n})
+}
+
+func (d *differ) removed(n *html.Node, path string) {
+	d.changes = append(d.changes, Change{Kind: NodeRemoved, Path: path, Old: renderSnippet(n), OldNode: n})
+}
+
+// attrsEqual reports whether a and b have the same attributes, regardless of
+// order and ignoring the attributes listed in the options.
+func (d *differ) attrsEqual(a *html.Node, b *html.Node) bool {
+	keep := func(attrs []html.Attribute) []html.Attribute {
+		attrs = slices.DeleteFunc(slices.Clone(attrs), func(attr html.Attribute) bool {
+			return slices.Contains(d.opts.IgnoreAttrs, qualifiedAttrKey(attr))
+		})
+		slices.SortStableFunc(attrs, compareAttrs)
+		return attrs
+	}
+
+	return slices.Equal(keep(a.Attr), keep(b.Attr))
+}
+
+// normalizeText returns the content of a text or comment node as compared by
+// the options.
+func (d *differ) normalizeText(n *html.Node) string {
+	if !d.opts.IgnoreWhitespace || n.Type != html.TextNode {
+		return n.Data
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && preservesWhitespace(p) {
+			return n.Data
+		}
+	}
+	return strings.Join(splitHtmlTokens(n.Data), " ")
+}
+
+// diffNodesMatch reports whether a and b are counterparts, that is nodes of
+// the same type and, for elements, with the same tag.
+func diffNodesMatch(a *html.Node, b *html.Node) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	return a.Type != html.ElementNode || (a.Data == b.Data && a.Namespace == b.Namespace)
+}
+
+// renderSnippet renders a node for display, ignoring rendering errors.
+func renderSnippet(n *html.Node) string {
+	var sb strings.Builder
+	_ = html.Render(&sb, n)
+	return sb.String()
+}
@@ -0,0 +1,166 @@
+package htmlutil
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// DumpOptions controls the output of DumpTree().
+type DumpOptions struct {
+	// MaxDepth is the depth below the provided node after which descendants
+	// are summarized as a child count instead of being printed. If 0, the
+	// whole tree is printed.
+	MaxDepth int
+
+	// MaxTextLength is the number of characters of text and comment content
+	// previewed before it is truncated. If 0, 40 characters are previewed.
+	MaxTextLength int
+
+	// MaxNodes is the number of nodes printed before the output is cut
+	// short. If 0, all nodes are printed.
+	MaxNodes int
+
+	// ShowWhitespace prints text nodes consisting solely of whitespace as
+	// "␣". If false, they are not printed.
+	ShowWhitespace bool
+}
+
+// dumpAttrs lists the attributes printed by DumpTree(), in order.
+var dumpAttrs = []string{"id", "class", "name", "type", "role", "rel", "href", "src"}
+
+// DumpTree writes an indented outline of the provided node and its
+// descendants to w, one node per line, for debugging.
+//
+// Each line starts with the path of the node as child indexes from the
+// provided node, as in "[0][3][1]", followed by the node type and, for
+// elements, the tag and key attributes such as id and class, or for text and
+// comments a quoted preview of the content with control characters escaped.
+// The indexes count every child, including whitespace-only text nodes that
+// are not printed.
+func DumpTree(w io.Writer, n *html.Node, opts DumpOptions) error {
+	if opts.MaxTextLength <= 0 {
+		opts.MaxTextLength = 40
+	}
+
+	d := treeDumper{w: w, opts: opts}
+	d.dump(n, "", 0)
+	if d.skipped > 0 {
+		d.printf("... %d more nodes\n", d.skipped)
+	}
+
+	return d.err
+}
+
+// DumpTreeString returns the outline written by DumpTree() as a string.
+func DumpTreeString(n *html.Node, opts DumpOptions) string {
+	var sb strings.Builder
+	_ = DumpTree(&sb, n, opts)
+	return sb.String()
+}
+
+// treeDumper holds the state of DumpTree(). Write errors are kept in err and
+// stop any further output.
+type treeDumper struct {
+	w       io.Writer
+	opts    DumpOptions
+	printed int
+	skipped int
+	err     error
+}
+
+func (d *treeDumper) dump(n *html.Node, path string, depth int) {
+	if n == nil {
+		d.printf("nil\n")
+		return
+	}
+
+	if n.Type == html.TextNode && strings.TrimFunc(n.Data, isHtmlSpace) == "" && !d.opts.ShowWhitespace {
+		return
+	}
+	if d.opts.MaxNodes > 0 && d.printed >= d.opts.MaxNodes {
+		d.skipped++
+		return
+	}
+	d.printed++
+
+	label := path
+	if label == "" {
+		label = "[]"
+	}
+	d.printf("%s%s %s", strings.Repeat("  ", depth), label, d.describe(n))
+
+	if d.opts.MaxDepth > 0 && depth >= d.opts.MaxDepth && n.FirstChild != nil {
+		count := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			count++
+		}
+		d.printf(" (%d children)\n", count)
+		return
+	}
+	d.printf("\n")
+
+	i := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		d.dump(c, path+"["+strconv.Itoa(i)+"]", depth+1)
+		i++
+	}
+}
+
+// describe returns the node type and details printed for n.
+func (d *treeDumper) describe(n *html.Node) string {
+	switch n.Type {
+	case html.DocumentNode:
+		return "Document"
+	case html.ElementNode:
+		var sb strings.Builder
+		sb.WriteString("Element ")
+		if n.Namespace != "" {
+			sb.WriteString(n.Namespace + ":")
+		}
+		sb.WriteString(n.Data)
+		for _, key := range dumpAttrs {
+			if val, ok := GetAttr(n, key); ok {
+				sb.WriteString(" " + key + "=" + d.preview(val))
+			}
+		}
+		return sb.String()
+	case html.TextNode:
+		if strings.TrimFunc(n.Data, isHtmlSpace) == "" {
+			return "Text ␣"
+		}
+		return "Text " + d.preview(n.Data)
+	case html.CommentNode:
+		return "Comment " + d.preview(n.Data)
+	case html.DoctypeNode:
+		return "Doctype " + n.Data
+	case html.RawNode:
+		return "Raw " + d.preview(n.Data)
+	}
+	return "Error"
+}
+
+// preview quotes s, truncated to the maximum text length, escaping control
+// characters.
+func (d *treeDumper) preview(s string) string {
+	if utf8.RuneCountInString(s) <= d.opts.MaxTextLength {
+		return strconv.Quote(s)
+	}
+
+	i := 0
+	for range d.opts.MaxTextLength {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return strconv.Quote(s[:i]) + "…"
+}
+
+func (d *treeDumper) printf(format string, args ...any) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
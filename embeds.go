@@ -0,0 +1,193 @@
+package htmlutil
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// Embed describes an element embedding external content, found by
+// ExtractEmbeds(): an iframe, embed, object, video, or audio element.
+type Embed struct {
+	// Tag is the tag of the element.
+	Tag string
+
+	// Src is the URL of the embedded content, resolved against the base URL:
+	// the src attribute, the data attribute of object elements, or the
+	// data-src attribute of lazy-loaded iframes. It is nil if there is no URL,
+	// as for media elements using source children, or if it can't be parsed.
+	Src *url.URL
+
+	// Lazy reports whether Src was taken from a data-src attribute set for a
+	// lazy-loading script.
+	Lazy bool
+
+	// Provider is the name of the provider of the embedded content, such as
+	// "YouTube", as registered with RegisterEmbedProvider(), or empty if the
+	// host is unknown. For media elements without a src, the URL of the first
+	// source with a known provider is used.
+	Provider string
+
+	// Width and Height are the trimmed width and height attributes, which may
+	// hold percentages as well as pixels.
+	Width  string
+	Height string
+
+	Title string
+
+	// Sandboxed reports whether an iframe has a sandbox attribute, in which
+	// case Sandbox holds the restrictions it lifts, such as "allow-scripts".
+	Sandboxed bool
+	Sandbox   []string
+
+	// Allow is the permissions policy of an iframe, such as "fullscreen;
+	// autoplay".
+	Allow string
+
+	// SrcDoc holds the nodes parsed from the srcdoc attribute of an iframe,
+	// which browsers display instead of Src, or nil if it has none.
+	SrcDoc []*html.Node
+
+	// Sources holds the source children of video and audio elements.
+	Sources []EmbedSource
+
+	Node *html.Node
+}
+
+// EmbedSource is a source element of a video or audio element.
+type EmbedSource struct {
+	// Src is the src attribute resolved against the base URL, or nil if it
+	// can't be parsed.
+	Src   *url.URL
+	Type  string
+	Media string
+}
+
+// embedProviders maps the domains of embed providers to their names.
+var embedProviders = struct {
+	sync.RWMutex
+	domains map[string]string
+}{domains: map[string]string{
+	"codepen.io":           "CodePen",
+	"dailymotion.com":      "Dailymotion",
+	"facebook.com":         "Facebook",
+	"instagram.com":        "Instagram",
+	"soundcloud.com":       "SoundCloud",
+	"open.spotify.com":     "Spotify",
+	"tiktok.com":           "TikTok",
+	"twitch.tv":            "Twitch",
+	"twitter.com":          "Twitter",
+	"x.com":                "Twitter",
+	"vimeo.com":            "Vimeo",
+	"youtu.be":             "YouTube",
+	"youtube.com":          "YouTube",
+	"youtube-nocookie.com": "YouTube",
+}}
+
+// RegisterEmbedProvider sets the name of the provider of the embeds whose
+// host is the provided domain or one of its subdomains, such as "Vimeo" for
+// "vimeo.com", replacing any name already registered for the domain. When
+// several registered domains match a host, the longest one wins, so
+// subdomains can be given their own provider.
+func RegisterEmbedProvider(domain string, provider string) {
+	embedProviders.Lock()
+	defer embedProviders.Unlock()
+
+	embedProviders.domains[strings.ToLower(strings.TrimSuffix(domain, "."))] = provider
+}
+
+// embedProvider returns the name of the provider registered for the host of
+// u, or an empty string if there is none.
+func embedProvider(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	embedProviders.RLock()
+	defer embedProviders.RUnlock()
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for host != "" {
+		if provider, ok := embedProviders.domains[host]; ok {
+			return provider
+		}
+
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok {
+			break
+		}
+		host = parent
+	}
+	return ""
+}
+
+// ExtractEmbeds returns the iframe, embed, object, video, and audio elements
+// of the provided document, in document order.
+//
+// Relative URLs are resolved against base, or against the href of the
+// document's <base> element if there is one. Iframes without a src but with a
+// data-src attribute are reported as lazy-loaded with that URL. The srcdoc
+// attribute of iframes is parsed as a fragment whose nodes are detached from
+// the document.
+func ExtractEmbeds(doc *html.Node, base *url.URL) []Embed {
+	embeds := []Embed{}
+	base, _ = documentBaseURL(doc, base)
+
+	for _, n := range GetAllHtmlNodesFunc(doc, isEmbed) {
+		embed := Embed{
+			Tag:    n.Data,
+			Width:  strings.TrimSpace(GetAttrOr(n, "width", "")),
+			Height: strings.TrimSpace(GetAttrOr(n, "height", "")),
+			Title:  strings.TrimSpace(GetAttrOr(n, "title", "")),
+			Node:   n,
+		}
+
+		srcAttr := "src"
+		if n.Data == "object" {
+			srcAttr = "data"
+		}
+		src := strings.TrimSpace(GetAttrOr(n, srcAttr, ""))
+		if lazy := strings.TrimSpace(GetAttrOr(n, "data-src", "")); n.Data == "iframe" && lazy != "" && (src == "" || src == "about:blank") {
+			src = lazy
+			embed.Lazy = true
+		}
+		if src != "" {
+			embed.Src, _ = resolveURL(base, src)
+			embed.Provider = embedProvider(embed.Src)
+		}
+
+		switch n.Data {
+		case "iframe":
+			var sandbox string
+			sandbox, embed.Sandboxed = GetAttr(n, "sandbox")
+			embed.Sandbox = splitHtmlTokens(sandbox)
+			embed.Allow = strings.TrimSpace(GetAttrOr(n, "allow", ""))
+			if srcdoc, ok := GetAttr(n, "srcdoc"); ok {
+				embed.SrcDoc, _ = ParseFragmentString(srcdoc, "body")
+			}
+		case "video", "audio":
+			for _, source := range childElementsByTag(n, "source") {
+				s := EmbedSource{
+					Type:  strings.TrimSpace(GetAttrOr(source, "type", "")),
+					Media: strings.TrimSpace(GetAttrOr(source, "media", "")),
+				}
+				s.Src, _ = resolveURL(base, strings.TrimSpace(GetAttrOr(source, "src", "")))
+				if embed.Provider == "" {
+					embed.Provider = embedProvider(s.Src)
+				}
+				embed.Sources = append(embed.Sources, s)
+			}
+		}
+
+		embeds = append(embeds, embed)
+	}
+
+	return embeds
+}
+
+// isEmbed reports whether n is an element embedding external content.
+func isEmbed(n *html.Node) bool {
+	return isHtmlElement(n, "iframe", "embed", "object", "video", "audio")
+}
@@ -0,0 +1,107 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultKeepEmpty lists the elements kept by RemoveEmptyElements() when
+// EmptyOptions.Keep is nil, because they are meaningful even when empty.
+var DefaultKeepEmpty = []string{
+	"iframe", "script", "style", "td", "template", "textarea", "th",
+}
+
+// mediaElements lists the elements that are visible content on their own,
+// in addition to void elements.
+var mediaElements = map[string]bool{
+	"audio": true, "canvas": true, "object": true, "picture": true,
+	"video": true,
+}
+
+// EmptyOptions controls which elements RemoveEmptyElements() removes.
+type EmptyOptions struct {
+	// Keep lists the tags of the elements that are never removed. If nil,
+	// DefaultKeepEmpty is used.
+	Keep []string
+}
+
+// RemoveEmptyElements removes the elements within the provided node that have
+// no visible content, and returns the number of elements removed.
+//
+// An element has visible content if it contains non-whitespace text, a void
+// element such as img, br, or hr, a media element such as video or canvas, an
+// SVG or MathML element, or an element from the keep-list. Elements left empty
+// by the removal of their children are removed as well, so that
+// "<div> <span></span> </div>" is removed entirely. The html, head, and body
+// elements and the provided node itself are always kept.
+func RemoveEmptyElements(n *html.Node, opts EmptyOptions) int {
+	if opts.Keep == nil {
+		opts.Keep = DefaultKeepEmpty
+	}
+
+	p := emptyPruner{keep: stringSet(opts.Keep)}
+	p.prune(n)
+	return p.removed
+}
+
+// emptyPruner removes empty elements in a single depth-first pass: children
+// are pruned before their parent is checked, so the tree reaches a fixed
+// point without needing further passes.
+type emptyPruner struct {
+	keep    map[string]bool
+	removed int
+}
+
+// prune removes the empty elements among the descendants of n, and reports
+// whether n has visible content afterwards.
+func (p *emptyPruner) prune(n *html.Node) bool {
+	if n.Type == html.ElementNode && p.keepsAsIs(n) {
+		return true
+	}
+
+	content := false
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		switch c.Type {
+		case html.TextNode:
+			if strings.TrimFunc(c.Data, isHtmlSpace) != "" {
+				content = true
+			}
+		case html.ElementNode:
+			if p.prune(c) || isStructuralElement(c) {
+				content = true
+			} else {
+				n.RemoveChild(c)
+				p.removed++
+			}
+		}
+
+		c = next
+	}
+
+	return content || (n.Type == html.ElementNode && p.keep[n.Data])
+}
+
+// keepsAsIs reports whether n is visible content on its own, whose children
+// are left untouched.
+func (p *emptyPruner) keepsAsIs(n *html.Node) bool {
+	if n.Namespace != "" {
+		return true
+	}
+	return voidElements[n.Data] || mediaElements[n.Data]
+}
+
+// isStructuralElement reports whether n is an html, head, or body element.
+func isStructuralElement(n *html.Node) bool {
+	if n.Namespace != "" {
+		return false
+	}
+
+	switch n.Data {
+	case "html", "head", "body":
+		return true
+	}
+	return false
+}
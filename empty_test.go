@@ -0,0 +1,84 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemoveEmptyElements(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		opts        EmptyOptions
+		want        string
+		wantRemoved int
+	}{
+		{"empty paragraph", `<p></p><p>x</p>`, EmptyOptions{}, `<p>x</p>`, 1},
+		{"whitespace", "<div> <span>\n\t</span> </div><p>x</p>", EmptyOptions{}, `<p>x</p>`, 2},
+		{"nbsp is content", `<p>&nbsp;</p>`, EmptyOptions{}, "<p> </p>", 0},
+		{"comments", `<div><!-- x --></div><p>x</p>`, EmptyOptions{}, `<p>x</p>`, 1},
+		{"void elements", `<p><br></p><div><img src="a.png"></div><div><span><hr></span></div>`, EmptyOptions{}, `<p><br/></p><div><img src="a.png"/></div><div><span><hr/></span></div>`, 0},
+		{"input", `<form><input name="q"></form>`, EmptyOptions{}, `<form><input name="q"/></form>`, 0},
+		{"media", `<div><video src="v.mp4"></video></div><canvas></canvas>`, EmptyOptions{}, `<div><video src="v.mp4"></video></div><canvas></canvas>`, 0},
+		{"svg", `<div><svg><g></g></svg></div>`, EmptyOptions{}, `<div><svg><g></g></svg></div>`, 0},
+		{"empty table cells", `<table><tr><td></td><td>x</td></tr></table><em></em>`, EmptyOptions{}, `<table><tbody><tr><td></td><td>x</td></tr></tbody></table>`, 1},
+		{"empty table", `<table><tr><th></th></tr></table>`, EmptyOptions{}, `<table><tbody><tr><th></th></tr></tbody></table>`, 0},
+		{"iframe", `<div><iframe src="/e"></iframe></div>`, EmptyOptions{}, `<div><iframe src="/e"></iframe></div>`, 0},
+		{"script", `<div><script>a()</script></div><script></script>`, EmptyOptions{}, `<div><script>a()</script></div><script></script>`, 0},
+		{"textarea", `<textarea></textarea>`, EmptyOptions{}, `<textarea></textarea>`, 0},
+		{"custom keep", `<td></td><i class="icon"></i><b></b>`, EmptyOptions{Keep: []string{"i"}}, `<i class="icon"></i>`, 1},
+		{"empty keep", `<table><tr><td></td></tr></table>`, EmptyOptions{Keep: []string{}}, ``, 4},
+		{"siblings", `<ul><li></li><li>a</li><li> </li><li><b></b></li></ul>`, EmptyOptions{}, `<ul><li>a</li></ul>`, 4},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, tt.doc)
+
+		if got := RemoveEmptyElements(doc, tt.opts); got != tt.wantRemoved {
+			t.Errorf("%s: got %d removed, want %d", tt.name, got, tt.wantRemoved)
+		}
+		if got := bodyHtml(t, doc); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		checkSiblings(t, doc)
+	}
+}
+
+func TestRemoveEmptyElementsStructure(t *testing.T) {
+	doc := mustParse(t, `<html><head></head><body><div></div></body></html>`)
+
+	if got := RemoveEmptyElements(doc, EmptyOptions{}); got != 1 {
+		t.Errorf("got %d removed, want 1", got)
+	}
+	if got := mustRender(t, doc); got != `<html><head></head><body></body></html>` {
+		t.Errorf("got %q, want html, head, and body kept", got)
+	}
+
+	// The provided node is kept even if it is empty
+	div := NewElement("div", nil, NewElement("span", nil))
+	if got := RemoveEmptyElements(div, EmptyOptions{}); got != 1 || mustRender(t, div) != "<div></div>" {
+		t.Errorf("got %d removed, %q", got, mustRender(t, div))
+	}
+}
+
+func TestRemoveEmptyElementsNested(t *testing.T) {
+	const depth = 1000
+	doc := mustParse(t, `<p>x</p>`+strings.Repeat("<span> ", depth)+strings.Repeat("</span>", depth))
+
+	// One call reaches the fixed point
+	if got := RemoveEmptyElements(doc, EmptyOptions{}); got != depth {
+		t.Errorf("got %d removed, want %d", got, depth)
+	}
+	if got := bodyHtml(t, doc); got != `<p>x</p>` {
+		t.Errorf("got %q", got)
+	}
+	if got := RemoveEmptyElements(doc, EmptyOptions{}); got != 0 {
+		t.Errorf("second call: got %d removed, want 0", got)
+	}
+
+	// The innermost content keeps all of its ancestors
+	doc = mustParse(t, strings.Repeat("<span>", depth)+"x"+strings.Repeat("</span>", depth))
+	if got := RemoveEmptyElements(doc, EmptyOptions{}); got != 0 {
+		t.Errorf("nested content: got %d removed, want 0", got)
+	}
+}
@@ -0,0 +1,182 @@
+package htmlutil
+
+import (
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FeedLink is a feed advertised by a <link rel="alternate"> element.
+type FeedLink struct {
+	// URL is the href of the link, resolved against the base URL.
+	URL *url.URL
+
+	// Type is the media type of the feed, such as "application/rss+xml",
+	// lowercased and without parameters.
+	Type string
+
+	Title string
+
+	Node *html.Node
+}
+
+// feedTypes lists the media types of RSS, Atom, and JSON feeds.
+var feedTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/json":      true,
+	"application/rdf+xml":   true,
+}
+
+// DiscoverFeeds returns the RSS, Atom, and JSON feeds advertised by the
+// <link rel="alternate"> elements of the provided document, in document
+// order. Link elements are found anywhere in the document, not only in
+// <head>.
+//
+// Relative URLs are resolved against base, or against the href of the
+// document's <base> element if there is one. Links whose href is empty or
+// cannot be parsed are skipped.
+func DiscoverFeeds(doc *html.Node, base *url.URL) []FeedLink {
+	feeds := []FeedLink{}
+	base, _ = documentBaseURL(doc, base)
+
+	for _, link := range GetAllHtmlNodesNS(doc, "", "link", "href", "") {
+		if !hasRelToken(link, "alternate") {
+			continue
+		}
+
+		mediaType, _, err := mime.ParseMediaType(GetAttrOr(link, "type", ""))
+		if err != nil || !feedTypes[mediaType] {
+			continue
+		}
+
+		u, ok := linkURL(link, base)
+		if !ok {
+			continue
+		}
+
+		feeds = append(feeds, FeedLink{
+			URL:   u,
+			Type:  mediaType,
+			Title: strings.TrimSpace(GetAttrOr(link, "title", "")),
+			Node:  link,
+		})
+	}
+
+	return feeds
+}
+
+// IconLink is an icon advertised by a link element.
+type IconLink struct {
+	// URL is the href of the link, resolved against the base URL.
+	URL *url.URL
+
+	// Rel is the kind of icon: "icon", "apple-touch-icon",
+	// "apple-touch-icon-precomposed", or "mask-icon".
+	Rel string
+
+	// Type is the type attribute of the link.
+	Type string
+
+	// Sizes are the sizes listed by the sizes attribute of the link, and
+	// AnySize reports whether it lists "any", as scalable icons do.
+	Sizes   []IconSize
+	AnySize bool
+
+	Node *html.Node
+}
+
+// IconSize is a size of an icon, in pixels.
+type IconSize struct {
+	Width  int
+	Height int
+}
+
+// DiscoverIcons returns the icons advertised by the link elements of the
+// provided document, in document order: rel="icon" and rel="shortcut icon",
+// rel="apple-touch-icon" and its precomposed variant, and rel="mask-icon".
+// Link elements are found anywhere in the document, not only in <head>.
+//
+// URLs are resolved like the ones of DiscoverFeeds(). Invalid tokens of the
+// sizes attribute are ignored.
+func DiscoverIcons(doc *html.Node, base *url.URL) []IconLink {
+	icons := []IconLink{}
+	base, _ = documentBaseURL(doc, base)
+
+	for _, link := range GetAllHtmlNodesNS(doc, "", "link", "href", "") {
+		rel := iconRel(link)
+		if rel == "" {
+			continue
+		}
+
+		u, ok := linkURL(link, base)
+		if !ok {
+			continue
+		}
+
+		icon := IconLink{
+			URL:  u,
+			Rel:  rel,
+			Type: strings.TrimSpace(GetAttrOr(link, "type", "")),
+			Node: link,
+		}
+		icon.Sizes, icon.AnySize = parseIconSizes(GetAttrOr(link, "sizes", ""))
+		icons = append(icons, icon)
+	}
+
+	return icons
+}
+
+// iconRel returns the kind of icon a link element advertises, or an empty
+// string if it is not an icon.
+func iconRel(link *html.Node) string {
+	for _, rel := range []string{"icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon"} {
+		if hasRelToken(link, rel) {
+			return rel
+		}
+	}
+	return ""
+}
+
+// parseIconSizes parses the sizes attribute of a link element, returning the
+// listed sizes and whether "any" is listed.
+func parseIconSizes(s string) ([]IconSize, bool) {
+	var sizes []IconSize
+	anySize := false
+
+	for _, token := range splitHtmlTokens(s) {
+		if strings.EqualFold(token, "any") {
+			anySize = true
+			continue
+		}
+
+		w, h, ok := strings.Cut(strings.ToLower(token), "x")
+		if !ok {
+			continue
+		}
+		width, werr := strconv.Atoi(w)
+		height, herr := strconv.Atoi(h)
+		if werr != nil || herr != nil || width <= 0 || height <= 0 || w[0] == '0' || h[0] == '0' || w[0] == '+' || h[0] == '+' {
+			continue
+		}
+		sizes = append(sizes, IconSize{Width: width, Height: height})
+	}
+
+	return sizes, anySize
+}
+
+// linkURL returns the href of the provided element resolved against base,
+// and false if it is empty or cannot be parsed.
+func linkURL(n *html.Node, base *url.URL) (*url.URL, bool) {
+	href := strings.TrimSpace(GetAttrOr(n, "href", ""))
+	if href == "" {
+		return nil, false
+	}
+
+	u, err := resolveURL(base, href)
+	return u, err == nil
+}
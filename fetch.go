@@ -0,0 +1,131 @@
+package htmlutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/html"
+)
+
+// ErrNotHTML is returned by ParseURL() when a response has a content type
+// other than HTML.
+var ErrNotHTML = errors.New("htmlutil: response is not HTML")
+
+// DefaultMaxBodySize is the number of bytes of a response read by ParseURL()
+// when FetchOptions.MaxBodySize is 0.
+const DefaultMaxBodySize = 10 << 20
+
+// FetchOptions controls the requests made by ParseURL().
+type FetchOptions struct {
+	// MaxBodySize is the number of bytes of the response body read before
+	// giving up with an error. If 0, DefaultMaxBodySize is used, and if
+	// negative, the whole body is read.
+	MaxBodySize int64
+
+	// Header holds additional headers sent with the request, such as
+	// User-Agent.
+	Header http.Header
+}
+
+// PageInfo describes the response to a request made by ParseURL().
+type PageInfo struct {
+	// URL is the final URL of the page, after redirects
+	URL *url.URL
+
+	StatusCode  int
+	ContentType string
+
+	// Charset is the canonical name of the encoding the page was decoded
+	// from, such as "utf-8" or "windows-1252".
+	Charset string
+}
+
+// ParseURL fetches the page at the provided URL with the provided client, or
+// http.DefaultClient if nil, and parses it as an HTML document.
+//
+// The body is decoded to UTF-8 from the encoding detected by DetectCharset(),
+// using the charset of the Content-Type header as the hint, which is simply
+// ignored if it isn't supported. Responses whose content type is neither text/html nor
+// application/xhtml+xml are rejected with ErrNotHTML before their body is
+// read, and responses larger than the maximum body size result in an error.
+//
+// Responses with an error status code are parsed like any other, so the
+// status code should be checked in the returned PageInfo.
+func ParseURL(ctx context.Context, client *http.Client, rawURL string, opts FetchOptions) (*html.Node, *PageInfo, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("htmlutil: invalid request: %w", err)
+	}
+	for key, values := range opts.Header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	info := &PageInfo{
+		URL:         resp.Request.URL,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if info.ContentType != "" {
+		mediaType, _, err := mime.ParseMediaType(info.ContentType)
+		if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+			return nil, info, fmt.Errorf("%w: content type %q", ErrNotHTML, info.ContentType)
+		}
+	}
+
+	limit := opts.MaxBodySize
+	if limit == 0 {
+		limit = DefaultMaxBodySize
+	}
+	var body io.Reader = resp.Body
+	if limit > 0 {
+		body = &maxBytesReader{r: resp.Body, n: limit}
+	}
+
+	doc, name, err := parseDecoded(body, charsetFromContentType(info.ContentType))
+	info.Charset = name
+	if err != nil {
+		return nil, info, err
+	}
+	return doc, info, nil
+}
+
+// maxBytesReader reads from r, returning an error once more than n bytes
+// have been read.
+type maxBytesReader struct {
+	r io.Reader
+	n int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.n < 0 {
+		return 0, errors.New("htmlutil: response body too large")
+	}
+
+	// Read one byte past the limit to tell a body of exactly n bytes from a
+	// larger one
+	if int64(len(p)) > m.n+1 {
+		p = p[:m.n+1]
+	}
+	n, err := m.r.Read(p)
+	m.n -= int64(n)
+	if m.n < 0 {
+		return n, errors.New("htmlutil: response body too large")
+	}
+	return n, err
+}
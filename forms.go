@@ -0,0 +1,264 @@
+package htmlutil
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Form is a form element of an HTML document and the fields it owns.
+type Form struct {
+	// Action is the action attribute of the form, which is not resolved
+	// against the URL of the document.
+	Action string
+
+	// Method is the lowercased method attribute of the form, "get" if it is
+	// missing or invalid.
+	Method string
+
+	// Enctype is the lowercased enctype attribute of the form,
+	// "application/x-www-form-urlencoded" if it is missing or invalid.
+	Enctype string
+
+	Name string
+	Id   string
+
+	// Fields are the input, select, textarea, and button elements owned by
+	// the form, in document order, whether they are nested in it or
+	// associated with it through their form attribute.
+	Fields []Field
+
+	Node *html.Node
+}
+
+// Field is a form control.
+type Field struct {
+	Name string
+
+	// Type is the lowercased type attribute of input and button elements,
+	// with the defaults applied, "select-one" or "select-multiple" for select
+	// elements, and "textarea" for textarea elements.
+	Type string
+
+	// Value is the value of the field: the value attribute of input and button
+	// elements, the text of textarea elements, and the value of the first
+	// selected option of select elements. Checkboxes and radio buttons
+	// without a value attribute have a value of "on".
+	Value string
+
+	// Options are the options of select elements, in document order.
+	Options []FieldOption
+
+	// Checked reports whether a checkbox or radio button is checked.
+	Checked bool
+
+	Required bool
+
+	// Disabled reports whether the field is disabled, either itself or by a
+	// disabled fieldset ancestor.
+	Disabled bool
+
+	Node *html.Node
+}
+
+// FieldOption is an option of a select element.
+type FieldOption struct {
+	// Value is the value attribute of the option, or its normalized text if
+	// it has none.
+	Value    string
+	Text     string
+	Selected bool
+}
+
+// ExtractForms returns the forms of the provided document in document order.
+//
+// A field with a form attribute belongs to the form with that id, wherever it
+// is located, and to no form if there is no such form. Other fields belong to
+// the form they are nested in. Fields without a name are listed in Fields but
+// are left out of the values returned by Form.Values().
+func ExtractForms(doc *html.Node) []Form {
+	forms := []Form{}
+	formIndex := make(map[*html.Node]int)
+
+	for _, n := range GetAllHtmlNodes(doc, "form", "", "") {
+		if n.Namespace != "" {
+			continue
+		}
+
+		formIndex[n] = len(forms)
+		forms = append(forms, Form{
+			Action:  GetAttrOr(n, "action", ""),
+			Method:  enumeratedAttr(n, "method", "get", "post", "dialog"),
+			Enctype: enumeratedAttr(n, "enctype", "application/x-www-form-urlencoded", "multipart/form-data", "text/plain"),
+			Name:    GetAttrOr(n, "name", ""),
+			Id:      GetAttrOr(n, "id", ""),
+			Node:    n,
+		})
+	}
+
+	ids := BuildIdIndex(doc)
+	for _, n := range GetAllHtmlNodesFunc(doc, isFormField) {
+		owner := Closest(n.Parent, "form", "", "")
+		if formId, ok := GetAttr(n, "form"); ok {
+			owner = ids.Lookup(formId)
+		}
+
+		if i, ok := formIndex[owner]; ok {
+			forms[i].Fields = append(forms[i].Fields, newField(n))
+		}
+	}
+
+	return forms
+}
+
+// Values returns the names and values the form would submit without a submit
+// button being used: the enabled, named fields, excluding unchecked checkboxes
+// and radio buttons, file inputs, and buttons. Select elements submit each of
+// their selected options.
+func (f Form) Values() url.Values {
+	values := url.Values{}
+
+	for _, field := range f.Fields {
+		if field.Name == "" || field.Disabled {
+			continue
+		}
+
+		switch field.Type {
+		case "checkbox", "radio":
+			if field.Checked {
+				values.Add(field.Name, field.Value)
+			}
+		case "select-one", "select-multiple":
+			for _, option := range field.Options {
+				if option.Selected {
+					values.Add(field.Name, option.Value)
+				}
+			}
+		case "file", "image", "submit", "reset", "button":
+		default:
+			values.Add(field.Name, field.Value)
+		}
+	}
+
+	return values
+}
+
+// isFormField reports whether n is an element that can be a field of a form.
+func isFormField(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+
+	switch n.Data {
+	case "input", "select", "textarea", "button":
+		return true
+	}
+	return false
+}
+
+func newField(n *html.Node) Field {
+	_, required := GetAttr(n, "required")
+	field := Field{
+		Name:     GetAttrOr(n, "name", ""),
+		Value:    GetAttrOr(n, "value", ""),
+		Required: required,
+		Disabled: isDisabledField(n),
+		Node:     n,
+	}
+
+	switch n.Data {
+	case "input":
+		field.Type = strings.ToLower(GetAttrOr(n, "type", "text"))
+		if _, ok := GetAttr(n, "value"); !ok && (field.Type == "checkbox" || field.Type == "radio") {
+			field.Value = "on"
+		}
+		_, field.Checked = GetAttr(n, "checked")
+	case "button":
+		field.Type = enumeratedAttr(n, "type", "submit", "reset", "button")
+	case "textarea":
+		field.Type = "textarea"
+		field.Value = GetText(n)
+	case "select":
+		field.Type = "select-one"
+		_, multiple := GetAttr(n, "multiple")
+		if multiple {
+			field.Type = "select-multiple"
+		}
+		field.Options = selectOptions(n, multiple)
+
+		field.Value = ""
+		for _, option := range field.Options {
+			if option.Selected {
+				field.Value = option.Value
+				break
+			}
+		}
+	}
+
+	return field
+}
+
+// selectOptions returns the options of a select element. As in browsers, the
+// first option of a single select is selected if no other is, and only the
+// last selected option stays selected.
+func selectOptions(sel *html.Node, multiple bool) []FieldOption {
+	options := []FieldOption{}
+	selected := -1
+
+	for _, n := range GetAllHtmlNodes(sel, "option", "", "") {
+		option := FieldOption{Text: GetNormalizedText(n)}
+		option.Value = GetAttrOr(n, "value", option.Text)
+		_, option.Selected = GetAttr(n, "selected")
+
+		if option.Selected && !multiple {
+			if selected >= 0 {
+				options[selected].Selected = false
+			}
+			selected = len(options)
+		}
+		options = append(options, option)
+	}
+
+	if !multiple && selected < 0 && len(options) > 0 {
+		options[0].Selected = true
+	}
+
+	return options
+}
+
+// isDisabledField reports whether a form field is disabled, either by its own
+// disabled attribute or by a disabled fieldset ancestor. Fields in the first
+// legend of a disabled fieldset are not disabled by it.
+func isDisabledField(n *html.Node) bool {
+	if _, ok := GetAttr(n, "disabled"); ok {
+		return true
+	}
+
+	for child, p := n, n.Parent; p != nil; child, p = p, p.Parent {
+		if p.Type != html.ElementNode || p.Data != "fieldset" || p.Namespace != "" {
+			continue
+		}
+		if _, ok := GetAttr(p, "disabled"); !ok {
+			continue
+		}
+		if legends := GetChildHtmlNodes(p, "legend", "", "", 1); len(legends) > 0 && legends[0] == child {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+// enumeratedAttr returns the lowercased value of the provided attribute of n
+// if it is one of the provided values, and the first value otherwise.
+func enumeratedAttr(n *html.Node, key string, values ...string) string {
+	val := strings.ToLower(strings.TrimSpace(GetAttrOr(n, key, "")))
+	for _, v := range values {
+		if val == v {
+			return v
+		}
+	}
+	return values[0]
+}
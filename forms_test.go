@@ -0,0 +1,127 @@
+package htmlutil
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+const formsFixture = `<form id="signup" action="/signup" method="POST" enctype="multipart/form-data" name="s">` +
+	`<input name="user" value="ann">` +
+	`<input type="CHECKBOX" name="news">` +
+	`<input type="checkbox" name="terms" value="yes" checked>` +
+	`<input type="radio" name="plan" value="free">` +
+	`<input type="radio" name="plan" value="pro" checked>` +
+	`<input type="file" name="avatar">` +
+	`<input value="unnamed">` +
+	`<select name="colors" multiple>` +
+	`<option value="r" selected>Red</option><option>Green</option><option value="b" selected>Blue</option>` +
+	`</select>` +
+	`<select name="size"><option>S</option><option selected>M</option><option selected>L</option></select>` +
+	`<select name="first"><option value="1">One</option><option value="2">Two</option></select>` +
+	`<fieldset disabled><legend><input name="inlegend" value="l"></legend><input name="locked" value="x"></fieldset>` +
+	`<input name="off" value="o" disabled required>` +
+	`<button name="go" value="1">Go</button>` +
+	`</form>` +
+	`<textarea name="bio" form="signup">  Hello
+world</textarea>` +
+	`<input name="orphan" form="missing">` +
+	`<form><input name="nested" form="signup"><input name="plain"></form>`
+
+func TestExtractForms(t *testing.T) {
+	forms := ExtractForms(mustParse(t, formsFixture))
+	if len(forms) != 2 {
+		t.Fatalf("got %d forms, want 2", len(forms))
+	}
+
+	f := forms[0]
+	if f.Action != "/signup" || f.Method != "post" || f.Enctype != "multipart/form-data" || f.Name != "s" || f.Id != "signup" {
+		t.Errorf("got form %+v", f)
+	}
+	if g := forms[1]; g.Method != "get" || g.Enctype != "application/x-www-form-urlencoded" {
+		t.Errorf("got defaults %q %q", g.Method, g.Enctype)
+	}
+
+	var names, types []string
+	for _, field := range f.Fields {
+		names = append(names, field.Name)
+		types = append(types, field.Type)
+	}
+	wantNames := []string{"user", "news", "terms", "plan", "plan", "avatar", "", "colors", "size", "first",
+		"inlegend", "locked", "off", "go", "bio", "nested"}
+	wantTypes := []string{"text", "checkbox", "checkbox", "radio", "radio", "file", "text", "select-multiple", "select-one", "select-one",
+		"text", "text", "text", "submit", "textarea", "text"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("got field names %q, want %q", names, wantNames)
+	}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Errorf("got field types %q, want %q", types, wantTypes)
+	}
+
+	if got := forms[1].Fields; len(got) != 1 || got[0].Name != "plain" {
+		t.Errorf("second form: got %+v, want only the field without a form attribute", got)
+	}
+
+	byName := func(name string) Field {
+		for _, field := range f.Fields {
+			if field.Name == name {
+				return field
+			}
+		}
+		t.Fatalf("no field %q", name)
+		return Field{}
+	}
+
+	if got := byName("news"); got.Value != "on" || got.Checked {
+		t.Errorf("news: got %+v", got)
+	}
+	if got := byName("bio").Value; got != "  Hello\nworld" {
+		t.Errorf("bio: got value %q", got)
+	}
+	if got := byName("off"); !got.Disabled || !got.Required {
+		t.Errorf("off: got %+v", got)
+	}
+	if byName("inlegend").Disabled || !byName("locked").Disabled {
+		t.Error("fieldset legend handling")
+	}
+
+	colors := byName("colors")
+	wantOptions := []FieldOption{{"r", "Red", true}, {"Green", "Green", false}, {"b", "Blue", true}}
+	if !reflect.DeepEqual(colors.Options, wantOptions) {
+		t.Errorf("colors: got options %+v, want %+v", colors.Options, wantOptions)
+	}
+	if colors.Value != "r" {
+		t.Errorf("colors: got value %q, want the first selected option", colors.Value)
+	}
+	if got := byName("size"); got.Value != "L" || got.Options[1].Selected {
+		t.Errorf("size: got %+v, want only the last selected option", got)
+	}
+	if got := byName("first"); got.Value != "1" || !got.Options[0].Selected {
+		t.Errorf("first: got %+v, want the first option selected", got)
+	}
+}
+
+func TestFormValues(t *testing.T) {
+	forms := ExtractForms(mustParse(t, formsFixture))
+
+	want := url.Values{
+		"user":     {"ann"},
+		"terms":    {"yes"},
+		"plan":     {"pro"},
+		"colors":   {"r", "b"},
+		"size":     {"L"},
+		"first":    {"1"},
+		"inlegend": {"l"},
+		"bio":      {"  Hello\nworld"},
+		"nested":   {""},
+	}
+	if got := forms[0].Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestExtractFormsNone(t *testing.T) {
+	if got := ExtractForms(mustParse(t, `<input name="a">`)); got == nil || len(got) != 0 {
+		t.Errorf("got %#v, want an empty slice", got)
+	}
+}
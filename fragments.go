@@ -0,0 +1,181 @@
+package htmlutil
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// BrokenFragment is a reference to an id that doesn't exist in its document.
+type BrokenFragment struct {
+	// Node is the element holding the reference.
+	Node *html.Node
+
+	// Attr is the attribute holding the reference, such as "href" or
+	// "aria-labelledby".
+	Attr string
+
+	// Fragment is the missing id.
+	Fragment string
+
+	// Candidates are the existing ids closest to Fragment by edit distance,
+	// closest first, to help fix the reference.
+	Candidates []string
+}
+
+// FragmentLinkOptions controls the references checked by
+// ValidateFragmentLinksWithOptions().
+type FragmentLinkOptions struct {
+	// CheckIdReferences also checks the ids referenced by aria-labelledby,
+	// aria-describedby, and aria-controls attributes, and by the for
+	// attribute of label elements.
+	CheckIdReferences bool
+}
+
+// ValidateFragmentLinks is a convenience function for
+// ValidateFragmentLinksWithOptions() that only checks links.
+func ValidateFragmentLinks(doc *html.Node) []BrokenFragment {
+	return ValidateFragmentLinksWithOptions(doc, FragmentLinkOptions{})
+}
+
+// ValidateFragmentLinksWithOptions returns the in-page links within the
+// provided document, such as <a href="#section-3">, whose fragment matches
+// neither an id attribute nor the name attribute of an anchor, in document
+// order.
+//
+// Links whose href has a scheme, host, path, or query point to another page
+// and are ignored, and so are links to "#" and "#top", which browsers treat
+// as the top of the page. The ids of elements inside template elements are
+// not part of the document and don't satisfy links.
+func ValidateFragmentLinksWithOptions(doc *html.Node, opts FragmentLinkOptions) []BrokenFragment {
+	targets := make(map[string]bool)
+	walkHtmlIds(doc, func(n *html.Node, id string) WalkAction {
+		targets[id] = true
+		return WalkContinue
+	})
+
+	var ids []string
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, a := range renderedHtmlNodesFunc(doc, func(n *html.Node) bool { return isHtmlElement(n, "a") }) {
+		if name := GetAttrOr(a, "name", ""); name != "" {
+			targets[name] = true
+		}
+	}
+
+	broken := []BrokenFragment{}
+	check := func(n *html.Node, attr string, fragment string) {
+		if !targets[fragment] {
+			broken = append(broken, BrokenFragment{
+				Node:       n,
+				Attr:       attr,
+				Fragment:   fragment,
+				Candidates: closestStrings(fragment, ids, 3),
+			})
+		}
+	}
+
+	Walk(doc, func(n *html.Node) WalkAction {
+		if n.Type != html.ElementNode {
+			return WalkContinue
+		}
+
+		if isHtmlElement(n, "a", "area") {
+			if fragment, ok := inPageFragment(GetAttrOr(n, "href", "")); ok {
+				check(n, "href", fragment)
+			}
+		}
+
+		if opts.CheckIdReferences {
+			for _, attr := range []string{"aria-labelledby", "aria-describedby", "aria-controls"} {
+				for _, id := range splitHtmlTokens(GetAttrOr(n, attr, "")) {
+					check(n, attr, id)
+				}
+			}
+			if isHtmlElement(n, "label") {
+				if id, ok := GetAttr(n, "for"); ok && id != "" {
+					check(n, "for", id)
+				}
+			}
+		}
+		return WalkContinue
+	})
+
+	return broken
+}
+
+// inPageFragment returns the decoded fragment of href if it only consists of
+// a fragment other than "" or "top".
+func inPageFragment(href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if !strings.HasPrefix(href, "#") {
+		return "", false
+	}
+
+	fragment := href[1:]
+	if decoded, err := url.PathUnescape(fragment); err == nil {
+		fragment = decoded
+	}
+	if fragment == "" || strings.EqualFold(fragment, "top") {
+		return "", false
+	}
+	return fragment, true
+}
+
+// closestStrings returns up to limit of the provided candidates closest to s by
+// edit distance, closest first. Candidates differing in more than half of
+// the characters of the longer string are left out.
+func closestStrings(s string, candidates []string, limit int) []string {
+	type scored struct {
+		value    string
+		distance int
+	}
+
+	var matches []scored
+	for _, c := range candidates {
+		if d := editDistance(s, c); d <= max(len([]rune(s)), len([]rune(c)))/2 {
+			matches = append(matches, scored{c, d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	closest := []string{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		closest = append(closest, matches[i].value)
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in
+// runes.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			above := row[j]
+			row[j] = min(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = above
+		}
+	}
+
+	return row[len(rb)]
+}
@@ -3,3 +3,5 @@ module github.com/twodarek/go-htmlutil
 go 1.24
 
 require golang.org/x/net v0.37.0
+
+require golang.org/x/text v0.23.0
@@ -0,0 +1,155 @@
+package htmlutil
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HashOptions controls which parts of a tree HashHtmlNode() ignores.
+type HashOptions struct {
+	// ExcludeAttrs lists the attributes left out of the hash, such as "id",
+	// "nonce", or "data-reactid". Namespaced attributes are written as
+	// "namespace:key".
+	ExcludeAttrs []string
+
+	// ExcludeTags lists the elements left out of the hash along with their
+	// content, such as "script".
+	ExcludeTags []string
+}
+
+// HashHtmlNode returns the hex-encoded SHA-256 hash of a canonical
+// serialization of the provided node and its descendants, so that trees with
+// the same content hash identically regardless of how they were formatted.
+//
+// Attributes are hashed in sorted order, comments are ignored, and adjacent
+// text is merged and has its whitespace collapsed and trimmed, except inside
+// pre, textarea, and other elements where whitespace is significant. The
+// serialization is independent of html.Render, so hashes are stable across
+// runs and versions of Go and golang.org/x/net.
+func HashHtmlNode(n *html.Node, opts HashOptions) (string, error) {
+	if n == nil {
+		return "", errors.New("htmlutil: cannot hash a nil node")
+	}
+
+	h := htmlHasher{
+		hash:  sha256.New(),
+		attrs: stringSet(opts.ExcludeAttrs),
+		tags:  stringSet(opts.ExcludeTags),
+	}
+	if err := h.node(n, false); err != nil {
+		return "", err
+	}
+	h.flushText(false)
+
+	return hex.EncodeToString(h.hash.Sum(nil)), nil
+}
+
+// htmlHasher writes the canonical serialization of a tree to a hash. Each
+// node is written as a kind byte followed by length-prefixed fields, and the
+// children of elements and documents are delimited by '(' and ')'.
+type htmlHasher struct {
+	hash  hash.Hash
+	attrs map[string]bool
+	tags  map[string]bool
+
+	// text accumulates adjacent text until the next node is written.
+	text strings.Builder
+}
+
+// node writes n and its descendants. literal reports whether n is within an
+// element where whitespace is significant.
+func (h *htmlHasher) node(n *html.Node, literal bool) error {
+	switch n.Type {
+	case html.TextNode:
+		h.text.WriteString(n.Data)
+		return nil
+	case html.CommentNode:
+		return nil
+	case html.ErrorNode:
+		return errors.New("htmlutil: cannot hash an error node")
+	case html.ElementNode:
+		if h.tags[n.Data] {
+			return nil
+		}
+	}
+
+	h.flushText(literal)
+
+	switch n.Type {
+	case html.DocumentNode:
+		h.hash.Write([]byte{'#'})
+	case html.DoctypeNode:
+		h.hash.Write([]byte{'!'})
+		h.field(n.Data)
+		h.attributes(n.Attr)
+		return nil
+	case html.ElementNode:
+		h.hash.Write([]byte{'<'})
+		h.field(n.Namespace)
+		h.field(n.Data)
+		h.attributes(n.Attr)
+		literal = literal || preservesWhitespace(n)
+	case html.RawNode:
+		h.hash.Write([]byte{'%'})
+		h.field(n.Data)
+		return nil
+	}
+
+	h.hash.Write([]byte{'('})
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := h.node(c, literal); err != nil {
+			return err
+		}
+	}
+	h.flushText(literal)
+	h.hash.Write([]byte{')'})
+
+	return nil
+}
+
+// flushText writes the accumulated text, if any is left once normalized.
+func (h *htmlHasher) flushText(literal bool) {
+	text := h.text.String()
+	h.text.Reset()
+
+	if !literal {
+		text = strings.Join(splitHtmlTokens(text), " ")
+	}
+	if text == "" {
+		return
+	}
+
+	h.hash.Write([]byte{'"'})
+	h.field(text)
+}
+
+// attributes writes the attributes that are not excluded, in sorted order.
+func (h *htmlHasher) attributes(attrs []html.Attribute) {
+	attrs = slices.DeleteFunc(slices.Clone(attrs), func(a html.Attribute) bool {
+		return h.attrs[qualifiedAttrKey(a)]
+	})
+	slices.SortFunc(attrs, compareAttrs)
+
+	h.length(len(attrs))
+	for _, a := range attrs {
+		h.field(a.Namespace)
+		h.field(a.Key)
+		h.field(a.Val)
+	}
+}
+
+func (h *htmlHasher) field(s string) {
+	h.length(len(s))
+	h.hash.Write([]byte(s))
+}
+
+func (h *htmlHasher) length(n int) {
+	h.hash.Write(binary.AppendUvarint(nil, uint64(n)))
+}
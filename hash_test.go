@@ -0,0 +1,115 @@
+package htmlutil
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func mustHash(t testing.TB, n *html.Node, opts HashOptions) string {
+	t.Helper()
+
+	h, err := HashHtmlNode(n, opts)
+	if err != nil {
+		t.Fatalf("HashHtmlNode: %v", err)
+	}
+	return h
+}
+
+// The golden hashes must never change: they are stored by users to
+// deduplicate content across runs.
+func TestHashHtmlNodeGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		opts   HashOptions
+		want   string
+	}{
+		{
+			name:   "empty document",
+			markup: ``,
+			want:   "55db46e22121365a3b23383ba20f25db10a24df266655733c9275d446de41927",
+		},
+		{
+			name:   "article",
+			markup: `<!DOCTYPE html><html lang="en"><head><title>T</title></head><body><article class="post" id="p1"><h1>Title</h1><p>Some <b>bold</b> text.</p></article></body></html>`,
+			want:   "97e077bde018963950934b2698aa24a418e633ea3e9d37e05529e3925c1ac45a",
+		},
+		{
+			name:   "excluded attributes and tags",
+			markup: `<div id="x" data-reactid="7"><script nonce="abc">track()</script><p>Text</p></div>`,
+			opts:   HashOptions{ExcludeAttrs: []string{"id", "data-reactid", "nonce"}, ExcludeTags: []string{"script"}},
+			want:   "cfd7befcddb5e7b4e9d2c09eec835cbbdf172a1ee489f4a7372c00bf915ba9bc",
+		},
+		{
+			name:   "namespaces and literal whitespace",
+			markup: "<svg><use xlink:href=\"#i\"></use></svg><pre>  a\n  b</pre>",
+			want:   "a9def9007fdce331c74f0addef580456a45f0add5bebb1d183a0591651c9825f",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := mustHash(t, mustParse(t, tt.markup), tt.opts); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHashHtmlNodeEquivalent(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		opts HashOptions
+	}{
+		{"attribute order", `<a href="/" class="x">a</a>`, `<a class="x" href="/">a</a>`, HashOptions{}},
+		{"formatting", "<div>\n  <p>Some   text</p>\n</div>", `<div><p>Some text</p></div>`, HashOptions{}},
+		{"comments", `<p>a<!-- x -->b</p>`, `<p>ab</p>`, HashOptions{}},
+		{"excluded attributes", `<p id="a1" nonce="x">t</p>`, `<p id="b2">t</p>`, HashOptions{ExcludeAttrs: []string{"id", "nonce"}}},
+		{"excluded tags", `<p>t<script>a()</script></p>`, `<p>t<script>b()</script></p><script>c()</script>`, HashOptions{ExcludeTags: []string{"script"}}},
+		{"excluded tag between text", `<p>a <style>x</style> b</p>`, `<p>a b</p>`, HashOptions{ExcludeTags: []string{"style"}}},
+	}
+
+	for _, tt := range tests {
+		a, b := mustHash(t, mustParse(t, tt.a), tt.opts), mustHash(t, mustParse(t, tt.b), tt.opts)
+		if a != b {
+			t.Errorf("%s: got different hashes %s and %s", tt.name, a, b)
+		}
+	}
+}
+
+func TestHashHtmlNodeDifferent(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+	}{
+		{"text", `<p>a</p>`, `<p>b</p>`},
+		{"tag", `<p>a</p>`, `<div>a</div>`},
+		{"attribute value", `<p class="a">t</p>`, `<p class="b">t</p>`},
+		{"attribute split", `<p a="bc">t</p>`, `<p ab="c">t</p>`},
+		{"structure", `<p><b>a</b>b</p>`, `<p><b>ab</b></p>`},
+		{"text across elements", `<p>a</p><p>b</p>`, `<p>a b</p>`},
+		{"pre whitespace", `<pre>a  b</pre>`, `<pre>a b</pre>`},
+		{"namespace", `<svg><a></a></svg>`, `<a></a>`},
+		{"doctype", `<!DOCTYPE html><p>a</p>`, `<p>a</p>`},
+	}
+
+	for _, tt := range tests {
+		a, b := mustHash(t, mustParse(t, tt.a), HashOptions{}), mustHash(t, mustParse(t, tt.b), HashOptions{})
+		if a == b {
+			t.Errorf("%s: got the same hash %s", tt.name, a)
+		}
+	}
+}
+
+func TestHashHtmlNodeErrors(t *testing.T) {
+	if _, err := HashHtmlNode(nil, HashOptions{}); err == nil {
+		t.Error("nil node: got no error")
+	}
+
+	n := NewElement("div", nil, &html.Node{Type: html.ErrorNode})
+	if _, err := HashHtmlNode(n, HashOptions{}); err == nil {
+		t.Error("error node: got no error")
+	}
+}
@@ -0,0 +1,302 @@
+package htmlutil
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Heading is a heading element of an HTML document.
+type Heading struct {
+	// Level is the rank of the heading, from 1 for h1 to 6 for h6.
+	Level int
+
+	// Text is the normalized text of the heading.
+	Text string
+
+	// ID is the id attribute of the heading, or empty if it has none.
+	ID string
+
+	Node *html.Node
+}
+
+// ExtractHeadings returns the h1 to h6 elements within the provided node, in
+// document order.
+func ExtractHeadings(n *html.Node) []Heading {
+	headings := []Heading{}
+
+	for _, h := range GetAllHtmlNodesFunc(n, isHeading) {
+		headings = append(headings, Heading{
+			Level: headingLevel(h),
+			Text:  GetNormalizedText(h),
+			ID:    GetAttrOr(h, "id", ""),
+			Node:  h,
+		})
+	}
+
+	return headings
+}
+
+// TOCOptions controls the output of BuildTOC().
+type TOCOptions struct {
+	// MinLevel and MaxLevel restrict the table of contents to the headings
+	// with a level in that range. If 0, they default to 1 and 6.
+	MinLevel int
+	MaxLevel int
+
+	// Ordered uses ol elements instead of ul elements for the lists.
+	Ordered bool
+}
+
+// BuildTOC returns a detached list element holding a table of contents of the
+// headings within the provided node. Each heading becomes a list item with a
+// link to its id, or only its text if it has no id; use AssignHeadingIds()
+// first to give every heading one.
+//
+// Headings with a higher level than the previous one are nested in a list
+// within the item of the previous heading. When levels are skipped, as from h2
+// straight to h4, a single level of nesting is added.
+func BuildTOC(n *html.Node, opts TOCOptions) *html.Node {
+	minLevel, maxLevel := opts.MinLevel, opts.MaxLevel
+	if minLevel == 0 {
+		minLevel = 1
+	}
+	if maxLevel == 0 {
+		maxLevel = 6
+	}
+
+	listTag := "ul"
+	if opts.Ordered {
+		listTag = "ol"
+	}
+
+	root := NewElement(listTag, nil)
+
+	// Each list of the stack holds the items of a given level, the deepest
+	// list being last
+	type tocList struct {
+		node  *html.Node
+		level int
+	}
+	var stack []tocList
+
+	for _, h := range ExtractHeadings(n) {
+		if h.Level < minLevel || h.Level > maxLevel {
+			continue
+		}
+
+		// Close the lists nested in one whose level the heading reaches
+		for len(stack) > 1 && stack[len(stack)-2].level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		switch {
+		case len(stack) == 0:
+			stack = append(stack, tocList{root, h.Level})
+		case stack[len(stack)-1].level > h.Level:
+			// The deepest list was opened for a skipped level, as for an
+			// h4 following an h2, and its items now include the heading
+			stack[len(stack)-1].level = h.Level
+		case stack[len(stack)-1].level < h.Level:
+			list := NewElement(listTag, nil)
+			stack[len(stack)-1].node.LastChild.AppendChild(list)
+			stack = append(stack, tocList{list, h.Level})
+		}
+
+		stack[len(stack)-1].node.AppendChild(tocItem(h))
+	}
+
+	return root
+}
+
+func tocItem(h Heading) *html.Node {
+	if h.ID == "" {
+		return NewElement("li", nil, NewText(h.Text))
+	}
+	return NewElement("li", nil, NewElement("a", map[string]string{"href": "#" + h.ID}, NewText(h.Text)))
+}
+
+// AssignHeadingIds gives an id attribute to the headings within the provided
+// node that lack one, derived from their text, and returns the number of ids
+// assigned. The text is lowercased, letters and digits are kept, and runs of
+// other characters become a single hyphen. Ids already used in the document
+// are avoided by appending -2, -3, and so on.
+func AssignHeadingIds(n *html.Node) int {
+	used := make(map[string]bool)
+	walkHtmlIds(n, func(n *html.Node, id string) WalkAction {
+		used[id] = true
+		return WalkContinue
+	})
+
+	assigned := 0
+	for _, h := range ExtractHeadings(n) {
+		if h.ID != "" {
+			continue
+		}
+
+		slug := slugify(h.Text)
+		id := slug
+		for i := 2; used[id]; i++ {
+			id = slug + "-" + strconv.Itoa(i)
+		}
+
+		used[id] = true
+		SetHtmlAttr(h.Node, "id", id)
+		assigned++
+	}
+
+	return assigned
+}
+
+// slugify converts text to a lowercase string of letters, digits, and hyphens
+// suitable for an id. It returns "section" if nothing is left.
+func slugify(text string) string {
+	var sb strings.Builder
+	hyphen := false
+
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			hyphen = false
+			sb.WriteRune(r)
+		} else {
+			hyphen = true
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "section"
+	}
+	return sb.String()
+}
+
+// isHeading reports whether n is an h1 to h6 element.
+func isHeading(n *html.Node) bool {
+	return headingLevel(n) > 0
+}
+
+// headingLevel returns the level of an h1 to h6 element, or 0 if n is not a
+// heading.
+func headingLevel(n *html.Node) int {
+	if n.Type != html.ElementNode || n.Namespace != "" || len(n.Data) != 2 || n.Data[0] != 'h' {
+		return 0
+	}
+	if level := int(n.Data[1] - '0'); level >= 1 && level <= 6 {
+		return level
+	}
+	return 0
+}
+
+// ShiftOptions controls the output of ShiftHeadingsWithOptions().
+type ShiftOptions struct {
+	// ConvertOverflow replaces the headings shifted past h6 with p elements
+	// with role="heading" and an aria-level attribute holding their level,
+	// such as 7, instead of clamping them to h6.
+	ConvertOverflow bool
+}
+
+// ShiftHeadings is a convenience function for ShiftHeadingsWithOptions() that
+// clamps headings to h6.
+func ShiftHeadings(n *html.Node, delta int) int {
+	return ShiftHeadingsWithOptions(n, delta, ShiftOptions{})
+}
+
+// ShiftHeadingsWithOptions changes the level of the h1 to h6 elements within
+// the provided node by delta, so that a delta of 1 turns h1 into h2 and h2
+// into h3, and returns the number of headings changed. Levels are clamped to
+// h1 for negative deltas, and to h6 unless opts.ConvertOverflow is set.
+//
+// Headings keep their attributes and children, and only their rank changes:
+// headings inside section and article elements are shifted like any other.
+func ShiftHeadingsWithOptions(n *html.Node, delta int, opts ShiftOptions) int {
+	shifted := 0
+
+	for _, h := range GetAllHtmlNodesFunc(n, isHeading) {
+		level := max(headingLevel(h)+delta, 1)
+		if level > 6 && opts.ConvertOverflow {
+			renameHtmlNode(h, "p")
+			SetHtmlAttr(h, "role", "heading")
+			SetHtmlAttr(h, "aria-level", strconv.Itoa(level))
+			shifted++
+			continue
+		}
+
+		if renameHtmlNode(h, "h"+strconv.Itoa(min(level, 6))) {
+			shifted++
+		}
+	}
+
+	return shifted
+}
+
+// Section is a section of a document implied by its headings.
+type Section struct {
+	// Heading is the heading starting the section, or nil for the root
+	// section returned by SectionOutline().
+	Heading *html.Node
+
+	// Level is the level of the heading, or 0 for the root section.
+	Level int
+
+	// Title is the normalized text of the heading.
+	Title string
+
+	// Content holds the nodes following the heading up to the next heading,
+	// in document order. Each node is the largest subtree holding no heading.
+	Content []*html.Node
+
+	// Subsections holds the sections started by the following headings of a
+	// higher level, up to the next heading of the same or a lower level.
+	Subsections []*Section
+}
+
+// SectionOutline returns the sections implied by the headings within the
+// provided node. The returned root section holds the content preceding the
+// first heading, and the top-level sections as subsections.
+//
+// Each heading starts a section that ends at the next heading of the same or a
+// lower level, so an h3 following an h2 is a subsection of it. As in browsers,
+// the outline only depends on the levels of headings: section and article
+// elements are not taken into account, and the headings inside them are
+// nested according to their level like any other.
+func SectionOutline(n *html.Node) *Section {
+	// Mark the nodes holding headings, whose children are split between
+	// sections
+	holdsHeading := make(map[*html.Node]bool)
+	for _, h := range GetAllHtmlNodesFunc(n, isHeading) {
+		for p := h.Parent; p != nil && p != n.Parent && !holdsHeading[p]; p = p.Parent {
+			holdsHeading[p] = true
+		}
+	}
+
+	root := &Section{}
+	stack := []*Section{root}
+
+	var outline func(n *html.Node)
+	outline = func(n *html.Node) {
+		switch {
+		case isHeading(n):
+			s := &Section{Heading: n, Level: headingLevel(n), Title: GetNormalizedText(n)}
+			for len(stack) > 1 && stack[len(stack)-1].Level >= s.Level {
+				stack = stack[:len(stack)-1]
+			}
+			parent := stack[len(stack)-1]
+			parent.Subsections = append(parent.Subsections, s)
+			stack = append(stack, s)
+		case holdsHeading[n]:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				outline(c)
+			}
+		default:
+			current := stack[len(stack)-1]
+			current.Content = append(current.Content, n)
+		}
+	}
+	outline(n)
+
+	return root
+}
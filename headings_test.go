@@ -0,0 +1,270 @@
+package htmlutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractHeadings(t *testing.T) {
+	doc := mustParse(t, `<h1 id="top">  Title  <em>here</em></h1><p>text</p>`+
+		`<section><h3>Deep</h3></section><h7>not</h7><svg><title>not</title></svg><h6 id="">Last</h6>`)
+
+	got := ExtractHeadings(doc)
+	want := []Heading{
+		{Level: 1, Text: "Title here", ID: "top"},
+		{Level: 3, Text: "Deep"},
+		{Level: 6, Text: "Last"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d headings, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].Node == nil || got[i].Node.Data[1] != byte('0'+want[i].Level) {
+			t.Errorf("%d: got node %v", i, got[i].Node)
+		}
+		got[i].Node = nil
+		if got[i] != want[i] {
+			t.Errorf("%d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := ExtractHeadings(mustParse(t, `<p>none</p>`)); got == nil || len(got) != 0 {
+		t.Errorf("got %#v, want an empty slice", got)
+	}
+}
+
+func TestBuildTOC(t *testing.T) {
+	doc := mustParse(t, `<h1 id="a">A</h1><h2 id="b">B</h2><h4 id="c">C</h4><h3>No id</h3>`+
+		`<h2 id="d">D</h2><h5 id="e">E</h5><h1 id="f">F</h1>`)
+
+	tests := []struct {
+		name string
+		opts TOCOptions
+		want string
+	}{
+		{
+			name: "skipped levels",
+			want: `<ul><li><a href="#a">A</a><ul>` +
+				`<li><a href="#b">B</a><ul><li><a href="#c">C</a></li><li>No id</li></ul></li>` +
+				`<li><a href="#d">D</a><ul><li><a href="#e">E</a></li></ul></li>` +
+				`</ul></li><li><a href="#f">F</a></li></ul>`,
+		},
+		{
+			name: "level range",
+			opts: TOCOptions{MinLevel: 2, MaxLevel: 3},
+			want: `<ul><li><a href="#b">B</a><ul><li>No id</li></ul></li><li><a href="#d">D</a></li></ul>`,
+		},
+		{
+			name: "ordered",
+			opts: TOCOptions{MinLevel: 4, Ordered: true},
+			want: `<ol><li><a href="#c">C</a><ol><li><a href="#e">E</a></li></ol></li></ol>`,
+		},
+		{
+			name: "no headings",
+			opts: TOCOptions{MinLevel: 6},
+			want: `<ul></ul>`,
+		},
+	}
+
+	for _, tt := range tests {
+		toc := BuildTOC(doc, tt.opts)
+		if toc.Parent != nil {
+			t.Errorf("%s: table of contents is attached", tt.name)
+		}
+		if got := mustRender(t, toc); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTOCStartingDeep(t *testing.T) {
+	doc := mustParse(t, `<h3 id="a">A</h3><h2 id="b">B</h2><h3 id="c">C</h3>`)
+
+	want := `<ul><li><a href="#a">A</a></li><li><a href="#b">B</a><ul><li><a href="#c">C</a></li></ul></li></ul>`
+	if got := mustRender(t, BuildTOC(doc, TOCOptions{})); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestAssignHeadingIds(t *testing.T) {
+	doc := mustParse(t, `<div id="intro"></div>`+
+		`<h1>Intro</h1><h2>Intro</h2><h2 id="kept">Intro</h2><h2>intro</h2>`+
+		`<h3>Hello, World!</h3><h3>  ¿Qué tal?  </h3><h3>***</h3><h3></h3><h4>Section</h4>`)
+
+	if got := AssignHeadingIds(doc); got != 8 {
+		t.Errorf("got %d ids assigned, want 8", got)
+	}
+
+	var ids []string
+	for _, h := range ExtractHeadings(doc) {
+		ids = append(ids, h.ID)
+	}
+	want := []string{"intro-2", "intro-3", "kept", "intro-4", "hello-world", "qué-tal", "section", "section-2", "section-3"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("got ids %q, want %q", ids, want)
+	}
+
+	if got := AssignHeadingIds(doc); got != 0 {
+		t.Errorf("second call: got %d ids assigned, want 0", got)
+	}
+
+	// The assigned ids make every heading a link of the table of contents
+	toc := BuildTOC(doc, TOCOptions{})
+	if got := len(GetAllHtmlNodes(toc, "a", "", "")); got != len(want) {
+		t.Errorf("got %d links, want %d", got, len(want))
+	}
+}
+
+func TestShiftHeadings(t *testing.T) {
+	const doc = `<h1 class="t">A <b>b</b></h1><section><h2>B</h2><article><h3>C</h3><h5>D</h5></article></section><h6>E</h6>`
+
+	tests := []struct {
+		name        string
+		delta       int
+		want        string
+		wantShifted int
+	}{
+		{"demote", 1, `<h2 class="t">A <b>b</b></h2><section><h3>B</h3><article><h4>C</h4><h6>D</h6></article></section><h6>E</h6>`, 4},
+		{"clamp at h6", 3, `<h4 class="t">A <b>b</b></h4><section><h5>B</h5><article><h6>C</h6><h6>D</h6></article></section><h6>E</h6>`, 4},
+		{"promote", -1, `<h1 class="t">A <b>b</b></h1><section><h1>B</h1><article><h2>C</h2><h4>D</h4></article></section><h5>E</h5>`, 4},
+		{"clamp at h1", -10, `<h1 class="t">A <b>b</b></h1><section><h1>B</h1><article><h1>C</h1><h1>D</h1></article></section><h1>E</h1>`, 4},
+		{"zero", 0, doc, 0},
+	}
+
+	for _, tt := range tests {
+		root := mustParse(t, doc)
+
+		if got := ShiftHeadings(root, tt.delta); got != tt.wantShifted {
+			t.Errorf("%s: got %d shifted, want %d", tt.name, got, tt.wantShifted)
+		}
+		if got := bodyHtml(t, root); got != tt.want {
+			t.Errorf("%s: got  %s\nwant %s", tt.name, got, tt.want)
+		}
+		for _, h := range GetAllHtmlNodesFunc(root, isHeading) {
+			if h.DataAtom == 0 || h.DataAtom.String() != h.Data {
+				t.Errorf("%s: got atom %v for %s", tt.name, h.DataAtom, h.Data)
+			}
+		}
+	}
+}
+
+func TestShiftHeadingsConvertOverflow(t *testing.T) {
+	root := mustParse(t, `<h4 id="a">A</h4><section><h5>B</h5><h6>C</h6></section><p>x</p>`)
+
+	if got := ShiftHeadingsWithOptions(root, 2, ShiftOptions{ConvertOverflow: true}); got != 3 {
+		t.Errorf("got %d shifted, want 3", got)
+	}
+	want := `<h6 id="a">A</h6><section><p role="heading" aria-level="7">B</p><p role="heading" aria-level="8">C</p></section><p>x</p>`
+	if got := bodyHtml(t, root); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// The converted headings are no longer h1 to h6 elements, so shifting
+	// back only changes the others
+	if got := ShiftHeadingsWithOptions(root, -2, ShiftOptions{ConvertOverflow: true}); got != 1 {
+		t.Errorf("shifting back: got %d shifted, want 1", got)
+	}
+}
+
+// outlineString describes a section tree as the title of each section, the
+// tags of its content, and its subsections.
+func outlineString(s *Section) string {
+	var sb strings.Builder
+
+	sb.WriteString(s.Title)
+	for _, n := range s.Content {
+		if n.Type == html.ElementNode {
+			sb.WriteString("[" + n.Data + "]")
+		} else if text := strings.TrimSpace(n.Data); text != "" {
+			sb.WriteString("[" + text + "]")
+		}
+	}
+	if len(s.Subsections) > 0 {
+		sb.WriteString("(")
+		for i, sub := range s.Subsections {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(outlineString(sub))
+		}
+		sb.WriteString(")")
+	}
+
+	return sb.String()
+}
+
+func TestSectionOutline(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "flat",
+			doc:  `<p>intro</p><h1>A</h1><p>a</p><h2>B</h2><ul></ul><h2>C</h2><h1>D</h1><table></table>`,
+			want: `[p](A[p](B[ul] C) D[table])`,
+		},
+		{
+			name: "skipped levels",
+			doc:  `<h2>A</h2><h4>B</h4><h3>C</h3><h1>D</h1>`,
+			want: `(A(B C) D)`,
+		},
+		{
+			name: "headings in sections",
+			doc:  `<h1>A</h1><section><h2>B</h2><p>b</p><article><h3>C</h3><p>c</p></article></section><h2>D</h2>`,
+			want: `(A(B[p](C[p]) D))`,
+		},
+		{
+			name: "sections ignored",
+			doc:  `<section><h1>A</h1><p>a</p></section><section><h1>B</h1><section><h1>C</h1></section></section>`,
+			want: `(A[p] B C)`,
+		},
+		{
+			name: "content after a section",
+			doc:  `<h1>A</h1><article><h2>B</h2></article><p>still in B</p><aside>also</aside>`,
+			want: `(A(B[p][aside]))`,
+		},
+		{
+			name: "content around a heading",
+			doc:  `<div>before<h1>A</h1>after<span>x</span></div>`,
+			want: `[before](A[after][span])`,
+		},
+		{
+			name: "no headings",
+			doc:  `<p>a</p><section><p>b</p></section>`,
+			want: `[body]`,
+		},
+	}
+
+	for _, tt := range tests {
+		body := GetFirstHtmlNode(mustParse(t, tt.doc), "body", "", "")
+		if got := outlineString(SectionOutline(body)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSectionOutlineRoot(t *testing.T) {
+	doc := mustParse(t, `<h1>A <i>title</i></h1><p>x</p>`)
+	h1 := GetFirstHtmlNode(doc, "h1", "", "")
+
+	root := SectionOutline(doc)
+	if root.Heading != nil || root.Level != 0 || root.Title != "" {
+		t.Errorf("got root %+v", root)
+	}
+	if len(root.Subsections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(root.Subsections))
+	}
+	if s := root.Subsections[0]; s.Heading != h1 || s.Level != 1 || s.Title != "A title" {
+		t.Errorf("got section %+v", s)
+	}
+
+	// A heading provided as the root starts the only section
+	if got := outlineString(SectionOutline(h1)); got != "(A title)" {
+		t.Errorf("got %s", got)
+	}
+}
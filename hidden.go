@@ -0,0 +1,70 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// IsHiddenNode reports whether the provided node is hidden from view by
+// itself or by one of its ancestors. An element is hidden if it has the
+// hidden attribute, an inline style of display: none or visibility: hidden,
+// or aria-hidden="true", or if it is an input of type hidden.
+//
+// Only these inline signals are checked. Stylesheets, including style
+// elements within the document, are not evaluated, so an element hidden by a
+// CSS class is reported as visible.
+func IsHiddenNode(n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if isHiddenElement(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveHiddenNodes removes the descendants of the provided node that are
+// hidden, as reported by IsHiddenNode(), and returns the number of elements
+// removed. The content of removed elements is removed with them and is not
+// counted.
+func RemoveHiddenNodes(root *html.Node) int {
+	var hidden []*html.Node
+
+	Walk(root, func(n *html.Node) WalkAction {
+		if n != root && isHiddenElement(n) {
+			hidden = append(hidden, n)
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+
+	removed, _ := RemoveNodes(hidden)
+	return removed
+}
+
+// isHiddenElement reports whether n is an element hidden by one of its own
+// attributes, regardless of its ancestors.
+func isHiddenElement(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	if _, ok := GetAttr(n, "hidden"); ok {
+		return true
+	}
+	if ariaHidden, _ := GetAttr(n, "aria-hidden"); strings.EqualFold(strings.TrimSpace(ariaHidden), "true") {
+		return true
+	}
+	if inputType, _ := GetAttr(n, "type"); isHtmlElement(n, "input") && strings.EqualFold(strings.TrimSpace(inputType), "hidden") {
+		return true
+	}
+
+	if _, ok := GetAttr(n, "style"); !ok {
+		return false
+	}
+	if display, _ := GetStyleProperty(n, "display"); strings.EqualFold(display, "none") {
+		return true
+	}
+	visibility, _ := GetStyleProperty(n, "visibility")
+	return strings.EqualFold(visibility, "hidden") || strings.EqualFold(visibility, "collapse")
+}
@@ -5,12 +5,15 @@ package htmlutil
 
 import (
 	"bytes"
-	"reflect"
+	"errors"
 	"strings"
 
 	"golang.org/x/net/html"
 )
 
+// ErrNodeNotFound is returned when no HTML node matches the search criteria.
+var ErrNodeNotFound = errors.New("htmlutil: node not found")
+
 // GetAllHtmlNodes is a convenience function for GetHtmlNodes() that returns all
 // matching HTML nodes.
 func GetAllHtmlNodes(n *html.Node, tag string, attr string, attrValue string) []*html.Node {
@@ -23,28 +26,54 @@ func GetAllHtmlNodesAllowAttrSubstring(n *html.Node, tag string, attr string, at
 	return GetHtmlNodes(n, tag, attr, attrValue, -1, true)
 }
 
+// FindFirstHtmlNode is a convenience function for GetHtmlNodes() that returns
+// the first matching node, or ErrNodeNotFound if there is no match.
+func FindFirstHtmlNode(n *html.Node, tag string, attr string, attrValue string) (*html.Node, error) {
+	return firstHtmlNode(GetHtmlNodes(n, tag, attr, attrValue, 1, false))
+}
+
+// FindFirstHtmlNodeAllowAttrSubstring is a convenience function for GetHtmlNodes() that returns
+// the first matching node, allowing for an attribute value to be a superstring of the string passed in.
+// ErrNodeNotFound is returned if there is no match.
+func FindFirstHtmlNodeAllowAttrSubstring(n *html.Node, tag string, attr string, attrValue string) (*html.Node, error) {
+	return firstHtmlNode(GetHtmlNodes(n, tag, attr, attrValue, 1, true))
+}
+
 // GetFirstHtmlNode is a convenience function for GetHtmlNodes() that returns
 // the first matching node.
+//
+// If there is no match, an empty node is returned. Use FindFirstHtmlNode() to
+// distinguish a real match from the empty placeholder.
 func GetFirstHtmlNode(n *html.Node, tag string, attr string, attrValue string) *html.Node {
-	htmlNodes := GetHtmlNodes(n, tag, attr, attrValue, 1, false)
-
-	if len(htmlNodes) > 0 {
-		return htmlNodes[0]
-	}
-
-	return &html.Node{}
+	return orEmptyHtmlNode(FindFirstHtmlNode(n, tag, attr, attrValue))
 }
 
 // GetFirstHtmlNodeAllowAttrSubstring is a convenience function for GetHtmlNodes() that returns
 // the first matching node, allowing for an attribute value to be a superstring of the string passed in.
+//
+// If there is no match, an empty node is returned. Use FindFirstHtmlNodeAllowAttrSubstring() to
+// distinguish a real match from the empty placeholder.
 func GetFirstHtmlNodeAllowAttrSubstring(n *html.Node, tag string, attr string, attrValue string) *html.Node {
-	htmlNodes := GetHtmlNodes(n, tag, attr, attrValue, 1, true)
+	return orEmptyHtmlNode(FindFirstHtmlNodeAllowAttrSubstring(n, tag, attr, attrValue))
+}
 
+// firstHtmlNode returns the first of the provided nodes, or ErrNodeNotFound if
+// there are none.
+func firstHtmlNode(htmlNodes []*html.Node) (*html.Node, error) {
 	if len(htmlNodes) > 0 {
-		return htmlNodes[0]
+		return htmlNodes[0], nil
 	}
 
-	return &html.Node{}
+	return nil, ErrNodeNotFound
+}
+
+// orEmptyHtmlNode returns the provided node, or an empty node if err is not nil.
+func orEmptyHtmlNode(n *html.Node, err error) *html.Node {
+	if err != nil {
+		return &html.Node{}
+	}
+
+	return n
 }
 
 // GetHtmlNodes returns the HTML nodes found within the provided node given a
@@ -55,61 +84,224 @@ func GetFirstHtmlNodeAllowAttrSubstring(n *html.Node, tag string, attr string, a
 //
 // If the count is -1, all nodes will be returned.
 func GetHtmlNodes(n *html.Node, tag string, attr string, attrValue string, count int, allowAttrSubstring bool) []*html.Node {
+	return GetHtmlNodesWithOptions(n, tag, attr, attrValue, count, MatchOptions{AllowAttrSubstring: allowAttrSubstring})
+}
+
+// MatchOptions controls how the tag, attribute, and attribute value criteria
+// are compared by GetHtmlNodesWithOptions().
+//
+// The zero value compares everything case-sensitively and requires attribute
+// values to match exactly, which is the behavior of GetHtmlNodes() and the
+// functions built on it.
+type MatchOptions struct {
+	// AllowAttrSubstring allows an attribute value to be a superstring of the
+	// attribute value criteria.
+	AllowAttrSubstring bool
+
+	// CaseInsensitiveTag compares tag names case-insensitively.
+	CaseInsensitiveTag bool
+
+	// CaseInsensitiveAttr compares attribute keys case-insensitively.
+	CaseInsensitiveAttr bool
+
+	// CaseInsensitiveAttrValue compares attribute values case-insensitively.
+	CaseInsensitiveAttrValue bool
+
+	// SkipTemplateContent skips the content of the template elements within
+	// the searched node, which isn't part of the rendered document. The
+	// template elements themselves can still match. By default, their content
+	// is searched like any other subtree, since the parser keeps it as their
+	// children; see GetTemplateContent().
+	SkipTemplateContent bool
+}
+
+// GetHtmlNodesWithOptions is like GetHtmlNodes() but allows the comparison of
+// the tag, attribute, and attribute value to be controlled with MatchOptions.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesWithOptions(n *html.Node, tag string, attr string, attrValue string, count int, opts MatchOptions) []*html.Node {
+	return NewMatcherWithOptions(tag, attr, attrValue, opts).Find(n, count)
+}
+
+// GetChildHtmlNodes returns the immediate children of the provided node
+// matching the provided tag, attribute, and attribute value, up to the
+// provided count. Deeper descendants are never inspected, so the cells of a
+// nested table are not returned when searching the rows of a table.
+//
+// The tag, attribute, and attribute value are all optional. If they are empty,
+// they will not be used as search criteria.
+//
+// If the count is -1, all matching children will be returned.
+func GetChildHtmlNodes(n *html.Node, tag string, attr string, attrValue string, count int) []*html.Node {
+	return GetHtmlNodesMaxDepth(n, tag, attr, attrValue, count, 1)
+}
+
+// GetHtmlNodesMaxDepth is like GetHtmlNodes() without attribute substrings,
+// but only inspects the descendants of the provided node at most maxDepth
+// levels below it. The node itself is not inspected, so a maxDepth of 1
+// matches its children only, as GetChildHtmlNodes() does. If maxDepth is
+// negative, the depth is not limited.
+func GetHtmlNodesMaxDepth(n *html.Node, tag string, attr string, attrValue string, count int, maxDepth int) []*html.Node {
 	var foundNodes []*html.Node
 
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		// Find the element with the matching tag
-		if n.Type == html.ElementNode && (tag == "" || n.Data == tag) {
-			// If attribute and attribute value are empty, don't iterate through
-			// the list of attributes. This ensures a match even if the list of
-			// attributes is empty.
-			if attr == "" && attrValue == "" {
-				foundNodes = append(foundNodes, n)
-
-			} else {
-				for _, a := range n.Attr {
-					if attr == "" || a.Key == attr {
-						if attrValue == "" || a.Val == attrValue || isStringSubstring(a.Val, attrValue, allowAttrSubstring) {
-							foundNodes = append(foundNodes, n)
-							if count != -1 && len(foundNodes) >= count {
-								break
-							}
-						}
-					}
-				}
-			}
+	if n == nil || count == 0 || maxDepth == 0 {
+		return foundNodes
+	}
+
+	m := NewMatcher(tag, attr, attrValue)
+	WalkWithDepth(n, func(c *html.Node, depth int) WalkAction {
+		if depth == 0 {
+			return WalkContinue
 		}
 
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			// Stop parsing if we've reached the desired count
-			if count == -1 || len(foundNodes) < count {
-				f(c)
+		if m.Match(c) {
+			foundNodes = append(foundNodes, c)
+
+			// Stop parsing as soon as we've reached the desired count
+			if count >= 0 && len(foundNodes) >= count {
+				return WalkStop
 			}
 		}
-	}
-	f(n)
+
+		if maxDepth > 0 && depth >= maxDepth {
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
 
 	return foundNodes
 }
 
-func isStringSubstring(value, substring string, allowAttrSubstring bool) bool {
-	if !allowAttrSubstring || reflect.TypeOf(value).String() != "string" {
-		return false
+// htmlNodeMatches reports whether n is an element node matching the provided
+// tag, attribute, and attribute value. A node matches at most once, no matter
+// how many of its attributes satisfy the criteria.
+func htmlNodeMatches(n *html.Node, tag string, attr string, attrValue string, opts MatchOptions) bool {
+	return NewMatcherWithOptions(tag, attr, attrValue, opts).Match(n)
+}
+
+// stringsEqual compares two strings, optionally ignoring case.
+func stringsEqual(a string, b string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// GetAllHtmlNodesFunc is a convenience function for GetHtmlNodesFunc() that
+// returns all matching HTML nodes.
+func GetAllHtmlNodesFunc(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	return GetHtmlNodesFunc(n, match, -1)
+}
+
+// FindFirstHtmlNodeFunc is a convenience function for GetHtmlNodesFunc() that
+// returns the first matching node, or ErrNodeNotFound if there is no match.
+func FindFirstHtmlNodeFunc(n *html.Node, match func(*html.Node) bool) (*html.Node, error) {
+	return firstHtmlNode(GetHtmlNodesFunc(n, match, 1))
+}
+
+// GetFirstHtmlNodeFunc is a convenience function for GetHtmlNodesFunc() that
+// returns the first matching node.
+//
+// If there is no match, an empty node is returned.
+func GetFirstHtmlNodeFunc(n *html.Node, match func(*html.Node) bool) *html.Node {
+	return orEmptyHtmlNode(FindFirstHtmlNodeFunc(n, match))
+}
+
+// GetHtmlNodesFunc returns the HTML nodes found within the provided node for
+// which match returns true, up to the provided count.
+//
+// Unlike GetHtmlNodes, match is called for every node in the tree, not just
+// element nodes. Nodes are returned in document order.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesFunc(n *html.Node, match func(*html.Node) bool, count int) []*html.Node {
+	return findHtmlNodesFunc(n, match, count, false)
+}
+
+// findHtmlNodesFunc implements GetHtmlNodesFunc(), skipping the content of
+// the template elements below root if skipTemplates is true.
+func findHtmlNodesFunc(root *html.Node, match func(*html.Node) bool, count int, skipTemplates bool) []*html.Node {
+	var foundNodes []*html.Node
+
+	if count == 0 {
+		return foundNodes
 	}
-	return strings.Contains(value, substring)
+
+	Walk(root, func(n *html.Node) WalkAction {
+		action := WalkContinue
+		if skipTemplates && n != root && isTemplate(n) {
+			action = WalkSkipChildren
+		}
+		if !match(n) {
+			return action
+		}
+		foundNodes = append(foundNodes, n)
+
+		// Stop parsing as soon as we've reached the desired count
+		if count >= 0 && len(foundNodes) >= count {
+			return WalkStop
+		}
+		return action
+	})
+
+	return foundNodes
+}
+
+// CountHtmlNodes returns the number of HTML nodes found within the provided
+// node given a tag, attribute, and attribute value, using the same criteria as
+// GetHtmlNodes(). It is equivalent to len(GetAllHtmlNodes(...)) but doesn't
+// build a slice.
+func CountHtmlNodes(n *html.Node, tag string, attr string, attrValue string) int {
+	m := NewMatcher(tag, attr, attrValue)
+	count := 0
+
+	Walk(n, func(n *html.Node) WalkAction {
+		if m.Match(n) {
+			count++
+		}
+		return WalkContinue
+	})
+
+	return count
+}
+
+// HasHtmlNode reports whether the provided node contains an HTML node matching
+// the provided tag, attribute, and attribute value, using the same criteria as
+// GetHtmlNodes(). The search stops at the first match.
+func HasHtmlNode(n *html.Node, tag string, attr string, attrValue string) bool {
+	m := NewMatcher(tag, attr, attrValue)
+	return !Walk(n, func(n *html.Node) WalkAction {
+		if m.Match(n) {
+			return WalkStop
+		}
+		return WalkContinue
+	})
 }
 
 // HtmlNodeToString converts an HTML node to a string for easier printing.
 func HtmlNodeToString(n *html.Node) (string, error) {
-	var buf bytes.Buffer
+	buf := getRenderBuffer(n)
+	defer putRenderBuffer(buf)
 
-	if err := html.Render(&buf, n); err != nil {
+	if err := html.Render(buf, n); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
+// AppendHtmlNode appends the rendering of an HTML node to dst and returns the
+// extended buffer, so that a buffer can be reused across calls instead of
+// allocating a string for each node.
+func AppendHtmlNode(dst []byte, n *html.Node) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	buf.Grow(estimateRenderedSize(n))
+
+	if err := html.Render(buf, n); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
 // RemoveAllHtmlAttrs is a convenience function for RemoveHtmlAttrs() that
 // removes all matching attributes.
 func RemoveAllHtmlAttrs(n *html.Node, tag string, attr string, attrValue string) {
@@ -125,28 +317,46 @@ func RemoveFirstHtmlAttr(n *html.Node, tag string, attr string, attrValue string
 // RemoveHtmlAttrs removes HTML attributes matching the provided tag, attribute,
 // and value up to the provided count.
 //
-// Tag is optional. If no tag is provided, all attributes matching the attribute
-// and value will be removed.
+// The attribute is required: if it is empty, nothing is removed, so that a
+// missing key can't strip every attribute of the matching elements. The tag
+// and attribute value are optional. If no tag is provided, all attributes
+// matching the attribute and value will be removed. If no attribute value is
+// provided, all attributes with a matching key will be removed regardless of
+// their value.
 //
-// If the count is -1, all attributes meeting the criteria will be removed.
+// The count bounds the number of attributes removed, not the number of nodes
+// they are removed from. If the count is -1, all attributes meeting the
+// criteria will be removed.
 func RemoveHtmlAttrs(node *html.Node, tag string, attr string, attrValue string, count int) {
-	var processNode func(*html.Node, string, string)
-	processNode = func(nodeToProcess *html.Node, attr string, attrValue string) {
-		for i, a := range nodeToProcess.Attr {
-			// If we have a matching attribute and value
-			if a.Key == attr && a.Val == attrValue {
-				// Go idiom to delete the item from the array
-				nodeToProcess.Attr = append(nodeToProcess.Attr[:i], nodeToProcess.Attr[i+1:]...)
-
-				// Run this function again on the newly modified node in case there are any other matches
-				processNode(nodeToProcess, attr, attrValue)
+	if attr == "" {
+		return
+	}
+
+	m := NewMatcher(tag, attr, attrValue)
+	remaining := count
+
+	Walk(node, func(n *html.Node) WalkAction {
+		if remaining == 0 {
+			return WalkStop
+		}
+		if !m.Match(n) {
+			return WalkContinue
+		}
+
+		// Filter the attributes in a single pass, keeping those that don't
+		// match or are over the count
+		attrs := n.Attr[:0]
+		for _, a := range n.Attr {
+			if remaining != 0 && a.Key == attr && (attrValue == "" || a.Val == attrValue) {
+				remaining--
+				continue
 			}
+			attrs = append(attrs, a)
 		}
-	}
+		n.Attr = attrs
 
-	for _, nodeToProcess := range GetHtmlNodes(node, tag, attr, attrValue, count, false) {
-		processNode(nodeToProcess, attr, attrValue)
-	}
+		return WalkContinue
+	})
 }
 
 // RemoveAllHtmlNodes is a convenience function for RemoveHtmlNodes() that
@@ -167,14 +377,57 @@ func RemoveFirstHtmlNode(n *html.Node, tag string, attr string, attrValue string
 // The tag, attribute, and attribute value are all optional. If they are empty,
 // they will not be used as search criteria.
 //
+// Matching nodes without a parent, such as the provided node itself when it is
+// detached, can't be removed and are skipped. Use RemoveHtmlNodesReport() to
+// find out how many nodes were removed and skipped.
+//
 // If the count is -1, all nodes meeting the criteria will be removed.
 func RemoveHtmlNodes(n *html.Node, tag string, attr string, attrValue string, count int) {
-	nodesToDelete := GetHtmlNodes(n, tag, attr, attrValue, count, false)
+	RemoveHtmlNodesReport(n, tag, attr, attrValue, count)
+}
+
+// RemoveHtmlNodesReport is like RemoveHtmlNodes() but returns the number of
+// matching nodes that were removed and the number that were skipped because
+// they had no parent.
+func RemoveHtmlNodesReport(n *html.Node, tag string, attr string, attrValue string, count int) (removed int, skipped int) {
+	return RemoveNodes(GetHtmlNodes(n, tag, attr, attrValue, count, false))
+}
+
+// RemoveNodes detaches the provided nodes from their parents, and returns the
+// number of distinct nodes removed from their tree and the number of entries
+// skipped.
+//
+// The nodes can come from anywhere, such as the results of several Get
+// calls, and can be in any order. Nil entries and duplicates are skipped, as
+// are nodes that are already detached. Nodes with an ancestor in the slice
+// are left in place within that ancestor, which is removed with them, and
+// count as removed.
+func RemoveNodes(nodes []*html.Node) (removed int, skipped int) {
+	// Find the attached nodes before the tree is modified, so that the order
+	// of the slice doesn't matter
+	var attached []*html.Node
+	set := make(map[*html.Node]bool, len(nodes))
+	for _, n := range nodes {
+		if n == nil || set[n] || n.Parent == nil {
+			skipped++
+			continue
+		}
+		set[n] = true
+		attached = append(attached, n)
+	}
 
-	if len(nodesToDelete) > 0 {
-		// Delete nodes in reverse order (so the children get deleted first)
-		for i := len(nodesToDelete) - 1; i >= 0; i-- {
-			nodesToDelete[i].Parent.RemoveChild(nodesToDelete[i])
+	for _, n := range attached {
+		nested := false
+		for p := n.Parent; p != nil; p = p.Parent {
+			if set[p] {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			n.Parent.RemoveChild(n)
 		}
 	}
+
+	return len(attached), skipped
 }
@@ -0,0 +1,957 @@
+package htmlutil
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// mustParse parses an HTML document, failing the test on error.
+func mustParse(t testing.TB, s string) *html.Node {
+	t.Helper()
+
+	doc, err := ParseString(s)
+	if err != nil {
+		t.Fatalf("ParseString(%q): %v", s, err)
+	}
+	return doc
+}
+
+// mustRender renders a node with HtmlNodeToString, failing the test on error.
+func mustRender(t testing.TB, n *html.Node) string {
+	t.Helper()
+
+	s, err := HtmlNodeToString(n)
+	if err != nil {
+		t.Fatalf("HtmlNodeToString: %v", err)
+	}
+	return s
+}
+
+// nodeNames returns a short description of each node for comparisons: the
+// id attribute of elements that have one, the tag of other elements, and the
+// data of other nodes.
+func nodeNames(nodes []*html.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if id, ok := GetAttr(n, "id"); ok {
+			names = append(names, id)
+		} else {
+			names = append(names, n.Data)
+		}
+	}
+	return names
+}
+
+func TestGetHtmlNodesFunc(t *testing.T) {
+	doc := mustParse(t, `<div id="a" class="card big" data-id="1"></div>`+
+		`<div id="b" class="card"></div>`+
+		`<div id="c" class="cardboard" data-id="3"><!--note--></div>`+
+		`<ol><li id="d">one</li></ol><ul><li id="e">two</li></ul>`+
+		`<div id="f" class="card" data-id="6"><p id="g">text</p></div>`)
+
+	tests := []struct {
+		name  string
+		match func(*html.Node) bool
+		count int
+		want  []string
+	}{
+		{
+			name: "attributes",
+			match: func(n *html.Node) bool {
+				_, ok := GetAttr(n, "data-id")
+				return n.Data == "div" && HasClass(n, "card") && ok
+			},
+			count: -1,
+			want:  []string{"a", "f"},
+		},
+		{
+			name: "attributes with count",
+			match: func(n *html.Node) bool {
+				_, ok := GetAttr(n, "data-id")
+				return ok
+			},
+			count: 2,
+			want:  []string{"a", "c"},
+		},
+		{
+			name: "node type",
+			match: func(n *html.Node) bool {
+				return n.Type == html.CommentNode
+			},
+			count: -1,
+			want:  []string{"note"},
+		},
+		{
+			name: "parent",
+			match: func(n *html.Node) bool {
+				return n.Data == "li" && n.Parent != nil && n.Parent.Data == "ol"
+			},
+			count: -1,
+			want:  []string{"d"},
+		},
+		{
+			name: "grandparent",
+			match: func(n *html.Node) bool {
+				return n.Type == html.TextNode && n.Parent.Parent != nil && HasClass(n.Parent.Parent, "card")
+			},
+			count: -1,
+			want:  []string{"text"},
+		},
+		{
+			name:  "zero count",
+			match: func(n *html.Node) bool { return true },
+			count: 0,
+			want:  []string{},
+		},
+		{
+			name:  "no match",
+			match: func(n *html.Node) bool { return false },
+			count: -1,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeNames(GetHtmlNodesFunc(doc, tt.match, tt.count))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHtmlNodesFuncMatchesGetHtmlNodes(t *testing.T) {
+	doc := mustParse(t, `<div id="a"><div id="b"><span id="c"></span></div></div><div id="d"></div>`)
+
+	for _, count := range []int{-1, 1, 2, 3} {
+		want := nodeNames(GetHtmlNodes(doc, "div", "", "", count, false))
+		got := nodeNames(GetHtmlNodesFunc(doc, func(n *html.Node) bool {
+			return n.Type == html.ElementNode && n.Data == "div"
+		}, count))
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("count %d: got %q, want %q", count, got, want)
+		}
+	}
+}
+
+func TestGetHtmlNodesFuncWrappers(t *testing.T) {
+	doc := mustParse(t, `<p id="a"></p><p id="b"></p>`)
+	isP := func(n *html.Node) bool { return n.Data == "p" }
+	isTable := func(n *html.Node) bool { return n.Data == "table" }
+
+	if got := nodeNames(GetAllHtmlNodesFunc(doc, isP)); strings.Join(got, ",") != "a,b" {
+		t.Errorf("GetAllHtmlNodesFunc: got %q", got)
+	}
+
+	if n, err := FindFirstHtmlNodeFunc(doc, isP); err != nil || GetAttrOr(n, "id", "") != "a" {
+		t.Errorf("FindFirstHtmlNodeFunc: got %v, %v", n, err)
+	}
+	if n, err := FindFirstHtmlNodeFunc(doc, isTable); n != nil || !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("FindFirstHtmlNodeFunc not found: got %v, %v", n, err)
+	}
+
+	if n := GetFirstHtmlNodeFunc(doc, isP); GetAttrOr(n, "id", "") != "a" {
+		t.Errorf("GetFirstHtmlNodeFunc: got %v", n)
+	}
+	if n := GetFirstHtmlNodeFunc(doc, isTable); n == nil || n.Type != html.ErrorNode || n.Data != "" {
+		t.Errorf("GetFirstHtmlNodeFunc not found: got %+v, want an empty node", n)
+	}
+}
+
+func TestFindFirstHtmlNode(t *testing.T) {
+	doc := mustParse(t, `<div id="outer"><p id="p1" class="x">a</p><p id="p2">b</p></div>`)
+
+	n, err := FindFirstHtmlNode(doc, "p", "class", "x")
+	if err != nil || GetAttrOr(n, "id", "") != "p1" {
+		t.Errorf("match: got %v, %v", n, err)
+	}
+
+	n, err = FindFirstHtmlNode(doc, "table", "", "")
+	if n != nil || !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("not found: got %v, %v; want nil, ErrNodeNotFound", n, err)
+	}
+
+	n, err = FindFirstHtmlNodeAllowAttrSubstring(doc, "p", "id", "2")
+	if err != nil || GetAttrOr(n, "id", "") != "p2" {
+		t.Errorf("substring: got %v, %v", n, err)
+	}
+}
+
+func TestFindFirstHtmlNodeEmptyCriteria(t *testing.T) {
+	// With no criteria, the first element of a bare document is the <html>
+	// element the parser creates
+	doc := mustParse(t, "")
+
+	n, err := FindFirstHtmlNode(doc, "", "", "")
+	if err != nil || n.Data != "html" {
+		t.Fatalf("got %v, %v; want the html element", n, err)
+	}
+
+	// A detached text node contains no elements at all
+	text := &html.Node{Type: html.TextNode, Data: "text"}
+	if n, err := FindFirstHtmlNode(text, "", "", ""); n != nil || !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("text node: got %v, %v; want nil, ErrNodeNotFound", n, err)
+	}
+}
+
+func TestGetFirstHtmlNodeNotFound(t *testing.T) {
+	doc := mustParse(t, `<p>a</p>`)
+
+	n := GetFirstHtmlNode(doc, "table", "", "")
+	if n == nil {
+		t.Fatal("got nil, want an empty node")
+	}
+	if n.Type != html.ErrorNode || n.Data != "" || n.Parent != nil || n.FirstChild != nil {
+		t.Errorf("got %+v, want an empty node", n)
+	}
+
+	if n := GetFirstHtmlNodeAllowAttrSubstring(doc, "p", "id", "x"); n.Type != html.ErrorNode {
+		t.Errorf("substring: got %+v, want an empty node", n)
+	}
+}
+
+func TestFindFirstHtmlNodeChained(t *testing.T) {
+	doc := mustParse(t, `<ul id="a"><li>1</li></ul><ul id="b"><li>2</li><li>3</li></ul>`)
+
+	ul, err := FindFirstHtmlNode(doc, "ul", "id", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := GetAllHtmlNodes(ul, "li", "", "")
+	if len(items) != 2 || GetText(items[0]) != "2" || GetText(items[1]) != "3" {
+		t.Errorf("got %d items under #b, want 2 and 3", len(items))
+	}
+
+	li, err := FindFirstHtmlNode(ul, "li", "", "")
+	if err != nil || GetText(li) != "2" {
+		t.Errorf("chained first: got %v, %v", li, err)
+	}
+
+	// Chaining from a missing node finds nothing instead of searching an
+	// unrelated tree
+	missing := GetFirstHtmlNode(doc, "ol", "", "")
+	if got := GetAllHtmlNodes(missing, "li", "", ""); len(got) != 0 {
+		t.Errorf("chained from empty node: got %d nodes, want 0", len(got))
+	}
+	if _, err := FindFirstHtmlNode(missing, "", "", ""); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("chained from empty node: got %v, want ErrNodeNotFound", err)
+	}
+}
+
+func TestGetHtmlNodesDeduplicatesMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		tag       string
+		attr      string
+		attrValue string
+		want      int
+	}{
+		{"duplicate values", `<div data-a="foo" data-b="foo"></div>`, "div", "", "foo", 1},
+		{"duplicate keys", `<div class="a" class="b"></div>`, "div", "class", "", 1},
+		{"duplicate keys without criteria", `<div class="a" class="b"></div>`, "div", "", "", 1},
+		{"duplicate keys and values", `<div data-a="foo" data-a="foo" data-b="foo"></div>`, "", "", "foo", 1},
+		{"several nodes", `<p x="1" y="1"></p><p x="1" y="1"></p>`, "p", "", "1", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+			if got := GetAllHtmlNodes(doc, tt.tag, tt.attr, tt.attrValue); len(got) != tt.want {
+				t.Errorf("GetAllHtmlNodes: got %d nodes, want %d", len(got), tt.want)
+			}
+			if got := GetAllHtmlNodesAllowAttrSubstring(doc, tt.tag, tt.attr, tt.attrValue); len(got) != tt.want {
+				t.Errorf("GetAllHtmlNodesAllowAttrSubstring: got %d nodes, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveHtmlNodesDuplicateAttributes(t *testing.T) {
+	doc := mustParse(t, `<div data-a="foo" data-b="foo">x</div><p>y</p>`)
+
+	RemoveAllHtmlNodes(doc, "div", "", "foo")
+
+	body := GetFirstHtmlNode(doc, "body", "", "")
+	if got := mustRender(t, body); got != "<body><p>y</p></body>" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRemoveHtmlAttrs(t *testing.T) {
+	const doc = `<p id="a" style="color: red" style="margin: 0" class="x">1</p>` +
+		`<div id="b" style="color: red" onclick="go()">2</div>` +
+		`<p id="c" style="">3</p>`
+
+	tests := []struct {
+		name      string
+		tag       string
+		attr      string
+		attrValue string
+		count     int
+		want      []string
+	}{
+		{
+			name:  "key only",
+			attr:  "style",
+			count: -1,
+			want:  []string{"id=a class=x", "id=b onclick=go()", "id=c"},
+		},
+		{
+			name:      "key and value",
+			attr:      "style",
+			attrValue: "color: red",
+			count:     -1,
+			want:      []string{"id=a style=margin: 0 class=x", "id=b onclick=go()", "id=c style="},
+		},
+		{
+			name:  "tag and key",
+			tag:   "p",
+			attr:  "style",
+			count: -1,
+			want:  []string{"id=a class=x", "id=b style=color: red onclick=go()", "id=c"},
+		},
+		{
+			name:  "count within a node",
+			attr:  "style",
+			count: 1,
+			want:  []string{"id=a style=margin: 0 class=x", "id=b style=color: red onclick=go()", "id=c style="},
+		},
+		{
+			name:  "count across nodes",
+			attr:  "style",
+			count: 3,
+			want:  []string{"id=a class=x", "id=b onclick=go()", "id=c style="},
+		},
+		{
+			name:  "empty key",
+			count: -1,
+			want:  []string{"id=a style=color: red style=margin: 0 class=x", "id=b style=color: red onclick=go()", "id=c style="},
+		},
+		{
+			name:      "empty key with value",
+			attrValue: "color: red",
+			count:     -1,
+			want:      []string{"id=a style=color: red style=margin: 0 class=x", "id=b style=color: red onclick=go()", "id=c style="},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, doc)
+			RemoveHtmlAttrs(doc, tt.tag, tt.attr, tt.attrValue, tt.count)
+
+			var got []string
+			for _, id := range []string{"a", "b", "c"} {
+				got = append(got, attrString(GetFirstHtmlNode(doc, "", "id", id)))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveAllHtmlAttrsEventHandlers(t *testing.T) {
+	doc := mustParse(t, `<a href="/" onclick="a()" onmouseover="b()">x</a><img src="i.png" onerror="c()">`)
+
+	for _, key := range []string{"onclick", "onmouseover", "onerror"} {
+		RemoveAllHtmlAttrs(doc, "", key, "")
+	}
+
+	body := GetFirstHtmlNode(doc, "body", "", "")
+	if got, want := mustRender(t, body), `<body><a href="/">x</a><img src="i.png"/></body>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRemoveFirstHtmlAttr(t *testing.T) {
+	doc := mustParse(t, `<p id="a" data-x="1" data-x="2"></p><p id="b" data-x="3"></p>`)
+
+	RemoveFirstHtmlAttr(doc, "p", "data-x", "")
+
+	a := GetFirstHtmlNode(doc, "", "id", "a")
+	b := GetFirstHtmlNode(doc, "", "id", "b")
+	if got := attrString(a) + "|" + attrString(b); got != "id=a data-x=2|id=b data-x=3" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// rescanRemoveHtmlAttrs is the implementation RemoveHtmlAttrs() used before
+// the removal was made a single pass, kept to check that the results haven't
+// changed. It rescans a node from the start after every removal, and its count
+// bounds the number of nodes rather than attributes.
+func rescanRemoveHtmlAttrs(node *html.Node, tag string, attr string, attrValue string, count int) {
+	var processNode func(*html.Node, string, string)
+	processNode = func(nodeToProcess *html.Node, attr string, attrValue string) {
+		for i, a := range nodeToProcess.Attr {
+			if (attr == "" || a.Key == attr) && (attrValue == "" || a.Val == attrValue) {
+				nodeToProcess.Attr = append(nodeToProcess.Attr[:i], nodeToProcess.Attr[i+1:]...)
+				processNode(nodeToProcess, attr, attrValue)
+				return
+			}
+		}
+	}
+
+	for _, nodeToProcess := range GetHtmlNodes(node, tag, attr, attrValue, count, false) {
+		processNode(nodeToProcess, attr, attrValue)
+	}
+}
+
+func TestRemoveHtmlAttrsMatchesRescan(t *testing.T) {
+	queries := []struct {
+		tag, attr, attrValue string
+	}{
+		{"", "class", ""},
+		{"div", "class", ""},
+		{"", "class", "a"},
+		{"div", "class", "a b"},
+		{"a", "href", ""},
+		{"", "href", "#x"},
+		{"", "data-x", "a"},
+		{"", "id", ""},
+		{"", "missing", ""},
+	}
+
+	// Every element of the corpus has at most one attribute with a given key,
+	// so the count bounds the same removals with either algorithm
+	for i, doc := range traversalCorpus() {
+		for _, q := range queries {
+			for _, count := range []int{-1, 0, 1, 2, 5} {
+				want := mustParse(t, doc)
+				rescanRemoveHtmlAttrs(want, q.tag, q.attr, q.attrValue, count)
+				got := mustParse(t, doc)
+				RemoveHtmlAttrs(got, q.tag, q.attr, q.attrValue, count)
+
+				if a, b := mustRender(t, want), mustRender(t, got); a != b {
+					t.Errorf("doc %d, %v, count %d: got\n%s\nwant\n%s", i, q, count, b, a)
+				}
+			}
+		}
+	}
+}
+
+// recursiveGetHtmlNodes is the recursive implementation GetHtmlNodes() used
+// before the traversal was made iterative, kept to check that the results
+// haven't changed.
+func recursiveGetHtmlNodes(n *html.Node, tag string, attr string, attrValue string, count int, allowAttrSubstring bool) []*html.Node {
+	var foundNodes []*html.Node
+
+	var processNode func(n *html.Node) bool
+	processNode = func(n *html.Node) bool {
+		if n.Type == html.ElementNode && (tag == "" || n.Data == tag) {
+			matched := attr == "" && attrValue == ""
+			for _, a := range n.Attr {
+				if (attr == "" || a.Key == attr) &&
+					(attrValue == "" || a.Val == attrValue || (allowAttrSubstring && strings.Contains(a.Val, attrValue))) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				foundNodes = append(foundNodes, n)
+				if count >= 0 && len(foundNodes) >= count {
+					return false
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if !processNode(c) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if count != 0 {
+		processNode(n)
+	}
+	return foundNodes
+}
+
+// traversalCorpus returns the documents used to compare traversals.
+func traversalCorpus() []string {
+	return []string{
+		``,
+		`<p>text only</p>`,
+		`<div class="a"><div class="b"><div class="a b">deep</div></div><p class="a">x</p></div><div class="a"></div>`,
+		`<ul><li>1<ul><li>1.1</li><li>1.2<ul><li>1.2.1</li></ul></li></ul></li><li>2</li></ul>`,
+		`<table><tr><td>a<table><tr><td>b</td></tr></table></td></tr></table>`,
+		`<svg><a href="#x"><title>t</title></a></svg><a href="#x" data-x="a">y</a><a data-x="ab" data-y="a">z</a>`,
+		largePage(20),
+	}
+}
+
+func TestGetHtmlNodesMatchesRecursiveTraversal(t *testing.T) {
+	queries := []struct {
+		tag, attr, attrValue string
+	}{
+		{"", "", ""},
+		{"div", "", ""},
+		{"div", "class", "a"},
+		{"", "class", ""},
+		{"", "", "a"},
+		{"a", "href", "#x"},
+		{"li", "", ""},
+		{"td", "", ""},
+		{"p", "", ""},
+		{"a", "", "/wiki/Link_3_1"},
+	}
+
+	for i, doc := range traversalCorpus() {
+		root := mustParse(t, doc)
+		for _, q := range queries {
+			for _, count := range []int{-1, 0, 1, 2, 5} {
+				for _, substring := range []bool{false, true} {
+					want := recursiveGetHtmlNodes(root, q.tag, q.attr, q.attrValue, count, substring)
+					got := GetHtmlNodes(root, q.tag, q.attr, q.attrValue, count, substring)
+
+					if len(got) != len(want) {
+						t.Errorf("doc %d, %v, count %d, substring %v: got %d nodes, want %d", i, q, count, substring, len(got), len(want))
+						continue
+					}
+					for j := range want {
+						if got[j] != want[j] {
+							t.Errorf("doc %d, %v, count %d, substring %v: node %d differs", i, q, count, substring, j)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// deepChain returns a document whose body contains a chain of depth nested
+// div elements, the innermost of which contains a text node.
+func deepChain(depth int) *html.Node {
+	doc := &html.Node{Type: html.DocumentNode}
+	body := NewElement("body", nil)
+	doc.AppendChild(NewElement("html", nil, body))
+
+	parent := body
+	for i := 0; i < depth; i++ {
+		div := &html.Node{Type: html.ElementNode, Data: "div", Attr: []html.Attribute{{Key: "class", Val: "d"}}}
+		parent.AppendChild(div)
+		parent = div
+	}
+	parent.AppendChild(NewText("leaf"))
+
+	return doc
+}
+
+func TestDeepDocument(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping deep document in short mode")
+	}
+
+	const depth = 1_000_000
+	doc := deepChain(depth)
+
+	if got := len(GetAllHtmlNodes(doc, "div", "", "")); got != depth {
+		t.Errorf("GetAllHtmlNodes: got %d nodes, want %d", got, depth)
+	}
+	if got := CountHtmlNodes(doc, "div", "class", "d"); got != depth {
+		t.Errorf("CountHtmlNodes: got %d, want %d", got, depth)
+	}
+	if got := GetText(doc); got != "leaf" {
+		t.Errorf("GetText: got %q", got)
+	}
+	if got := GetNormalizedText(doc); got != "leaf" {
+		t.Errorf("GetNormalizedText: got %q", got)
+	}
+
+	clone := CloneHtmlNode(doc)
+	if got := CountHtmlNodes(clone, "div", "", ""); got != depth {
+		t.Errorf("CloneHtmlNode: got %d divs, want %d", got, depth)
+	}
+
+	RemoveAllHtmlAttrs(doc, "div", "class", "")
+	if HasHtmlNode(doc, "", "class", "") {
+		t.Error("RemoveAllHtmlAttrs left class attributes")
+	}
+
+	UnwrapAllHtmlNodes(clone, "div", "", "")
+	if got := mustRender(t, clone); got != "<html><body>leaf</body></html>" {
+		t.Errorf("UnwrapAllHtmlNodes: got %q", got)
+	}
+}
+
+func TestGetHtmlNodesCount(t *testing.T) {
+	// Matches nested inside other matches, some with several matching
+	// attributes
+	doc := mustParse(t, `<div id="1" data-a="x" data-b="x">`+
+		`<div id="2" data-a="x"><div id="3" data-a="x" data-b="x"></div></div>`+
+		`<div id="4" data-b="x"><div id="5" data-a="x"><div id="6" data-a="x"></div></div></div>`+
+		`</div><div id="7" data-a="x"></div>`)
+	const total = 7
+
+	for _, count := range []int{1, 2, total - 1, total} {
+		got := GetHtmlNodes(doc, "div", "", "x", count, false)
+		if len(got) != count {
+			t.Errorf("count %d: got %d nodes", count, len(got))
+			continue
+		}
+		for i, n := range got {
+			if id := GetAttrOr(n, "id", ""); id != string(rune('1'+i)) {
+				t.Errorf("count %d: node %d has id %q, want document order", count, i, id)
+			}
+		}
+	}
+
+	if got := GetHtmlNodes(doc, "div", "", "x", total+1, false); len(got) != total {
+		t.Errorf("count above the number of matches: got %d nodes, want %d", len(got), total)
+	}
+	if got := GetHtmlNodes(doc, "div", "", "x", -1, false); len(got) != total {
+		t.Errorf("count -1: got %d nodes, want %d", len(got), total)
+	}
+}
+
+func TestGetHtmlNodesFuncStopsAtCount(t *testing.T) {
+	doc := mustParse(t, `<div id="a"><div id="b"><div id="c"></div></div></div><div id="d"></div>`)
+
+	var visited []string
+	got := GetHtmlNodesFunc(doc, func(n *html.Node) bool {
+		if n.Data == "div" {
+			visited = append(visited, GetAttrOr(n, "id", ""))
+			return true
+		}
+		return false
+	}, 2)
+
+	if len(got) != 2 {
+		t.Errorf("got %d nodes, want 2", len(got))
+	}
+	if strings.Join(visited, ",") != "a,b" {
+		t.Errorf("visited %q after the count was reached, want the walk to stop at b", visited)
+	}
+}
+
+func TestRemoveHtmlNodesReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		tag         string
+		count       int
+		want        string
+		wantRemoved int
+	}{
+		{"siblings", `<p>a</p><div>b</div><p>c</p><p>d</p>`, "p", -1, `<div>b</div>`, 3},
+		{"siblings with count", `<p>a</p><div>b</div><p>c</p><p>d</p>`, "p", 2, `<div>b</div><p>d</p>`, 2},
+		{"nested", `<div>a<div>b<div>c</div></div></div><p>d</p>`, "div", -1, `<p>d</p>`, 3},
+		{"nested siblings", `<ul><li>a<ul><li>b</li><li>c</li></ul></li></ul><p>d</p>`, "li", -1, `<ul></ul><p>d</p>`, 3},
+		{"no match", `<p>a</p>`, "table", -1, `<p>a</p>`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+
+			removed, skipped := RemoveHtmlNodesReport(doc, tt.tag, "", "", tt.count)
+			if removed != tt.wantRemoved || skipped != 0 {
+				t.Errorf("got %d removed, %d skipped; want %d, 0", removed, skipped, tt.wantRemoved)
+			}
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
+
+func TestRemoveHtmlNodesRoot(t *testing.T) {
+	div := NewElement("div", nil, NewElement("div", nil, NewText("a")), NewElement("p", nil))
+
+	removed, skipped := RemoveHtmlNodesReport(div, "div", "", "", -1)
+	if removed != 1 || skipped != 1 {
+		t.Errorf("got %d removed, %d skipped; want 1, 1", removed, skipped)
+	}
+	if got := mustRender(t, div); got != "<div><p></p></div>" {
+		t.Errorf("got %q, want the detached root kept", got)
+	}
+
+	// The root is the first match, so nothing is removed, and the wrappers
+	// must not panic either
+	RemoveFirstHtmlNode(div, "", "", "")
+	RemoveAllHtmlNodes(div, "div", "", "")
+	if got := mustRender(t, div); got != "<div><p></p></div>" {
+		t.Errorf("got %q, want the tree unchanged", got)
+	}
+}
+
+func TestRemoveNodes(t *testing.T) {
+	doc := mustParse(t, `<div id="a"><p id="b">x<span id="c">y</span></p></div><p id="d">z</p><p id="e"></p>`)
+	byID := func(id string) *html.Node { return GetFirstHtmlNode(doc, "", "id", id) }
+	a, b, c, d := byID("a"), byID("b"), byID("c"), byID("d")
+	detached := NewElement("p", nil)
+
+	// Descendants before their ancestors, duplicates, nil entries, and a
+	// detached node
+	removed, skipped := RemoveNodes([]*html.Node{c, nil, d, b, c, a, detached, d})
+	if removed != 4 || skipped != 4 {
+		t.Errorf("got %d removed, %d skipped; want 4, 4", removed, skipped)
+	}
+	if got := bodyHtml(t, doc); got != `<p id="e"></p>` {
+		t.Errorf("got %q", got)
+	}
+	checkSiblings(t, doc)
+
+	// Nested nodes stay in place within their removed ancestor
+	if a.Parent != nil || b.Parent != a || c.Parent != b {
+		t.Error("nested nodes were detached from their removed ancestor")
+	}
+
+	// Removing the nodes again skips them all
+	if removed, skipped := RemoveNodes([]*html.Node{a, d}); removed != 0 || skipped != 2 {
+		t.Errorf("second removal: got %d removed, %d skipped; want 0, 2", removed, skipped)
+	}
+	if removed, skipped := RemoveNodes(nil); removed != 0 || skipped != 0 {
+		t.Errorf("no nodes: got %d removed, %d skipped", removed, skipped)
+	}
+}
+
+// randomTree returns a random document of elements, text, and comments.
+func randomTree(r *rand.Rand) *html.Node {
+	tags := []string{"div", "p", "span", "ul", "li", "b", "a", "section", "br", "img"}
+
+	var build func(n *html.Node, depth int)
+	build = func(n *html.Node, depth int) {
+		for i := r.Intn(5); i > 0; i-- {
+			switch k := r.Intn(10); {
+			case k < 2:
+				n.AppendChild(NewText(strconv.Itoa(r.Intn(100))))
+			case k < 3:
+				n.AppendChild(NewComment("c"))
+			default:
+				tag := tags[r.Intn(len(tags))]
+				c := NewElement(tag, map[string]string{"id": strconv.Itoa(r.Intn(1000))})
+				n.AppendChild(c)
+				if depth < 6 && !voidElements[tag] {
+					build(c, depth+1)
+				}
+			}
+		}
+	}
+
+	doc := &html.Node{Type: html.DocumentNode}
+	body := NewElement("body", nil)
+	doc.AppendChild(NewElement("html", nil, NewElement("head", nil), body))
+	build(body, 0)
+	return doc
+}
+
+func TestRemoveNodesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		doc := randomTree(r)
+		var all []*html.Node
+		Walk(doc, func(n *html.Node) WalkAction { all = append(all, n); return WalkContinue })
+
+		// A random subset of the nodes of the tree, in a random order, with
+		// duplicates, nil entries, and nodes detached beforehand
+		var nodes []*html.Node
+		for _, n := range all {
+			if r.Intn(4) == 0 {
+				nodes = append(nodes, n)
+			}
+		}
+		for j := r.Intn(3); j > 0; j-- {
+			nodes = append(nodes, nil, all[r.Intn(len(all))])
+		}
+		r.Shuffle(len(nodes), func(a, b int) { nodes[a], nodes[b] = nodes[b], nodes[a] })
+		if n := all[r.Intn(len(all))]; r.Intn(2) == 0 && n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+
+		set := make(map[*html.Node]bool)
+		for _, n := range nodes {
+			set[n] = true
+		}
+		// The nodes left in the document are those that were in it and neither
+		// in the set nor within a node in the set, other than the document
+		want := make(map[*html.Node]bool)
+		Walk(doc, func(n *html.Node) WalkAction {
+			want[n] = !set[n] || n.Parent == nil
+			if n.Parent != nil && !want[n.Parent] {
+				want[n] = false
+			}
+			return WalkContinue
+		})
+
+		removed, skipped := RemoveNodes(nodes)
+		if removed+skipped != len(nodes) {
+			t.Fatalf("tree %d: got %d removed and %d skipped for %d nodes", i, removed, skipped, len(nodes))
+		}
+		checkSiblings(t, doc)
+		if _, err := HtmlNodeToString(doc); err != nil {
+			t.Fatalf("tree %d: %v", i, err)
+		}
+
+		left := make(map[*html.Node]bool)
+		Walk(doc, func(n *html.Node) WalkAction { left[n] = true; return WalkContinue })
+		for n, w := range want {
+			if left[n] != w {
+				t.Fatalf("tree %d: node %q left in the document: %v, want %v", i, n.Data, left[n], w)
+			}
+		}
+	}
+}
+
+func TestGetChildHtmlNodes(t *testing.T) {
+	doc := mustParse(t, `<ul id="outer"><li id="a">a<ul id="inner"><li id="a1">a1</li></ul></li><!-- c --><li id="b" class="x">b</li>text<li id="c" class="x">c</li></ul>`)
+	outer := GetFirstHtmlNode(doc, "ul", "id", "outer")
+
+	tests := []struct {
+		name                 string
+		tag, attr, attrValue string
+		count                int
+		want                 string
+	}{
+		{"all children", "", "", "", -1, "a,b,c"},
+		{"tag", "li", "", "", -1, "a,b,c"},
+		{"nested lists not searched", "ul", "", "", -1, ""},
+		{"attribute", "li", "class", "x", -1, "b,c"},
+		{"count", "li", "", "", 2, "a,b"},
+		{"zero count", "li", "", "", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetChildHtmlNodes(outer, tt.tag, tt.attr, tt.attrValue, tt.count)
+			if strings.Join(nodeNames(got), ",") != tt.want {
+				t.Errorf("got %q, want %q", nodeNames(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetChildHtmlNodesNestedTables(t *testing.T) {
+	doc := mustParse(t, `<table id="outer"><tr id="r1"><td id="c1"><table><tr id="inner"><td id="ic">x</td></tr></table></td></tr><tr id="r2"><td id="c2">y</td></tr></table>`)
+	outer := GetFirstHtmlNode(doc, "table", "id", "outer")
+	tbody := FirstElementChild(outer)
+
+	if got := nodeNames(GetChildHtmlNodes(tbody, "tr", "", "", -1)); strings.Join(got, ",") != "r1,r2" {
+		t.Errorf("rows: got %q", got)
+	}
+	if got := GetChildHtmlNodes(outer, "tr", "", "", -1); len(got) != 0 {
+		t.Errorf("rows of the table: got %q, want none, since they are in tbody", nodeNames(got))
+	}
+
+	var cells []string
+	for _, tr := range GetChildHtmlNodes(tbody, "tr", "", "", -1) {
+		cells = append(cells, nodeNames(GetChildHtmlNodes(tr, "td", "", "", -1))...)
+	}
+	if strings.Join(cells, ",") != "c1,c2" {
+		t.Errorf("cells: got %q, want no cells of the nested table", cells)
+	}
+}
+
+func TestGetHtmlNodesMaxDepth(t *testing.T) {
+	doc := mustParse(t, `<div id="root"><div id="d1"><div id="d2"><div id="d3"></div></div></div><div id="e1"></div></div>`)
+	root := GetFirstHtmlNode(doc, "div", "id", "root")
+
+	tests := []struct {
+		maxDepth int
+		count    int
+		want     string
+	}{
+		{0, -1, ""},
+		{1, -1, "d1,e1"},
+		{2, -1, "d1,d2,e1"},
+		{3, -1, "d1,d2,d3,e1"},
+		{-1, -1, "d1,d2,d3,e1"},
+		{2, 2, "d1,d2"},
+	}
+
+	for _, tt := range tests {
+		got := nodeNames(GetHtmlNodesMaxDepth(root, "div", "", "", tt.count, tt.maxDepth))
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("maxDepth %d, count %d: got %q, want %q", tt.maxDepth, tt.count, got, tt.want)
+		}
+	}
+
+	if got, want := GetHtmlNodesMaxDepth(root, "", "", "", -1, 1), GetChildHtmlNodes(root, "", "", "", -1); strings.Join(nodeNames(got), ",") != strings.Join(nodeNames(want), ",") {
+		t.Errorf("maxDepth 1: got %q, want the same as GetChildHtmlNodes, %q", nodeNames(got), nodeNames(want))
+	}
+	if got := GetChildHtmlNodes(nil, "", "", "", -1); len(got) != 0 {
+		t.Errorf("nil node: got %d nodes", len(got))
+	}
+}
+
+func TestCountAndHasHtmlNodesAgreeWithGetAllHtmlNodes(t *testing.T) {
+	fixtures := append(traversalCorpus(),
+		`<form><input type="text"><img src="a.png"><img src="b.png" alt=""><img alt="c"></form>`,
+		`<div data-a="foo" data-b="foo"><div data-a="foo"></div></div>`,
+	)
+	queries := []struct{ tag, attr, attrValue string }{
+		{"", "", ""},
+		{"form", "", ""},
+		{"img", "", ""},
+		{"img", "alt", ""},
+		{"img", "alt", "c"},
+		{"div", "", "foo"},
+		{"", "data-a", "foo"},
+		{"a", "href", ""},
+		{"table", "", ""},
+		{"p", "", ""},
+		{"svg", "", ""},
+	}
+
+	for i, fixture := range fixtures {
+		doc := mustParse(t, fixture)
+		for _, q := range queries {
+			want := len(GetAllHtmlNodes(doc, q.tag, q.attr, q.attrValue))
+			if got := CountHtmlNodes(doc, q.tag, q.attr, q.attrValue); got != want {
+				t.Errorf("fixture %d, %v: CountHtmlNodes got %d, want %d", i, q, got, want)
+			}
+			if got := HasHtmlNode(doc, q.tag, q.attr, q.attrValue); got != (want > 0) {
+				t.Errorf("fixture %d, %v: HasHtmlNode got %v, want %v", i, q, got, want > 0)
+			}
+		}
+	}
+}
+
+func TestHasHtmlNodeNil(t *testing.T) {
+	if HasHtmlNode(nil, "", "", "") || CountHtmlNodes(nil, "", "", "") != 0 {
+		t.Error("found nodes in a nil node")
+	}
+}
+
+// BenchmarkRemoveHtmlAttrs removes 1,000 attributes from an element, as found
+// in machine-generated markup. Rescanning the attributes after every removal
+// makes the old algorithm quadratic in their number.
+func BenchmarkRemoveHtmlAttrs(b *testing.B) {
+	attrs := make([]html.Attribute, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		attrs = append(attrs, html.Attribute{Key: "data-track", Val: strconv.Itoa(i)})
+	}
+	n := NewElement("div", nil)
+
+	for _, bb := range []struct {
+		name   string
+		remove func(*html.Node, string, string, string, int)
+	}{
+		{"SinglePass", RemoveHtmlAttrs},
+		{"Rescan", rescanRemoveHtmlAttrs},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				n.Attr = slices.Clone(attrs)
+				bb.remove(n, "", "data-track", "", -1)
+				if len(n.Attr) != 0 {
+					b.Fatalf("%d attributes left", len(n.Attr))
+				}
+			}
+		})
+	}
+}
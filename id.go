@@ -0,0 +1,114 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// GetElementById returns the first element within the provided node whose id
+// attribute is the provided id, or nil if there is none. As in the DOM, the
+// comparison is case-sensitive, empty ids never match, and the content of
+// template elements is not searched.
+//
+// Each call traverses the tree. Use BuildIdIndex() to look up many ids in the
+// same document.
+func GetElementById(doc *html.Node, id string) *html.Node {
+	var found *html.Node
+	if id == "" {
+		return found
+	}
+
+	walkHtmlIds(doc, func(n *html.Node, nodeId string) WalkAction {
+		if nodeId == id {
+			found = n
+			return WalkStop
+		}
+		return WalkContinue
+	})
+
+	return found
+}
+
+// IdIndex maps the ids of a document to their elements for repeated lookups.
+// It is built by BuildIdIndex() and reflects the tree at that time: it becomes
+// invalid once the tree is modified and must be rebuilt.
+type IdIndex struct {
+	nodes      map[string]*html.Node
+	duplicates []string
+}
+
+// BuildIdIndex indexes the elements within the provided node by id, using the
+// same rules as GetElementById().
+func BuildIdIndex(doc *html.Node) IdIndex {
+	idx := IdIndex{nodes: make(map[string]*html.Node)}
+	reported := make(map[string]bool)
+
+	walkHtmlIds(doc, func(n *html.Node, id string) WalkAction {
+		if _, ok := idx.nodes[id]; !ok {
+			idx.nodes[id] = n
+		} else if !reported[id] {
+			reported[id] = true
+			idx.duplicates = append(idx.duplicates, id)
+		}
+		return WalkContinue
+	})
+
+	return idx
+}
+
+// Lookup returns the first element with the provided id, or nil if there is
+// none.
+func (idx IdIndex) Lookup(id string) *html.Node {
+	return idx.nodes[id]
+}
+
+// Duplicates returns the ids used by more than one element, in the document
+// order of their second occurrence.
+func (idx IdIndex) Duplicates() []string {
+	return append([]string(nil), idx.duplicates...)
+}
+
+// walkHtmlIds calls fn for each element within n with a non-empty id, in
+// document order, skipping the content of template elements.
+func walkHtmlIds(n *html.Node, fn func(n *html.Node, id string) WalkAction) {
+	Walk(n, func(n *html.Node) WalkAction {
+		if n.Type != html.ElementNode {
+			return WalkContinue
+		}
+
+		action := WalkContinue
+		if id, ok := GetAttr(n, "id"); ok && id != "" {
+			action = fn(n, id)
+		}
+		if action == WalkContinue && isTemplate(n) {
+			action = WalkSkipChildren
+		}
+		return action
+	})
+}
+
+// FindDuplicateIds returns every id used by more than one element within the
+// provided node, mapped to the elements using it in document order. Ids are
+// compared case-sensitively, empty ids are ignored, and the content of
+// template elements is skipped since it isn't part of the rendered document.
+func FindDuplicateIds(doc *html.Node) map[string][]*html.Node {
+	byId := make(map[string][]*html.Node)
+
+	walkHtmlIds(doc, func(n *html.Node, id string) WalkAction {
+		byId[id] = append(byId[id], n)
+		return WalkContinue
+	})
+
+	for id, nodes := range byId {
+		if len(nodes) < 2 {
+			delete(byId, id)
+		}
+	}
+
+	return byId
+}
+
+// CountDuplicateIds is a convenience function for FindDuplicateIds() that
+// returns the number of ids used by more than one element.
+func CountDuplicateIds(doc *html.Node) int {
+	return len(FindDuplicateIds(doc))
+}
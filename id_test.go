@@ -0,0 +1,122 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const idFixture = `<div id="main" class="first">` +
+	`<p id="intro">a</p><p id="">empty</p><p id>bare</p>` +
+	`<p id="intro" class="second">b</p><p id="Intro">c</p>` +
+	`<span id="x"></span><span id="x"></span><span id="x"></span>` +
+	`<template><p id="tpl">t</p></template>` +
+	`</div>`
+
+func TestGetElementById(t *testing.T) {
+	doc := mustParse(t, idFixture)
+
+	tests := []struct {
+		id        string
+		wantText  string
+		wantFound bool
+	}{
+		{"intro", "a", true},
+		{"Intro", "c", true},
+		{"main", "aemptybarebc", true},
+		{"", "", false},
+		{"tpl", "", false},
+		{"missing", "", false},
+	}
+
+	for _, tt := range tests {
+		n := GetElementById(doc, tt.id)
+		if (n != nil) != tt.wantFound {
+			t.Errorf("%q: got %v, want found %v", tt.id, n, tt.wantFound)
+			continue
+		}
+		if n != nil && GetText(n) != tt.wantText {
+			t.Errorf("%q: got text %q, want %q", tt.id, GetText(n), tt.wantText)
+		}
+	}
+}
+
+func TestIdIndex(t *testing.T) {
+	doc := mustParse(t, idFixture)
+	idx := BuildIdIndex(doc)
+
+	for _, id := range []string{"main", "intro", "Intro", "x", "", "tpl", "missing"} {
+		if got, want := idx.Lookup(id), GetElementById(doc, id); got != want {
+			t.Errorf("Lookup(%q): got %v, want %v as returned by GetElementById", id, got, want)
+		}
+	}
+
+	if got := idx.Lookup("intro"); HasClass(got, "second") {
+		t.Error("Lookup returned the second element with a duplicate id")
+	}
+
+	if got := strings.Join(idx.Duplicates(), ","); got != "intro,x" {
+		t.Errorf("Duplicates: got %q, want each duplicate once, in document order", got)
+	}
+
+	// The returned slice is a copy
+	idx.Duplicates()[0] = "changed"
+	if idx.Duplicates()[0] != "intro" {
+		t.Error("Duplicates returned the internal slice")
+	}
+}
+
+func TestIdIndexEmpty(t *testing.T) {
+	idx := BuildIdIndex(mustParse(t, `<p id="">a</p>`))
+
+	if idx.Lookup("") != nil {
+		t.Error("empty id found")
+	}
+	if got := idx.Duplicates(); len(got) != 0 {
+		t.Errorf("Duplicates: got %q", got)
+	}
+}
+
+func TestFindDuplicateIds(t *testing.T) {
+	doc := mustParse(t, `<html><head><meta id="m"><style id="shared"></style></head><body>`+
+		`<div id="shared">1</div><p id="m">2</p><p id="M">3</p><p id="">4</p><p id="">5</p>`+
+		`<template><p id="unique">6</p><p id="unique">7</p><div id="shared">8</div></template>`+
+		`<div id="unique">9</div><span id="m">10</span>`+
+		`</body></html>`)
+
+	got := FindDuplicateIds(doc)
+
+	want := map[string][]string{
+		"shared": {"style", "div"},
+		"m":      {"meta", "p", "span"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("got ids %v, want %v", got, want)
+	}
+	for id, tags := range want {
+		var gotTags []string
+		for _, n := range got[id] {
+			if GetAttrOr(n, "id", "") != id {
+				t.Errorf("%q: got node with id %q", id, GetAttrOr(n, "id", ""))
+			}
+			gotTags = append(gotTags, n.Data)
+		}
+		if strings.Join(gotTags, ",") != strings.Join(tags, ",") {
+			t.Errorf("%q: got %q, want %q in document order", id, gotTags, tags)
+		}
+	}
+
+	if got := CountDuplicateIds(doc); got != 2 {
+		t.Errorf("CountDuplicateIds: got %d, want 2", got)
+	}
+}
+
+func TestFindDuplicateIdsNone(t *testing.T) {
+	doc := mustParse(t, `<p id="a"></p><p id="b"></p><template><p id="a"></p></template>`)
+
+	if got := FindDuplicateIds(doc); len(got) != 0 {
+		t.Errorf("got %v, want no duplicates", got)
+	}
+	if got := CountDuplicateIds(doc); got != 0 {
+		t.Errorf("CountDuplicateIds: got %d", got)
+	}
+}
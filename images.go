@@ -0,0 +1,104 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LazyImageOptions controls the changes made by NormalizeLazyImages().
+type LazyImageOptions struct {
+	// PlaceholderClasses lists classes used by lazy-loading scripts, such as
+	// "lazyload", that are removed from the normalized elements.
+	PlaceholderClasses []string
+
+	// SetLoadingLazy sets loading="lazy" on every img element.
+	SetLoadingLazy bool
+
+	// SetDecodingAsync sets decoding="async" on every img element.
+	SetDecodingAsync bool
+}
+
+// NormalizeLazyImages moves the URLs that lazy-loading scripts keep in
+// data-src and data-srcset attributes to the src and srcset attributes of the
+// img and source elements within the provided node, and returns the number of
+// elements modified.
+//
+// The URLs are only copied when the target attribute is missing, empty, or a
+// data: URL placeholder, and the data-src and data-srcset attributes are left
+// in place. The classes listed in opts.PlaceholderClasses are removed from
+// the elements that were normalized.
+//
+// The content of noscript elements is parsed as text when scripting is
+// enabled, as it is by html.Parse(), so fallback images inside noscript are
+// not modified.
+func NormalizeLazyImages(n *html.Node, opts LazyImageOptions) int {
+	modified := 0
+
+	for _, img := range GetAllHtmlNodesFunc(n, isImageSource) {
+		changed := false
+		for _, pair := range [][2]string{{"data-src", "src"}, {"data-srcset", "srcset"}} {
+			lazy, ok := GetAttr(img, pair[0])
+			lazy = strings.TrimSpace(lazy)
+			if !ok || lazy == "" || (img.Data == "source" && pair[1] == "src") {
+				continue
+			}
+
+			if current, _ := GetAttr(img, pair[1]); strings.TrimSpace(current) == "" || urlSchemeIs(current, "data") {
+				SetHtmlAttr(img, pair[1], lazy)
+				changed = true
+			}
+		}
+
+		if changed && len(opts.PlaceholderClasses) > 0 {
+			RemoveClass(img, opts.PlaceholderClasses...)
+		}
+
+		if img.Data == "img" {
+			if opts.SetLoadingLazy && GetAttrOr(img, "loading", "") != "lazy" {
+				SetHtmlAttr(img, "loading", "lazy")
+				changed = true
+			}
+			if opts.SetDecodingAsync && GetAttrOr(img, "decoding", "") != "async" {
+				SetHtmlAttr(img, "decoding", "async")
+				changed = true
+			}
+		}
+
+		if changed {
+			modified++
+		}
+	}
+
+	return modified
+}
+
+// MakeImagesLazy sets loading="lazy" on the img elements within the provided
+// node that have no loading attribute, except for the first skipFirst images
+// in document order, which are usually visible when the page loads. It returns
+// the number of images modified.
+func MakeImagesLazy(n *html.Node, skipFirst int) int {
+	modified := 0
+
+	for i, img := range GetAllHtmlNodes(n, "img", "", "") {
+		if i < skipFirst || img.Namespace != "" {
+			continue
+		}
+		if _, ok := GetAttr(img, "loading"); !ok {
+			SetHtmlAttr(img, "loading", "lazy")
+			modified++
+		}
+	}
+
+	return modified
+}
+
+// isImageSource reports whether n is an img element or a source element of a
+// picture element.
+func isImageSource(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+
+	return n.Data == "img" || (n.Data == "source" && n.Parent != nil && n.Parent.Data == "picture")
+}
@@ -0,0 +1,145 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLazyImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		markup   string
+		opts     LazyImageOptions
+		want     string
+		modified int
+	}{
+		{
+			name:     "placeholder src",
+			markup:   `<img src="data:image/gif;base64,R0lGOD" data-src="a.jpg" data-srcset="a.jpg 1x, a2.jpg 2x" class="lazyload card">`,
+			opts:     LazyImageOptions{PlaceholderClasses: []string{"lazyload", "lazy"}},
+			want:     `<img src="a.jpg" data-src="a.jpg" data-srcset="a.jpg 1x, a2.jpg 2x" class="card" srcset="a.jpg 1x, a2.jpg 2x"/>`,
+			modified: 1,
+		},
+		{
+			name:     "missing and empty targets",
+			markup:   `<img data-src=" a.jpg "><img src=" " data-src="b.jpg">`,
+			want:     `<img data-src=" a.jpg " src="a.jpg"/><img src="b.jpg" data-src="b.jpg"/>`,
+			modified: 2,
+		},
+		{
+			name:     "real src kept",
+			markup:   `<img src="real.jpg" data-src="lazy.jpg" class="lazyload">`,
+			opts:     LazyImageOptions{PlaceholderClasses: []string{"lazyload"}},
+			want:     `<img src="real.jpg" data-src="lazy.jpg" class="lazyload"/>`,
+			modified: 0,
+		},
+		{
+			name:     "empty data-src",
+			markup:   `<img src="data:," data-src="">`,
+			want:     `<img src="data:," data-src=""/>`,
+			modified: 0,
+		},
+		{
+			name: "picture with multiple sources",
+			markup: `<picture>` +
+				`<source type="image/avif" data-srcset="a.avif 1x, a2.avif 2x" class="lazy">` +
+				`<source type="image/webp" srcset="data:image/gif;base64,R0lGOD" data-srcset="a.webp" data-src="a.webp">` +
+				`<source type="image/jpeg" srcset="a.jpg">` +
+				`<img src="data:image/gif;base64,R0lGOD" data-src="a.jpg" class="lazy">` +
+				`</picture>`,
+			opts: LazyImageOptions{PlaceholderClasses: []string{"lazy"}},
+			want: `<picture>` +
+				`<source type="image/avif" data-srcset="a.avif 1x, a2.avif 2x" srcset="a.avif 1x, a2.avif 2x"/>` +
+				`<source type="image/webp" srcset="a.webp" data-srcset="a.webp" data-src="a.webp"/>` +
+				`<source type="image/jpeg" srcset="a.jpg"/>` +
+				`<img src="a.jpg" data-src="a.jpg"/>` +
+				`</picture>`,
+			modified: 3,
+		},
+		{
+			name:     "source outside picture",
+			markup:   `<video><source data-srcset="v.mp4"></video>`,
+			want:     `<video><source data-srcset="v.mp4"/></video>`,
+			modified: 0,
+		},
+		{
+			name:     "loading and decoding",
+			markup:   `<img src="a.jpg"><img src="b.jpg" loading="lazy" decoding="async"><img src="c.jpg" loading="eager">`,
+			opts:     LazyImageOptions{SetLoadingLazy: true, SetDecodingAsync: true},
+			want:     `<img src="a.jpg" loading="lazy" decoding="async"/><img src="b.jpg" loading="lazy" decoding="async"/><img src="c.jpg" loading="lazy" decoding="async"/>`,
+			modified: 2,
+		},
+		{
+			name:     "noscript fallback",
+			markup:   `<img data-src="a.jpg"><noscript><img src="data:," data-src="b.jpg"></noscript>`,
+			opts:     LazyImageOptions{SetLoadingLazy: true},
+			want:     `<img data-src="a.jpg" src="a.jpg" loading="lazy"/><noscript><img src="data:," data-src="b.jpg"></noscript>`,
+			modified: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, tt.markup)
+		if got := NormalizeLazyImages(doc, tt.opts); got != tt.modified {
+			t.Errorf("%s: got %d modified, want %d", tt.name, got, tt.modified)
+		}
+		if got := bodyHtml(t, doc); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeLazyImagesParsedNoscript(t *testing.T) {
+	doc := mustParse(t, `<body><noscript><img src="data:," data-src="b.jpg"></noscript>`)
+
+	content, err := ExtractNoscriptContent(doc)
+	if err != nil {
+		t.Fatalf("ExtractNoscriptContent: %v", err)
+	}
+	if len(content) != 1 {
+		t.Fatalf("got %d nodes, want 1", len(content))
+	}
+
+	if got := NormalizeLazyImages(content[0], LazyImageOptions{}); got != 1 {
+		t.Errorf("got %d modified, want 1", got)
+	}
+	if got := GetAttrOr(content[0], "src", ""); got != "b.jpg" {
+		t.Errorf("got src %q, want the parsed fallback image normalized", got)
+	}
+}
+
+func TestMakeImagesLazy(t *testing.T) {
+	markup := `<img id="a"><p><img id="b"></p><img id="c" loading="eager"><img id="d"><img id="e" loading="lazy"><img id="f">`
+
+	tests := []struct {
+		skipFirst int
+		want      string
+		modified  int
+	}{
+		{0, "a b d f", 4},
+		{2, "d f", 2},
+		{4, "f", 1},
+		{10, "", 0},
+		{-1, "a b d f", 4},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, markup)
+		if got := MakeImagesLazy(doc, tt.skipFirst); got != tt.modified {
+			t.Errorf("skip %d: got %d modified, want %d", tt.skipFirst, got, tt.modified)
+		}
+
+		var lazy []string
+		for _, img := range GetAllHtmlNodes(doc, "img", "loading", "lazy") {
+			if id := GetAttrOr(img, "id", ""); id != "e" {
+				lazy = append(lazy, id)
+			}
+		}
+		if got := strings.Join(lazy, " "); got != tt.want {
+			t.Errorf("skip %d: got %q lazy, want %q", tt.skipFirst, got, tt.want)
+		}
+		if got := GetAttrOr(GetElementById(doc, "c"), "loading", ""); got != "eager" {
+			t.Errorf("skip %d: got loading %q, want the existing attribute kept", tt.skipFirst, got)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package htmlutil
+
+import (
+	"iter"
+
+	"golang.org/x/net/html"
+)
+
+// NodesSeq returns an iterator over the HTML nodes found within the provided
+// node given a tag, attribute, and attribute value, in document order. The
+// criteria are the same as for GetHtmlNodes(), but no slice of results is
+// built, so breaking out of the loop early avoids the work of finding the
+// remaining matches.
+//
+// The loop body may remove the yielded node from the tree. Its descendants
+// are then skipped.
+func NodesSeq(n *html.Node, tag string, attr string, attrValue string) iter.Seq[*html.Node] {
+	m := NewMatcher(tag, attr, attrValue)
+	return func(yield func(*html.Node) bool) {
+		walkSeq(n, true, func(n *html.Node) bool {
+			return !m.Match(n) || yield(n)
+		})
+	}
+}
+
+// DescendantsSeq returns an iterator over all the descendants of the provided
+// node, not including the node itself, in document order.
+//
+// The loop body may remove the yielded node from the tree. Its descendants
+// are then skipped.
+func DescendantsSeq(n *html.Node) iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		walkSeq(n, false, yield)
+	}
+}
+
+// walkSeq walks the tree rooted at root, calling visit for each node until it
+// returns false. Nodes removed from the tree by visit have their descendants
+// skipped.
+func walkSeq(root *html.Node, includeRoot bool, visit func(*html.Node) bool) {
+	Walk(root, func(n *html.Node) WalkAction {
+		if n == root && !includeRoot {
+			return WalkContinue
+		}
+		if !visit(n) {
+			return WalkStop
+		}
+		if n != root && n.Parent == nil {
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+}
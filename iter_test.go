@@ -0,0 +1,137 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestNodesSeq(t *testing.T) {
+	doc := mustParse(t, `<div id="a" class="x"><div id="b"><p id="c" class="x"></p></div></div><p id="d" class="x"></p>`)
+
+	for _, q := range []struct{ tag, attr, attrValue string }{
+		{"", "", ""},
+		{"div", "", ""},
+		{"", "class", "x"},
+		{"p", "class", "x"},
+		{"table", "", ""},
+	} {
+		var got []*html.Node
+		for n := range NodesSeq(doc, q.tag, q.attr, q.attrValue) {
+			got = append(got, n)
+		}
+
+		want := GetAllHtmlNodes(doc, q.tag, q.attr, q.attrValue)
+		if strings.Join(nodeNames(got), ",") != strings.Join(nodeNames(want), ",") {
+			t.Errorf("%v: got %q, want %q", q, nodeNames(got), nodeNames(want))
+		}
+	}
+}
+
+func TestNodesSeqBreak(t *testing.T) {
+	doc := mustParse(t, `<p id="a"></p><p id="b"></p><p id="c"></p>`)
+
+	var got []string
+	for n := range NodesSeq(doc, "p", "", "") {
+		got = append(got, GetAttrOr(n, "id", ""))
+		if len(got) == 2 {
+			break
+		}
+	}
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNodesSeqRemoveYielded(t *testing.T) {
+	doc := mustParse(t, `<p id="a"><span id="x"></span></p><p id="b"></p><div><p id="c"></p></div><p id="d"></p>`)
+
+	var got []string
+	for n := range NodesSeq(doc, "", "", "") {
+		if n.Data == "p" || n.Data == "span" {
+			got = append(got, GetAttrOr(n, "id", ""))
+			n.Parent.RemoveChild(n)
+		}
+	}
+
+	// The span is skipped along with its removed parent
+	if strings.Join(got, ",") != "a,b,c,d" {
+		t.Errorf("got %q", got)
+	}
+	if got, want := bodyHtml(t, doc), "<div></div>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDescendantsSeq(t *testing.T) {
+	doc := mustParse(t, `<div id="root">a<!--c--><p id="p">b</p></div>`)
+	root := GetFirstHtmlNode(doc, "div", "", "")
+
+	var got []string
+	for n := range DescendantsSeq(root) {
+		got = append(got, n.Data)
+	}
+	if strings.Join(got, ",") != "a,c,p,b" {
+		t.Errorf("got %q, want the descendants without the root", got)
+	}
+
+	for n := range DescendantsSeq(root) {
+		n.Parent.RemoveChild(n)
+	}
+	if root.FirstChild != nil {
+		t.Errorf("got %q left, want every child removed", mustRender(t, root))
+	}
+}
+
+// The benchmarks below search a page of about 5MB for its first three links,
+// or count all of them.
+
+func BenchmarkNodesSeqFirst3(b *testing.B) {
+	doc := mustParse(b, largePage(5000))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		found := 0
+		for range NodesSeq(doc, "a", "", "") {
+			found++
+			if found == 3 {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkGetAllHtmlNodesFirst3(b *testing.B) {
+	doc := mustParse(b, largePage(5000))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = GetAllHtmlNodes(doc, "a", "", "")[:3]
+	}
+}
+
+func BenchmarkNodesSeqCount(b *testing.B) {
+	doc := mustParse(b, largePage(5000))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		found := 0
+		for range NodesSeq(doc, "a", "", "") {
+			found++
+		}
+	}
+}
+
+func BenchmarkGetAllHtmlNodesCount(b *testing.B) {
+	doc := mustParse(b, largePage(5000))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = len(GetAllHtmlNodes(doc, "a", "", ""))
+	}
+}
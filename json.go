@@ -0,0 +1,180 @@
+package htmlutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// jsonNode is the JSON representation of a node used by NodeToJSON() and
+// NodeFromJSON().
+type jsonNode struct {
+	Type      string      `json:"type"`
+	Tag       string      `json:"tag,omitempty"`
+	Namespace string      `json:"namespace,omitempty"`
+	Attrs     []jsonAttr  `json:"attrs,omitempty"`
+	Children  []*jsonNode `json:"children,omitempty"`
+	Data      string      `json:"data,omitempty"`
+}
+
+type jsonAttr struct {
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+// jsonNodeTypes maps node types to their names in the JSON representation.
+var jsonNodeTypes = map[html.NodeType]string{
+	html.DocumentNode: "document",
+	html.ElementNode:  "element",
+	html.TextNode:     "text",
+	html.CommentNode:  "comment",
+	html.DoctypeNode:  "doctype",
+	html.RawNode:      "raw",
+}
+
+// NodeToJSON converts an HTML node and its descendants to JSON. Each node is
+// an object with the following fields, empty fields being omitted:
+//
+//   - type: one of "document", "element", "text", "comment", "doctype", or
+//     "raw"
+//   - tag and namespace: the tag and namespace of an element
+//   - attrs: the attributes of an element or doctype, in order, as objects
+//     with namespace, key, and value fields
+//   - children: the child nodes
+//   - data: the content of a text, comment, or raw node, or the name of a
+//     doctype
+//
+// NodeFromJSON() converts the result back to an identical tree.
+func NodeToJSON(n *html.Node) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := EncodeNodeJSON(&buf, n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeNodeJSON writes the JSON representation of an HTML node and its
+// descendants, as returned by NodeToJSON(), to w. Nodes are written as they
+// are visited, so large documents are never held in memory twice.
+func EncodeNodeJSON(w io.Writer, n *html.Node) error {
+	if n == nil {
+		return errors.New("htmlutil: cannot encode a nil node")
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := encodeJSONNode(bw, n); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func encodeJSONNode(w *bufio.Writer, n *html.Node) error {
+	typ, ok := jsonNodeTypes[n.Type]
+	if !ok {
+		return fmt.Errorf("htmlutil: cannot encode node of type %d", n.Type)
+	}
+
+	// Encode the fields other than children in one go, then stream the
+	// children before closing the object
+	fields := jsonNode{Type: typ}
+	switch n.Type {
+	case html.ElementNode:
+		fields.Tag = n.Data
+		fields.Namespace = n.Namespace
+	case html.TextNode, html.CommentNode, html.DoctypeNode, html.RawNode:
+		fields.Data = n.Data
+	}
+	for _, a := range n.Attr {
+		fields.Attrs = append(fields.Attrs, jsonAttr{Namespace: a.Namespace, Key: a.Key, Value: a.Val})
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if n.FirstChild == nil {
+		_, err = w.Write(b)
+		return err
+	}
+
+	// Drop the closing brace to append the children
+	w.Write(b[:len(b)-1])
+	w.WriteString(`,"children":[`)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c != n.FirstChild {
+			w.WriteByte(',')
+		}
+		if err := encodeJSONNode(w, c); err != nil {
+			return err
+		}
+	}
+	_, err = w.WriteString("]}")
+
+	return err
+}
+
+// NodeFromJSON converts the JSON representation of a node returned by
+// NodeToJSON() back to an HTML node.
+func NodeFromJSON(data []byte) (*html.Node, error) {
+	var root jsonNode
+
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("htmlutil: invalid node JSON: %w", err)
+	}
+	return decodeJSONNode(&root)
+}
+
+func decodeJSONNode(j *jsonNode) (*html.Node, error) {
+	n := &html.Node{}
+
+	switch j.Type {
+	case "document":
+		n.Type = html.DocumentNode
+	case "element":
+		if j.Tag == "" {
+			return nil, errors.New("htmlutil: invalid node JSON: element without tag")
+		}
+		n.Type = html.ElementNode
+		n.Data = j.Tag
+		n.Namespace = j.Namespace
+		n.DataAtom = atom.Lookup([]byte(j.Tag))
+	case "text":
+		n.Type = html.TextNode
+	case "comment":
+		n.Type = html.CommentNode
+	case "doctype":
+		n.Type = html.DoctypeNode
+	case "raw":
+		n.Type = html.RawNode
+	default:
+		return nil, fmt.Errorf("htmlutil: invalid node JSON: unknown node type %q", j.Type)
+	}
+
+	if n.Type != html.ElementNode {
+		n.Data = j.Data
+	}
+	for _, a := range j.Attrs {
+		n.Attr = append(n.Attr, html.Attribute{Namespace: a.Namespace, Key: a.Key, Val: a.Value})
+	}
+
+	if len(j.Children) > 0 && n.Type != html.DocumentNode && n.Type != html.ElementNode {
+		return nil, fmt.Errorf("htmlutil: invalid node JSON: %s node with children", j.Type)
+	}
+	for _, jc := range j.Children {
+		c, err := decodeJSONNode(jc)
+		if err != nil {
+			return nil, err
+		}
+		n.AppendChild(c)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,167 @@
+package htmlutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// sameTrees reports the first difference between two trees, comparing the
+// type, data, atom, namespace, and attributes of every node.
+func sameTrees(t testing.TB, a *html.Node, b *html.Node) {
+	t.Helper()
+
+	if d := FirstDifference(a, b, CompareOptions{}); d != nil {
+		t.Errorf("trees differ at %s", d)
+		return
+	}
+
+	var nodesA, nodesB []*html.Node
+	Walk(a, func(n *html.Node) WalkAction { nodesA = append(nodesA, n); return WalkContinue })
+	Walk(b, func(n *html.Node) WalkAction { nodesB = append(nodesB, n); return WalkContinue })
+	for i := range nodesA {
+		if nodesA[i].DataAtom != nodesB[i].DataAtom {
+			t.Errorf("node %d (%s): got atom %v, want %v", i, nodesB[i].Data, nodesB[i].DataAtom, nodesA[i].DataAtom)
+		}
+	}
+}
+
+func TestNodeJSONRoundTrip(t *testing.T) {
+	doc := mustParse(t, `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`+
+		`<html lang="en"><head><title>"Quotes" &amp; <tags></title></head><body>`+
+		`<!-- a comment --><p class="x" data-empty="">Text with\nnewlines, tabs\t, and unicode ✓</p>`+
+		`<svg viewBox="0 0 1 1"><use xlink:href="#icon"></use><foreignObject><p>in</p></foreignObject></svg>`+
+		`<math><mi xml:lang="en">x</mi></math><my-element>custom</my-element>`+
+		`<template><p>in template</p></template><textarea>  raw <b>text</b></textarea></body></html>`)
+	body := GetFirstHtmlNode(doc, "body", "", "")
+	body.AppendChild(&html.Node{Type: html.RawNode, Data: "<not>escaped</not>"})
+
+	data, err := NodeToJSON(doc)
+	if err != nil {
+		t.Fatalf("NodeToJSON: %v", err)
+	}
+
+	got, err := NodeFromJSON(data)
+	if err != nil {
+		t.Fatalf("NodeFromJSON: %v", err)
+	}
+	sameTrees(t, doc, got)
+	checkSiblings(t, got)
+
+	if a, b := mustRender(t, doc), mustRender(t, got); a != b {
+		t.Errorf("renders differ:\ngot  %s\nwant %s", b, a)
+	}
+
+	for _, s := range []string{`"type":"doctype"`, `"type":"comment"`, `"type":"raw"`, `"namespace":"svg"`, `"namespace":"xlink"`, `"key":"public"`} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("JSON does not contain %s", s)
+		}
+	}
+}
+
+func TestNodeJSONSchema(t *testing.T) {
+	n := NewElement("a", map[string]string{"href": "/"}, NewText("x"), NewComment("c"))
+
+	data, err := NodeToJSON(n)
+	if err != nil {
+		t.Fatalf("NodeToJSON: %v", err)
+	}
+
+	want := `{"type":"element","tag":"a","attrs":[{"key":"href","value":"/"}],"children":[{"type":"text","data":"x"},{"type":"comment","data":"c"}]}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+func TestEncodeNodeJSON(t *testing.T) {
+	doc := mustParse(t, largePage(20))
+
+	var buf bytes.Buffer
+	if err := EncodeNodeJSON(&buf, doc); err != nil {
+		t.Fatalf("EncodeNodeJSON: %v", err)
+	}
+
+	data, err := NodeToJSON(doc)
+	if err != nil {
+		t.Fatalf("NodeToJSON: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("EncodeNodeJSON and NodeToJSON output differ")
+	}
+
+	got, err := NodeFromJSON(buf.Bytes())
+	if err != nil {
+		t.Fatalf("NodeFromJSON: %v", err)
+	}
+	sameTrees(t, doc, got)
+}
+
+func TestNodeJSONErrors(t *testing.T) {
+	if _, err := NodeToJSON(nil); err == nil {
+		t.Error("nil node: got no error")
+	}
+	if _, err := NodeToJSON(NewElement("div", nil, &html.Node{Type: html.ErrorNode})); err == nil {
+		t.Error("error node: got no error")
+	}
+
+	for _, data := range []string{
+		``,
+		`{"type":`,
+		`[]`,
+		`{"type":"unknown"}`,
+		`{"type":"element"}`,
+		`{"type":"text","data":"x","children":[{"type":"text"}]}`,
+		`{"type":"document","children":[{"type":"element","tag":"p","children":[{"type":"bogus"}]}]}`,
+	} {
+		_, err := NodeFromJSON([]byte(data))
+		if err == nil || !strings.HasPrefix(err.Error(), "htmlutil: invalid node JSON") {
+			t.Errorf("%s: got %v, want an invalid node JSON error", data, err)
+		}
+	}
+}
+
+// BenchmarkNodeFromJSON compares decoding a cached tree with parsing the page
+// again. With encoding/json, decoding is about twice as slow as html.Parse and
+// allocates twice as much on a 5MB page, so caching JSON trees doesn't save
+// parsing time.
+func BenchmarkNodeFromJSON(b *testing.B) {
+	page := largePage(5000)
+	doc := mustParse(b, page)
+	data, err := NodeToJSON(doc)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("html.Parse", func(b *testing.B) {
+		b.SetBytes(int64(len(page)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := html.Parse(strings.NewReader(page)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("NodeFromJSON", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NodeFromJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkNodeToJSON(b *testing.B) {
+	doc := mustParse(b, largePage(5000))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := EncodeNodeJSON(&bytes.Buffer{}, doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package htmlutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ExtractJSONLD returns the content of the <script type="application/ld+json">
+// elements of the provided document, in document order. The type is matched
+// case-insensitively and parameters such as "; charset=utf-8" are ignored.
+//
+// An HTML comment wrapped around the JSON, an old pattern for hiding scripts
+// from ancient browsers, is stripped. Blocks that are not valid JSON are
+// skipped, and the errors are returned joined together along with the valid
+// blocks.
+func ExtractJSONLD(doc *html.Node) ([]json.RawMessage, error) {
+	var blocks []json.RawMessage
+	var errs []error
+
+	for i, script := range GetAllHtmlNodesFunc(doc, isJSONLDScript) {
+		var block json.RawMessage
+		if err := json.Unmarshal([]byte(jsonLDText(script)), &block); err != nil {
+			errs = append(errs, fmt.Errorf("htmlutil: invalid JSON-LD block %d: %w", i, err))
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, errors.Join(errs...)
+}
+
+// ExtractJSONLDInto unmarshals the first JSON-LD block of the provided
+// document that unmarshals cleanly into v, which must be a non-nil pointer.
+// Blocks that fail to unmarshal leave v untouched. If no block unmarshals,
+// the errors are returned joined together, or ErrNodeNotFound if the document
+// has no JSON-LD block.
+func ExtractJSONLDInto(doc *html.Node, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("htmlutil: ExtractJSONLDInto needs a non-nil pointer, got %T", v)
+	}
+
+	blocks, err := ExtractJSONLD(doc)
+	errs := []error{err}
+
+	for _, block := range blocks {
+		// Unmarshal into a new value so a failed attempt doesn't leave v
+		// partially filled
+		target := reflect.New(rv.Elem().Type())
+		if err := json.Unmarshal(block, target.Interface()); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		rv.Elem().Set(target.Elem())
+		return nil
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return ErrNodeNotFound
+}
+
+// isJSONLDScript reports whether n is a script element with the
+// application/ld+json type.
+func isJSONLDScript(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" || n.Data != "script" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(GetAttrOr(n, "type", ""))
+	return err == nil && mediaType == "application/ld+json"
+}
+
+// jsonLDText returns the text of a JSON-LD script element with a surrounding
+// HTML comment removed.
+func jsonLDText(script *html.Node) string {
+	text := strings.TrimSpace(GetText(script))
+	if strings.HasPrefix(text, "<!--") {
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(text, "<!--"), "-->"))
+	}
+	return text
+}
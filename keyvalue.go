@@ -0,0 +1,109 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// KV is a key with its values, extracted from a definition list by
+// ExtractDefinitionList() or from a table by ExtractKeyValueTable().
+type KV struct {
+	// Key is the normalized text of the term or key cell.
+	Key string
+
+	// Values are the normalized texts of the descriptions or value cells.
+	Values []string
+
+	// KeyNode and ValueNodes are the elements the key and values were
+	// extracted from, for processing markup such as links in the values.
+	KeyNode    *html.Node
+	ValueNodes []*html.Node
+}
+
+// ExtractDefinitionList returns the terms of a dl element with their
+// descriptions, in document order.
+//
+// A term followed by several dd elements has several values, and several dt
+// elements followed by the same dd elements each result in a KV with those
+// values. Groups of terms and descriptions wrapped in div elements, as
+// allowed by the HTML spec, are supported. An error is returned if the
+// provided node is not a dl element.
+func ExtractDefinitionList(dl *html.Node) ([]KV, error) {
+	if err := checkElement(dl, "dl"); err != nil {
+		return nil, err
+	}
+
+	var items []*html.Node
+	for _, c := range childElementsByTag(dl, "dt", "dd", "div") {
+		if c.Data == "div" {
+			items = append(items, childElementsByTag(c, "dt", "dd")...)
+		} else {
+			items = append(items, c)
+		}
+	}
+
+	kvs := []KV{}
+	var terms, descriptions []*html.Node
+	flush := func() {
+		for _, dt := range terms {
+			kv := KV{Key: GetNormalizedText(dt), KeyNode: dt, Values: []string{}, ValueNodes: []*html.Node{}}
+			for _, dd := range descriptions {
+				kv.Values = append(kv.Values, GetNormalizedText(dd))
+				kv.ValueNodes = append(kv.ValueNodes, dd)
+			}
+			kvs = append(kvs, kv)
+		}
+		terms, descriptions = nil, nil
+	}
+
+	for _, item := range items {
+		if item.Data == "dt" {
+			// A term after descriptions starts a new group
+			if len(descriptions) > 0 {
+				flush()
+			}
+			terms = append(terms, item)
+		} else {
+			descriptions = append(descriptions, item)
+		}
+	}
+	flush()
+
+	return kvs, nil
+}
+
+// ExtractKeyValueTable returns the rows of a table laid out as keys and
+// values, such as a specification table or an infobox, in document order.
+//
+// The key of each row is its first th cell, or its first cell if it has no th
+// cell, and its values are the other cells. Rows without cells are skipped.
+// Cells of nested tables are not included. An error is returned if the
+// provided node is not a table element.
+func ExtractKeyValueTable(table *html.Node) ([]KV, error) {
+	if err := checkElement(table, "table"); err != nil {
+		return nil, err
+	}
+
+	kvs := []KV{}
+	for _, tr := range tableRows(table) {
+		cells := childElementsByTag(tr, "td", "th")
+		if len(cells) == 0 {
+			continue
+		}
+
+		key := cells[0]
+		if ths := childElementsByTag(tr, "th"); len(ths) > 0 {
+			key = ths[0]
+		}
+
+		kv := KV{Key: cellText(key), KeyNode: key, Values: []string{}, ValueNodes: []*html.Node{}}
+		for _, cell := range cells {
+			if cell != key {
+				kv.Values = append(kv.Values, cellText(cell))
+				kv.ValueNodes = append(kv.ValueNodes, cell)
+			}
+		}
+		kvs = append(kvs, kv)
+	}
+
+	return kvs, nil
+}
@@ -0,0 +1,230 @@
+package htmlutil
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Link is a hyperlink extracted from an anchor element.
+type Link struct {
+	// URL is the href of the anchor, resolved against the base URL.
+	URL *url.URL
+
+	// Text is the normalized text of the anchor.
+	Text string
+
+	// Rel is the value of the anchor's rel attribute.
+	Rel string
+
+	// Node is the anchor element the link was extracted from.
+	Node *html.Node
+}
+
+// ExtractLinks returns the links of all anchor elements within the provided
+// node, in document order. Duplicate links are preserved.
+//
+// Relative URLs are resolved against base, or against the href of the
+// document's <base> element if there is one. If base is nil and the document
+// has no <base> element, URLs are left as they are.
+//
+// Anchors without an href, or with an empty or javascript: href, are skipped.
+// Anchors whose href cannot be parsed are skipped as well, and the parse
+// errors are returned joined together along with the links that could be
+// extracted.
+func ExtractLinks(n *html.Node, base *url.URL) ([]Link, error) {
+	var links []Link
+	var errs []error
+
+	base, err := documentBaseURL(n, base)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, a := range GetAllHtmlNodes(n, "a", "href", "") {
+		href, _ := GetAttr(a, "href")
+		href = strings.TrimSpace(href)
+		if href == "" || isJavascriptURL(href) {
+			continue
+		}
+
+		u, err := resolveURL(base, href)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		rel, _ := GetAttr(a, "rel")
+		links = append(links, Link{
+			URL:  u,
+			Text: GetNormalizedText(a),
+			Rel:  rel,
+			Node: a,
+		})
+	}
+
+	return links, errors.Join(errs...)
+}
+
+// documentBaseURL returns the URL that relative URLs within the document
+// containing n should be resolved against: the href of the first <base>
+// element with one, resolved against base, or base itself if there is no such
+// element.
+func documentBaseURL(n *html.Node, base *url.URL) (*url.URL, error) {
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	baseNode, err := FindFirstHtmlNode(root, "base", "href", "")
+	if err != nil {
+		return base, nil
+	}
+
+	href, _ := GetAttr(baseNode, "href")
+	u, err := resolveURL(base, strings.TrimSpace(href))
+	if err != nil {
+		return base, err
+	}
+	return u, nil
+}
+
+// resolveURL parses ref and resolves it against base, if base is not nil.
+func resolveURL(base *url.URL, ref string) (*url.URL, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("htmlutil: invalid URL %q: %w", ref, err)
+	}
+
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u, nil
+}
+
+// isJavascriptURL reports whether the provided URL uses the javascript:
+// scheme. Browsers ignore leading whitespace and control characters, as well
+// as tabs and newlines within the scheme, so they are ignored here too.
+func isJavascriptURL(s string) bool {
+	return urlSchemeIs(s, "javascript")
+}
+
+// urlSchemeIs reports whether the provided URL uses the provided scheme, which
+// must be lowercase, after stripping characters browsers ignore.
+func urlSchemeIs(s string, scheme string) bool {
+	s = strings.TrimLeftFunc(s, func(r rune) bool { return r <= ' ' })
+	s = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, s)
+
+	return len(s) > len(scheme) && s[len(scheme)] == ':' && strings.EqualFold(s[:len(scheme)], scheme)
+}
+
+// ExternalLinkOptions controls which links HardenExternalLinks() treats as
+// external and how they are modified.
+type ExternalLinkOptions struct {
+	// ExtraRel lists rel tokens added to external links along with
+	// "noopener" and "noreferrer", such as "nofollow" or "ugc".
+	ExtraRel []string
+
+	// InternalHosts lists hosts other than the host of the base URL whose
+	// links are treated as internal. Hosts are compared case-insensitively
+	// and without their port.
+	InternalHosts []string
+}
+
+// HardenExternalLinks sets target="_blank" on the anchors within the provided
+// node whose href points to a host other than the host of base, and adds
+// "noopener noreferrer" to their rel attribute, and returns the number of
+// anchors modified.
+//
+// Existing rel tokens such as "nofollow" are kept in order, and tokens are not
+// duplicated. A link is external if its href, resolved against the document's
+// <base> element if there is one, is an http or https URL with a host, as
+// protocol-relative URLs like "//cdn.example.com/" have. Relative links are
+// internal, and so are links to the hosts listed in opts.InternalHosts.
+func HardenExternalLinks(n *html.Node, base *url.URL, opts ExternalLinkOptions) int {
+	internal := make(map[string]bool)
+	if base != nil {
+		internal[strings.ToLower(base.Hostname())] = true
+	}
+	for _, host := range opts.InternalHosts {
+		if u, err := url.Parse("//" + host); err == nil {
+			internal[strings.ToLower(u.Hostname())] = true
+		}
+	}
+
+	docBase, _ := documentBaseURL(n, base)
+	rel := append([]string{"noopener", "noreferrer"}, opts.ExtraRel...)
+
+	modified := 0
+	for _, a := range GetAllHtmlNodes(n, "a", "href", "") {
+		href, _ := GetAttr(a, "href")
+		u, err := resolveURL(docBase, strings.TrimSpace(href))
+		if err != nil || u.Host == "" || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		if internal[strings.ToLower(u.Hostname())] {
+			continue
+		}
+
+		changed := false
+		if target, _ := GetAttr(a, "target"); target != "_blank" {
+			SetHtmlAttr(a, "target", "_blank")
+			changed = true
+		}
+		if current, _ := GetAttr(a, "rel"); mergeRelTokens(current, rel) != current {
+			SetHtmlAttr(a, "rel", mergeRelTokens(current, rel))
+			changed = true
+		}
+
+		if changed {
+			modified++
+		}
+	}
+
+	return modified
+}
+
+// mergeRelTokens returns the tokens of rel without duplicates, followed by the
+// provided tokens it doesn't already have. Tokens are compared
+// case-insensitively. If nothing is added or removed, rel is returned as is.
+func mergeRelTokens(rel string, tokens []string) string {
+	var merged []string
+	seen := make(map[string]bool)
+
+	add := func(token string) bool {
+		if seen[strings.ToLower(token)] {
+			return false
+		}
+		seen[strings.ToLower(token)] = true
+		merged = append(merged, token)
+		return true
+	}
+
+	current := splitHtmlTokens(rel)
+	for _, token := range current {
+		add(token)
+	}
+	removed := len(merged) != len(current)
+
+	added := false
+	for _, token := range tokens {
+		for _, t := range splitHtmlTokens(token) {
+			if add(t) {
+				added = true
+			}
+		}
+	}
+
+	if !added && !removed {
+		return rel
+	}
+	return strings.Join(merged, " ")
+}
@@ -0,0 +1,150 @@
+package htmlutil
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// mustParseURL parses a URL, failing the test on error.
+func mustParseURL(t testing.TB, s string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %v", s, err)
+	}
+	return u
+}
+
+func TestExtractLinks(t *testing.T) {
+	doc := mustParse(t, `<p>`+
+		`<a href="/about" rel="author">About  <b>us</b></a>`+
+		`<a href="#top">Top</a>`+
+		`<a href="https://other.example/x">Other</a>`+
+		`<a href="">Empty</a>`+
+		`<a>No href</a>`+
+		`<a href=" javascript:void(0)">JS</a>`+
+		`<a href="/about">About again</a>`+
+		`<a href="?q=1">Query</a>`+
+		`</p>`)
+
+	links, err := ExtractLinks(doc, mustParseURL(t, "https://example.com/dir/page.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ url, text, rel string }{
+		{"https://example.com/about", "About us", "author"},
+		{"https://example.com/dir/page.html#top", "Top", ""},
+		{"https://other.example/x", "Other", ""},
+		{"https://example.com/about", "About again", ""},
+		{"https://example.com/dir/page.html?q=1", "Query", ""},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d", len(links), len(want))
+	}
+	for i, w := range want {
+		l := links[i]
+		if l.URL.String() != w.url || l.Text != w.text || l.Rel != w.rel {
+			t.Errorf("link %d: got %q %q %q, want %q %q %q", i, l.URL, l.Text, l.Rel, w.url, w.text, w.rel)
+		}
+		if l.Node == nil || l.Node.Data != "a" {
+			t.Errorf("link %d: got node %v, want the anchor", i, l.Node)
+		}
+	}
+}
+
+func TestExtractLinksBaseElement(t *testing.T) {
+	doc := mustParse(t, `<head><base href="/static/"></head><body><a href="img.png">Img</a><a href="#x">Frag</a></body>`)
+
+	links, err := ExtractLinks(doc, mustParseURL(t, "https://example.com/page"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, l := range links {
+		got = append(got, l.URL.String())
+	}
+	want := []string{"https://example.com/static/img.png", "https://example.com/static/#x"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The <base> element applies even when searching a subtree of the document
+	body := GetFirstHtmlNode(doc, "body", "", "")
+	links, _ = ExtractLinks(body, mustParseURL(t, "https://example.com/page"))
+	if len(links) != 2 || links[0].URL.String() != want[0] {
+		t.Errorf("subtree: got %v", links)
+	}
+}
+
+func TestExtractLinksWithoutBase(t *testing.T) {
+	doc := mustParse(t, `<a href="/a">A</a><a href="#b">B</a>`)
+
+	links, err := ExtractLinks(doc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 || links[0].URL.String() != "/a" || links[1].URL.String() != "#b" {
+		t.Errorf("got %v, want the hrefs left as they are", links)
+	}
+}
+
+func TestExtractLinksMalformed(t *testing.T) {
+	doc := mustParse(t, `<a href="/a">A</a><a href="http://[::1">Bad</a><a href="%zz">Bad</a><a href="/b">B</a>`)
+
+	links, err := ExtractLinks(doc, mustParseURL(t, "https://example.com/"))
+	if err == nil {
+		t.Fatal("got no error for malformed URLs")
+	}
+	if !strings.Contains(err.Error(), "http://[::1") || !strings.Contains(err.Error(), "%zz") {
+		t.Errorf("error %q doesn't mention both malformed URLs", err)
+	}
+
+	if len(links) != 2 || links[0].Text != "A" || links[1].Text != "B" {
+		t.Errorf("got %v, want the valid links A and B", links)
+	}
+}
+
+func TestMergeRelTokens(t *testing.T) {
+	tests := []struct {
+		rel    string
+		tokens []string
+		want   string
+	}{
+		{"", []string{"noopener", "noreferrer"}, "noopener noreferrer"},
+		{"nofollow", []string{"noopener", "noreferrer"}, "nofollow noopener noreferrer"},
+		{" NoOpener  noreferrer ", []string{"noopener", "noreferrer"}, " NoOpener  noreferrer "},
+		{"noopener noopener", []string{"noopener", "noreferrer"}, "noopener noreferrer"},
+		{"noopener NOOPENER", []string{"noopener"}, "noopener"},
+		{"a", []string{"b c", "a"}, "a b c"},
+	}
+
+	for _, tt := range tests {
+		if got := mergeRelTokens(tt.rel, tt.tokens); got != tt.want {
+			t.Errorf("%q + %q: got %q, want %q", tt.rel, tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestHardenExternalLinks(t *testing.T) {
+	doc := mustParse(t, `<a href="https://other.com/" rel="noopener noopener">dup</a>`+
+		`<a href="https://other.com/" target="_blank" rel="noreferrer noopener">done</a>`+
+		`<a href="/local">local</a><a href="https://example.com/x">same host</a>`+
+		`<a href="//cdn.example.net/f">cdn</a><a href="mailto:a@example.com">mail</a>`)
+
+	got := HardenExternalLinks(doc, mustParseURL(t, "https://example.com/"), ExternalLinkOptions{InternalHosts: []string{"cdn.example.net"}})
+	if got != 1 {
+		t.Errorf("got %d links modified, want 1", got)
+	}
+
+	want := `<a href="https://other.com/" rel="noopener noreferrer" target="_blank">dup</a>` +
+		`<a href="https://other.com/" target="_blank" rel="noreferrer noopener">done</a>` +
+		`<a href="/local">local</a><a href="https://example.com/x">same host</a>` +
+		`<a href="//cdn.example.net/f">cdn</a><a href="mailto:a@example.com">mail</a>`
+	if got := bodyHtml(t, doc); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
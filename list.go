@@ -0,0 +1,134 @@
+package htmlutil
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ListItemTree is a list extracted by ExtractList(), with its nested lists.
+type ListItemTree struct {
+	// Ordered reports whether the list is an ol element.
+	Ordered bool
+
+	// Start is the number of the first item of an ordered list, and Reversed
+	// reports whether its items are numbered downwards.
+	Start    int
+	Reversed bool
+
+	Items []*ListItem
+
+	// Node is the list element. It is left out of the JSON encoding of the
+	// tree, so that the tree can be served as a menu.
+	Node *html.Node `json:"-"`
+}
+
+// ListItem is an item of a ListItemTree.
+type ListItem struct {
+	// Text is the normalized text of the item, excluding its nested lists.
+	Text string
+
+	// Link is the href of the first link of the item outside its nested
+	// lists, or an empty string.
+	Link string
+
+	// Value is the number of the item in an ordered list, or 0 in an
+	// unordered list.
+	Value int
+
+	// Children is the nested list of the item, or nil if it has none. The
+	// items of several nested lists are combined into one tree, which takes
+	// the type and numbering of the first one.
+	Children *ListItemTree
+
+	// Node is the li element.
+	Node *html.Node `json:"-"`
+}
+
+// ExtractList returns the items of a ul, ol, or menu element as a tree
+// following the nesting of its lists, such as the menus of a site's
+// navigation.
+//
+// Items are numbered as a browser would, honoring the start, reversed, and
+// value attributes of ordered lists. The text and link of an item exclude its
+// nested lists, which can be found anywhere within the item, so an item such
+// as "<li>Category <ul>...</ul></li>" has the text "Category". An error is
+// returned if the provided node is not a list element.
+func ExtractList(n *html.Node) (*ListItemTree, error) {
+	if err := checkElement(n, "ul", "ol", "menu"); err != nil {
+		return nil, err
+	}
+	return extractList(n), nil
+}
+
+func extractList(list *html.Node) *ListItemTree {
+	tree := &ListItemTree{Ordered: list.Data == "ol", Items: []*ListItem{}, Node: list}
+	lis := childElementsByTag(list, "li")
+
+	if tree.Ordered {
+		_, tree.Reversed = GetAttr(list, "reversed")
+		tree.Start = 1
+		if tree.Reversed {
+			tree.Start = len(lis)
+		}
+		if start, err := strconv.Atoi(strings.TrimSpace(GetAttrOr(list, "start", ""))); err == nil {
+			tree.Start = start
+		}
+	}
+
+	number := tree.Start
+	for _, li := range lis {
+		item := &ListItem{Node: li}
+
+		if tree.Ordered {
+			if value, err := strconv.Atoi(strings.TrimSpace(GetAttrOr(li, "value", ""))); err == nil {
+				number = value
+			}
+			item.Value = number
+			if tree.Reversed {
+				number--
+			} else {
+				number++
+			}
+		}
+
+		// The nested lists are blocks, so the text on either side of them is
+		// separated
+		var t textNormalizer
+		t.skip = func(n *html.Node) bool {
+			if isListElement(n) {
+				t.pendingSpace = true
+				return true
+			}
+			return false
+		}
+		t.writeNode(li)
+		item.Text = t.sb.String()
+
+		Walk(li, func(n *html.Node) WalkAction {
+			switch {
+			case isListElement(n):
+				nested := extractList(n)
+				if item.Children == nil {
+					item.Children = nested
+				} else {
+					item.Children.Items = append(item.Children.Items, nested.Items...)
+				}
+				return WalkSkipChildren
+			case item.Link == "" && isHtmlElement(n, "a"):
+				item.Link = strings.TrimSpace(GetAttrOr(n, "href", ""))
+			}
+			return WalkContinue
+		})
+
+		tree.Items = append(tree.Items, item)
+	}
+
+	return tree
+}
+
+// isListElement reports whether n is a ul, ol, or menu element.
+func isListElement(n *html.Node) bool {
+	return isHtmlElement(n, "ul", "ol", "menu")
+}
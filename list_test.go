@@ -0,0 +1,158 @@
+package htmlutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// navBar is the navigation bar of a documentation site, with dropdown menus
+// whose category labels are next to their nested lists.
+const navBar = `<!DOCTYPE html><html><head><title>Docs</title></head><body>
+<header class="site-header">
+  <nav class="navbar" aria-label="Main">
+    <a class="navbar-brand" href="/"><img src="/logo.svg" alt="Acme"></a>
+    <ul class="navbar-nav">
+      <li class="nav-item"><a class="nav-link active" href="/" aria-current="page">Home</a></li>
+      <li class="nav-item dropdown">
+        <a class="nav-link dropdown-toggle" href="/products/" role="button" aria-expanded="false">Products</a>
+        <ul class="dropdown-menu">
+          <li><a class="dropdown-item" href="/products/cloud/">Cloud  Hosting</a></li>
+          <li><a class="dropdown-item" href="/products/cdn/">CDN</a></li>
+          <li class="dropdown-divider"></li>
+          <li>
+            <span class="dropdown-header">Enterprise</span>
+            <ul class="submenu">
+              <li><a href="/enterprise/support/">Support</a></li>
+              <li><a href="/enterprise/sla/">SLA</a></li>
+            </ul>
+          </li>
+        </ul>
+      </li>
+      <li class="nav-item dropdown">
+        Docs
+        <div class="dropdown-panel">
+          <ol>
+            <li><a href="/docs/start/">Getting started</a></li>
+            <li><a href="/docs/api/">API reference</a></li>
+          </ol>
+        </div>
+      </li>
+      <li class="nav-item"><a class="nav-link" href="https://blog.example.com/">Blog</a> <span class="badge">New</span></li>
+    </ul>
+  </nav>
+</header>
+<main><p>Content</p></main>
+</body></html>`
+
+func TestExtractListNavBar(t *testing.T) {
+	doc := mustParse(t, navBar)
+
+	tree, err := ExtractList(GetFirstHtmlNode(doc, "ul", "class", "navbar-nav"))
+	if err != nil {
+		t.Fatalf("ExtractList: %v", err)
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	want := `{"Ordered":false,"Start":0,"Reversed":false,"Items":[` +
+		`{"Text":"Home","Link":"/","Value":0,"Children":null},` +
+		`{"Text":"Products","Link":"/products/","Value":0,"Children":{"Ordered":false,"Start":0,"Reversed":false,"Items":[` +
+		`{"Text":"Cloud Hosting","Link":"/products/cloud/","Value":0,"Children":null},` +
+		`{"Text":"CDN","Link":"/products/cdn/","Value":0,"Children":null},` +
+		`{"Text":"","Link":"","Value":0,"Children":null},` +
+		`{"Text":"Enterprise","Link":"","Value":0,"Children":{"Ordered":false,"Start":0,"Reversed":false,"Items":[` +
+		`{"Text":"Support","Link":"/enterprise/support/","Value":0,"Children":null},` +
+		`{"Text":"SLA","Link":"/enterprise/sla/","Value":0,"Children":null}]}}]}},` +
+		`{"Text":"Docs","Link":"","Value":0,"Children":{"Ordered":true,"Start":1,"Reversed":false,"Items":[` +
+		`{"Text":"Getting started","Link":"/docs/start/","Value":1,"Children":null},` +
+		`{"Text":"API reference","Link":"/docs/api/","Value":2,"Children":null}]}},` +
+		`{"Text":"Blog New","Link":"https://blog.example.com/","Value":0,"Children":null}]}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+
+	if tree.Items[1].Node != GetFirstHtmlNode(doc, "li", "class", "nav-item dropdown") {
+		t.Error("item node is not the li element")
+	}
+}
+
+func TestExtractListNumbering(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		start  int
+		want   []int
+	}{
+		{"default", `<ol><li>a</li><li>b</li><li>c</li></ol>`, 1, []int{1, 2, 3}},
+		{"start", `<ol start="5"><li>a</li><li>b</li></ol>`, 5, []int{5, 6}},
+		{"negative start", `<ol start="-1"><li>a</li><li>b</li></ol>`, -1, []int{-1, 0}},
+		{"invalid start", `<ol start="x"><li>a</li><li>b</li></ol>`, 1, []int{1, 2}},
+		{"value", `<ol><li>a</li><li value="10">b</li><li>c</li></ol>`, 1, []int{1, 10, 11}},
+		{"reversed", `<ol reversed><li>a</li><li>b</li><li>c</li></ol>`, 3, []int{3, 2, 1}},
+		{"reversed start", `<ol reversed start="10"><li>a</li><li value="4">b</li><li>c</li></ol>`, 10, []int{10, 4, 3}},
+		{"unordered", `<ul start="5"><li value="3">a</li><li>b</li></ul>`, 0, []int{0, 0}},
+	}
+
+	for _, tt := range tests {
+		tree, err := ExtractList(GetFirstHtmlNodeFunc(mustParse(t, tt.markup), isListElement))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		var got []int
+		for _, item := range tree.Items {
+			got = append(got, item.Value)
+		}
+		if tree.Start != tt.start || len(got) != len(tt.want) {
+			t.Errorf("%s: got start %d and values %v, want %d and %v", tt.name, tree.Start, got, tt.start, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got values %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestExtractListSeveralNestedLists(t *testing.T) {
+	doc := mustParse(t, `<ul><li>Fruits<ul><li>Apple</li></ul>and<ol start="3"><li>Pear</li></ol></li></ul>`)
+
+	tree, err := ExtractList(GetFirstHtmlNode(doc, "ul", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := tree.Items[0]
+	if item.Text != "Fruits and" {
+		t.Errorf("got text %q", item.Text)
+	}
+	if item.Children == nil || item.Children.Ordered || len(item.Children.Items) != 2 || item.Children.Items[1].Text != "Pear" {
+		t.Fatalf("got children %+v, want the nested lists combined", item.Children)
+	}
+	if item.Children.Items[1].Value != 3 {
+		t.Errorf("got value %d, want the numbering of its own list", item.Children.Items[1].Value)
+	}
+}
+
+func TestExtractListErrors(t *testing.T) {
+	doc := mustParse(t, `<div><li>a</li></div>`)
+
+	for _, n := range []*html.Node{nil, doc, GetFirstHtmlNode(doc, "div", "", ""), GetFirstHtmlNode(doc, "li", "", "")} {
+		if _, err := ExtractList(n); err == nil {
+			t.Errorf("%v: got no error", n)
+		}
+	}
+
+	tree, err := ExtractList(NewElement("ul", nil))
+	if err != nil || tree.Items == nil || len(tree.Items) != 0 {
+		t.Errorf("empty list: got %+v, %v", tree, err)
+	}
+}
@@ -0,0 +1,549 @@
+package htmlutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HeadingStyle selects how NodeToMarkdown() writes headings.
+type HeadingStyle int
+
+const (
+	// HeadingATX writes headings prefixed with # characters.
+	HeadingATX HeadingStyle = iota
+
+	// HeadingSetext writes level 1 and 2 headings underlined with = and -
+	// characters. Lower levels fall back to ATX headings.
+	HeadingSetext
+)
+
+// LinkStyle selects how NodeToMarkdown() writes links.
+type LinkStyle int
+
+const (
+	// LinkInline writes links as [text](url).
+	LinkInline LinkStyle = iota
+
+	// LinkReference writes links as [text][n], with the URLs listed as
+	// numbered references at the end of the document.
+	LinkReference
+)
+
+// MarkdownOptions controls the output of NodeToMarkdown().
+type MarkdownOptions struct {
+	// HeadingStyle selects how headings are written.
+	HeadingStyle HeadingStyle
+
+	// LinkStyle selects how links are written.
+	LinkStyle LinkStyle
+
+	// KeepUnsupportedHTML keeps elements that have no Markdown equivalent,
+	// such as iframe or sup, as raw HTML. If false, only their content is
+	// converted.
+	KeepUnsupportedHTML bool
+}
+
+// NodeToMarkdown converts an HTML node and its descendants to GitHub Flavored
+// Markdown.
+//
+// Headings, paragraphs, emphasis, strikethrough, links, images, inline code,
+// preformatted blocks, blockquotes, horizontal rules, nested ordered and
+// unordered lists, and tables are converted. Whitespace is collapsed as a
+// browser would, except inside pre elements, and br elements become hard
+// line breaks. Scripts, styles, templates, and comments are dropped.
+func NodeToMarkdown(n *html.Node, opts MarkdownOptions) (string, error) {
+	c := markdownConverter{opts: opts}
+
+	md, err := c.blocks([]*html.Node{n}, "\n\n")
+	if err != nil {
+		return "", err
+	}
+
+	if len(c.refs) > 0 {
+		var sb strings.Builder
+		for i, ref := range c.refs {
+			fmt.Fprintf(&sb, "[%d]: %s\n", i+1, ref)
+		}
+		md = strings.TrimRight(md, "\n") + "\n\n" + sb.String()
+	}
+
+	return strings.TrimSpace(md), nil
+}
+
+// markdownConverter holds the state of a single NodeToMarkdown() call.
+type markdownConverter struct {
+	opts MarkdownOptions
+	refs []string
+}
+
+// markdownTransparentTags lists the elements without a Markdown equivalent
+// whose content is converted even when unsupported HTML is kept, since
+// keeping them as raw HTML would add nothing.
+var markdownTransparentTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "body": true,
+	"div": true, "footer": true, "header": true, "hgroup": true, "html": true,
+	"main": true, "nav": true, "section": true, "span": true,
+}
+
+// markdownSkippedTags lists the elements dropped along with their content.
+var markdownSkippedTags = map[string]bool{
+	"head": true, "noscript": true, "script": true, "style": true,
+	"template": true,
+}
+
+// blocks converts the provided nodes as a sequence of blocks joined by sep.
+// Runs of inline content between block elements become paragraphs.
+func (c *markdownConverter) blocks(nodes []*html.Node, sep string) (string, error) {
+	var blocks []string
+	var inline strings.Builder
+
+	flush := func() {
+		if p := cleanInlineMarkdown(trimHardBreaks(inline.String())); p != "" {
+			blocks = append(blocks, p)
+		}
+		inline.Reset()
+	}
+
+	for _, n := range nodes {
+		switch {
+		case n.Type == html.DocumentNode:
+			flush()
+			b, err := c.blocks(childNodes(n), sep)
+			if err != nil {
+				return "", err
+			}
+			if b != "" {
+				blocks = append(blocks, b)
+			}
+		case isBlockElement(n):
+			flush()
+			b, err := c.block(n)
+			if err != nil {
+				return "", err
+			}
+			if b != "" {
+				blocks = append(blocks, b)
+			}
+		default:
+			if err := c.inline(&inline, n); err != nil {
+				return "", err
+			}
+		}
+	}
+	flush()
+
+	return strings.Join(blocks, sep), nil
+}
+
+// block converts a block element.
+func (c *markdownConverter) block(n *html.Node) (string, error) {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return c.heading(n)
+	case "blockquote":
+		inner, err := c.blocks(childNodes(n), "\n\n")
+		if err != nil || inner == "" {
+			return "", err
+		}
+		return prefixLines(inner, "> ", "> "), nil
+	case "ul", "ol":
+		return c.list(n)
+	case "pre":
+		return markdownCodeBlock(n), nil
+	case "hr":
+		return "---", nil
+	case "table":
+		return c.table(n)
+	case "li", "dd", "dt", "figcaption", "p", "summary":
+		return c.blocks(childNodes(n), "\n\n")
+	}
+
+	if c.opts.KeepUnsupportedHTML && !markdownTransparentTags[n.Data] {
+		return HtmlNodeToString(n)
+	}
+	return c.blocks(childNodes(n), "\n\n")
+}
+
+func (c *markdownConverter) heading(n *html.Node) (string, error) {
+	text, err := c.inlineText(n)
+	if err != nil || text == "" {
+		return "", err
+	}
+
+	level := int(n.Data[1] - '0')
+	if c.opts.HeadingStyle == HeadingSetext && level <= 2 {
+		underline := "="
+		if level == 2 {
+			underline = "-"
+		}
+		return text + "\n" + strings.Repeat(underline, max(len([]rune(text)), 3)), nil
+	}
+
+	return strings.Repeat("#", level) + " " + text, nil
+}
+
+func (c *markdownConverter) list(n *html.Node) (string, error) {
+	ordered := n.Data == "ol"
+	number := 1
+	if start, err := strconv.Atoi(strings.TrimSpace(GetAttrOr(n, "start", ""))); ordered && err == nil {
+		number = start
+	}
+
+	var items []string
+	for _, li := range childElementsByTag(n, "li") {
+		if value, err := strconv.Atoi(strings.TrimSpace(GetAttrOr(li, "value", ""))); ordered && err == nil {
+			number = value
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		content, err := c.blocks(childNodes(li), "\n")
+		if err != nil {
+			return "", err
+		}
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+
+	return strings.Join(items, "\n"), nil
+}
+
+func (c *markdownConverter) table(n *html.Node) (string, error) {
+	trs := tableRows(n)
+	if len(trs) == 0 {
+		return "", nil
+	}
+
+	var rows [][]string
+	width := 0
+	for _, tr := range trs {
+		var row []string
+		for _, cell := range childElementsByTag(tr, "td", "th") {
+			text, err := c.inlineText(cell)
+			if err != nil {
+				return "", err
+			}
+			row = append(row, text)
+		}
+		rows = append(rows, row)
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// inlineText converts the children of n as inline content on a single line.
+func (c *markdownConverter) inlineText(n *html.Node) (string, error) {
+	var sb strings.Builder
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if err := c.inline(&sb, child); err != nil {
+			return "", err
+		}
+	}
+
+	text := strings.ReplaceAll(sb.String(), "\\\n", " ")
+	return cleanInlineMarkdown(strings.ReplaceAll(text, "\n", " ")), nil
+}
+
+// inline converts n as inline content, writing it to sb.
+func (c *markdownConverter) inline(sb *strings.Builder, n *html.Node) error {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(escapeMarkdown(collapseHtmlSpace(n.Data)))
+		return nil
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	if markdownSkippedTags[n.Data] {
+		return nil
+	}
+
+	switch n.Data {
+	case "br":
+		sb.WriteString("\\\n")
+		return nil
+	case "img":
+		src := GetAttrOr(n, "src", "")
+		if src == "" {
+			return nil
+		}
+		alt := escapeMarkdown(collapseHtmlSpace(GetAttrOr(n, "alt", "")))
+		sb.WriteString("![" + alt + "]" + c.linkTarget(src, GetAttrOr(n, "title", "")))
+		return nil
+	case "code", "kbd", "samp", "tt":
+		sb.WriteString(markdownCodeSpan(collapseHtmlSpace(GetText(n))))
+		return nil
+	case "b", "strong":
+		return c.wrapInline(sb, n, "**")
+	case "i", "em", "cite", "dfn", "var":
+		return c.wrapInline(sb, n, "*")
+	case "s", "del", "strike":
+		return c.wrapInline(sb, n, "~~")
+	case "a":
+		href := strings.TrimSpace(GetAttrOr(n, "href", ""))
+		text, err := c.inlineText(n)
+		if err != nil {
+			return err
+		}
+		if href == "" || text == "" {
+			sb.WriteString(text)
+			return nil
+		}
+		sb.WriteString("[" + text + "]" + c.linkTarget(href, GetAttrOr(n, "title", "")))
+		return nil
+	}
+
+	if isBlockElement(n) {
+		// Blocks that end up inside inline content, such as a paragraph inside
+		// a link, are flattened into it
+		text, err := c.inlineText(n)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(" " + text + " ")
+		return nil
+	}
+
+	if c.opts.KeepUnsupportedHTML && !markdownTransparentTags[n.Data] && !isInlineFormattingTag(n.Data) {
+		s, err := HtmlNodeToString(n)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(s)
+		return nil
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if err := c.inline(sb, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wrapInline converts the children of n wrapped in the provided delimiter.
+// Leading and trailing whitespace is moved outside of the delimiters, since
+// Markdown doesn't allow it inside them.
+func (c *markdownConverter) wrapInline(sb *strings.Builder, n *html.Node, delim string) error {
+	var inner strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if err := c.inline(&inner, child); err != nil {
+			return err
+		}
+	}
+
+	s := inner.String()
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		sb.WriteString(s)
+		return nil
+	}
+
+	if strings.HasPrefix(s, " ") {
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(delim + trimmed + delim)
+	if strings.HasSuffix(s, " ") {
+		sb.WriteByte(' ')
+	}
+	return nil
+}
+
+// linkTarget returns the part of a link or image following its text: either
+// an inline (url "title") or a numbered [n] reference.
+func (c *markdownConverter) linkTarget(url string, title string) string {
+	url = strings.ReplaceAll(strings.ReplaceAll(url, " ", "%20"), ")", "%29")
+	if title != "" {
+		url += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+
+	if c.opts.LinkStyle == LinkReference {
+		c.refs = append(c.refs, url)
+		return "[" + strconv.Itoa(len(c.refs)) + "]"
+	}
+	return "(" + url + ")"
+}
+
+// isInlineFormattingTag reports whether tag is a phrasing element with no
+// semantics worth keeping as raw HTML.
+func isInlineFormattingTag(tag string) bool {
+	switch tag {
+	case "abbr", "bdi", "bdo", "data", "font", "label", "q", "small", "time", "u":
+		return true
+	}
+	return false
+}
+
+// markdownCodeBlock converts a pre element to a fenced code block. The
+// language is taken from a language-* class on the pre element or on a code
+// element inside it.
+func markdownCodeBlock(pre *html.Node) string {
+	code := strings.TrimSuffix(GetText(pre), "\n")
+
+	lang := codeLanguage(pre)
+	if inner := FirstElementChild(pre); lang == "" && inner != nil && inner.Data == "code" {
+		lang = codeLanguage(inner)
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+// codeLanguage returns the language of a language-* or lang-* class of n.
+func codeLanguage(n *html.Node) string {
+	for _, class := range splitHtmlTokens(GetAttrOr(n, "class", "")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if lang, ok := strings.CutPrefix(class, prefix); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+// markdownCodeSpan returns s as an inline code span, using a run of backticks
+// longer than any run inside s.
+func markdownCodeSpan(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return s
+	}
+
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// markdownEscaper escapes the characters with an inline meaning in Markdown.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `~`, `\~`, `|`, `\|`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// cleanInlineMarkdown collapses runs of spaces in converted inline content,
+// trims each line and the space before its hard break, and escapes characters at the start of a line that would
+// otherwise start a block.
+func cleanInlineMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+
+	for i, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if i < len(lines)-1 {
+			// The line ends with the backslash of a hard break
+			if before, ok := strings.CutSuffix(line, ` \`); ok {
+				line = before + `\`
+			}
+		}
+		if line != "" && strings.ContainsRune("#>-+=", rune(line[0])) {
+			line = `\` + line
+		} else if j := strings.IndexFunc(line, func(r rune) bool { return r < '0' || r > '9' }); j > 0 && line[j] == '.' {
+			line = line[:j] + `\` + line[j:]
+		}
+		lines[i] = line
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// trimHardBreaks removes the hard line breaks, and the whitespace around
+// them, at the end of converted inline content, since a hard break ending a
+// block is written as a stray backslash.
+func trimHardBreaks(s string) string {
+	for {
+		s = strings.TrimRight(s, " ")
+		trimmed, ok := strings.CutSuffix(s, "\\\n")
+		if !ok {
+			return s
+		}
+		s = trimmed
+	}
+}
+
+// prefixLines prefixes the first line of s with first and every other
+// non-empty line with rest.
+func prefixLines(s string, first string, rest string) string {
+	lines := strings.Split(s, "\n")
+
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line == "":
+			lines[i] = strings.TrimRight(rest, " ")
+		default:
+			lines[i] = rest + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// collapseHtmlSpace replaces each run of ASCII whitespace in s with a single
+// space.
+func collapseHtmlSpace(s string) string {
+	var sb strings.Builder
+	space := false
+
+	for _, r := range s {
+		if isHtmlSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+	if space {
+		sb.WriteByte(' ')
+	}
+
+	return sb.String()
+}
+
+// childNodes returns the children of n.
+func childNodes(n *html.Node) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, c)
+	}
+	return children
+}
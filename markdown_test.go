@@ -0,0 +1,135 @@
+package htmlutil
+
+import "testing"
+
+func TestNodeToMarkdownInlineWhitespace(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"inside emphasis", `<p>a<b> bold </b>c</p>`, `a **bold** c`},
+		{"nested emphasis", `<p><em>one <strong>two </strong></em>three</p>`, `*one **two*** three`},
+		{"only whitespace", `<p>a<i> </i>b</p>`, `a b`},
+		{"newlines", "<p>a\n  <i>\n  b\n</i>\n  c</p>", `a *b* c`},
+		{"adjacent", `<p><b>a</b><i>b</i></p>`, `**a***b*`},
+		{"span", `<p>a<span> b </span>c</p>`, `a b c`},
+		{"pre", "<pre>  keep\n\n    this</pre>", "```\n  keep\n\n    this\n```"},
+		{"escaped", `<p>*not* <i>a_b</i></p>`, `\*not\* *a\_b*`},
+	}
+
+	for _, tt := range tests {
+		got, err := NodeToMarkdown(mustParse(t, tt.doc), MarkdownOptions{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNodeToMarkdownHardBreaks(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"break", `<p>a<br>b</p>`, "a\\\nb"},
+		{"spaces around", `<p>a <br> b</p>`, "a\\\nb"},
+		{"in emphasis", `<p><b>a<br>b</b></p>`, "**a\\\nb**"},
+		{"end of paragraph", `<p>a<br></p><p>b</p>`, "a\n\nb"},
+		{"several at the end", `<p>a<br> <br> </p>`, "a"},
+		{"before a block", `<div>a<br><p>b</p></div>`, "a\n\nb"},
+		{"end of list item", `<ul><li>a<br></li><li>b</li></ul>`, "- a\n- b"},
+		{"escaped backslash", `<p>a\<br></p>`, `a\\`},
+		{"heading", `<h2>a<br>b</h2>`, "## a b"},
+		{"table cell", `<table><tr><th>a<br>b</th></tr></table>`, "| a b |\n| --- |"},
+	}
+
+	for _, tt := range tests {
+		got, err := NodeToMarkdown(mustParse(t, tt.doc), MarkdownOptions{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNodeToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		opts MarkdownOptions
+		want string
+	}{
+		{
+			name: "nested lists",
+			doc:  `<ul><li>a<ul><li>b<ol start="3"><li>c</li><li value="7">d</li><li>e</li></ol></li></ul></li><li>f</li></ul>`,
+			want: "- a\n  - b\n    3. c\n    7. d\n    8. e\n- f",
+		},
+		{
+			name: "list item paragraphs",
+			doc:  `<ol><li><p>a</p><p>b</p></li><li>c</li></ol>`,
+			want: "1. a\n   b\n2. c",
+		},
+		{
+			name: "links",
+			doc:  `<p><a href="/a b" title="Say &quot;hi&quot;">one <i>two</i></a> <a href="/c)">c</a> <a>no href</a> <a href="/x"></a></p>`,
+			want: `[one *two*](/a%20b "Say \"hi\"") [c](/c%29) no href`,
+		},
+		{
+			name: "reference links",
+			doc:  `<p><a href="/a">a</a> <img src="/i.png" alt="i"></p><p><a href="/b">b</a></p>`,
+			opts: MarkdownOptions{LinkStyle: LinkReference},
+			want: "[a][1] ![i][2]\n\n[b][3]\n\n[1]: /a\n[2]: /i.png\n[3]: /b",
+		},
+		{
+			name: "code spans",
+			doc:  "<p><code>a  b</code> <code>x`y</code> <code>`z`</code> <kbd>*k*</kbd> <code> </code></p>",
+			want: "`a b` ``x`y`` `` `z` `` `*k*`",
+		},
+		{
+			name: "code block language",
+			doc:  "<pre><code class=\"language-go\">a := \"```\"\n</code></pre>",
+			want: "````go\na := \"```\"\n````",
+		},
+		{
+			name: "setext headings",
+			doc:  `<h1>Title</h1><h2>Go</h2><h3>Small</h3>`,
+			opts: MarkdownOptions{HeadingStyle: HeadingSetext},
+			want: "Title\n=====\n\nGo\n---\n\n### Small",
+		},
+		{
+			name: "blockquote",
+			doc:  `<blockquote><p>a</p><p>b</p></blockquote>`,
+			want: "> a\n>\n> b",
+		},
+		{
+			name: "unsupported html",
+			doc:  `<p>x<sup>2</sup> <u>u</u></p><script>s()</script>`,
+			opts: MarkdownOptions{KeepUnsupportedHTML: true},
+			want: `x<sup>2</sup> u`,
+		},
+		{
+			name: "block markers escaped",
+			doc:  `<p># not a heading</p><p>1. not a list</p>`,
+			want: "\\# not a heading\n\n1\\. not a list",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := NodeToMarkdown(mustParse(t, tt.doc), tt.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Matcher is a compiled set of tag, attribute, and attribute value criteria,
+// for running the same query against many nodes or documents. Its criteria
+// are resolved once, when it is created, rather than for every node. It is
+// the matching engine behind GetHtmlNodes() and the functions built on it.
+//
+// A Matcher is immutable and safe for concurrent use.
+type Matcher struct {
+	tag     string
+	tagAtom atom.Atom
+	key     string
+	match   AttrMatch
+	opts    MatchOptions
+}
+
+// NewMatcher returns a Matcher for the provided tag, attribute, and attribute
+// value, with the same semantics as GetHtmlNodes() without attribute
+// substrings.
+//
+// The tag, attribute, and attribute value are all optional. If they are empty,
+// they will not be used as search criteria.
+func NewMatcher(tag string, attr string, attrValue string) *Matcher {
+	return NewMatcherWithOptions(tag, attr, attrValue, MatchOptions{})
+}
+
+// NewMatcherWithOptions is like NewMatcher() but allows the comparison of the
+// tag, attribute, and attribute value to be controlled with MatchOptions, as
+// GetHtmlNodesWithOptions() does.
+func NewMatcherWithOptions(tag string, attr string, attrValue string, opts MatchOptions) *Matcher {
+	match := AttrMatch{Kind: AttrMatchExact, Value: attrValue, CaseInsensitive: opts.CaseInsensitiveAttrValue}
+	if opts.AllowAttrSubstring {
+		match.Kind = AttrMatchContains
+	}
+
+	m := newAttrMatcher(tag, attr, match, opts)
+	return &m
+}
+
+// newAttrMatcher returns a Matcher for the provided tag and an attribute with
+// the provided key whose value satisfies match.
+func newAttrMatcher(tag string, key string, match AttrMatch, opts MatchOptions) Matcher {
+	if opts.CaseInsensitiveTag {
+		tag = strings.ToLower(tag)
+	}
+	if opts.CaseInsensitiveAttr {
+		key = strings.ToLower(key)
+	}
+	if match.CaseInsensitive {
+		match.Value = strings.ToLower(match.Value)
+	}
+
+	return Matcher{
+		tag:     tag,
+		tagAtom: atom.Lookup([]byte(tag)),
+		key:     key,
+		match:   match,
+		opts:    opts,
+	}
+}
+
+// Match reports whether n is an element node matching the criteria of the
+// Matcher.
+//
+// Tags known to the parser are compared by their atom, relying on the
+// DataAtom of elements being consistent with their tag, as it is for parsed
+// elements and those created or renamed with this package.
+func (m *Matcher) Match(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+
+	if m.tag != "" {
+		if m.tagAtom != 0 && n.DataAtom != 0 {
+			if n.DataAtom != m.tagAtom {
+				return false
+			}
+		} else if !stringsEqual(n.Data, m.tag, m.opts.CaseInsensitiveTag) {
+			return false
+		}
+	}
+
+	// If attribute and attribute value are empty, don't iterate through the
+	// list of attributes. This ensures a match even if the list of attributes
+	// is empty.
+	if m.key == "" && m.match.Value == "" {
+		return true
+	}
+
+	for _, a := range n.Attr {
+		if (m.key == "" || stringsEqual(a.Key, m.key, m.opts.CaseInsensitiveAttr)) && m.match.matchesLowered(a.Val) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Find returns the HTML nodes found within the provided node that match, up
+// to the provided count.
+//
+// If the count is -1, all nodes will be returned.
+func (m *Matcher) Find(root *html.Node, count int) []*html.Node {
+	return findHtmlNodesFunc(root, m.Match, count, m.opts.SkipTemplateContent)
+}
+
+// FindAll is a convenience function for Find() that returns all matching HTML
+// nodes.
+func (m *Matcher) FindAll(root *html.Node) []*html.Node {
+	return m.Find(root, -1)
+}
+
+// FindFirst is a convenience function for Find() that returns the first
+// matching node, or ErrNodeNotFound if there is no match.
+func (m *Matcher) FindFirst(root *html.Node) (*html.Node, error) {
+	return firstHtmlNode(m.Find(root, 1))
+}
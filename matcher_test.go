@@ -0,0 +1,165 @@
+package htmlutil
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         *html.Node
+		tag       string
+		attr      string
+		attrValue string
+		opts      MatchOptions
+		want      bool
+	}{
+		{"tag", NewElement("div", nil), "div", "", "", MatchOptions{}, true},
+		{"other tag", NewElement("div", nil), "span", "", "", MatchOptions{}, false},
+		{"no criteria", NewElement("div", nil), "", "", "", MatchOptions{}, true},
+		{"custom element", NewElement("my-card", nil), "my-card", "", "", MatchOptions{}, true},
+		{"custom element criteria", NewElement("div", nil), "my-card", "", "", MatchOptions{}, false},
+		{"node without atom", &html.Node{Type: html.ElementNode, Data: "div"}, "div", "", "", MatchOptions{}, true},
+		{"uppercase tag", NewElement("div", nil), "DIV", "", "", MatchOptions{}, false},
+		{"uppercase tag ignored", NewElement("div", nil), "DIV", "", "", MatchOptions{CaseInsensitiveTag: true}, true},
+		{"uppercase node ignored", &html.Node{Type: html.ElementNode, Data: "DIV"}, "div", "", "", MatchOptions{CaseInsensitiveTag: true}, true},
+		{"attribute", NewElement("a", map[string]string{"href": "/"}), "a", "href", "", MatchOptions{}, true},
+		{"attribute value", NewElement("a", map[string]string{"href": "/"}), "", "href", "/", MatchOptions{}, true},
+		{"other attribute value", NewElement("a", map[string]string{"href": "/"}), "", "href", "/x", MatchOptions{}, false},
+		{"value of any attribute", NewElement("a", map[string]string{"title": "x"}), "", "", "x", MatchOptions{}, true},
+		{"missing attribute", NewElement("a", nil), "a", "href", "", MatchOptions{}, false},
+		{"uppercase attribute ignored", NewElement("a", map[string]string{"href": "/"}), "", "HREF", "", MatchOptions{CaseInsensitiveAttr: true}, true},
+		{"uppercase value ignored", NewElement("a", map[string]string{"class": "Btn"}), "", "class", "BTN", MatchOptions{CaseInsensitiveAttrValue: true}, true},
+		{"substring", NewElement("a", map[string]string{"class": "btn big"}), "", "class", "big", MatchOptions{AllowAttrSubstring: true}, true},
+		{"no substring", NewElement("a", map[string]string{"class": "btn big"}), "", "class", "big", MatchOptions{}, false},
+		{"text node", NewText("div"), "", "", "", MatchOptions{}, false},
+		{"nil node", nil, "", "", "", MatchOptions{}, false},
+	}
+
+	for _, tt := range tests {
+		m := NewMatcherWithOptions(tt.tag, tt.attr, tt.attrValue, tt.opts)
+		if got := m.Match(tt.n); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherMatchesGetHtmlNodes(t *testing.T) {
+	queries := []struct {
+		tag, attr, attrValue string
+	}{
+		{"", "", ""},
+		{"div", "", ""},
+		{"div", "class", "a"},
+		{"", "class", ""},
+		{"", "", "a"},
+		{"a", "href", "#x"},
+		{"title", "", ""},
+		{"td", "", ""},
+		{"a", "", "/wiki/Link_3_1"},
+	}
+
+	for i, doc := range traversalCorpus() {
+		root := mustParse(t, doc)
+		for _, q := range queries {
+			for _, substring := range []bool{false, true} {
+				m := NewMatcherWithOptions(q.tag, q.attr, q.attrValue, MatchOptions{AllowAttrSubstring: substring})
+				want := recursiveGetHtmlNodes(root, q.tag, q.attr, q.attrValue, -1, substring)
+
+				got := m.FindAll(root)
+				if len(got) != len(want) {
+					t.Errorf("doc %d, %v, substring %v: got %d nodes, want %d", i, q, substring, len(got), len(want))
+					continue
+				}
+				for j := range want {
+					if got[j] != want[j] {
+						t.Errorf("doc %d, %v, substring %v: node %d differs", i, q, substring, j)
+						break
+					}
+				}
+
+				first, err := m.FindFirst(root)
+				if len(want) == 0 {
+					if err != ErrNodeNotFound {
+						t.Errorf("doc %d, %v, substring %v: got %v, want ErrNodeNotFound", i, q, substring, err)
+					}
+				} else if first != want[0] {
+					t.Errorf("doc %d, %v, substring %v: got first node %v", i, q, substring, first)
+				}
+			}
+		}
+	}
+}
+
+func TestMatcherFind(t *testing.T) {
+	root := mustParse(t, `<p>1</p><div><p>2</p><p>3</p></div><template><p>4</p></template>`)
+
+	m := NewMatcher("p", "", "")
+	for _, count := range []int{0, 1, 2, 3} {
+		if got := len(m.Find(root, count)); got != count {
+			t.Errorf("count %d: got %d nodes", count, got)
+		}
+	}
+	if got := len(m.FindAll(root)); got != 4 {
+		t.Errorf("got %d nodes, want template content included", got)
+	}
+
+	m = NewMatcherWithOptions("p", "", "", MatchOptions{SkipTemplateContent: true})
+	if got := len(m.FindAll(root)); got != 3 {
+		t.Errorf("got %d nodes, want template content skipped", got)
+	}
+}
+
+func TestMatcherRenamedElement(t *testing.T) {
+	root := mustParse(t, `<b>x</b>`)
+	b := GetFirstHtmlNode(root, "b", "", "")
+	RenameAllHtmlTags(root, "b", "strong")
+
+	if b.DataAtom != atom.Strong {
+		t.Fatalf("got atom %v", b.DataAtom)
+	}
+	if NewMatcher("b", "", "").Match(b) || !NewMatcher("strong", "", "").Match(b) {
+		t.Error("renamed element matched by its old tag")
+	}
+}
+
+// BenchmarkMatcher compares matching a common tag on a large document by its
+// atom with matching it by string equality, and compiling the matcher once
+// with compiling it for every query. The traversal dominates all three, so the
+// differences are within a few percent.
+func BenchmarkMatcher(b *testing.B) {
+	root := mustParse(b, largePage(5000))
+	m := NewMatcher("div", "", "")
+	want := len(m.FindAll(root))
+
+	b.Run("Atom", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if got := len(m.FindAll(root)); got != want {
+				b.Fatalf("got %d nodes, want %d", got, want)
+			}
+		}
+	})
+
+	b.Run("String", func(b *testing.B) {
+		match := func(n *html.Node) bool { return n.Type == html.ElementNode && n.Data == "div" }
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if got := len(GetAllHtmlNodesFunc(root, match)); got != want {
+				b.Fatalf("got %d nodes, want %d", got, want)
+			}
+		}
+	})
+
+	b.Run("GetAllHtmlNodes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if got := len(GetAllHtmlNodes(root, "div", "", "")); got != want {
+				b.Fatalf("got %d nodes, want %d", got, want)
+			}
+		}
+	})
+}
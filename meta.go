@@ -0,0 +1,127 @@
+package htmlutil
+
+import (
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Meta is the metadata of an HTML document.
+type Meta struct {
+	// Title is the og:title property, or the text of the <title> element if
+	// there is no og:title property.
+	Title string
+
+	// Description is the content of the description meta tag, or the
+	// og:description property if there is no description meta tag.
+	Description string
+
+	// Canonical is the href of the rel="canonical" link element.
+	Canonical string
+
+	// Charset is the character encoding declared by the document, either with
+	// a charset meta tag or with a Content-Type http-equiv meta tag.
+	Charset string
+
+	// OpenGraph maps OpenGraph properties such as "og:image" to their values,
+	// in document order.
+	OpenGraph map[string][]string
+
+	// Twitter maps Twitter card properties such as "twitter:card" to their
+	// values, in document order.
+	Twitter map[string][]string
+}
+
+// ExtractMeta returns the metadata of the provided document. Meta tags are
+// read from the whole document, not only from <head>, since browsers honor
+// them anywhere.
+//
+// OpenGraph properties are read from <meta property>, and from <meta name>
+// when no property attribute is present. Twitter card properties are read
+// from <meta name>, and from <meta property> when no name attribute is
+// present.
+func ExtractMeta(doc *html.Node) Meta {
+	meta := Meta{
+		OpenGraph: make(map[string][]string),
+		Twitter:   make(map[string][]string),
+	}
+
+	for _, n := range GetAllHtmlNodes(doc, "meta", "", "") {
+		property, hasProperty := GetAttr(n, "property")
+		name, hasName := GetAttr(n, "name")
+		content := GetAttrOr(n, "content", "")
+
+		switch {
+		case strings.HasPrefix(property, "og:"):
+			meta.OpenGraph[property] = append(meta.OpenGraph[property], content)
+		case !hasProperty && strings.HasPrefix(name, "og:"):
+			meta.OpenGraph[name] = append(meta.OpenGraph[name], content)
+		case strings.HasPrefix(name, "twitter:"):
+			meta.Twitter[name] = append(meta.Twitter[name], content)
+		case !hasName && strings.HasPrefix(property, "twitter:"):
+			meta.Twitter[property] = append(meta.Twitter[property], content)
+		case strings.EqualFold(name, "description"):
+			if meta.Description == "" {
+				meta.Description = strings.TrimSpace(content)
+			}
+		}
+
+		if meta.Charset == "" {
+			meta.Charset = metaCharset(n)
+		}
+	}
+
+	if values := meta.OpenGraph["og:title"]; len(values) > 0 {
+		meta.Title = strings.TrimSpace(values[0])
+	} else if title, err := FindFirstHtmlNodeFunc(doc, isHtmlTitle); err == nil {
+		meta.Title = GetNormalizedText(title)
+	}
+
+	if values := meta.OpenGraph["og:description"]; meta.Description == "" && len(values) > 0 {
+		meta.Description = strings.TrimSpace(values[0])
+	}
+
+	for _, n := range GetAllHtmlNodes(doc, "link", "rel", "") {
+		if hasRelToken(n, "canonical") {
+			meta.Canonical = strings.TrimSpace(GetAttrOr(n, "href", ""))
+			break
+		}
+	}
+
+	return meta
+}
+
+// isHtmlTitle reports whether n is an HTML title element, as opposed to an SVG
+// title element.
+func isHtmlTitle(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.Data == "title" && n.Namespace == ""
+}
+
+// metaCharset returns the character encoding declared by a meta element, or
+// an empty string if it doesn't declare one.
+func metaCharset(n *html.Node) string {
+	if charset, ok := GetAttr(n, "charset"); ok {
+		return strings.ToLower(strings.TrimSpace(charset))
+	}
+
+	if httpEquiv, _ := GetAttr(n, "http-equiv"); strings.EqualFold(strings.TrimSpace(httpEquiv), "content-type") {
+		if _, params, err := mime.ParseMediaType(GetAttrOr(n, "content", "")); err == nil {
+			return strings.ToLower(params["charset"])
+		}
+	}
+
+	return ""
+}
+
+// hasRelToken reports whether the rel attribute of n contains the provided
+// token, which must be lowercase. Link types are case-insensitive.
+func hasRelToken(n *html.Node, token string) bool {
+	rel, _ := GetAttr(n, "rel")
+	for _, t := range splitHtmlTokens(rel) {
+		if strings.ToLower(t) == token {
+			return true
+		}
+	}
+	return false
+}
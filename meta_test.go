@@ -0,0 +1,120 @@
+package htmlutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMeta(t *testing.T) {
+	doc := mustParse(t, `<!DOCTYPE html><html><head>`+
+		`<meta charset="UTF-8">`+
+		`<title> Page   title </title>`+
+		`<meta name="description" content=" The description. ">`+
+		`<meta property="og:title" content="OG title">`+
+		`<meta property="og:image" content="https://example.com/1.png">`+
+		`<meta property="og:image" content="https://example.com/2.png">`+
+		`<meta name="twitter:card" content="summary">`+
+		`<link rel="Canonical" href=" https://example.com/page ">`+
+		`</head><body>`+
+		`<meta property="og:image" content="https://example.com/3.png">`+
+		`<meta name="twitter:site" content="@example">`+
+		`<svg><title>Icon</title></svg>`+
+		`</body></html>`)
+
+	meta := ExtractMeta(doc)
+
+	if meta.Title != "OG title" {
+		t.Errorf("Title: got %q", meta.Title)
+	}
+	if meta.Description != "The description." {
+		t.Errorf("Description: got %q", meta.Description)
+	}
+	if meta.Canonical != "https://example.com/page" {
+		t.Errorf("Canonical: got %q", meta.Canonical)
+	}
+	if meta.Charset != "utf-8" {
+		t.Errorf("Charset: got %q", meta.Charset)
+	}
+
+	wantOG := map[string][]string{
+		"og:title": {"OG title"},
+		"og:image": {"https://example.com/1.png", "https://example.com/2.png", "https://example.com/3.png"},
+	}
+	if !reflect.DeepEqual(meta.OpenGraph, wantOG) {
+		t.Errorf("OpenGraph: got %v, want %v", meta.OpenGraph, wantOG)
+	}
+
+	wantTwitter := map[string][]string{
+		"twitter:card": {"summary"},
+		"twitter:site": {"@example"},
+	}
+	if !reflect.DeepEqual(meta.Twitter, wantTwitter) {
+		t.Errorf("Twitter: got %v, want %v", meta.Twitter, wantTwitter)
+	}
+}
+
+func TestExtractMetaFallbacks(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		title       string
+		description string
+		charset     string
+	}{
+		{
+			name:  "title element",
+			doc:   `<svg><title>Icon</title></svg><title> Real  title </title>`,
+			title: "Real title",
+		},
+		{
+			name:        "og:description",
+			doc:         `<meta property="og:description" content="OG description">`,
+			description: "OG description",
+		},
+		{
+			name:        "description preferred over og:description",
+			doc:         `<meta property="og:description" content="OG"><meta name="Description" content="Plain">`,
+			description: "Plain",
+		},
+		{
+			name:        "first description",
+			doc:         `<meta name="description" content="First"><meta name="description" content="Second">`,
+			description: "First",
+		},
+		{
+			name:    "http-equiv charset",
+			doc:     `<meta http-equiv="Content-Type" content="text/html; charset=ISO-8859-1">`,
+			charset: "iso-8859-1",
+		},
+		{
+			name: "empty document",
+			doc:  ``,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := ExtractMeta(mustParse(t, tt.doc))
+			if meta.Title != tt.title || meta.Description != tt.description || meta.Charset != tt.charset {
+				t.Errorf("got %q, %q, %q; want %q, %q, %q", meta.Title, meta.Description, meta.Charset, tt.title, tt.description, tt.charset)
+			}
+		})
+	}
+}
+
+func TestExtractMetaNameAndProperty(t *testing.T) {
+	doc := mustParse(t, `<meta name="og:type" content="article">`+
+		`<meta property="twitter:title" content="T">`+
+		`<meta property="og:url" name="twitter:url" content="https://example.com/">`)
+
+	meta := ExtractMeta(doc)
+
+	wantOG := map[string][]string{"og:type": {"article"}, "og:url": {"https://example.com/"}}
+	if !reflect.DeepEqual(meta.OpenGraph, wantOG) {
+		t.Errorf("OpenGraph: got %v, want %v", meta.OpenGraph, wantOG)
+	}
+	wantTwitter := map[string][]string{"twitter:title": {"T"}}
+	if !reflect.DeepEqual(meta.Twitter, wantTwitter) {
+		t.Errorf("Twitter: got %v, want %v", meta.Twitter, wantTwitter)
+	}
+}
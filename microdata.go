@@ -0,0 +1,168 @@
+package htmlutil
+
+import (
+	"slices"
+	"sort"
+
+	"golang.org/x/net/html"
+)
+
+// Item is a microdata item, an element with an itemscope attribute.
+type Item struct {
+	// Type is the itemtype attribute of the item.
+	Type string
+
+	// ID is the itemid attribute of the item.
+	ID string
+
+	// Properties maps property names to their values, in document order. A
+	// value is an Item for properties that are items themselves, and a string
+	// otherwise.
+	Properties map[string][]any
+
+	Node *html.Node
+}
+
+// ExtractMicrodata returns the top-level microdata items of the provided
+// document, the elements with an itemscope attribute and no itemprop
+// attribute, in document order.
+//
+// The properties of an item are the elements with an itemprop attribute
+// within it, not counting those within nested items, and within the elements
+// whose ids are listed in its itemref attribute. Property values follow the
+// microdata spec: the content attribute of meta elements, the src attribute
+// of media elements, the href attribute of a, area, and link elements, the
+// data attribute of object elements, the value attribute of data and meter
+// elements, the datetime attribute of time elements, and the normalized text
+// of other elements. Items are not nested in themselves, so properties that
+// would create a cycle through itemref are skipped.
+func ExtractMicrodata(doc *html.Node) []Item {
+	m := microdataExtractor{
+		ids:   BuildIdIndex(doc),
+		order: make(map[*html.Node]int),
+	}
+	Walk(doc, func(n *html.Node) WalkAction {
+		m.order[n] = len(m.order)
+		return WalkContinue
+	})
+
+	items := []Item{}
+	for _, n := range GetAllHtmlNodesFunc(doc, isTopLevelItem) {
+		items = append(items, m.item(n, nil))
+	}
+
+	return items
+}
+
+// microdataExtractor holds the document-wide state of ExtractMicrodata().
+type microdataExtractor struct {
+	ids   IdIndex
+	order map[*html.Node]int
+}
+
+// item extracts the item of the provided itemscope element. The items being
+// extracted, of which this one is a property, are listed in parents.
+func (m *microdataExtractor) item(n *html.Node, parents []*html.Node) Item {
+	item := Item{
+		Type:       GetAttrOr(n, "itemtype", ""),
+		ID:         GetAttrOr(n, "itemid", ""),
+		Properties: make(map[string][]any),
+		Node:       n,
+	}
+	parents = append(parents, n)
+
+	for _, prop := range m.properties(n) {
+		var value any
+		if _, ok := GetAttr(prop, "itemscope"); ok {
+			if slices.Contains(parents, prop) {
+				continue
+			}
+			value = m.item(prop, parents)
+		} else {
+			value = microdataValue(prop)
+		}
+
+		for _, name := range splitHtmlTokens(GetAttrOr(prop, "itemprop", "")) {
+			item.Properties[name] = append(item.Properties[name], value)
+		}
+	}
+
+	return item
+}
+
+// properties returns the property elements of the provided item, in document
+// order.
+func (m *microdataExtractor) properties(root *html.Node) []*html.Node {
+	var pending []*html.Node
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		pending = append(pending, c)
+	}
+	for _, id := range splitHtmlTokens(GetAttrOr(root, "itemref", "")) {
+		if n := m.ids.Lookup(id); n != nil && n != root {
+			pending = append(pending, n)
+		}
+	}
+
+	var props []*html.Node
+	seen := make(map[*html.Node]bool)
+
+	for len(pending) > 0 {
+		n := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[n] || n.Type != html.ElementNode {
+			continue
+		}
+		seen[n] = true
+
+		if _, ok := GetAttr(n, "itemprop"); ok {
+			props = append(props, n)
+		}
+		if _, ok := GetAttr(n, "itemscope"); ok {
+			continue
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			pending = append(pending, c)
+		}
+	}
+
+	sort.Slice(props, func(i, j int) bool {
+		return m.order[props[i]] < m.order[props[j]]
+	})
+	return props
+}
+
+// microdataValue returns the value of a property element that is not an
+// item.
+func microdataValue(n *html.Node) string {
+	if n.Namespace == "" {
+		switch n.Data {
+		case "meta":
+			return GetAttrOr(n, "content", "")
+		case "audio", "embed", "iframe", "img", "source", "track", "video":
+			return GetAttrOr(n, "src", "")
+		case "a", "area", "link":
+			return GetAttrOr(n, "href", "")
+		case "object":
+			return GetAttrOr(n, "data", "")
+		case "data", "meter":
+			return GetAttrOr(n, "value", "")
+		case "time":
+			if datetime, ok := GetAttr(n, "datetime"); ok {
+				return datetime
+			}
+		}
+	}
+	return GetNormalizedText(n)
+}
+
+// isTopLevelItem reports whether n is an element with an itemscope attribute
+// and no itemprop attribute.
+func isTopLevelItem(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	_, scope := GetAttr(n, "itemscope")
+	_, prop := GetAttr(n, "itemprop")
+	return scope && !prop
+}
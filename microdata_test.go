@@ -0,0 +1,173 @@
+package htmlutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+const productFixture = `<html><head><title>Product</title></head><body>
+<div itemscope itemtype="https://schema.org/Product" itemid="urn:sku:K-100" itemref="brand reviews">
+  <h1 itemprop="name">Kettle  <small>K-100</small></h1>
+  <img itemprop="image" src="/kettle.jpg" alt="">
+  <meta itemprop="sku" content="K-100">
+  <link itemprop="url" href="https://shop.example/kettle">
+  <p itemprop="description">Boils water fast.</p>
+  <div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+    <data itemprop="price" value="39.90">$39.90</data>
+    <meta itemprop="priceCurrency" content="USD">
+    <link itemprop="availability" href="https://schema.org/InStock">In stock
+    <time itemprop="priceValidUntil" datetime="2026-12-31">end of year</time>
+  </div>
+</div>
+<aside>
+  <div id="brand" itemprop="brand" itemscope itemtype="https://schema.org/Brand">
+    <span itemprop="name">Acme</span>
+  </div>
+  <div id="reviews">
+    <div itemprop="review" itemscope itemtype="https://schema.org/Review">
+      <span itemprop="author">Ann</span>
+      <time itemprop="datePublished">2026-01-02</time>
+      <span itemprop="reviewRating" itemscope itemtype="https://schema.org/Rating"><meter itemprop="ratingValue" value="4">4/5</meter></span>
+    </div>
+    <p itemprop="category keywords">Kitchen</p>
+  </div>
+</aside>
+</body></html>`
+
+// itemMap returns the type, id, and properties of an item as nested maps, for
+// comparisons without the nodes.
+func itemMap(item Item) map[string]any {
+	m := map[string]any{}
+	if item.Type != "" {
+		m["@type"] = item.Type
+	}
+	if item.ID != "" {
+		m["@id"] = item.ID
+	}
+	for name, values := range item.Properties {
+		var vals []any
+		for _, v := range values {
+			if nested, ok := v.(Item); ok {
+				vals = append(vals, itemMap(nested))
+			} else {
+				vals = append(vals, v)
+			}
+		}
+		m[name] = vals
+	}
+	return m
+}
+
+func TestExtractMicrodata(t *testing.T) {
+	doc := mustParse(t, productFixture)
+
+	items := ExtractMicrodata(doc)
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	if items[0].Node != GetFirstHtmlNode(doc, "div", "itemid", "urn:sku:K-100") {
+		t.Error("got the wrong item node")
+	}
+
+	want := map[string]any{
+		"@type":       "https://schema.org/Product",
+		"@id":         "urn:sku:K-100",
+		"name":        []any{"Kettle K-100"},
+		"image":       []any{"/kettle.jpg"},
+		"sku":         []any{"K-100"},
+		"url":         []any{"https://shop.example/kettle"},
+		"description": []any{"Boils water fast."},
+		"offers": []any{map[string]any{
+			"@type":           "https://schema.org/Offer",
+			"price":           []any{"39.90"},
+			"priceCurrency":   []any{"USD"},
+			"availability":    []any{"https://schema.org/InStock"},
+			"priceValidUntil": []any{"2026-12-31"},
+		}},
+		"brand": []any{map[string]any{
+			"@type": "https://schema.org/Brand",
+			"name":  []any{"Acme"},
+		}},
+		"review": []any{map[string]any{
+			"@type":         "https://schema.org/Review",
+			"author":        []any{"Ann"},
+			"datePublished": []any{"2026-01-02"},
+			"reviewRating": []any{map[string]any{
+				"@type":       "https://schema.org/Rating",
+				"ratingValue": []any{"4"},
+			}},
+		}},
+		"category": []any{"Kitchen"},
+		"keywords": []any{"Kitchen"},
+	}
+
+	if got := itemMap(items[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
+
+func TestExtractMicrodataOrder(t *testing.T) {
+	doc := mustParse(t, `<p id="first" itemprop="n">1</p>`+
+		`<div itemscope itemref="last first"><span itemprop="n">2</span><span itemprop="n">3</span></div>`+
+		`<p id="last" itemprop="n">4</p><div itemscope><i itemprop="n">other</i></div>`)
+
+	items := ExtractMicrodata(doc)
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	if got, want := items[0].Properties["n"], []any{"1", "2", "3", "4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v in document order", got, want)
+	}
+	if got, want := items[1].Properties["n"], []any{"other"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second item: got %v, want %v", got, want)
+	}
+}
+
+func TestExtractMicrodataCycle(t *testing.T) {
+	doc := mustParse(t, `<div itemscope itemref="b"><span itemprop="x">a</span></div>`+
+		`<div id="b" itemprop="child" itemscope itemref="b2"><span itemprop="y">b</span></div>`+
+		`<div id="b2"><div id="b" itemprop="loop"></div></div>`+
+		`<div id="self" itemscope itemref="self"><span itemprop="z">s</span></div>`)
+
+	items := ExtractMicrodata(doc)
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+
+	want := map[string]any{
+		"x": []any{"a"},
+		"child": []any{map[string]any{
+			"y":    []any{"b"},
+			"loop": []any{""},
+		}},
+	}
+	if got := itemMap(items[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+	if got, want := itemMap(items[1]), map[string]any{"z": []any{"s"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("self reference: got %v, want %v", got, want)
+	}
+}
+
+func TestExtractMicrodataRecursiveItemref(t *testing.T) {
+	doc := mustParse(t, `<div itemscope><div id="p" itemprop="part" itemscope itemref="p2"></div></div>`+
+		`<div id="p2"><div itemprop="back" itemscope itemref="p"></div></div>`)
+
+	items := ExtractMicrodata(doc)
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+
+	want := map[string]any{
+		"part": []any{map[string]any{
+			"back": []any{map[string]any{}},
+		}},
+	}
+	if got := itemMap(items[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+
+	if got := ExtractMicrodata(mustParse(t, `<p itemprop="x">no item</p>`)); got == nil || len(got) != 0 {
+		t.Errorf("got %#v, want an empty slice", got)
+	}
+}
@@ -0,0 +1,249 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MinifyOptions controls which transformations MinifyHtmlNode() applies. The
+// zero value applies all of them except emptying boolean attributes.
+type MinifyOptions struct {
+	// KeepWhitespace leaves text nodes as they are instead of collapsing their
+	// whitespace and removing whitespace-only text between block elements.
+	KeepWhitespace bool
+
+	// KeepComments leaves comments in place.
+	KeepComments bool
+
+	// KeepConditionalComments leaves Internet Explorer conditional comments
+	// such as <!--[if IE]>...<![endif]--> in place when other comments are
+	// removed.
+	KeepConditionalComments bool
+
+	// KeepDefaultAttrs leaves attributes whose value is the default defined by
+	// the HTML spec, such as type="text" on input elements, in place.
+	KeepDefaultAttrs bool
+
+	// EmptyBooleanAttrs replaces the value of boolean attributes such as
+	// disabled="disabled" with an empty string.
+	EmptyBooleanAttrs bool
+}
+
+// defaultAttrValues maps a tag to the attributes that can be removed from
+// elements with that tag when they have the listed value, compared
+// case-insensitively.
+var defaultAttrValues = map[string]map[string]string{
+	"area":     {"shape": "rect"},
+	"button":   {"type": "submit"},
+	"form":     {"method": "get", "enctype": "application/x-www-form-urlencoded"},
+	"input":    {"type": "text"},
+	"link":     {"type": "text/css"},
+	"ol":       {"type": "1"},
+	"script":   {"type": "text/javascript", "language": "javascript"},
+	"style":    {"type": "text/css", "media": "all"},
+	"td":       {"colspan": "1", "rowspan": "1"},
+	"textarea": {"wrap": "soft"},
+	"th":       {"colspan": "1", "rowspan": "1"},
+}
+
+// booleanAttrs lists the attributes whose presence alone is meaningful.
+var booleanAttrs = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true,
+	"autoplay": true, "checked": true, "controls": true, "default": true,
+	"defer": true, "disabled": true, "formnovalidate": true, "hidden": true,
+	"inert": true, "ismap": true, "itemscope": true, "loop": true,
+	"multiple": true, "muted": true, "nomodule": true, "novalidate": true,
+	"open": true, "playsinline": true, "readonly": true, "required": true,
+	"reversed": true, "selected": true,
+}
+
+// MinifyHtmlNode reduces the rendered size of an HTML node and its
+// descendants in place, leaving the tree renderable by HtmlNodeToString().
+//
+// Whitespace in text nodes is collapsed to a single space, and whitespace-only
+// text nodes between block elements are removed. Comments and attributes
+// with default values are removed, and boolean attributes can be emptied.
+// Content inside pre, textarea, script, and style elements is never touched.
+func MinifyHtmlNode(n *html.Node, opts MinifyOptions) {
+	Walk(n, func(n *html.Node) WalkAction {
+		switch n.Type {
+		case html.ElementNode:
+			minifyAttrs(n, opts)
+			if preservesWhitespace(n) {
+				return WalkSkipChildren
+			}
+		case html.TextNode:
+			if !opts.KeepWhitespace {
+				minifyText(n)
+			}
+		case html.CommentNode:
+			if !opts.KeepComments && !(opts.KeepConditionalComments && isConditionalComment(n)) && n.Parent != nil {
+				n.Parent.RemoveChild(n)
+			}
+		}
+		return WalkContinue
+	})
+}
+
+func minifyAttrs(n *html.Node, opts MinifyOptions) {
+	defaults := defaultAttrValues[n.Data]
+	if n.Namespace != "" {
+		defaults = nil
+	}
+
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Namespace == "" {
+			if def, ok := defaults[a.Key]; ok && !opts.KeepDefaultAttrs && strings.EqualFold(strings.TrimSpace(a.Val), def) {
+				continue
+			}
+			if opts.EmptyBooleanAttrs && booleanAttrs[a.Key] {
+				a.Val = ""
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	n.Attr = attrs
+}
+
+// minifyText collapses the whitespace of a text node, removing it entirely if
+// it is whitespace between block elements.
+func minifyText(n *html.Node) {
+	n.Data = collapseHtmlSpace(n.Data)
+	if isInterBlockWhitespace(n) {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+// RemoveHtmlComments removes the comments within the provided node and returns
+// the number of comments removed. If keepConditional is true, Internet
+// Explorer conditional comments are kept. Comments inside pre and textarea
+// elements are left untouched.
+func RemoveHtmlComments(n *html.Node, keepConditional bool) int {
+	removed := 0
+
+	Walk(n, func(n *html.Node) WalkAction {
+		switch n.Type {
+		case html.ElementNode:
+			if preservesWhitespace(n) {
+				return WalkSkipChildren
+			}
+		case html.CommentNode:
+			if n.Parent != nil && !(keepConditional && isConditionalComment(n)) {
+				n.Parent.RemoveChild(n)
+				removed++
+			}
+		}
+		return WalkContinue
+	})
+
+	return removed
+}
+
+// RemoveWhitespaceTextNodes removes the text nodes within the provided node
+// that consist solely of whitespace and sit between block elements, where the
+// whitespace is not rendered. It returns the number of text nodes removed.
+//
+// Whitespace between inline elements, as in "<b>a</b> <i>b</i>", is kept, and
+// so is the content of pre and textarea elements.
+func RemoveWhitespaceTextNodes(n *html.Node) int {
+	removed := 0
+
+	Walk(n, func(n *html.Node) WalkAction {
+		switch n.Type {
+		case html.ElementNode:
+			if preservesWhitespace(n) {
+				return WalkSkipChildren
+			}
+		case html.TextNode:
+			if isInterBlockWhitespace(n) {
+				n.Parent.RemoveChild(n)
+				removed++
+			}
+		}
+		return WalkContinue
+	})
+
+	return removed
+}
+
+// isInterBlockWhitespace reports whether n is a whitespace-only text node
+// between block elements, whose whitespace is not rendered.
+func isInterBlockWhitespace(n *html.Node) bool {
+	if n.Parent == nil || strings.TrimFunc(n.Data, isHtmlSpace) != "" {
+		return false
+	}
+
+	return isBlockBoundary(n.PrevSibling, n.Parent) && isBlockBoundary(n.NextSibling, n.Parent)
+}
+
+// isBlockBoundary reports whether whitespace next to sibling, a sibling of a
+// text node within parent, is insignificant. A nil sibling stands for the
+// start or end of parent.
+func isBlockBoundary(sibling *html.Node, parent *html.Node) bool {
+	if sibling == nil {
+		return parent.Type == html.DocumentNode || isBlockElement(parent) || isMetadataContainer(parent)
+	}
+
+	switch sibling.Type {
+	case html.ElementNode:
+		return isBlockElement(sibling) || isMetadataContainer(sibling) || isMetadataElement(sibling)
+	case html.CommentNode, html.DoctypeNode:
+		return true
+	}
+	return false
+}
+
+// isMetadataContainer reports whether n is an element that contains no
+// rendered text of its own, such as head or a table section.
+func isMetadataContainer(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+
+	switch n.Data {
+	case "head", "table", "thead", "tbody", "tfoot", "tr", "colgroup", "select", "datalist", "optgroup":
+		return true
+	}
+	return false
+}
+
+// isMetadataElement reports whether n is an element that is never rendered,
+// or is rendered as a block, such as a script, link, or table cell.
+func isMetadataElement(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+
+	switch n.Data {
+	case "base", "caption", "col", "link", "meta", "noscript", "option", "script", "style", "td", "template", "th", "title":
+		return true
+	}
+	return false
+}
+
+// preservesWhitespace reports whether n is an element whose content must be
+// left untouched because whitespace inside it is significant.
+func preservesWhitespace(n *html.Node) bool {
+	if childTextNodesAreLiteral(n) {
+		return true
+	}
+	if n.Namespace != "" {
+		return false
+	}
+
+	switch n.Data {
+	case "pre", "listing", "textarea":
+		return true
+	}
+	return false
+}
+
+// isConditionalComment reports whether n is an Internet Explorer conditional
+// comment, or one of the comments delimiting a downlevel-revealed
+// conditional.
+func isConditionalComment(n *html.Node) bool {
+	data := strings.TrimSpace(n.Data)
+	return strings.HasPrefix(data, "[if ") || strings.HasPrefix(data, "<![endif]") || strings.HasSuffix(data, "<![endif]")
+}
@@ -0,0 +1,203 @@
+package htmlutil
+
+import "testing"
+
+// minifyFixtures are pages exercising the transformations of
+// MinifyHtmlNode().
+var minifyFixtures = []string{
+	`<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>Blog</title>
+    <link rel="stylesheet" type="text/css" href="/style.css">
+    <style type="text/css" media="all">
+      body { margin: 0 }
+    </style>
+    <!--[if IE]><link rel="stylesheet" href="/ie.css"><![endif]-->
+  </head>
+  <body>
+    <!-- navigation -->
+    <nav>
+      <ul>
+        <li><a href="/">Home</a></li>
+        <li><a href="/about">About</a></li>
+      </ul>
+    </nav>
+    <article>
+      <h1>Post   title</h1>
+      <p>Some    <b>bold</b> <i>italic</i>
+         text.</p>
+      <pre>
+  code    block
+      </pre>
+    </article>
+    <script type="text/javascript">
+      if (a  &&  b) { run(); }
+    </script>
+  </body>
+</html>`,
+	`<html><body>
+  <form method="get" action="/search" enctype="application/x-www-form-urlencoded">
+    <input type="text" name="q" required="required">
+    <textarea wrap="soft">  keep
+   this  </textarea>
+    <select><option selected="selected">a</option><option>b</option></select>
+    <button type="submit" disabled="disabled">Go</button>
+  </form>
+  <table>
+    <tr>
+      <td colspan="1">1</td>
+      <td rowspan="2">2</td>
+    </tr>
+  </table>
+</body></html>`,
+	largePage(10),
+}
+
+func TestMinifyHtmlNode(t *testing.T) {
+	for i, fixture := range minifyFixtures {
+		doc := mustParse(t, fixture)
+		before := mustRender(t, doc)
+		text := NodeToText(doc, TextOptions{})
+
+		MinifyHtmlNode(doc, MinifyOptions{})
+		after := mustRender(t, doc)
+
+		if len(after) >= len(before) {
+			t.Errorf("fixture %d: minified to %d bytes from %d", i, len(after), len(before))
+		}
+		if got := NodeToText(doc, TextOptions{}); got != text {
+			t.Errorf("fixture %d: visible text changed:\ngot:\n%s\nwant:\n%s", i, got, text)
+		}
+
+		// Minifying again changes nothing
+		MinifyHtmlNode(doc, MinifyOptions{})
+		if again := mustRender(t, doc); again != after {
+			t.Errorf("fixture %d: not idempotent:\n%s\n%s", i, after, again)
+		}
+	}
+}
+
+func TestMinifyHtmlNodeOutput(t *testing.T) {
+	doc := mustParse(t, minifyFixtures[1])
+	MinifyHtmlNode(doc, MinifyOptions{EmptyBooleanAttrs: true})
+
+	// The whitespace between the inline form controls is kept, collapsed
+	want := `<form action="/search"> <input name="q" required=""/> ` +
+		"<textarea>  keep\n   this  </textarea> " +
+		`<select><option selected="">a</option><option>b</option></select> ` +
+		`<button disabled="">Go</button> </form>` +
+		`<table><tbody><tr><td>1</td><td rowspan="2">2</td></tr></tbody></table>`
+	if got := bodyHtml(t, doc); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMinifyHtmlNodePreservesRawText(t *testing.T) {
+	doc := mustParse(t, minifyFixtures[0])
+	MinifyHtmlNode(doc, MinifyOptions{})
+
+	tests := []struct{ tag, want string }{
+		{"pre", "  code    block\n      "},
+		{"script", "\n      if (a  &&  b) { run(); }\n    "},
+		{"style", "\n      body { margin: 0 }\n    "},
+	}
+	for _, tt := range tests {
+		if got := GetText(GetFirstHtmlNode(doc, tt.tag, "", "")); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+
+	if got := GetFirstHtmlNode(doc, "h1", "", ""); GetText(got) != "Post title" {
+		t.Errorf("h1: got %q, want collapsed whitespace", GetText(got))
+	}
+}
+
+func TestMinifyHtmlNodeOptions(t *testing.T) {
+	const fixture = `<p>a  b<!-- c --><!--[if IE]>ie<![endif]--></p><input type="text" checked="checked">`
+
+	tests := []struct {
+		name string
+		opts MinifyOptions
+		want string
+	}{
+		{"defaults", MinifyOptions{}, `<p>a b</p><input checked="checked"/>`},
+		{"keep whitespace", MinifyOptions{KeepWhitespace: true}, `<p>a  b</p><input checked="checked"/>`},
+		{"keep comments", MinifyOptions{KeepComments: true}, `<p>a b<!-- c --><!--[if IE]>ie<![endif]--></p><input checked="checked"/>`},
+		{"keep conditional comments", MinifyOptions{KeepConditionalComments: true}, `<p>a b<!--[if IE]>ie<![endif]--></p><input checked="checked"/>`},
+		{"keep default attributes", MinifyOptions{KeepDefaultAttrs: true}, `<p>a b</p><input type="text" checked="checked"/>`},
+		{"empty boolean attributes", MinifyOptions{EmptyBooleanAttrs: true}, `<p>a b</p><input checked=""/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, fixture)
+			MinifyHtmlNode(doc, tt.opts)
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveHtmlComments(t *testing.T) {
+	const fixture = `<!-- a --><p>x<!-- b --></p><pre>keep<!-- c --></pre><textarea><!-- d --></textarea>` +
+		`<!--[if IE]><p>ie</p><![endif]--><div><!-- e --><!-- f --></div>`
+
+	tests := []struct {
+		name            string
+		keepConditional bool
+		want            string
+		wantRemoved     int
+	}{
+		{"all", false, `<p>x</p><pre>keep<!-- c --></pre><textarea>&lt;!-- d --&gt;</textarea><div></div>`, 5},
+		{"keep conditional", true, `<p>x</p><pre>keep<!-- c --></pre><textarea>&lt;!-- d --&gt;</textarea><!--[if IE]><p>ie</p><![endif]--><div></div>`, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, fixture)
+
+			if removed := RemoveHtmlComments(doc, tt.keepConditional); removed != tt.wantRemoved {
+				t.Errorf("removed %d comments, want %d", removed, tt.wantRemoved)
+			}
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
+
+func TestRemoveWhitespaceTextNodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		want        string
+		wantRemoved int
+	}{
+		{"between inline elements", `<p><b>a</b> <i>b</i></p>`, `<p><b>a</b> <i>b</i></p>`, 0},
+		{"between text and inline", `<p>a <b>b</b> c</p>`, `<p>a <b>b</b> c</p>`, 0},
+		{"between blocks", "<div>\n  <p>a</p>\n  <p>b</p>\n</div>", `<div><p>a</p><p>b</p></div>`, 3},
+		{"block and inline", "<div>\n<p>a</p> <span>b</span>\n</div>", "<div><p>a</p> <span>b</span>\n</div>", 1},
+		{"pre", "<pre>\n  <b>a</b>\n  <b>b</b>\n</pre>", "<pre>  <b>a</b>\n  <b>b</b>\n</pre>", 0},
+		{"textarea", "<textarea>  </textarea>", "<textarea>  </textarea>", 0},
+		{"list", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>", `<ul><li>a</li><li>b</li></ul>`, 3},
+		{"comments", "<div><!-- a --> <!-- b --></div>", `<div><!-- a --><!-- b --></div>`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+
+			if removed := RemoveWhitespaceTextNodes(GetFirstHtmlNode(doc, "body", "", "")); removed != tt.wantRemoved {
+				t.Errorf("removed %d text nodes, want %d", removed, tt.wantRemoved)
+			}
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package htmlutil
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// UnwrapAllHtmlNodes is a convenience function for UnwrapHtmlNodes() that
+// unwraps all matching HTML nodes.
+func UnwrapAllHtmlNodes(n *html.Node, tag string, attr string, attrValue string) {
+	UnwrapHtmlNodes(n, tag, attr, attrValue, -1)
+}
+
+// UnwrapFirstHtmlNode is a convenience function for UnwrapHtmlNodes() that
+// unwraps the first matching node.
+func UnwrapFirstHtmlNode(n *html.Node, tag string, attr string, attrValue string) {
+	UnwrapHtmlNodes(n, tag, attr, attrValue, 1)
+}
+
+// UnwrapHtmlNodes removes the HTML nodes found within the provided node given a
+// tag, attribute, and attribute value up to the provided count, moving their
+// children into their parent at the position they occupied.
+//
+// The tag, attribute, and attribute value are all optional. If they are empty,
+// they will not be used as search criteria.
+//
+// Matching nodes without a parent are left in place.
+//
+// If the count is -1, all nodes meeting the criteria will be unwrapped.
+func UnwrapHtmlNodes(n *html.Node, tag string, attr string, attrValue string, count int) {
+	nodesToUnwrap := GetHtmlNodes(n, tag, attr, attrValue, count, false)
+
+	// Unwrap nodes in reverse order (so nested matches get unwrapped first)
+	for i := len(nodesToUnwrap) - 1; i >= 0; i-- {
+		unwrapHtmlNode(nodesToUnwrap[i])
+	}
+}
+
+// unwrapHtmlNode replaces n with its children.
+func unwrapHtmlNode(n *html.Node) {
+	if n.Parent == nil {
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = n.FirstChild {
+		n.RemoveChild(c)
+		n.Parent.InsertBefore(c, n)
+	}
+	n.Parent.RemoveChild(n)
+}
+
+// WrapAllHtmlNodes is a convenience function for WrapHtmlNodes() that wraps
+// all matching HTML nodes.
+func WrapAllHtmlNodes(root *html.Node, tag string, attr string, attrValue string, wrapperTag string, wrapperAttrs []html.Attribute) {
+	WrapHtmlNodes(root, tag, attr, attrValue, wrapperTag, wrapperAttrs, -1)
+}
+
+// WrapFirstHtmlNode is a convenience function for WrapHtmlNodes() that wraps
+// the first matching node.
+func WrapFirstHtmlNode(root *html.Node, tag string, attr string, attrValue string, wrapperTag string, wrapperAttrs []html.Attribute) {
+	WrapHtmlNodes(root, tag, attr, attrValue, wrapperTag, wrapperAttrs, 1)
+}
+
+// WrapHtmlNodes wraps the HTML nodes found within the provided node given a
+// tag, attribute, and attribute value up to the provided count in a new
+// element with the provided tag and attributes. Each wrapper takes the
+// position its node had among its former siblings.
+//
+// The tag, attribute, and attribute value are all optional. If they are empty,
+// they will not be used as search criteria. Matches are found before any
+// wrappers are inserted, so wrappers are never wrapped themselves.
+//
+// Matching nodes without a parent are left unwrapped.
+//
+// If the count is -1, all nodes meeting the criteria will be wrapped.
+func WrapHtmlNodes(root *html.Node, tag string, attr string, attrValue string, wrapperTag string, wrapperAttrs []html.Attribute, count int) {
+	for _, n := range GetHtmlNodes(root, tag, attr, attrValue, count, false) {
+		if n.Parent == nil {
+			continue
+		}
+
+		wrapper := NewElement(wrapperTag, nil)
+		wrapper.Attr = append([]html.Attribute(nil), wrapperAttrs...)
+
+		n.Parent.InsertBefore(wrapper, n)
+		n.Parent.RemoveChild(n)
+		wrapper.AppendChild(n)
+	}
+}
+
+// ReplaceAllHtmlNodes is a convenience function for ReplaceHtmlNodes() that
+// replaces all matching HTML nodes.
+func ReplaceAllHtmlNodes(root *html.Node, tag string, attr string, attrValue string, makeReplacement func(old *html.Node) *html.Node) {
+	ReplaceHtmlNodes(root, tag, attr, attrValue, -1, makeReplacement)
+}
+
+// ReplaceFirstHtmlNode is a convenience function for ReplaceHtmlNodes() that
+// replaces the first matching node.
+func ReplaceFirstHtmlNode(root *html.Node, tag string, attr string, attrValue string, makeReplacement func(old *html.Node) *html.Node) {
+	ReplaceHtmlNodes(root, tag, attr, attrValue, 1, makeReplacement)
+}
+
+// ReplaceHtmlNodes replaces the HTML nodes found within the provided node given
+// a tag, attribute, and attribute value up to the provided count with the node
+// returned by makeReplacement. If makeReplacement returns nil, the node is
+// removed.
+//
+// The tag, attribute, and attribute value are all optional. If they are empty,
+// they will not be used as search criteria.
+//
+// If makeReplacement returns a node that is already part of a tree, a copy of
+// it is inserted instead, so the same replacement can safely be returned for
+// every match. Matches nested inside an earlier match are replaced along with
+// it, and makeReplacement is not called for them. Matching nodes without a
+// parent are left in place.
+//
+// If the count is -1, all nodes meeting the criteria will be replaced.
+func ReplaceHtmlNodes(root *html.Node, tag string, attr string, attrValue string, count int, makeReplacement func(old *html.Node) *html.Node) {
+	replaced := make(map[*html.Node]bool)
+
+	for _, old := range GetHtmlNodes(root, tag, attr, attrValue, count, false) {
+		if old.Parent == nil || hasAncestorIn(old, replaced) {
+			continue
+		}
+		replaced[old] = true
+
+		parent := old.Parent
+		if replacement := makeReplacement(old); replacement != nil {
+			if replacement.Parent != nil || replacement.PrevSibling != nil || replacement.NextSibling != nil {
+				replacement = CloneHtmlNode(replacement)
+			}
+			parent.InsertBefore(replacement, old)
+		}
+		parent.RemoveChild(old)
+	}
+}
+
+// hasAncestorIn reports whether any ancestor of n is in the provided set.
+func hasAncestorIn(n *html.Node, set map[*html.Node]bool) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if set[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// CloneHtmlNode returns a deep copy of an HTML node and all of its
+// descendants, including their attributes. The returned node has no parent or
+// siblings, so it can be inserted anywhere, and modifying it does not affect
+// the original.
+func CloneHtmlNode(n *html.Node) *html.Node {
+	if n == nil {
+		return nil
+	}
+
+	clone := shallowCloneHtmlNode(n)
+
+	// Walk the original and the clone in lockstep, without recursion, so
+	// arbitrarily deep trees can't overflow the stack
+	src, dst := n, clone
+	for {
+		if src.FirstChild != nil {
+			src = src.FirstChild
+			dst.AppendChild(shallowCloneHtmlNode(src))
+			dst = dst.LastChild
+			continue
+		}
+
+		for src != n && src.NextSibling == nil {
+			src, dst = src.Parent, dst.Parent
+		}
+		if src == n {
+			return clone
+		}
+
+		src = src.NextSibling
+		dst.Parent.AppendChild(shallowCloneHtmlNode(src))
+		dst = dst.NextSibling
+	}
+}
+
+// shallowCloneHtmlNode returns a copy of n without any of its children.
+func shallowCloneHtmlNode(n *html.Node) *html.Node {
+	return &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      append([]html.Attribute(nil), n.Attr...),
+	}
+}
+
+// SetInnerHTML replaces the children of an HTML node with the nodes parsed
+// from the provided markup. The markup is parsed as a fragment in the context
+// of the node's tag, so content such as table cells or options is kept when
+// the node is a tr or select element.
+//
+// If the markup cannot be parsed, the error is returned and the node is left
+// unchanged. An empty string removes all of the node's children.
+func SetInnerHTML(n *html.Node, markup string) error {
+	children, err := parseFragmentIn(n, markup)
+	if err != nil {
+		return err
+	}
+
+	for c := n.FirstChild; c != nil; c = n.FirstChild {
+		n.RemoveChild(c)
+	}
+	for _, c := range children {
+		n.AppendChild(c)
+	}
+
+	return nil
+}
+
+// parseFragmentIn parses markup as a fragment in the context of the tag of n,
+// or as a document if n is not an element.
+func parseFragmentIn(n *html.Node, markup string) ([]*html.Node, error) {
+	var context *html.Node
+	if n.Type == html.ElementNode {
+		context = newContextNode(n.Data)
+		context.Namespace = n.Namespace
+	}
+
+	return html.ParseFragment(strings.NewReader(markup), context)
+}
+
+// InsertNodeAfter inserts newNode as the next sibling of ref. An error is
+// returned if ref has no parent or newNode is not detached.
+func InsertNodeAfter(ref *html.Node, newNode *html.Node) error {
+	if err := checkInsertion(ref, newNode); err != nil {
+		return err
+	}
+
+	ref.Parent.InsertBefore(newNode, ref.NextSibling)
+	return nil
+}
+
+// PrependChild inserts newNode as the first child of parent. An error is
+// returned if newNode is not detached, or is parent or one of its ancestors.
+func PrependChild(parent *html.Node, newNode *html.Node) error {
+	if parent == nil {
+		return errors.New("htmlutil: cannot prepend a child to a nil node")
+	}
+	if err := checkDetached(newNode); err != nil {
+		return err
+	}
+	if newNode == parent || IsDescendantOf(parent, newNode) {
+		return errors.New("htmlutil: cannot insert a node into itself or its descendants")
+	}
+
+	parent.InsertBefore(newNode, parent.FirstChild)
+	return nil
+}
+
+// InsertHTMLBefore parses markup as a fragment in the context of the parent of
+// ref and inserts the resulting nodes, in order, before ref. An error is
+// returned, and nothing is inserted, if ref has no parent or the markup cannot
+// be parsed.
+func InsertHTMLBefore(ref *html.Node, markup string) error {
+	return insertHTML(ref, markup, ref)
+}
+
+// InsertHTMLAfter is like InsertHTMLBefore() but inserts the nodes after ref.
+func InsertHTMLAfter(ref *html.Node, markup string) error {
+	if ref == nil {
+		return insertHTML(ref, markup, nil)
+	}
+	return insertHTML(ref, markup, ref.NextSibling)
+}
+
+// AppendHTML parses markup as a fragment in the context of parent and appends
+// the resulting nodes, in order, to its children. If the markup cannot be
+// parsed, the error is returned and nothing is appended.
+func AppendHTML(parent *html.Node, markup string) error {
+	children, err := parseFragmentIn(parent, markup)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range children {
+		parent.AppendChild(c)
+	}
+	return nil
+}
+
+// insertHTML parses markup in the context of the parent of ref and inserts
+// the resulting nodes before next, or at the end of the parent if next is
+// nil.
+func insertHTML(ref *html.Node, markup string, next *html.Node) error {
+	if ref == nil || ref.Parent == nil {
+		return errors.New("htmlutil: reference node has no parent")
+	}
+
+	children, err := parseFragmentIn(ref.Parent, markup)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range children {
+		ref.Parent.InsertBefore(c, next)
+	}
+	return nil
+}
+
+// checkInsertion returns an error if newNode cannot be inserted as a sibling
+// of ref.
+func checkInsertion(ref *html.Node, newNode *html.Node) error {
+	if ref == nil || ref.Parent == nil {
+		return errors.New("htmlutil: reference node has no parent")
+	}
+	if err := checkDetached(newNode); err != nil {
+		return err
+	}
+	if newNode == ref.Parent || IsDescendantOf(ref.Parent, newNode) {
+		return errors.New("htmlutil: cannot insert a node into itself or its descendants")
+	}
+	return nil
+}
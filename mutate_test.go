@@ -0,0 +1,513 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// bodyHtml renders the children of the body of a document.
+func bodyHtml(t testing.TB, doc *html.Node) string {
+	t.Helper()
+
+	s, err := HtmlNodeChildrenToString(GetFirstHtmlNode(doc, "body", "", ""))
+	if err != nil {
+		t.Fatalf("HtmlNodeChildrenToString: %v", err)
+	}
+	return s
+}
+
+// checkSiblings fails the test if the parent and sibling pointers of the
+// children of n, or of any of its descendants, are inconsistent.
+func checkSiblings(t testing.TB, n *html.Node) {
+	t.Helper()
+
+	Walk(n, func(n *html.Node) WalkAction {
+		var prev *html.Node
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Parent != n || c.PrevSibling != prev {
+				t.Fatalf("inconsistent pointers on %q under %q", c.Data, n.Data)
+			}
+			prev = c
+		}
+		if n.LastChild != prev {
+			t.Fatalf("inconsistent last child under %q", n.Data)
+		}
+		return WalkContinue
+	})
+}
+
+func TestUnwrapHtmlNodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		tag       string
+		attr      string
+		attrValue string
+		count     int
+		want      string
+	}{
+		{
+			name:  "middle child",
+			doc:   `<p>a <span class="t">b <i>c</i></span> d</p>`,
+			tag:   "span",
+			count: -1,
+			want:  `<p>a b <i>c</i> d</p>`,
+		},
+		{
+			name:  "first and last child",
+			doc:   `<p><font>a</font> b <font>c</font></p>`,
+			tag:   "font",
+			count: -1,
+			want:  `<p>a b c</p>`,
+		},
+		{
+			name:  "nested matches",
+			doc:   `<div><span>a<span>b<span>c</span></span>d</span></div>`,
+			tag:   "span",
+			count: -1,
+			want:  `<div>abcd</div>`,
+		},
+		{
+			name:      "attribute criteria",
+			doc:       `<p><span class="tracking">a</span><span class="keep">b</span></p>`,
+			tag:       "span",
+			attr:      "class",
+			attrValue: "tracking",
+			count:     -1,
+			want:      `<p>a<span class="keep">b</span></p>`,
+		},
+		{
+			name:  "empty element",
+			doc:   `<p>a<span></span>b</p>`,
+			tag:   "span",
+			count: -1,
+			want:  `<p>ab</p>`,
+		},
+		{
+			name:  "count",
+			doc:   `<p><b>a</b><b>b</b><b>c</b></p>`,
+			tag:   "b",
+			count: 2,
+			want:  `<p>ab<b>c</b></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+			UnwrapHtmlNodes(doc, tt.tag, tt.attr, tt.attrValue, tt.count)
+
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
+
+func TestUnwrapHtmlNodesWrappers(t *testing.T) {
+	doc := mustParse(t, `<p><em>a</em><em>b</em></p>`)
+	UnwrapFirstHtmlNode(doc, "em", "", "")
+	if got, want := bodyHtml(t, doc), `<p>a<em>b</em></p>`; got != want {
+		t.Errorf("UnwrapFirstHtmlNode: got %q, want %q", got, want)
+	}
+
+	UnwrapAllHtmlNodes(doc, "em", "", "")
+	if got, want := bodyHtml(t, doc), `<p>ab</p>`; got != want {
+		t.Errorf("UnwrapAllHtmlNodes: got %q, want %q", got, want)
+	}
+	checkSiblings(t, doc)
+}
+
+func TestUnwrapHtmlNodesDetachedRoot(t *testing.T) {
+	span := NewElement("span", nil, NewText("a"))
+
+	UnwrapAllHtmlNodes(span, "span", "", "")
+	if got := mustRender(t, span); got != "<span>a</span>" {
+		t.Errorf("got %q, want the detached root left in place", got)
+	}
+}
+
+func TestReplaceHtmlNodes(t *testing.T) {
+	picture := func(old *html.Node) *html.Node {
+		return NewElement("picture", nil, NewElement("img", map[string]string{"src": GetAttrOr(old, "data-placeholder", "")}))
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		count   int
+		replace func(old *html.Node) *html.Node
+		want    string
+	}{
+		{
+			name:    "first and last child",
+			doc:     `<div><img data-placeholder="a.png">text<img data-placeholder="b.png"></div>`,
+			count:   -1,
+			replace: picture,
+			want:    `<div><picture><img src="a.png"/></picture>text<picture><img src="b.png"/></picture></div>`,
+		},
+		{
+			name:    "only child",
+			doc:     `<p><img data-placeholder="a.png"></p>`,
+			count:   -1,
+			replace: picture,
+			want:    `<p><picture><img src="a.png"/></picture></p>`,
+		},
+		{
+			name:    "nil removes",
+			doc:     `<p>a<img data-placeholder="a.png">b</p>`,
+			count:   -1,
+			replace: func(*html.Node) *html.Node { return nil },
+			want:    `<p>ab</p>`,
+		},
+		{
+			name:    "count",
+			doc:     `<p><img data-placeholder="a.png"><img data-placeholder="b.png"></p>`,
+			count:   1,
+			replace: picture,
+			want:    `<p><picture><img src="a.png"/></picture><img data-placeholder="b.png"/></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+			ReplaceHtmlNodes(doc, "img", "data-placeholder", "", tt.count, tt.replace)
+
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
+
+func TestReplaceHtmlNodesNested(t *testing.T) {
+	doc := mustParse(t, `<div class="x">a<div class="x">b</div></div><div class="x">c</div>`)
+
+	calls := 0
+	ReplaceAllHtmlNodes(doc, "div", "class", "x", func(old *html.Node) *html.Node {
+		calls++
+		return NewElement("section", nil, NewText(GetText(old)))
+	})
+
+	if got, want := bodyHtml(t, doc), `<section>ab</section><section>c</section>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if calls != 2 {
+		t.Errorf("makeReplacement called %d times, want 2: nested matches are replaced with their ancestor", calls)
+	}
+	checkSiblings(t, doc)
+}
+
+func TestReplaceHtmlNodesSharedReplacement(t *testing.T) {
+	doc := mustParse(t, `<p><br><br><br></p>`)
+	hr := NewElement("hr", nil)
+
+	ReplaceAllHtmlNodes(doc, "br", "", "", func(*html.Node) *html.Node { return hr })
+
+	if got, want := bodyHtml(t, doc), `<p><hr/><hr/><hr/></p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	checkSiblings(t, doc)
+}
+
+func TestReplaceFirstHtmlNode(t *testing.T) {
+	doc := mustParse(t, `<p><b>a</b><b>b</b></p>`)
+
+	ReplaceFirstHtmlNode(doc, "b", "", "", func(old *html.Node) *html.Node {
+		return NewElement("strong", nil, NewText(GetText(old)))
+	})
+
+	if got, want := bodyHtml(t, doc), `<p><strong>a</strong><b>b</b></p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCloneHtmlNode(t *testing.T) {
+	doc := mustParse(t, `<!DOCTYPE html><html><head><script>if (a < b) {}</script><style>p > a {}</style></head>`+
+		`<body><div id="a" class="x"><!-- note --><p>text <b>bold</b></p></div></body></html>`)
+	want := mustRender(t, doc)
+
+	clone := CloneHtmlNode(doc)
+	if got := mustRender(t, clone); got != want {
+		t.Errorf("clone renders as %q, want %q", got, want)
+	}
+	if clone.Parent != nil || clone.PrevSibling != nil || clone.NextSibling != nil {
+		t.Error("clone is attached")
+	}
+	checkSiblings(t, clone)
+
+	// No node is shared between the trees
+	originals := make(map[*html.Node]bool)
+	Walk(doc, func(n *html.Node) WalkAction {
+		originals[n] = true
+		return WalkContinue
+	})
+	Walk(clone, func(n *html.Node) WalkAction {
+		if originals[n] {
+			t.Fatalf("node %q is shared with the original", n.Data)
+		}
+		return WalkContinue
+	})
+
+	// Every node type is copied
+	var types []html.NodeType
+	Walk(clone, func(n *html.Node) WalkAction {
+		types = append(types, n.Type)
+		return WalkContinue
+	})
+	for _, typ := range []html.NodeType{html.DocumentNode, html.DoctypeNode, html.ElementNode, html.TextNode, html.CommentNode} {
+		found := false
+		for _, got := range types {
+			found = found || got == typ
+		}
+		if !found {
+			t.Errorf("no node of type %v in the clone", typ)
+		}
+	}
+}
+
+func TestCloneHtmlNodeIsDeep(t *testing.T) {
+	doc := mustParse(t, `<div id="a" class="x"><p title="t">text</p></div>`)
+	div := GetFirstHtmlNode(doc, "div", "", "")
+	want := mustRender(t, div)
+
+	clone := CloneHtmlNode(div)
+	SetHtmlAttr(clone, "id", "b")
+	clone.Attr[1].Val = "y"
+	p := GetFirstHtmlNode(clone, "p", "", "")
+	SetHtmlAttr(p, "title", "changed")
+	p.FirstChild.Data = "changed"
+	clone.AppendChild(NewElement("span", nil))
+
+	if got := mustRender(t, div); got != want {
+		t.Errorf("original changed to %q, want %q", got, want)
+	}
+	if got := mustRender(t, clone); got != `<div id="b" class="y"><p title="changed">changed</p><span></span></div>` {
+		t.Errorf("unexpected clone %q", got)
+	}
+	if div.NextSibling != nil || clone.Parent != nil {
+		t.Error("clone is linked to the original")
+	}
+}
+
+func TestCloneHtmlNodeNil(t *testing.T) {
+	if got := CloneHtmlNode(nil); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestSetInnerHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		tag    string
+		markup string
+		want   string
+	}{
+		{
+			name:   "div",
+			doc:    `<p>before</p><div id="content"><p>old</p></div><p>after</p>`,
+			tag:    "div",
+			markup: `<h2>New</h2>text`,
+			want:   `<p>before</p><div id="content"><h2>New</h2>text</div><p>after</p>`,
+		},
+		{
+			name:   "tr",
+			doc:    `<table><tr><td>old</td></tr></table>`,
+			tag:    "tr",
+			markup: `<td>1</td><td>2</td>`,
+			want:   `<table><tbody><tr><td>1</td><td>2</td></tr></tbody></table>`,
+		},
+		{
+			name:   "td",
+			doc:    `<table><tr><td>old</td></tr></table>`,
+			tag:    "td",
+			markup: `<b>1</b>`,
+			want:   `<table><tbody><tr><td><b>1</b></td></tr></tbody></table>`,
+		},
+		{
+			name:   "select",
+			doc:    `<select><option>old</option></select>`,
+			tag:    "select",
+			markup: `<option value="a">A</option><option value="b">B</option>`,
+			want:   `<select><option value="a">A</option><option value="b">B</option></select>`,
+		},
+		{
+			name:   "empty",
+			doc:    `<div>a<b>b</b></div>`,
+			tag:    "div",
+			markup: "",
+			want:   `<div></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+			n := GetFirstHtmlNode(doc, tt.tag, "", "")
+
+			if err := SetInnerHTML(n, tt.markup); err != nil {
+				t.Fatal(err)
+			}
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
+
+func TestInsertNodeAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{"middle", "a", `<p id="a">a</p><hr/><p id="b">b</p>`},
+		{"last child", "b", `<p id="a">a</p><p id="b">b</p><hr/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, `<p id="a">a</p><p id="b">b</p>`)
+
+			if err := InsertNodeAfter(GetElementById(doc, tt.ref), NewElement("hr", nil)); err != nil {
+				t.Fatal(err)
+			}
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
+
+func TestPrependChild(t *testing.T) {
+	doc := mustParse(t, `<ul><li>b</li></ul><ol></ol>`)
+
+	if err := PrependChild(GetFirstHtmlNode(doc, "ul", "", ""), NewElement("li", nil, NewText("a"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := PrependChild(GetFirstHtmlNode(doc, "ol", "", ""), NewElement("li", nil, NewText("c"))); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bodyHtml(t, doc), `<ul><li>a</li><li>b</li></ul><ol><li>c</li></ol>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	checkSiblings(t, doc)
+}
+
+func TestInsertHTML(t *testing.T) {
+	const article = `<article><p id="p1">1</p><p id="p2">2</p><p id="p3">3</p></article>`
+
+	tests := []struct {
+		name   string
+		doc    string
+		insert func(doc *html.Node) error
+		want   string
+	}{
+		{
+			name: "after the third paragraph",
+			doc:  article,
+			insert: func(doc *html.Node) error {
+				return InsertHTMLAfter(GetAllHtmlNodes(doc, "p", "", "")[2], `<div class="ad"></div><!--ad-->text`)
+			},
+			want: `<article><p id="p1">1</p><p id="p2">2</p><p id="p3">3</p><div class="ad"></div><!--ad-->text</article>`,
+		},
+		{
+			name: "after a middle child",
+			doc:  article,
+			insert: func(doc *html.Node) error {
+				return InsertHTMLAfter(GetElementById(doc, "p1"), `<hr><hr>`)
+			},
+			want: `<article><p id="p1">1</p><hr/><hr/><p id="p2">2</p><p id="p3">3</p></article>`,
+		},
+		{
+			name: "before the first child",
+			doc:  article,
+			insert: func(doc *html.Node) error {
+				return InsertHTMLBefore(GetElementById(doc, "p1"), `<h1>T</h1><em>x</em>`)
+			},
+			want: `<article><h1>T</h1><em>x</em><p id="p1">1</p><p id="p2">2</p><p id="p3">3</p></article>`,
+		},
+		{
+			name: "table context",
+			doc:  `<table><tbody><tr id="r1"><td>1</td></tr></tbody></table>`,
+			insert: func(doc *html.Node) error {
+				return InsertHTMLAfter(GetElementById(doc, "r1"), `<tr><td>2</td></tr><tr><td>3</td></tr>`)
+			},
+			want: `<table><tbody><tr id="r1"><td>1</td></tr><tr><td>2</td></tr><tr><td>3</td></tr></tbody></table>`,
+		},
+		{
+			name: "append",
+			doc:  `<ul><li>a</li></ul>`,
+			insert: func(doc *html.Node) error {
+				return AppendHTML(GetFirstHtmlNode(doc, "ul", "", ""), `<li>b</li><li>c</li>`)
+			},
+			want: `<ul><li>a</li><li>b</li><li>c</li></ul>`,
+		},
+		{
+			name: "append in select",
+			doc:  `<select></select>`,
+			insert: func(doc *html.Node) error {
+				return AppendHTML(GetFirstHtmlNode(doc, "select", "", ""), `<option>a</option><option>b</option>`)
+			},
+			want: `<select><option>a</option><option>b</option></select>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, tt.doc)
+
+			if err := tt.insert(doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := bodyHtml(t, doc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			checkSiblings(t, doc)
+		})
+	}
+}
+
+func TestInsertionErrors(t *testing.T) {
+	doc := mustParse(t, `<div id="outer"><p id="inner">x</p></div>`)
+	inner := GetElementById(doc, "inner")
+	detached := NewElement("span", nil)
+	before := mustRender(t, doc)
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"InsertNodeAfter without parent", InsertNodeAfter(detached, NewElement("b", nil))},
+		{"InsertNodeAfter nil ref", InsertNodeAfter(nil, NewElement("b", nil))},
+		{"InsertNodeAfter attached node", InsertNodeAfter(inner, GetElementById(doc, "outer"))},
+		{"InsertNodeAfter nil node", InsertNodeAfter(inner, nil)},
+		{"PrependChild nil parent", PrependChild(nil, NewElement("b", nil))},
+		{"PrependChild attached node", PrependChild(detached, inner)},
+		{"PrependChild into itself", PrependChild(detached, detached)},
+		{"InsertHTMLBefore without parent", InsertHTMLBefore(detached, `<b>x</b>`)},
+		{"InsertHTMLAfter without parent", InsertHTMLAfter(detached, `<b>x</b>`)},
+		{"InsertHTMLAfter nil ref", InsertHTMLAfter(nil, `<b>x</b>`)},
+	}
+
+	for _, tt := range tests {
+		if tt.err == nil || !strings.HasPrefix(tt.err.Error(), "htmlutil: ") {
+			t.Errorf("%s: got %v, want an htmlutil error", tt.name, tt.err)
+		}
+	}
+
+	if got := mustRender(t, doc); got != before {
+		t.Errorf("document modified: got %s", got)
+	}
+	if detached.FirstChild != nil {
+		t.Errorf("detached node modified: got %s", mustRender(t, detached))
+	}
+}
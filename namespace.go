@@ -0,0 +1,113 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// AnyNamespace can be passed as the namespace to GetHtmlNodesNS() and
+// RemoveHtmlAttrsNS() to match elements in any namespace.
+const AnyNamespace = "*"
+
+// GetAllHtmlNodesNS is a convenience function for GetHtmlNodesNS() that
+// returns all matching HTML nodes.
+func GetAllHtmlNodesNS(n *html.Node, namespace string, tag string, attr string, attrValue string) []*html.Node {
+	return GetHtmlNodesNS(n, namespace, tag, attr, attrValue, -1)
+}
+
+// GetHtmlNodesNS is like GetHtmlNodes() without attribute substrings, but also
+// matches the namespace of elements and attributes, so that an SVG title can
+// be told apart from the document title.
+//
+// The namespace is the namespace of the element as set by the parser, such as
+// "svg" or "math". An empty namespace matches HTML elements only, and
+// AnyNamespace matches elements in any namespace.
+//
+// Namespaced attributes are written as "namespace:key", for example
+// "xlink:href", and an attribute without a prefix only matches attributes
+// without a namespace.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesNS(n *html.Node, namespace string, tag string, attr string, attrValue string, count int) []*html.Node {
+	return GetHtmlNodesFunc(n, func(n *html.Node) bool {
+		return htmlNodeMatchesNS(n, namespace, tag, attr, attrValue)
+	}, count)
+}
+
+// RemoveAllHtmlAttrsNS is a convenience function for RemoveHtmlAttrsNS() that
+// removes all matching attributes.
+func RemoveAllHtmlAttrsNS(n *html.Node, namespace string, tag string, attr string, attrValue string) {
+	RemoveHtmlAttrsNS(n, namespace, tag, attr, attrValue, -1)
+}
+
+// RemoveHtmlAttrsNS is like RemoveHtmlAttrs() but matches namespaces the way
+// GetHtmlNodesNS() does. For example, all xlink:href attributes can be removed
+// with:
+//
+//	RemoveAllHtmlAttrsNS(doc, AnyNamespace, "", "xlink:href", "")
+//
+// As with RemoveHtmlAttrs(), the attribute is required: if it is empty,
+// nothing is removed, and the count bounds the number of attributes removed,
+// not the number of nodes they are removed from.
+//
+// If the count is -1, all attributes meeting the criteria will be removed.
+func RemoveHtmlAttrsNS(n *html.Node, namespace string, tag string, attr string, attrValue string, count int) {
+	if attr == "" {
+		return
+	}
+
+	remaining := count
+
+	Walk(n, func(node *html.Node) WalkAction {
+		if remaining == 0 {
+			return WalkStop
+		}
+		if !htmlNodeMatchesNS(node, namespace, tag, attr, attrValue) {
+			return WalkContinue
+		}
+
+		attrs := node.Attr[:0]
+		for _, a := range node.Attr {
+			if remaining != 0 && qualifiedAttrKey(a) == attr && (attrValue == "" || a.Val == attrValue) {
+				remaining--
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		node.Attr = attrs
+
+		return WalkContinue
+	})
+}
+
+// htmlNodeMatchesNS reports whether n is an element node in the provided
+// namespace matching the provided tag, qualified attribute, and attribute
+// value.
+func htmlNodeMatchesNS(n *html.Node, namespace string, tag string, attr string, attrValue string) bool {
+	if n.Type != html.ElementNode || (namespace != AnyNamespace && n.Namespace != namespace) {
+		return false
+	}
+	if tag != "" && n.Data != tag {
+		return false
+	}
+
+	if attr == "" && attrValue == "" {
+		return true
+	}
+
+	for _, a := range n.Attr {
+		if (attr == "" || qualifiedAttrKey(a) == attr) && (attrValue == "" || a.Val == attrValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// qualifiedAttrKey returns the key of a, prefixed with its namespace and a
+// colon if it has one.
+func qualifiedAttrKey(a html.Attribute) string {
+	if a.Namespace == "" {
+		return a.Key
+	}
+	return a.Namespace + ":" + a.Key
+}
@@ -0,0 +1,156 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const svgFixture = `<html><head><title>Document</title></head><body>` +
+	`<svg id="icons" xmlns:xlink="http://www.w3.org/1999/xlink">` +
+	`<title>Icons</title>` +
+	`<a id="svg-link" xlink:href="/page" href="/plain"><text>Link</text></a>` +
+	`<use id="use1" xlink:href="#icon"></use>` +
+	`<use id="use2" href="#icon"></use>` +
+	`</svg>` +
+	`<math id="formula"><mi id="mi">x</mi></math>` +
+	`<a id="html-link" href="/page">HTML</a>` +
+	`</body></html>`
+
+func TestGetHtmlNodesNS(t *testing.T) {
+	doc := mustParse(t, svgFixture)
+
+	tests := []struct {
+		name                 string
+		namespace            string
+		tag, attr, attrValue string
+		count                int
+		want                 string
+	}{
+		{"html title", "", "title", "", "", -1, "title"},
+		{"svg title", "svg", "title", "", "", -1, "title"},
+		{"any title", AnyNamespace, "title", "", "", -1, "title,title"},
+		{"html anchors", "", "a", "", "", -1, "html-link"},
+		{"svg anchors", "svg", "a", "", "", -1, "svg-link"},
+		{"namespaced attribute", AnyNamespace, "", "xlink:href", "", -1, "svg-link,use1"},
+		{"namespaced attribute value", "svg", "", "xlink:href", "/page", -1, "svg-link"},
+		{"plain attribute", AnyNamespace, "", "href", "", -1, "svg-link,use2,html-link"},
+		{"plain attribute value", AnyNamespace, "", "href", "/page", -1, "html-link"},
+		{"any attribute value", AnyNamespace, "", "", "#icon", -1, "use1,use2"},
+		{"mathml", "math", "", "", "", -1, "formula,mi"},
+		{"count", AnyNamespace, "", "id", "", 2, "icons,svg-link"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeNames(GetHtmlNodesNS(doc, tt.namespace, tt.tag, tt.attr, tt.attrValue, tt.count))
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if tt.count < 0 {
+				all := nodeNames(GetAllHtmlNodesNS(doc, tt.namespace, tt.tag, tt.attr, tt.attrValue))
+				if strings.Join(all, ",") != tt.want {
+					t.Errorf("GetAllHtmlNodesNS: got %q, want %q", all, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveHtmlAttrsNS(t *testing.T) {
+	tests := []struct {
+		name                 string
+		namespace            string
+		tag, attr, attrValue string
+		count                int
+		want                 map[string]string
+	}{
+		{
+			name:      "namespaced attribute",
+			namespace: AnyNamespace,
+			attr:      "xlink:href",
+			count:     -1,
+			want:      map[string]string{"svg-link": "id=svg-link href=/plain", "use1": "id=use1", "use2": "id=use2 href=#icon", "html-link": "id=html-link href=/page"},
+		},
+		{
+			name:      "plain attribute in svg",
+			namespace: "svg",
+			attr:      "href",
+			count:     -1,
+			want:      map[string]string{"svg-link": "id=svg-link xlink:href=/page", "use1": "id=use1 xlink:href=#icon", "use2": "id=use2", "html-link": "id=html-link href=/page"},
+		},
+		{
+			name:  "html only",
+			attr:  "href",
+			count: -1,
+			want:  map[string]string{"svg-link": "id=svg-link xlink:href=/page href=/plain", "use2": "id=use2 href=#icon", "html-link": "id=html-link"},
+		},
+		{
+			name:      "value and count",
+			namespace: AnyNamespace,
+			tag:       "use",
+			attr:      "xlink:href",
+			attrValue: "#icon",
+			count:     1,
+			want:      map[string]string{"use1": "id=use1", "use2": "id=use2 href=#icon"},
+		},
+		{
+			name:      "count across nodes",
+			namespace: "svg",
+			attr:      "href",
+			count:     1,
+			want:      map[string]string{"svg-link": "id=svg-link xlink:href=/page", "use2": "id=use2 href=#icon"},
+		},
+		{
+			name:      "empty attribute",
+			namespace: AnyNamespace,
+			attrValue: "#icon",
+			count:     -1,
+			want:      map[string]string{"use1": "id=use1 xlink:href=#icon", "use2": "id=use2 href=#icon"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustParse(t, svgFixture)
+			RemoveHtmlAttrsNS(doc, tt.namespace, tt.tag, tt.attr, tt.attrValue, tt.count)
+
+			for id, want := range tt.want {
+				n := GetFirstHtmlNodeFunc(doc, func(n *html.Node) bool { return GetAttrOr(n, "id", "") == id })
+				if got := attrString(n); got != want {
+					t.Errorf("%s: got %q, want %q", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveHtmlAttrsNSCountsAttributes(t *testing.T) {
+	// The count bounds the attributes removed, as for RemoveHtmlAttrs(), so
+	// duplicates on one element use up the count
+	doc := mustParse(t, `<svg><use id="a" href="#1" href="#2" href="#3"></use><use id="b" href="#4"></use></svg>`)
+	RemoveHtmlAttrsNS(doc, "svg", "use", "href", "", 2)
+
+	uses := GetAllHtmlNodesNS(doc, "svg", "use", "", "")
+	if got := attrString(uses[0]) + "|" + attrString(uses[1]); got != "id=a href=#3|id=b href=#4" {
+		t.Errorf("got %q", got)
+	}
+
+	RemoveHtmlAttrsNS(doc, "svg", "use", "href", "", 0)
+	if got := len(GetAllHtmlNodesNS(doc, "svg", "", "href", "")); got != 2 {
+		t.Errorf("got %d nodes with href after a count of 0, want 2", got)
+	}
+}
+
+func TestRemoveAllHtmlAttrsNS(t *testing.T) {
+	doc := mustParse(t, svgFixture)
+	RemoveAllHtmlAttrsNS(doc, AnyNamespace, "", "xlink:href", "")
+
+	if got := GetAllHtmlNodesNS(doc, AnyNamespace, "", "xlink:href", ""); len(got) != 0 {
+		t.Errorf("got %q still carrying xlink:href", nodeNames(got))
+	}
+	if got := GetAllHtmlNodesNS(doc, AnyNamespace, "", "href", ""); len(got) != 3 {
+		t.Errorf("got %d nodes with a plain href, want 3", len(got))
+	}
+}
@@ -0,0 +1,182 @@
+package htmlutil
+
+import (
+	"errors"
+
+	"golang.org/x/net/html"
+)
+
+// GetAncestorHtmlNodes returns the ancestors of the provided node matching the
+// provided tag, attribute, and attribute value up to the provided count,
+// starting with the nearest. The node itself is not included.
+//
+// The tag, attribute, and attribute value are all optional. If they are empty,
+// they will not be used as search criteria.
+//
+// If the count is -1, all matching ancestors will be returned.
+func GetAncestorHtmlNodes(n *html.Node, tag string, attr string, attrValue string, count int) []*html.Node {
+	var foundNodes []*html.Node
+
+	if n == nil || count == 0 {
+		return foundNodes
+	}
+
+	m := NewMatcher(tag, attr, attrValue)
+	for p := n.Parent; p != nil; p = p.Parent {
+		if m.Match(p) {
+			foundNodes = append(foundNodes, p)
+			if count >= 0 && len(foundNodes) >= count {
+				break
+			}
+		}
+	}
+
+	return foundNodes
+}
+
+// Closest returns the nearest of the provided node and its ancestors matching
+// the provided tag, attribute, and attribute value, or nil if there is none.
+func Closest(n *html.Node, tag string, attr string, attrValue string) *html.Node {
+	m := NewMatcher(tag, attr, attrValue)
+	for ; n != nil; n = n.Parent {
+		if m.Match(n) {
+			return n
+		}
+	}
+	return nil
+}
+
+// IsDescendantOf reports whether the provided node is a descendant of
+// ancestor. A node is not a descendant of itself.
+func IsDescendantOf(n *html.Node, ancestor *html.Node) bool {
+	if n == nil || ancestor == nil {
+		return false
+	}
+
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// NextElementSibling returns the closest following sibling of the provided
+// node that is an element, skipping text, comment, and doctype nodes. It
+// returns nil if there is none.
+func NextElementSibling(n *html.Node) *html.Node {
+	if n == nil {
+		return nil
+	}
+
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// PrevElementSibling returns the closest preceding sibling of the provided
+// node that is an element, skipping text, comment, and doctype nodes. It
+// returns nil if there is none.
+func PrevElementSibling(n *html.Node) *html.Node {
+	if n == nil {
+		return nil
+	}
+
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// FirstElementChild returns the first child of the provided node that is an
+// element, or nil if there is none.
+func FirstElementChild(n *html.Node) *html.Node {
+	if n == nil {
+		return nil
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// LastElementChild returns the last child of the provided node that is an
+// element, or nil if there is none.
+func LastElementChild(n *html.Node) *html.Node {
+	if n == nil {
+		return nil
+	}
+
+	for c := n.LastChild; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildElements returns the children of the provided node that are elements,
+// in document order.
+func ChildElements(n *html.Node) []*html.Node {
+	children := []*html.Node{}
+	if n == nil {
+		return children
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// GetNodesBetween returns the siblings strictly between start and end, in
+// document order, including text and comment nodes. The result is empty if the
+// markers are adjacent.
+//
+// An error is returned if start and end aren't siblings with the same parent,
+// or if end precedes start.
+func GetNodesBetween(start *html.Node, end *html.Node) ([]*html.Node, error) {
+	nodes := []*html.Node{}
+
+	if start == nil || end == nil || start == end || start.Parent == nil || start.Parent != end.Parent {
+		return nodes, errors.New("htmlutil: markers are not distinct siblings")
+	}
+
+	for c := start.NextSibling; c != end; c = c.NextSibling {
+		if c == nil {
+			return []*html.Node{}, errors.New("htmlutil: end marker precedes start marker")
+		}
+		nodes = append(nodes, c)
+	}
+
+	return nodes, nil
+}
+
+// ExtractNodesBetween is like GetNodesBetween() but also detaches the nodes
+// between start and end and returns them as the children of a new document
+// node, which can be rendered with HtmlNodeToString() or
+// HtmlNodeChildrenToString(). The markers are left in place.
+func ExtractNodesBetween(start *html.Node, end *html.Node) (*html.Node, error) {
+	nodes, err := GetNodesBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	container := &html.Node{Type: html.DocumentNode}
+	for _, c := range nodes {
+		c.Parent.RemoveChild(c)
+		container.AppendChild(c)
+	}
+
+	return container, nil
+}
@@ -0,0 +1,81 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestElementSiblings(t *testing.T) {
+	doc := mustParse(t, "<div id=\"parent\">\n  <!-- first -->\n  <p id=\"a\">a</p>\n  <!-- between -->\n  text\n  <p id=\"b\">b</p><!-- c --><p id=\"c\">c</p>\n  <!-- last -->\n</div>")
+	parent := GetFirstHtmlNode(doc, "div", "", "")
+	a := GetFirstHtmlNode(doc, "", "id", "a")
+	b := GetFirstHtmlNode(doc, "", "id", "b")
+	c := GetFirstHtmlNode(doc, "", "id", "c")
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"next of a", GetAttrOr(NextElementSibling(a), "id", "nil"), "b"},
+		{"next of b", GetAttrOr(NextElementSibling(b), "id", "nil"), "c"},
+		{"next of c", GetAttrOr(NextElementSibling(c), "id", "nil"), "nil"},
+		{"prev of c", GetAttrOr(PrevElementSibling(c), "id", "nil"), "b"},
+		{"prev of b", GetAttrOr(PrevElementSibling(b), "id", "nil"), "a"},
+		{"prev of a", GetAttrOr(PrevElementSibling(a), "id", "nil"), "nil"},
+		{"next of text", GetAttrOr(NextElementSibling(a.NextSibling), "id", "nil"), "b"},
+		{"first child", GetAttrOr(FirstElementChild(parent), "id", "nil"), "a"},
+		{"last child", GetAttrOr(LastElementChild(parent), "id", "nil"), "c"},
+		{"first child of leaf", GetAttrOr(FirstElementChild(a), "id", "nil"), "nil"},
+		{"last child of leaf", GetAttrOr(LastElementChild(a), "id", "nil"), "nil"},
+		{"children", strings.Join(nodeNames(ChildElements(parent)), ","), "a,b,c"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestElementSiblingsDoctype(t *testing.T) {
+	doc := mustParse(t, `<!DOCTYPE html><!-- c --><html></html>`)
+
+	if got := FirstElementChild(doc); got == nil || got.Data != "html" {
+		t.Errorf("FirstElementChild: got %v, want the html element", got)
+	}
+	if got := PrevElementSibling(FirstElementChild(doc)); got != nil {
+		t.Errorf("PrevElementSibling: got %v, want the doctype skipped", got)
+	}
+}
+
+func TestElementSiblingsNil(t *testing.T) {
+	if NextElementSibling(nil) != nil || PrevElementSibling(nil) != nil || FirstElementChild(nil) != nil || LastElementChild(nil) != nil {
+		t.Error("got a node for a nil node")
+	}
+	if got := ChildElements(nil); got == nil || len(got) != 0 {
+		t.Errorf("ChildElements(nil): got %v, want an empty slice", got)
+	}
+
+	text := NewText("a")
+	if got := ChildElements(text); got == nil || len(got) != 0 {
+		t.Errorf("ChildElements(text): got %v, want an empty slice", got)
+	}
+}
+
+func TestDefinitionListNavigation(t *testing.T) {
+	doc := mustParse(t, "<dl>\n<dt>Name</dt>\n<!-- value follows -->\n<dd>Ann</dd>\n<dt>Age</dt>\n<dd>30</dd>\n</dl>")
+
+	var got []string
+	for _, dt := range GetAllHtmlNodes(doc, "dt", "", "") {
+		dd := NextElementSibling(dt)
+		if dd == nil || dd.Data != "dd" {
+			t.Fatalf("no dd after %q", GetText(dt))
+		}
+		got = append(got, GetText(dt)+"="+GetText(dd))
+	}
+
+	if strings.Join(got, ",") != "Name=Ann,Age=30" {
+		t.Errorf("got %q", got)
+	}
+}
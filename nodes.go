@@ -0,0 +1,73 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// FilterNodes returns the nodes for which keep returns true, in order. Nil
+// entries are dropped without being passed to keep.
+func FilterNodes(nodes []*html.Node, keep func(*html.Node) bool) []*html.Node {
+	kept := make([]*html.Node, 0, len(nodes))
+
+	for _, n := range nodes {
+		if n != nil && keep(n) {
+			kept = append(kept, n)
+		}
+	}
+
+	return kept
+}
+
+// PartitionNodes splits the provided nodes into those for which pred returns
+// true and those for which it returns false, preserving their order. Nil
+// entries are dropped without being passed to pred.
+func PartitionNodes(nodes []*html.Node, pred func(*html.Node) bool) (in []*html.Node, out []*html.Node) {
+	in, out = []*html.Node{}, []*html.Node{}
+
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		if pred(n) {
+			in = append(in, n)
+		} else {
+			out = append(out, n)
+		}
+	}
+
+	return in, out
+}
+
+// MapNodes returns the result of f for each of the provided nodes, in order,
+// for example to extract their text with GetNormalizedText() or an attribute
+// with a function wrapping GetAttrOr(). Nil entries are dropped without being
+// passed to f.
+func MapNodes(nodes []*html.Node, f func(*html.Node) string) []string {
+	values := make([]string, 0, len(nodes))
+
+	for _, n := range nodes {
+		if n != nil {
+			values = append(values, f(n))
+		}
+	}
+
+	return values
+}
+
+// UniqueNodes returns the provided nodes without duplicates, keeping the first
+// occurrence of each node, in order. Nil entries are dropped. This is useful
+// when combining the results of several queries that can match the same
+// nodes.
+func UniqueNodes(nodes []*html.Node) []*html.Node {
+	unique := make([]*html.Node, 0, len(nodes))
+	seen := make(map[*html.Node]bool, len(nodes))
+
+	for _, n := range nodes {
+		if n != nil && !seen[n] {
+			seen[n] = true
+			unique = append(unique, n)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,97 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// nodesDoc is a list of links, one of them external and one without an href.
+const nodesDoc = `<ul><li><a href="/a">A</a></li><li><a href="https://example.com/b" rel="external">  B  </a></li>` +
+	`<li><a href="/c">C</a></li><li><a>D</a></li></ul>`
+
+func isExternal(n *html.Node) bool {
+	return GetAttrOr(n, "rel", "") == "external"
+}
+
+func TestFilterNodes(t *testing.T) {
+	links := GetAllHtmlNodes(mustParse(t, nodesDoc), "a", "", "")
+
+	got := FilterNodes(links, func(n *html.Node) bool {
+		_, ok := GetAttr(n, "href")
+		return ok && !isExternal(n)
+	})
+	if strings.Join(MapNodes(got, GetText), ",") != "A,C" {
+		t.Errorf("got %q", MapNodes(got, GetText))
+	}
+
+	// Nil entries are dropped without being passed to keep
+	got = FilterNodes([]*html.Node{nil, links[0], nil}, func(n *html.Node) bool { return n.Data == "a" })
+	if len(got) != 1 || got[0] != links[0] {
+		t.Errorf("got %v", got)
+	}
+
+	if got := FilterNodes(nil, isExternal); got == nil || len(got) != 0 {
+		t.Errorf("nil slice: got %#v, want an empty slice", got)
+	}
+}
+
+func TestPartitionNodes(t *testing.T) {
+	links := GetAllHtmlNodes(mustParse(t, nodesDoc), "a", "", "")
+
+	in, out := PartitionNodes(append(links, nil), isExternal)
+	if got := strings.Join(MapNodes(in, GetNormalizedText), ","); got != "B" {
+		t.Errorf("in: got %q", got)
+	}
+	if got := strings.Join(MapNodes(out, GetText), ","); got != "A,C,D" {
+		t.Errorf("out: got %q", got)
+	}
+
+	in, out = PartitionNodes(nil, isExternal)
+	if in == nil || out == nil || len(in)+len(out) != 0 {
+		t.Errorf("nil slice: got %#v, %#v, want empty slices", in, out)
+	}
+}
+
+func TestMapNodes(t *testing.T) {
+	links := GetAllHtmlNodes(mustParse(t, nodesDoc), "a", "", "")
+
+	tests := []struct {
+		name  string
+		nodes []*html.Node
+		f     func(*html.Node) string
+		want  []string
+	}{
+		{"text", links, GetText, []string{"A", "  B  ", "C", "D"}},
+		{"normalized text", links, GetNormalizedText, []string{"A", "B", "C", "D"}},
+		{"attribute", links, func(n *html.Node) string { return GetAttrOr(n, "href", "") }, []string{"/a", "https://example.com/b", "/c", ""}},
+		{"nil entries", []*html.Node{nil, links[2], nil}, GetText, []string{"C"}},
+		{"nil slice", nil, GetText, []string{}},
+	}
+
+	for _, tt := range tests {
+		got := MapNodes(tt.nodes, tt.f)
+		if got == nil || strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("%s: got %#v, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueNodes(t *testing.T) {
+	doc := mustParse(t, nodesDoc)
+
+	// Combining overlapping queries, as the links are both matched by their
+	// tag and by their attributes
+	combined := append(GetAllHtmlNodes(doc, "a", "href", ""), GetAllHtmlNodes(doc, "a", "", "")...)
+	combined = append(combined, nil, combined[1])
+
+	got := UniqueNodes(combined)
+	if s := strings.Join(MapNodes(got, GetNormalizedText), ","); s != "A,B,C,D" {
+		t.Errorf("got %q, want the first occurrence of each node in order", s)
+	}
+
+	if got := UniqueNodes(nil); got == nil || len(got) != 0 {
+		t.Errorf("nil slice: got %#v, want an empty slice", got)
+	}
+}
@@ -0,0 +1,43 @@
+package htmlutil
+
+import (
+	"cmp"
+	"slices"
+
+	"golang.org/x/net/html"
+)
+
+// NormalizeOptions controls the changes made by NormalizeHtmlNodeWithOptions().
+type NormalizeOptions struct {
+	// SortAttrs sorts the attributes of each element by namespace and key, so
+	// that the rendered output does not depend on the order in which
+	// attributes were set. Attributes with the same namespace and key, as
+	// parsed from markup with duplicate attributes, keep their order.
+	SortAttrs bool
+}
+
+// NormalizeHtmlNode is a convenience function for
+// NormalizeHtmlNodeWithOptions() that leaves attributes in their order.
+func NormalizeHtmlNode(n *html.Node) {
+	NormalizeHtmlNodeWithOptions(n, NormalizeOptions{})
+}
+
+// NormalizeHtmlNodeWithOptions puts the provided node and its descendants in
+// normal form in place, like the DOM's Node.normalize(): zero-length text
+// nodes are removed and adjacent text nodes are merged into one. The text of
+// merged nodes is concatenated as is, so the text content of the tree is
+// unchanged.
+func NormalizeHtmlNodeWithOptions(n *html.Node, opts NormalizeOptions) {
+	Walk(n, func(n *html.Node) WalkAction {
+		if opts.SortAttrs && n.Type == html.ElementNode {
+			slices.SortStableFunc(n.Attr, func(a, b html.Attribute) int {
+				return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Key, b.Key))
+			})
+		}
+
+		// Normalize the children before the walk reads them, as
+		// MergeTextNodes() does
+		mergeTextChildren(n, true)
+		return WalkContinue
+	})
+}
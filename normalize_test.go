@@ -0,0 +1,104 @@
+package htmlutil
+
+import (
+	"math/rand"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestNormalizeHtmlNode(t *testing.T) {
+	doc := mustParse(t, `<p id="p" b="2" a="1">a</p>`)
+	p := GetFirstHtmlNode(doc, "p", "", "")
+	p.AppendChild(NewText(""))
+	p.AppendChild(NewText("b"))
+	p.AppendChild(NewElement("br", nil))
+	p.AppendChild(NewText(""))
+	p.AppendChild(NewElement("i", nil, NewText(""), NewText("c "), NewText(" d")))
+	p.AppendChild(NewText("e"))
+	p.AppendChild(NewText("&lt;f"))
+
+	NormalizeHtmlNode(doc)
+	checkSiblings(t, doc)
+
+	var texts []string
+	Walk(doc, func(n *html.Node) WalkAction {
+		if n.Type == html.TextNode {
+			texts = append(texts, n.Data)
+		}
+		return WalkContinue
+	})
+	if len(texts) != 3 || texts[0] != "ab" || texts[1] != "c  d" || texts[2] != "e&lt;f" {
+		t.Errorf("got text nodes %q", texts)
+	}
+	if got := attrString(p); got != "id=p b=2 a=1" {
+		t.Errorf("got attributes %q, want them in their order", got)
+	}
+}
+
+func TestNormalizeHtmlNodeSortAttrs(t *testing.T) {
+	doc := mustParse(t, `<p id="p" b="2" a="1" b="3"><svg><use xlink:href="#x" href="#y" class="c"></use></svg></p>`)
+
+	NormalizeHtmlNodeWithOptions(doc, NormalizeOptions{SortAttrs: true})
+
+	if got := attrString(GetFirstHtmlNode(doc, "p", "", "")); got != "a=1 b=2 b=3 id=p" {
+		t.Errorf("got %q, want duplicates kept in their order", got)
+	}
+	use := GetFirstHtmlNode(doc, "use", "", "")
+	if got := mustRender(t, use); got != `<use class="c" href="#y" xlink:href="#x"></use>` {
+		t.Errorf("got %q, want attributes without a namespace first", got)
+	}
+}
+
+// splitTextNodes splits the text nodes of a tree at random offsets, and adds
+// empty text nodes at random, as mutations of the tree do.
+func splitTextNodes(r *rand.Rand, doc *html.Node) {
+	var nodes []*html.Node
+	Walk(doc, func(n *html.Node) WalkAction { nodes = append(nodes, n); return WalkContinue })
+
+	for _, n := range nodes {
+		if n.Type == html.TextNode && len(n.Data) > 1 && r.Intn(2) == 0 {
+			i := 1 + r.Intn(len(n.Data)-1)
+			n.Parent.InsertBefore(NewText(n.Data[:i]), n)
+			n.Data = n.Data[i:]
+		}
+		if n.Parent != nil && r.Intn(5) == 0 {
+			n.Parent.InsertBefore(NewText(""), n.NextSibling)
+		}
+		if n.Type == html.ElementNode && !voidElements[n.Data] && r.Intn(5) == 0 {
+			n.AppendChild(NewText(""))
+		}
+	}
+}
+
+func TestNormalizeHtmlNodeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		doc := randomTree(r)
+		splitTextNodes(r, doc)
+		text, rendered := GetText(doc), mustRender(t, doc)
+
+		NormalizeHtmlNodeWithOptions(doc, NormalizeOptions{SortAttrs: i%2 == 0})
+		checkSiblings(t, doc)
+
+		if got := GetText(doc); got != text {
+			t.Fatalf("tree %d: got text %q, want %q", i, got, text)
+		}
+		if got := mustRender(t, doc); got != rendered {
+			t.Fatalf("tree %d: got  %s\nwant %s", i, got, rendered)
+		}
+		Walk(doc, func(n *html.Node) WalkAction {
+			if n.Type != html.TextNode {
+				return WalkContinue
+			}
+			if n.Data == "" {
+				t.Fatalf("tree %d: empty text node left", i)
+			}
+			if n.NextSibling != nil && n.NextSibling.Type == html.TextNode {
+				t.Fatalf("tree %d: adjacent text nodes %q and %q left", i, n.Data, n.NextSibling.Data)
+			}
+			return WalkContinue
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package htmlutil
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ExtractNoscriptContent returns the content of the noscript elements within
+// the provided document, as the detached nodes a browser with scripting
+// disabled would create, in document order.
+//
+// html.Parse() enables scripting, so the content of noscript elements is kept
+// as text. It is parsed again as a fragment in the context of the parent of
+// each noscript element. A noscript element in head may only hold link, meta,
+// and style elements: browsers move the content starting at the first other
+// element to the body, and it is left out. If the document was parsed with
+// scripting disabled, copies of the children of the noscript elements are
+// returned instead.
+//
+// Noscript elements whose content cannot be parsed are skipped, and the parse
+// errors are returned joined together once the whole document has been
+// processed.
+func ExtractNoscriptContent(doc *html.Node) ([]*html.Node, error) {
+	nodes := []*html.Node{}
+	var errs []error
+
+	for _, noscript := range GetAllHtmlNodesFunc(doc, isNoscript) {
+		content, err := noscriptContent(noscript)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		nodes = append(nodes, content...)
+	}
+
+	return nodes, errors.Join(errs...)
+}
+
+// PromoteNoscriptImages replaces the lazy-loaded img elements within the
+// provided document with the fallback image held by a noscript element that
+// immediately follows or precedes them, ignoring whitespace, and removes the
+// noscript elements. It returns the number of images replaced.
+//
+// An image is lazy-loaded if it has no src, a data: URL placeholder as src,
+// or a data-src or data-srcset attribute. The noscript element must contain a
+// single img element and nothing else but whitespace and comments.
+func PromoteNoscriptImages(doc *html.Node) int {
+	promoted := 0
+
+	for _, noscript := range GetAllHtmlNodesFunc(doc, isNoscript) {
+		fallback := noscriptImage(noscript)
+		if fallback == nil {
+			continue
+		}
+
+		for _, sibling := range []*html.Node{siblingElement(noscript, false), siblingElement(noscript, true)} {
+			if sibling != nil && isHtmlElement(sibling, "img") && isLazyImage(sibling) {
+				sibling.Parent.InsertBefore(fallback, sibling)
+				sibling.Parent.RemoveChild(sibling)
+				noscript.Parent.RemoveChild(noscript)
+				promoted++
+				break
+			}
+		}
+	}
+
+	return promoted
+}
+
+// noscriptContent returns the detached nodes parsed from the content of a
+// noscript element, as described by ExtractNoscriptContent().
+func noscriptContent(noscript *html.Node) ([]*html.Node, error) {
+	var nodes []*html.Node
+
+	if noscript.FirstChild != nil && (noscript.FirstChild.Type != html.TextNode || noscript.FirstChild.NextSibling != nil) {
+		for c := noscript.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, CloneHtmlNode(c))
+		}
+	} else if noscript.Parent != nil {
+		var err error
+		if nodes, err = parseFragmentIn(noscript.Parent, GetText(noscript)); err != nil {
+			return nil, err
+		}
+	}
+
+	if noscript.Parent == nil || !isHtmlElement(noscript.Parent, "head") {
+		return nodes, nil
+	}
+
+	var inHead []*html.Node
+	for _, n := range nodes {
+		switch {
+		case n.Type == html.CommentNode,
+			n.Type == html.TextNode && strings.TrimFunc(n.Data, isHtmlSpace) == "",
+			isHtmlElement(n, "link", "meta", "style"):
+			inHead = append(inHead, n)
+		}
+	}
+	return inHead, nil
+}
+
+// noscriptImage returns the img element that is the only content of a
+// noscript element, or nil if there is none.
+func noscriptImage(noscript *html.Node) *html.Node {
+	content, err := noscriptContent(noscript)
+	if err != nil {
+		return nil
+	}
+
+	var img *html.Node
+	for _, n := range content {
+		switch {
+		case n.Type == html.CommentNode,
+			n.Type == html.TextNode && strings.TrimFunc(n.Data, isHtmlSpace) == "":
+		case img == nil && isHtmlElement(n, "img"):
+			img = n
+		default:
+			return nil
+		}
+	}
+	return img
+}
+
+// siblingElement returns the next or previous sibling of n, skipping
+// whitespace-only text nodes, if it is an element, or nil otherwise.
+func siblingElement(n *html.Node, next bool) *html.Node {
+	for {
+		if next {
+			n = n.NextSibling
+		} else {
+			n = n.PrevSibling
+		}
+
+		switch {
+		case n == nil:
+			return nil
+		case n.Type == html.ElementNode:
+			return n
+		case n.Type != html.TextNode || strings.TrimFunc(n.Data, isHtmlSpace) != "":
+			return nil
+		}
+	}
+}
+
+// isLazyImage reports whether img is loaded by a lazy-loading script.
+func isLazyImage(img *html.Node) bool {
+	if _, ok := GetAttr(img, "data-src"); ok {
+		return true
+	}
+	if _, ok := GetAttr(img, "data-srcset"); ok {
+		return true
+	}
+
+	src := GetAttrOr(img, "src", "")
+	return strings.TrimSpace(src) == "" || urlSchemeIs(src, "data")
+}
+
+// isNoscript reports whether n is a noscript element.
+func isNoscript(n *html.Node) bool {
+	return isHtmlElement(n, "noscript")
+}
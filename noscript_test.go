@@ -0,0 +1,181 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// noscriptDoc has noscript elements in head, holding both content allowed in
+// head and content browsers move to the body, and in body.
+const noscriptDoc = `<!DOCTYPE html><html><head><title>T</title>` +
+	`<noscript><link rel="stylesheet" href="/noscript.css"><!-- c --><meta name="x" content="y"><style>.lazy { display: none }</style>` +
+	`<p>moved</p><link rel="stylesheet" href="/after.css"></noscript></head><body>` +
+	`<p>Text<noscript><img src="/a.png" alt="A"><p>block</p></noscript></p>` +
+	`<div><noscript><iframe src="https://example.com/tag"></iframe></noscript></div>` +
+	`<table><tr><td><noscript><b>cell</b></noscript></td></tr></table></body></html>`
+
+// renderNodes renders each node and joins the results with "|".
+func renderNodes(t *testing.T, nodes []*html.Node) string {
+	t.Helper()
+
+	var s []string
+	for _, n := range nodes {
+		s = append(s, mustRender(t, n))
+	}
+	return strings.Join(s, "|")
+}
+
+func TestExtractNoscriptContent(t *testing.T) {
+	want := `<link rel="stylesheet" href="/noscript.css"/>|<!-- c -->|<meta name="x" content="y"/>|<style>.lazy { display: none }</style>|` +
+		`<img src="/a.png" alt="A"/>|<p>block</p>|` +
+		`<iframe src="https://example.com/tag"></iframe>|` +
+		`<b>cell</b>`
+
+	doc := mustParse(t, noscriptDoc)
+	if noscript := GetFirstHtmlNode(doc, "noscript", "", ""); noscript.FirstChild == nil || noscript.FirstChild.Type != html.TextNode {
+		t.Fatal("noscript content was parsed as elements")
+	}
+
+	nodes, err := ExtractNoscriptContent(doc)
+	if err != nil {
+		t.Fatalf("ExtractNoscriptContent: %v", err)
+	}
+	if got := renderNodes(t, nodes); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	for _, n := range nodes {
+		if n.Parent != nil || n.PrevSibling != nil || n.NextSibling != nil {
+			t.Errorf("%s is not detached", mustRender(t, n))
+		}
+	}
+
+	// The document is left untouched
+	if got := mustRender(t, doc); !strings.Contains(got, `<noscript><img src="/a.png" alt="A"><p>block</p></noscript>`) {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestExtractNoscriptContentScriptingDisabled(t *testing.T) {
+	doc, err := html.ParseWithOptions(strings.NewReader(noscriptDoc), html.ParseOptionEnableScripting(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := ExtractNoscriptContent(doc)
+	if err != nil {
+		t.Fatalf("ExtractNoscriptContent: %v", err)
+	}
+
+	// The parser already moved the content not allowed in head to the body
+	want := `<link rel="stylesheet" href="/noscript.css"/>|<!-- c -->|<meta name="x" content="y"/>|<style>.lazy { display: none }</style>|` +
+		`<img src="/a.png" alt="A"/>|<iframe src="https://example.com/tag"></iframe>|<b>cell</b>`
+	if got := renderNodes(t, nodes); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestExtractNoscriptContentHeadOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"allowed", `<head><noscript><meta http-equiv="refresh" content="0; url=/basic"></noscript></head>`, `<meta http-equiv="refresh" content="0; url=/basic"/>`},
+		{"image", `<head><noscript><img src="/pixel.gif"></noscript></head>`, ``},
+		{"text", `<head><noscript>Enable JavaScript<link href="/a.css"></noscript></head>`, ``},
+		{"empty", `<head><noscript></noscript></head>`, ``},
+	}
+
+	for _, tt := range tests {
+		nodes, err := ExtractNoscriptContent(mustParse(t, "<html>"+tt.doc+"<body></body></html>"))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := renderNodes(t, nodes); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPromoteNoscriptImages(t *testing.T) {
+	tests := []struct {
+		name         string
+		doc          string
+		want         string
+		wantPromoted int
+	}{
+		{
+			name:         "data-src",
+			doc:          `<img class="lazy" data-src="/a.png"><noscript><img src="/a.png" alt="A"></noscript>`,
+			want:         `<img src="/a.png" alt="A"/>`,
+			wantPromoted: 1,
+		},
+		{
+			name:         "placeholder before",
+			doc:          "<figure><noscript>\n<img src=\"/b.png\"><!-- fallback -->\n</noscript>\n<img src=\"data:image/gif;base64,R0lGOD\"></figure>",
+			want:         "<figure>\n<img src=\"/b.png\"/></figure>",
+			wantPromoted: 1,
+		},
+		{
+			name:         "no src",
+			doc:          `<p><img data-srcset="/c.png 2x"> <noscript><img src="/c.png"></noscript></p>`,
+			want:         `<p><img src="/c.png"/> </p>`,
+			wantPromoted: 1,
+		},
+		{
+			name: "image not lazy",
+			doc:  `<img src="/d.png"><noscript><img src="/d-large.png"></noscript>`,
+			want: `<img src="/d.png"/><noscript><img src="/d-large.png"></noscript>`,
+		},
+		{
+			name: "not adjacent",
+			doc:  `<img data-src="/e.png"><span>x</span><noscript><img src="/e.png"></noscript>`,
+			want: `<img data-src="/e.png"/><span>x</span><noscript><img src="/e.png"></noscript>`,
+		},
+		{
+			name: "text between",
+			doc:  `<img data-src="/e.png">caption<noscript><img src="/e.png"></noscript>`,
+			want: `<img data-src="/e.png"/>caption<noscript><img src="/e.png"></noscript>`,
+		},
+		{
+			name: "more than an image",
+			doc:  `<img data-src="/f.png"><noscript><img src="/f.png"><p>Enable JavaScript</p></noscript>`,
+			want: `<img data-src="/f.png"/><noscript><img src="/f.png"><p>Enable JavaScript</p></noscript>`,
+		},
+		{
+			name:         "several images",
+			doc:          `<img data-src="/1.png"><noscript><img src="/1.png"></noscript><img data-src="/2.png"><noscript><img src="/2.png"></noscript>`,
+			want:         `<img src="/1.png"/><img src="/2.png"/>`,
+			wantPromoted: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, tt.doc)
+
+		if got := PromoteNoscriptImages(doc); got != tt.wantPromoted {
+			t.Errorf("%s: got %d promoted, want %d", tt.name, got, tt.wantPromoted)
+		}
+		if got := bodyHtml(t, doc); got != tt.want {
+			t.Errorf("%s: got  %q\nwant %q", tt.name, got, tt.want)
+		}
+		checkSiblings(t, doc)
+	}
+}
+
+func TestPromoteNoscriptImagesInHead(t *testing.T) {
+	// An image isn't allowed in a noscript element in head, so there is no
+	// fallback to promote
+	doc := mustParse(t, `<html><head><noscript><img src="/pixel.gif"></noscript></head><body><img data-src="/a.png"></body></html>`)
+	before := mustRender(t, doc)
+
+	if got := PromoteNoscriptImages(doc); got != 0 {
+		t.Errorf("got %d promoted", got)
+	}
+	if got := mustRender(t, doc); got != before {
+		t.Errorf("got %s, want the document unchanged", got)
+	}
+}
@@ -0,0 +1,80 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// NthChildElement returns the nth child element of the provided parent,
+// counting from 1, or nil if there is none. If n is negative, children are
+// counted from the end, so -1 returns the last child element. Text, comment,
+// and doctype nodes are not counted.
+func NthChildElement(parent *html.Node, n int) *html.Node {
+	if parent == nil || n == 0 {
+		return nil
+	}
+
+	if n > 0 {
+		for c := FirstElementChild(parent); c != nil; c = NextElementSibling(c) {
+			if n--; n == 0 {
+				return c
+			}
+		}
+		return nil
+	}
+
+	for c := LastElementChild(parent); c != nil; c = PrevElementSibling(c) {
+		if n++; n == 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+// FilterNthOfType returns the provided nodes that are the nth element of
+// their type among their siblings, like the CSS :nth-of-type() pseudo-class,
+// counting from 1. If n is negative, siblings are counted from the end, like
+// :nth-last-of-type(), so -1 keeps the nodes that are the last of their type.
+// Elements have the same type if they have the same tag and namespace.
+func FilterNthOfType(nodes []*html.Node, n int) []*html.Node {
+	filtered := []*html.Node{}
+
+	for _, node := range nodes {
+		if node.Type == html.ElementNode && n != 0 && positionOfType(node, n < 0) == abs(n) {
+			filtered = append(filtered, node)
+		}
+	}
+
+	return filtered
+}
+
+// GetHtmlNodesNthOfType returns, for each parent within the provided root,
+// its nth child element with the provided tag, using the same counting as
+// FilterNthOfType(). For example, GetHtmlNodesNthOfType(table, "td", 2)
+// returns the second cell of every row.
+func GetHtmlNodesNthOfType(root *html.Node, tag string, n int) []*html.Node {
+	return FilterNthOfType(GetAllHtmlNodes(root, tag, "", ""), n)
+}
+
+// positionOfType returns the 1-based position of n among its sibling elements
+// with the same tag and namespace, counting from the end if fromEnd is true.
+func positionOfType(n *html.Node, fromEnd bool) int {
+	next := PrevElementSibling
+	if fromEnd {
+		next = NextElementSibling
+	}
+
+	pos := 1
+	for s := next(n); s != nil; s = next(s) {
+		if s.Data == n.Data && s.Namespace == n.Namespace {
+			pos++
+		}
+	}
+	return pos
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,212 @@
+package htmlutil
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Pagination holds the URLs of the previous and next pages of a paginated
+// document.
+type Pagination struct {
+	// Next and Prev are the URLs of the next and previous pages, or nil if
+	// they weren't found.
+	Next *url.URL
+	Prev *url.URL
+
+	// NextCandidates and PrevCandidates are the anchors considered by the
+	// heuristics, best first. They are empty if heuristics are disabled.
+	NextCandidates []PaginationCandidate
+	PrevCandidates []PaginationCandidate
+}
+
+// PaginationCandidate is an anchor scored by the pagination heuristics.
+type PaginationCandidate struct {
+	URL   *url.URL
+	Score int
+
+	// Reasons describe what contributed to the score.
+	Reasons []string
+
+	Node *html.Node
+}
+
+// PaginationOptions controls how FindPaginationWithOptions() finds the
+// previous and next pages.
+type PaginationOptions struct {
+	// Heuristics enables scoring anchors when the document has no rel="next"
+	// or rel="prev" link.
+	Heuristics bool
+
+	// NextPatterns and PrevPatterns are the texts of anchors, compared
+	// case-insensitively with their normalized text, that point to the next
+	// and previous pages.
+	NextPatterns []string
+	PrevPatterns []string
+
+	// MinScore is the score a candidate needs to be chosen.
+	MinScore int
+}
+
+// DefaultPaginationOptions returns PaginationOptions with heuristics enabled
+// and English patterns such as "next" or "older posts".
+func DefaultPaginationOptions() PaginationOptions {
+	return PaginationOptions{
+		Heuristics:   true,
+		NextPatterns: []string{"next", "next page", "next »", "›", "»", ">", "older", "older posts", "older entries"},
+		PrevPatterns: []string{"prev", "previous", "previous page", "« previous", "‹", "«", "<", "newer", "newer posts", "newer entries"},
+		MinScore:     40,
+	}
+}
+
+// FindPagination is a convenience function for FindPaginationWithOptions()
+// using DefaultPaginationOptions().
+func FindPagination(doc *html.Node, base *url.URL) Pagination {
+	return FindPaginationWithOptions(doc, base, DefaultPaginationOptions())
+}
+
+// FindPaginationWithOptions returns the previous and next pages of the
+// provided document, whose URL is base.
+//
+// The href of the first link or anchor element with rel="next" or rel="prev"
+// (or rel="previous") is used when there is one. Otherwise, if heuristics are
+// enabled, the anchors pointing to the host of base are scored: matching one
+// of the patterns of opts, having a class or id containing "next" or "prev",
+// and having an href that differs from base only by a numeric component
+// incremented or decremented by one, as in "/page/2" and "/page/3", all add
+// to the score. The best candidate with at least opts.MinScore is chosen.
+func FindPaginationWithOptions(doc *html.Node, base *url.URL, opts PaginationOptions) Pagination {
+	var p Pagination
+	docBase, _ := documentBaseURL(doc, base)
+
+	for _, n := range GetAllHtmlNodesFunc(doc, func(n *html.Node) bool {
+		return isHtmlElement(n, "link", "a", "area") && GetAttrOr(n, "href", "") != ""
+	}) {
+		u, ok := linkURL(n, docBase)
+		if !ok {
+			continue
+		}
+		if p.Next == nil && hasRelToken(n, "next") {
+			p.Next = u
+		}
+		if p.Prev == nil && (hasRelToken(n, "prev") || hasRelToken(n, "previous")) {
+			p.Prev = u
+		}
+	}
+
+	if !opts.Heuristics {
+		return p
+	}
+
+	p.NextCandidates = paginationCandidates(doc, base, docBase, opts.NextPatterns, "next", 1)
+	p.PrevCandidates = paginationCandidates(doc, base, docBase, opts.PrevPatterns, "prev", -1)
+
+	if p.Next == nil && len(p.NextCandidates) > 0 && p.NextCandidates[0].Score >= opts.MinScore {
+		p.Next = p.NextCandidates[0].URL
+	}
+	if p.Prev == nil && len(p.PrevCandidates) > 0 && p.PrevCandidates[0].Score >= opts.MinScore {
+		p.Prev = p.PrevCandidates[0].URL
+	}
+
+	return p
+}
+
+// paginationCandidates scores the anchors of doc as links to the page in the
+// provided direction, 1 for the next page and -1 for the previous one, and
+// returns those with a positive score, best first.
+func paginationCandidates(doc *html.Node, base *url.URL, docBase *url.URL, patterns []string, name string, direction int) []PaginationCandidate {
+	candidates := []PaginationCandidate{}
+
+	for _, a := range GetAllHtmlNodesNS(doc, "", "a", "href", "") {
+		u, ok := linkURL(a, docBase)
+		if !ok || (base != nil && !strings.EqualFold(u.Hostname(), base.Hostname())) {
+			continue
+		}
+
+		c := PaginationCandidate{URL: u, Node: a}
+
+		text := strings.ToLower(GetNormalizedText(a))
+		for _, pattern := range patterns {
+			if text != "" && text == strings.ToLower(pattern) {
+				c.Score += 50
+				c.Reasons = append(c.Reasons, fmt.Sprintf("text matches %q", pattern))
+				break
+			}
+		}
+
+		if attrContainsAny(a, []string{"class", "id"}, []string{name}) {
+			c.Score += 20
+			c.Reasons = append(c.Reasons, fmt.Sprintf("class or id contains %q", name))
+		}
+
+		if base != nil && pageNumberStep(base, u) == direction {
+			c.Score += 40
+			if direction > 0 {
+				c.Reasons = append(c.Reasons, "increments the page number")
+			} else {
+				c.Reasons = append(c.Reasons, "decrements the page number")
+			}
+		}
+
+		if c.Score > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates
+}
+
+var (
+	digitsPattern    = regexp.MustCompile(`[0-9]+`)
+	pageParamPattern = regexp.MustCompile(`(?i)^[/?&]?(page|p|pg)?[=/-]?$`)
+)
+
+// pageNumberStep returns the difference between the numeric components of u
+// and base if their URLs, without fragments, only differ by one numeric
+// component, and 0 otherwise. A URL without numbers is treated as page 1 of
+// a URL with one more numeric component equal to 2.
+func pageNumberStep(base *url.URL, u *url.URL) int {
+	from, to := *base, *u
+	from.Fragment, to.Fragment = "", ""
+	a, b := from.String(), to.String()
+
+	aNumbers, bNumbers := digitsPattern.FindAllStringIndex(a, -1), digitsPattern.FindAllStringIndex(b, -1)
+	aSkeleton, bSkeleton := digitsPattern.ReplaceAllString(a, "0"), digitsPattern.ReplaceAllString(b, "0")
+
+	if len(aNumbers) == len(bNumbers) && aSkeleton == bSkeleton {
+		step := 0
+		for i := range aNumbers {
+			x, _ := strconv.Atoi(a[aNumbers[i][0]:aNumbers[i][1]])
+			y, _ := strconv.Atoi(b[bNumbers[i][0]:bNumbers[i][1]])
+			if x != y {
+				if step != 0 {
+					return 0
+				}
+				step = y - x
+			}
+		}
+		if step == 1 || step == -1 {
+			return step
+		}
+		return 0
+	}
+
+	// The first page often has no page number, as in "/article" and
+	// "/article?page=2"
+	prefix := strings.TrimSuffix(a, "/")
+	if len(bNumbers) == len(aNumbers)+1 && strings.HasPrefix(b, prefix) {
+		last := bNumbers[len(bNumbers)-1]
+		if b[last[0]:last[1]] == "2" && strings.Trim(b[last[1]:], "/") == "" && pageParamPattern.MatchString(b[len(prefix):last[0]]) {
+			return 1
+		}
+	}
+	return 0
+}
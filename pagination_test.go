@@ -0,0 +1,150 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// paginationURLs returns the next and previous URLs of p as strings, "<nil>"
+// for those that were not found.
+func paginationURLs(p Pagination) (string, string) {
+	next, prev := "<nil>", "<nil>"
+	if p.Next != nil {
+		next = p.Next.String()
+	}
+	if p.Prev != nil {
+		prev = p.Prev.String()
+	}
+	return next, prev
+}
+
+func TestFindPagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		markup   string
+		base     string
+		wantNext string
+		wantPrev string
+	}{
+		{
+			name: "link rel",
+			markup: `<html><head><link rel="prev" href="/story?page=1"><link rel="next" href="/story?page=3"></head>` +
+				`<body><p>Story</p></body></html>`,
+			base:     "https://example.com/story?page=2",
+			wantNext: "https://example.com/story?page=3",
+			wantPrev: "https://example.com/story?page=1",
+		},
+		{
+			name:     "anchor rel tokens",
+			markup:   `<a rel="nofollow NEXT" href="b.html">b</a><a rel="previous" href="//cdn.example/z.html">z</a><a rel="next" href="c.html">c</a>`,
+			base:     "https://example.com/a/a.html",
+			wantNext: "https://example.com/a/b.html",
+			wantPrev: "https://cdn.example/z.html",
+		},
+		{
+			name: "numbered pager",
+			markup: `<nav class="pager"><a href="/blog/page/1">1</a> <a href="/blog/page/2">2</a> <span>3</span> ` +
+				`<a href="/blog/page/4">4</a> <a href="/blog/page/5">5</a> <a href="/blog/page/12">12</a></nav>`,
+			base:     "https://example.com/blog/page/3",
+			wantNext: "https://example.com/blog/page/4",
+			wantPrev: "https://example.com/blog/page/2",
+		},
+		{
+			name:     "first page without a number",
+			markup:   `<a href="/article?page=2">2</a> <a href="/article?page=3">3</a>`,
+			base:     "https://example.com/article",
+			wantNext: "https://example.com/article?page=2",
+			wantPrev: "<nil>",
+		},
+		{
+			name:     "text patterns",
+			markup:   `<a href="/archive/older">Older posts</a> <a href="/archive/newer">  ‹  </a> <a href="/about">About</a>`,
+			base:     "https://example.com/archive",
+			wantNext: "https://example.com/archive/older",
+			wantPrev: "https://example.com/archive/newer",
+		},
+		{
+			name: "link rel wins over heuristics",
+			markup: `<html><head><link rel="next" href="/p/page/9"></head><body>` +
+				`<a href="/p/page/1">1</a> <a href="/p/page/3">3</a> <a class="next" href="/p/page/3">Next</a></body></html>`,
+			base:     "https://example.com/p/page/2",
+			wantNext: "https://example.com/p/page/9",
+			wantPrev: "https://example.com/p/page/1",
+		},
+		{
+			name:     "other hosts ignored",
+			markup:   `<a href="https://ads.example/page/4">Next</a>`,
+			base:     "https://example.com/page/3",
+			wantNext: "<nil>",
+			wantPrev: "<nil>",
+		},
+		{
+			name:     "class alone is not enough",
+			markup:   `<a class="next-article" href="/other">Read this</a>`,
+			base:     "https://example.com/story",
+			wantNext: "<nil>",
+			wantPrev: "<nil>",
+		},
+	}
+
+	for _, tt := range tests {
+		p := FindPagination(mustParse(t, tt.markup), mustParseURL(t, tt.base))
+		if next, prev := paginationURLs(p); next != tt.wantNext || prev != tt.wantPrev {
+			t.Errorf("%s: got next %s prev %s, want next %s prev %s", tt.name, next, prev, tt.wantNext, tt.wantPrev)
+		}
+	}
+}
+
+func TestFindPaginationCandidates(t *testing.T) {
+	doc := mustParse(t, `<a id="num" href="/list/2">2</a> <a id="other" href="/list/7">7</a> <a id="next" class="next" href="/list/2">Next</a>`)
+
+	p := FindPagination(doc, mustParseURL(t, "https://example.com/list/1"))
+
+	if len(p.NextCandidates) != 2 {
+		t.Fatalf("got %d next candidates, want 2", len(p.NextCandidates))
+	}
+	best := p.NextCandidates[0]
+	if GetAttrOr(best.Node, "id", "") != "next" || best.Score != 110 {
+		t.Errorf("got best candidate %s with score %d, want next with 110", GetAttrOr(best.Node, "id", ""), best.Score)
+	}
+	wantReasons := `text matches "next",class or id contains "next",increments the page number`
+	if got := strings.Join(best.Reasons, ","); got != wantReasons {
+		t.Errorf("got reasons %q, want %q", got, wantReasons)
+	}
+	if second := p.NextCandidates[1]; GetAttrOr(second.Node, "id", "") != "num" || second.Score != 40 {
+		t.Errorf("got second candidate %+v", second)
+	}
+	if len(p.PrevCandidates) != 0 {
+		t.Errorf("got prev candidates %+v", p.PrevCandidates)
+	}
+}
+
+func TestFindPaginationWithOptions(t *testing.T) {
+	markup := `<a href="/list/4">Suivant</a> <a href="/list/2">Précédent</a>`
+	base := mustParseURL(t, "https://example.com/list/3")
+
+	p := FindPaginationWithOptions(mustParse(t, markup), base, PaginationOptions{})
+	if next, prev := paginationURLs(p); next != "<nil>" || prev != "<nil>" {
+		t.Errorf("heuristics disabled: got next %s prev %s", next, prev)
+	}
+	if p.NextCandidates != nil || p.PrevCandidates != nil {
+		t.Error("heuristics disabled: got candidates")
+	}
+
+	opts := PaginationOptions{
+		Heuristics:   true,
+		NextPatterns: []string{"suivant"},
+		PrevPatterns: []string{"PRÉCÉDENT"},
+		MinScore:     60,
+	}
+	p = FindPaginationWithOptions(mustParse(t, markup), base, opts)
+	if next, prev := paginationURLs(p); next != "https://example.com/list/4" || prev != "https://example.com/list/2" {
+		t.Errorf("custom patterns: got next %s prev %s", next, prev)
+	}
+
+	opts.MinScore = 100
+	p = FindPaginationWithOptions(mustParse(t, markup), base, opts)
+	if next, prev := paginationURLs(p); next != "<nil>" || prev != "<nil>" {
+		t.Errorf("high min score: got next %s prev %s", next, prev)
+	}
+}
@@ -0,0 +1,105 @@
+package htmlutil
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultTrackingParams lists the query parameters removed by
+// CleanLinkParams() when ParamOptions.Remove is nil.
+var DefaultTrackingParams = []string{"utm_*", "fbclid", "gclid", "mc_eid"}
+
+// ParamOptions controls which query parameters CleanLinkParams() removes.
+// Parameter names are matched case-insensitively, either exactly or as glob
+// patterns using the syntax of path.Match(), such as "utm_*".
+type ParamOptions struct {
+	// Remove lists the parameters removed from links. If nil,
+	// DefaultTrackingParams is used. It is ignored if Keep is not nil.
+	Remove []string
+
+	// Keep switches to allowlist mode: if not nil, every parameter not listed
+	// is removed.
+	Keep []string
+}
+
+// CleanLinkParams removes the query parameters selected by the provided
+// options from the href attributes of the elements within the provided node,
+// and returns the number of attributes rewritten.
+//
+// The remaining parameters are kept in order and with their original
+// encoding, and the fragment is left untouched, even if it looks like a
+// query string. If no parameters are left, the "?" is removed as well.
+func CleanLinkParams(doc *html.Node, opts ParamOptions) int {
+	removes := func(name string) bool {
+		if opts.Keep != nil {
+			return !paramMatches(name, opts.Keep)
+		}
+		if opts.Remove == nil {
+			return paramMatches(name, DefaultTrackingParams)
+		}
+		return paramMatches(name, opts.Remove)
+	}
+
+	return RewriteURLs(doc, func(tag string, attr string, raw string) (string, bool) {
+		if attr != "href" {
+			return raw, false
+		}
+		return cleanURLParams(raw, removes)
+	})
+}
+
+// cleanURLParams removes the query parameters of raw for which removes
+// returns true. It reports whether any parameter was removed.
+func cleanURLParams(raw string, removes func(name string) bool) (string, bool) {
+	rest, fragment, hasFragment := strings.Cut(raw, "#")
+	base, query, hasQuery := strings.Cut(rest, "?")
+	if !hasQuery {
+		return raw, false
+	}
+
+	var kept []string
+	removed := false
+	for _, param := range strings.Split(query, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+
+		if param != "" && removes(name) {
+			removed = true
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if !removed {
+		return raw, false
+	}
+
+	cleaned := base
+	if query := strings.Join(kept, "&"); strings.Trim(query, "&") != "" {
+		cleaned += "?" + query
+	}
+	if hasFragment {
+		cleaned += "#" + fragment
+	}
+	return cleaned, true
+}
+
+// paramMatches reports whether the query parameter name matches one of the
+// provided names or glob patterns.
+func paramMatches(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == name {
+			return true
+		}
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
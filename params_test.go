@@ -0,0 +1,87 @@
+package htmlutil
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestCleanLinkParams(t *testing.T) {
+	tests := []struct {
+		name string
+		href string
+		opts ParamOptions
+		want string
+	}{
+		{"defaults", "/a?utm_source=x&id=1&utm_medium=y&fbclid=z", ParamOptions{}, "/a?id=1"},
+		{"all removed", "https://example.com/?utm_source=x&gclid=1", ParamOptions{}, "https://example.com/"},
+		{"no params", "/a", ParamOptions{}, "/a"},
+		{"empty query", "/a?", ParamOptions{}, "/a?"},
+		{"nothing removed", "/a?id=1&b=%2F+x", ParamOptions{}, "/a?id=1&b=%2F+x"},
+		{"repeated params", "/a?tag=1&utm_source=x&tag=2&utm_source=y&tag=3", ParamOptions{}, "/a?tag=1&tag=2&tag=3"},
+		{"repeated kept params", "/a?id=1&id=1", ParamOptions{Remove: []string{"x"}}, "/a?id=1&id=1"},
+		{"encoding kept", "/a?q=caf%C3%A9+au+lait&utm_source=x&path=%2Fb%3Fc%3Dd&sp=a%20b", ParamOptions{}, "/a?q=caf%C3%A9+au+lait&path=%2Fb%3Fc%3Dd&sp=a%20b"},
+		{"unencoded characters kept", "/a?q=a b&r=é&utm_source=x", ParamOptions{}, "/a?q=a b&r=é"},
+		{"encoded name", "/a?utm%5Fsource=x&id=1", ParamOptions{}, "/a?id=1"},
+		{"case", "/a?UTM_Source=x&FBCLID=y&id=1", ParamOptions{}, "/a?id=1"},
+		{"flag", "/a?fbclid&id", ParamOptions{}, "/a?id"},
+		{"empty params", "/a?id=1&&utm_source=x&b=2", ParamOptions{}, "/a?id=1&&b=2"},
+		{"only empty params left", "/a?&utm_source=x&", ParamOptions{}, "/a"},
+		{"fragment", "/a?utm_source=x&id=1#top", ParamOptions{}, "/a?id=1#top"},
+		{"fragment without query left", "/a?utm_source=x#top", ParamOptions{}, "/a#top"},
+		{"params in fragment", "/a#utm_source=x&id=1", ParamOptions{}, "/a#utm_source=x&id=1"},
+		{"params in fragment after query", "/a?utm_source=x#/app?utm_source=y&fbclid=z", ParamOptions{}, "/a#/app?utm_source=y&fbclid=z"},
+		{"custom", "/a?ref=x&utm_source=y&sid_1=z", ParamOptions{Remove: []string{"ref", "sid_?"}}, "/a?utm_source=y"},
+		{"empty remove list", "/a?utm_source=y", ParamOptions{Remove: []string{}}, "/a?utm_source=y"},
+		{"keep", "/a?id=1&utm_source=x&page=2&ref=y", ParamOptions{Keep: []string{"id", "page"}}, "/a?id=1&page=2"},
+		{"keep glob", "/a?p_a=1&q=2&p_b=3", ParamOptions{Keep: []string{"p_*"}}, "/a?p_a=1&p_b=3"},
+		{"keep none", "/a?id=1#x", ParamOptions{Keep: []string{}}, "/a#x"},
+		{"keep over remove", "/a?utm_source=x&id=1", ParamOptions{Remove: []string{"id"}, Keep: []string{"utm_source"}}, "/a?utm_source=x"},
+		{"invalid pattern", "/a?x=1&[=2", ParamOptions{Remove: []string{"["}}, "/a?x=1"},
+	}
+
+	for _, tt := range tests {
+		a := NewElement("a", map[string]string{"href": tt.href})
+		wantCount := 0
+		if tt.want != tt.href {
+			wantCount = 1
+		}
+
+		if got := CleanLinkParams(a, tt.opts); got != wantCount {
+			t.Errorf("%s: got %d links rewritten, want %d", tt.name, got, wantCount)
+		}
+		if got := GetAttrOr(a, "href", ""); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCleanLinkParamsDocument(t *testing.T) {
+	doc := mustParse(t, `<a href="/a?utm_source=x&amp;id=1">a</a><a href="/b">b</a>`+
+		`<link rel="canonical" href="https://example.com/?fbclid=1">`+
+		`<img src="/i.png?utm_source=x"><form action="/f?utm_source=x"></form><area href="/c?gclid=2">`)
+
+	if got := CleanLinkParams(doc, ParamOptions{}); got != 3 {
+		t.Errorf("got %d links rewritten, want 3", got)
+	}
+
+	var got []string
+	Walk(doc, func(n *html.Node) WalkAction {
+		for _, a := range n.Attr {
+			if a.Key == "href" || a.Key == "src" || a.Key == "action" {
+				got = append(got, a.Val)
+			}
+		}
+		return WalkContinue
+	})
+	want := []string{"/a?id=1", "/b", "https://example.com/", "/i.png?utm_source=x", "/f?utm_source=x", "/c"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want only href attributes cleaned: %q", got, want)
+			break
+		}
+	}
+}
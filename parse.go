@@ -0,0 +1,40 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ParseString parses an HTML document from a string.
+func ParseString(s string) (*html.Node, error) {
+	return html.Parse(strings.NewReader(s))
+}
+
+// ParseFragmentString parses an HTML fragment from a string as if it were the
+// contents of an element with the provided tag. If the tag is empty, "body"
+// is used.
+//
+// The context matters for content such as table rows, which must be parsed
+// with a context of "table" or "tbody" to be kept.
+//
+// The returned nodes are in document order and are detached, ready to be
+// appended elsewhere.
+func ParseFragmentString(s string, contextTag string) ([]*html.Node, error) {
+	if contextTag == "" {
+		contextTag = "body"
+	}
+
+	return html.ParseFragment(strings.NewReader(s), newContextNode(contextTag))
+}
+
+// newContextNode returns an element node suitable for use as the context of
+// html.ParseFragment.
+func newContextNode(tag string) *html.Node {
+	return &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Lookup([]byte(tag)),
+		Data:     tag,
+	}
+}
@@ -0,0 +1,85 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseString(t *testing.T) {
+	doc, err := ParseString(`<title>T</title><p>a</p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Type != html.DocumentNode {
+		t.Errorf("got node type %v, want a document", doc.Type)
+	}
+	if got := GetText(GetFirstHtmlNode(doc, "title", "", "")); got != "T" {
+		t.Errorf("title: got %q", got)
+	}
+
+	// Empty input still produces the implied document structure
+	doc, err = ParseString("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mustRender(t, doc), "<html><head></head><body></body></html>"; got != want {
+		t.Errorf("empty input: got %q, want %q", got, want)
+	}
+}
+
+func TestParseFragmentString(t *testing.T) {
+	tests := []struct {
+		name       string
+		markup     string
+		contextTag string
+		want       []string
+	}{
+		{"default context", `a<b>b</b><!--c-->`, "", []string{"a", "<b>b</b>", "<!--c-->"}},
+		{"body context", `<p>a</p><p>b</p>`, "body", []string{"<p>a</p>", "<p>b</p>"}},
+		{"rows in tbody", `<tr><td>1</td></tr><tr><td>2</td></tr>`, "tbody", []string{"<tr><td>1</td></tr>", "<tr><td>2</td></tr>"}},
+		{"rows in table", `<tr><td>1</td></tr>`, "table", []string{"<tbody><tr><td>1</td></tr></tbody>"}},
+		{"rows in body", `<tr><td>1</td></tr>`, "", []string{"1"}},
+		{"cells in tr", `<td>1</td><td>2</td>`, "tr", []string{"<td>1</td>", "<td>2</td>"}},
+		{"options in select", `<option>a</option><p>dropped</p>`, "select", []string{"<option>a</option>", "dropped"}},
+		{"empty", "", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := ParseFragmentString(tt.markup, tt.contextTag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(nodes))
+			for _, n := range nodes {
+				if n.Parent != nil || n.PrevSibling != nil || n.NextSibling != nil {
+					t.Errorf("node %q is attached", n.Data)
+				}
+				got = append(got, mustRender(t, n))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFragmentStringAppend(t *testing.T) {
+	doc := mustParse(t, `<table><tbody id="rows"></tbody></table>`)
+	tbody := GetFirstHtmlNode(doc, "tbody", "", "")
+
+	nodes, err := ParseFragmentString(`<tr><td>1</td></tr><tr><td>2</td></tr>`, "tbody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		tbody.AppendChild(n)
+	}
+
+	if got, want := mustRender(t, tbody), `<tbody id="rows"><tr><td>1</td></tr><tr><td>2</td></tr></tbody>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
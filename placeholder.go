@@ -0,0 +1,94 @@
+package htmlutil
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// PlaceholderOptions controls the placeholders inserted by
+// ReplaceEmbedsWithPlaceholders().
+type PlaceholderOptions struct {
+	// Base is the URL of the document, used to resolve the URLs of embeds as
+	// ExtractEmbeds() does.
+	Base *url.URL
+
+	// Providers lists the providers, as reported by Embed.Provider, whose
+	// embeds are replaced. If nil, the embeds of every known provider are
+	// replaced. Embeds from unknown hosts are never replaced.
+	Providers []string
+
+	// Tag is the tag of the placeholder elements. If empty, "div" is used.
+	Tag string
+
+	// Attrs and Text are the attributes and the text content of the
+	// placeholder elements. In both, "{{src}}", "{{provider}}", and
+	// "{{title}}" are replaced with the resolved URL, the provider, and the
+	// title of the embed.
+	Attrs []html.Attribute
+	Text  string
+
+	// PreserveOriginal keeps the markup of the replaced element in the
+	// data-original attribute of the placeholder, so that it can be restored
+	// when the placeholder is clicked.
+	PreserveOriginal bool
+}
+
+// ReplaceEmbedsWithPlaceholders replaces the embeds of third-party providers
+// found by ExtractEmbeds() within the provided document with placeholder
+// elements built as described by opts, and returns the number of embeds
+// replaced. Embeds inside a replaced embed, such as a video inside an object,
+// are replaced along with it.
+//
+// Substituted values are set on the tree as is, not as markup, so they are
+// escaped when the document is rendered and a hostile title can't break out
+// of an attribute or inject elements. Likewise, the data-original attribute
+// holds the markup of the replaced element, which is escaped when rendered and
+// decoded again when parsed: GetAttr() returns the original markup, ready for
+// ParseFragmentString().
+func ReplaceEmbedsWithPlaceholders(doc *html.Node, opts PlaceholderOptions) int {
+	tag := opts.Tag
+	if tag == "" {
+		tag = "div"
+	}
+
+	var replaced []*html.Node
+	for _, embed := range ExtractEmbeds(doc, opts.Base) {
+		if embed.Provider == "" || (opts.Providers != nil && !slices.Contains(opts.Providers, embed.Provider)) {
+			continue
+		}
+
+		n := embed.Node
+		if n.Parent == nil || slices.ContainsFunc(replaced, func(r *html.Node) bool { return IsDescendantOf(n, r) }) {
+			continue
+		}
+
+		src := ""
+		if embed.Src != nil {
+			src = embed.Src.String()
+		}
+		r := strings.NewReplacer("{{src}}", src, "{{provider}}", embed.Provider, "{{title}}", embed.Title)
+
+		placeholder := NewElement(tag, nil)
+		for _, a := range opts.Attrs {
+			a.Val = r.Replace(a.Val)
+			placeholder.Attr = append(placeholder.Attr, a)
+		}
+		if opts.PreserveOriginal {
+			if original, err := HtmlNodeToString(n); err == nil {
+				SetHtmlAttr(placeholder, "data-original", original)
+			}
+		}
+		if text := r.Replace(opts.Text); text != "" && !voidElements[tag] {
+			placeholder.AppendChild(NewText(text))
+		}
+
+		n.Parent.InsertBefore(placeholder, n)
+		n.Parent.RemoveChild(n)
+		replaced = append(replaced, n)
+	}
+
+	return len(replaced)
+}
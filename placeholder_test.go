@@ -0,0 +1,194 @@
+package htmlutil
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// embedsDoc has embeds of known providers, next to an embed from an unknown
+// host and a video of a known provider inside an object.
+const embedsDoc = `<article><p>Intro</p>` +
+	`<iframe src="https://www.youtube.com/embed/abc?rel=0&amp;start=10" title="A &quot;video&quot;" width="560" allowfullscreen></iframe>` +
+	`<iframe class="lazy" data-src="//player.vimeo.com/video/1" title="Vimeo"></iframe>` +
+	`<iframe src="/local/widget" title="Local"></iframe>` +
+	`<blockquote><iframe src="https://platform.twitter.com/embed/Tweet.html?id=1" title="Tweet"></iframe></blockquote>` +
+	`<object data="https://www.youtube.com/v/def"><video src="https://www.youtube.com/watch?v=def"></video></object>` +
+	`</article>`
+
+// placeholderOptions returns options for placeholders using every
+// substitution and preserving the original markup.
+func placeholderOptions(t testing.TB) PlaceholderOptions {
+	return PlaceholderOptions{
+		Base: mustParseURL(t, "https://example.com/blog/post"),
+		Attrs: []html.Attribute{
+			{Key: "class", Val: "embed-placeholder"},
+			{Key: "data-src", Val: "{{src}}"},
+			{Key: "data-provider", Val: "{{provider}}"},
+			{Key: "aria-label", Val: "Load {{title}} from {{provider}}"},
+		},
+		Text:             "Click to load content from {{provider}}",
+		PreserveOriginal: true,
+	}
+}
+
+func TestReplaceEmbedsWithPlaceholders(t *testing.T) {
+	doc := mustParse(t, embedsDoc)
+
+	if got := ReplaceEmbedsWithPlaceholders(doc, placeholderOptions(t)); got != 4 {
+		t.Errorf("got %d embeds replaced, want 4", got)
+	}
+	checkSiblings(t, doc)
+
+	placeholders := GetAllHtmlNodes(doc, "div", "class", "embed-placeholder")
+	want := []struct {
+		src, provider, label string
+	}{
+		{"https://www.youtube.com/embed/abc?rel=0&start=10", "YouTube", `Load A "video" from YouTube`},
+		{"https://player.vimeo.com/video/1", "Vimeo", "Load Vimeo from Vimeo"},
+		{"https://platform.twitter.com/embed/Tweet.html?id=1", "Twitter", "Load Tweet from Twitter"},
+		{"https://www.youtube.com/v/def", "YouTube", "Load  from YouTube"},
+	}
+	if len(placeholders) != len(want) {
+		t.Fatalf("got %d placeholders, want %d", len(placeholders), len(want))
+	}
+	for i, p := range placeholders {
+		got := []string{GetAttrOr(p, "data-src", ""), GetAttrOr(p, "data-provider", ""), GetAttrOr(p, "aria-label", "")}
+		if got[0] != want[i].src || got[1] != want[i].provider || got[2] != want[i].label {
+			t.Errorf("%d: got %q, want %+v", i, got, want[i])
+		}
+		if text := GetText(p); text != "Click to load content from "+want[i].provider {
+			t.Errorf("%d: got text %q", i, text)
+		}
+	}
+
+	// The embed of an unknown host is kept, and the video inside the object
+	// is replaced with it
+	if got := GetAllHtmlNodes(doc, "iframe", "", ""); len(got) != 1 || GetAttrOr(got[0], "title", "") != "Local" {
+		t.Errorf("got iframes %v", nodeNames(got))
+	}
+	if HasHtmlNode(doc, "video", "", "") || HasHtmlNode(doc, "object", "", "") {
+		t.Error("object left in the document")
+	}
+	if p := placeholders[2]; p.Parent == nil || p.Parent.Data != "blockquote" {
+		t.Error("placeholder not in the place of the embed")
+	}
+}
+
+func TestReplaceEmbedsWithPlaceholdersRoundTrip(t *testing.T) {
+	original := mustParse(t, embedsDoc)
+	embeds := GetAllHtmlNodesFunc(original, func(n *html.Node) bool {
+		return isHtmlElement(n, "iframe", "object") && GetAttrOr(n, "title", "") != "Local"
+	})
+
+	doc := mustParse(t, embedsDoc)
+	ReplaceEmbedsWithPlaceholders(doc, placeholderOptions(t))
+
+	// Parse the rendered document again, as a browser would, and restore the
+	// originals from the data-original attributes
+	doc = mustParse(t, mustRender(t, doc))
+	placeholders := GetAllHtmlNodes(doc, "div", "class", "embed-placeholder")
+	if len(placeholders) != len(embeds) {
+		t.Fatalf("got %d placeholders, want %d", len(placeholders), len(embeds))
+	}
+	for i, p := range placeholders {
+		markup, ok := GetAttr(p, "data-original")
+		if !ok {
+			t.Fatalf("%d: no data-original attribute", i)
+		}
+		nodes, err := ParseFragmentString(markup, p.Parent.Data)
+		if err != nil || len(nodes) != 1 {
+			t.Fatalf("%d: got %d nodes, %v", i, len(nodes), err)
+		}
+		if d := FirstDifference(embeds[i], nodes[0], CompareOptions{}); d != nil {
+			t.Errorf("%d: restored embed differs at %s", i, d)
+		}
+		p.Parent.InsertBefore(nodes[0], p)
+		p.Parent.RemoveChild(p)
+	}
+
+	if d := FirstDifference(mustParse(t, embedsDoc), doc, CompareOptions{}); d != nil {
+		t.Errorf("restored document differs at %s", d)
+	}
+}
+
+func TestReplaceEmbedsWithPlaceholdersHostileTitle(t *testing.T) {
+	const title = `"><script>alert(1)</script><img src=x onerror=alert(2) x="` + "'&amp;{{src}}"
+	iframe := NewElement("iframe", map[string]string{"src": "https://www.youtube.com/embed/x", "title": title})
+	doc := mustParse(t, `<p>x</p>`)
+	GetFirstHtmlNode(doc, "body", "", "").AppendChild(iframe)
+
+	opts := placeholderOptions(t)
+	opts.Tag = "button"
+	if got := ReplaceEmbedsWithPlaceholders(doc, opts); got != 1 {
+		t.Fatalf("got %d embeds replaced, want 1", got)
+	}
+
+	// The rendered placeholder parses back to the same single element, with
+	// the title intact and not substituted again
+	rendered := mustRender(t, doc)
+	reparsed := mustParse(t, rendered)
+	if HasHtmlNode(reparsed, "script", "", "") || HasHtmlNode(reparsed, "img", "", "") || HasHtmlNode(reparsed, "iframe", "", "") {
+		t.Fatalf("title broke out of the attribute: %s", rendered)
+	}
+	buttons := GetAllHtmlNodes(reparsed, "button", "", "")
+	if len(buttons) != 1 {
+		t.Fatalf("got %d placeholders in %s", len(buttons), rendered)
+	}
+	if got, want := GetAttrOr(buttons[0], "aria-label", ""), "Load "+title+" from YouTube"; got != want {
+		t.Errorf("got label %q, want %q", got, want)
+	}
+
+	original, err := ParseFragmentString(GetAttrOr(buttons[0], "data-original", ""), "body")
+	if err != nil || len(original) != 1 || GetAttrOr(original[0], "title", "") != title {
+		t.Errorf("got original %v, %v", original, err)
+	}
+}
+
+func TestReplaceEmbedsWithPlaceholdersOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PlaceholderOptions
+		want int
+		tag  string
+	}{
+		{"defaults", PlaceholderOptions{}, 4, "div"},
+		{"providers", PlaceholderOptions{Providers: []string{"YouTube"}}, 2, "div"},
+		{"no providers", PlaceholderOptions{Providers: []string{}}, 0, "div"},
+		{"void tag", PlaceholderOptions{Tag: "img", Text: "ignored", Attrs: []html.Attribute{{Key: "alt", Val: "{{provider}}"}}}, 4, "img"},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, embedsDoc)
+		if got := ReplaceEmbedsWithPlaceholders(doc, tt.opts); got != tt.want {
+			t.Errorf("%s: got %d embeds replaced, want %d", tt.name, got, tt.want)
+		}
+
+		article := GetFirstHtmlNode(doc, "article", "", "")
+		count := 0
+		for _, n := range GetAllHtmlNodes(article, tt.tag, "", "") {
+			count++
+			if _, ok := GetAttr(n, "data-original"); ok {
+				t.Errorf("%s: got data-original without PreserveOriginal", tt.name)
+			}
+			if n.FirstChild != nil && (tt.tag == "img" || tt.opts.Text == "") {
+				t.Errorf("%s: got text %q", tt.name, GetText(n))
+			}
+		}
+		if count != tt.want {
+			t.Errorf("%s: got %d %s placeholders, want %d", tt.name, count, tt.tag, tt.want)
+		}
+	}
+
+	// Relative URLs are resolved against the base URL
+	doc := mustParse(t, `<iframe src="//www.youtube.com/embed/rel"></iframe>`)
+	ReplaceEmbedsWithPlaceholders(doc, PlaceholderOptions{
+		Base:  &url.URL{Scheme: "http", Host: "example.com"},
+		Attrs: []html.Attribute{{Key: "data-src", Val: "{{src}}"}},
+	})
+	if got := GetAttrOr(GetFirstHtmlNode(doc, "div", "", ""), "data-src", ""); !strings.HasPrefix(got, "http://www.youtube.com/") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,277 @@
+package htmlutil
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// TextOptions controls the output of NodeToText() and GetTextWithOptions().
+type TextOptions struct {
+	// MaxLineWidth is the number of characters after which lines are wrapped
+	// at the previous space. Words longer than the width are not split. If 0,
+	// lines are not wrapped.
+	MaxLineWidth int
+
+	// SkipHidden skips the content of elements reported as hidden by
+	// IsHiddenNode().
+	SkipHidden bool
+}
+
+// NodeToText renders an HTML node and its descendants as plain text laid out
+// the way a browser would display it.
+//
+// Block elements such as paragraphs, headings, and divs start on a new line,
+// and br elements break lines. List items are prefixed with "- " or with
+// their number, and nested lists are indented. Table cells are separated by
+// tabs, one row per line. Whitespace is collapsed except inside pre elements.
+// The content of head, script, style, template, and noscript elements, as
+// well as comments, is skipped.
+func NodeToText(n *html.Node, opts TextOptions) string {
+	if opts.SkipHidden && IsHiddenNode(n) {
+		return ""
+	}
+
+	r := textRenderer{opts: opts}
+	r.render(n)
+	r.endLine()
+
+	return strings.Trim(r.out.String(), "\n")
+}
+
+// textRenderer accumulates the output of NodeToText() line by line.
+type textRenderer struct {
+	opts TextOptions
+	out  strings.Builder
+
+	// line is the line being built and lineWidth its width in runes.
+	line      strings.Builder
+	lineWidth int
+
+	// indent prefixes every line, and marker replaces it on the next line
+	// when a list item has just started.
+	indent string
+	marker string
+
+	pendingSpace bool
+
+	// noMarkers leaves out the markers and indentation of list items, for
+	// callers counting the words of the text.
+	noMarkers bool
+}
+
+func (r *textRenderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.writeText(n.Data)
+		return
+	case html.DocumentNode:
+		r.renderChildren(n)
+		return
+	case html.ElementNode:
+		if r.skips(n) {
+			return
+		}
+	default:
+		return
+	}
+
+	switch n.Data {
+	case "head", "script", "style", "template", "noscript":
+		return
+	case "br":
+		r.breakLine()
+		return
+	case "pre":
+		r.endLine()
+		r.writePre(GetTextWithOptions(n, r.opts))
+		return
+	case "table":
+		r.endLine()
+		r.writeTable(n)
+		return
+	case "ul", "ol":
+		r.endLine()
+		r.writeList(n)
+		return
+	}
+
+	block := isBlockElement(n)
+	if block {
+		r.endLine()
+	}
+	r.renderChildren(n)
+	if block {
+		r.endLine()
+	}
+}
+
+func (r *textRenderer) renderChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+func (r *textRenderer) writeList(list *html.Node) {
+	ordered := list.Data == "ol"
+	number := 1
+	if start, err := strconv.Atoi(strings.TrimSpace(GetAttrOr(list, "start", ""))); ordered && err == nil {
+		number = start
+	}
+
+	indent := r.indent
+	for _, li := range childElementsByTag(list, "li") {
+		if r.skips(li) {
+			continue
+		}
+		if value, err := strconv.Atoi(strings.TrimSpace(GetAttrOr(li, "value", ""))); ordered && err == nil {
+			number = value
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		if r.noMarkers {
+			marker = ""
+		}
+
+		r.endLine()
+		r.marker = indent + marker
+		r.indent = indent + strings.Repeat(" ", len(marker))
+		r.renderChildren(li)
+		r.endLine()
+		r.marker = ""
+	}
+	r.indent = indent
+}
+
+func (r *textRenderer) writeTable(table *html.Node) {
+	for _, tr := range tableRows(table) {
+		if r.skips(tr) {
+			continue
+		}
+
+		var cells []string
+		for _, cell := range childElementsByTag(tr, "td", "th") {
+			if !r.skips(cell) {
+				cells = append(cells, r.cellText(cell))
+			}
+		}
+
+		r.startLine()
+		r.line.WriteString(strings.Join(cells, "\t"))
+		r.endLine()
+	}
+}
+
+// skips reports whether n is a hidden element that is skipped.
+func (r *textRenderer) skips(n *html.Node) bool {
+	return r.opts.SkipHidden && isHiddenElement(n)
+}
+
+// cellText is like the cellText() function, but also skips hidden content if
+// requested.
+func (r *textRenderer) cellText(cell *html.Node) string {
+	if !r.opts.SkipHidden {
+		return cellText(cell)
+	}
+
+	t := textNormalizer{skip: func(n *html.Node) bool {
+		return n.Data == "table" || isHiddenElement(n)
+	}}
+	for c := cell.FirstChild; c != nil; c = c.NextSibling {
+		t.writeNode(c)
+	}
+	return t.sb.String()
+}
+
+func (r *textRenderer) writePre(text string) {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		r.startLine()
+		r.line.WriteString(line)
+		r.endLine()
+	}
+}
+
+// writeText writes text with whitespace collapsed, wrapping lines if needed.
+func (r *textRenderer) writeText(s string) {
+	for len(s) > 0 {
+		if i := strings.IndexFunc(s, func(c rune) bool { return !isHtmlSpace(c) }); i != 0 {
+			r.pendingSpace = true
+			if i < 0 {
+				return
+			}
+			s = s[i:]
+		}
+
+		end := strings.IndexFunc(s, isHtmlSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		r.writeWord(s[:end])
+		s = s[end:]
+	}
+}
+
+func (r *textRenderer) writeWord(word string) {
+	width := utf8.RuneCountInString(word)
+
+	switch {
+	case r.line.Len() == 0:
+		r.startLine()
+	case r.pendingSpace && r.opts.MaxLineWidth > 0 && r.lineWidth+1+width > r.opts.MaxLineWidth:
+		r.endLine()
+		r.startLine()
+	case r.pendingSpace:
+		r.line.WriteByte(' ')
+		r.lineWidth++
+	}
+	r.pendingSpace = false
+
+	r.line.WriteString(word)
+	r.lineWidth += width
+}
+
+// startLine writes the prefix of a new line.
+func (r *textRenderer) startLine() {
+	prefix := r.indent
+	if r.marker != "" {
+		prefix = r.marker
+		r.marker = ""
+	}
+
+	r.line.WriteString(prefix)
+	r.lineWidth = utf8.RuneCountInString(prefix)
+}
+
+// endLine ends the current line, if it has any content.
+func (r *textRenderer) endLine() {
+	if r.line.Len() == 0 {
+		return
+	}
+
+	r.out.WriteString(strings.TrimRight(r.line.String(), " "))
+	r.out.WriteByte('\n')
+	r.line.Reset()
+	r.lineWidth = 0
+	r.pendingSpace = false
+}
+
+// breakLine ends the current line even if it is empty, as a br element does.
+func (r *textRenderer) breakLine() {
+	if r.line.Len() == 0 {
+		r.out.WriteByte('\n')
+		r.pendingSpace = false
+		return
+	}
+	r.endLine()
+}
@@ -0,0 +1,96 @@
+package htmlutil
+
+import "testing"
+
+func TestNodeToText(t *testing.T) {
+	doc := mustParse(t, `<!DOCTYPE html><html><head><title>Fixture</title><style>p { color: red }</style></head><body>
+<h1>Fixture   page</h1>
+<p>First paragraph with <b>bold</b> and
+   <a href="/">a link</a>.<br>After a break.</p>
+<script>var hidden = true;</script>
+<ul>
+  <li>One</li>
+  <li>Two
+    <ol start="3">
+      <li>Three</li>
+      <li value="7">Seven</li>
+      <li>Eight</li>
+    </ol>
+  </li>
+  <li>Nine</li>
+</ul>
+<table>
+  <tr><th>Name</th><th>Age</th></tr>
+  <tr><td>Ann</td><td>30</td></tr>
+  <tr><td>Bob <i>Smith</i></td><td>41</td></tr>
+</table>
+<pre>  keep
+    this</pre>
+<!-- comment -->
+<div>Last <span>line</span></div>
+</body></html>`)
+
+	want := "Fixture page\n" +
+		"First paragraph with bold and a link.\n" +
+		"After a break.\n" +
+		"- One\n" +
+		"- Two\n" +
+		"  3. Three\n" +
+		"  7. Seven\n" +
+		"  8. Eight\n" +
+		"- Nine\n" +
+		"Name\tAge\n" +
+		"Ann\t30\n" +
+		"Bob Smith\t41\n" +
+		"  keep\n" +
+		"    this\n" +
+		"Last line"
+
+	if got := NodeToText(doc, TextOptions{}); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNodeToTextWrapping(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		width int
+		want  string
+	}{
+		{"wrap at spaces", `<p>one two three four five</p>`, 9, "one two\nthree\nfour five"},
+		{"long word", `<p>a extraordinarily b</p>`, 5, "a\nextraordinarily\nb"},
+		{"list indent", `<ul><li>alpha beta gamma</li></ul>`, 12, "- alpha beta\n  gamma"},
+		{"no wrapping", `<p>one two three four five</p>`, 0, "one two three four five"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NodeToText(mustParse(t, tt.doc), TextOptions{MaxLineWidth: tt.width}); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeToTextBreaks(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"consecutive br", `<p>a<br><br>b</p>`, "a\n\nb"},
+		{"inline elements", `<span>a</span><span>b</span> <em>c</em>`, "ab c"},
+		{"nested blocks", `<div><div>a</div>b<div>c</div></div>`, "a\nb\nc"},
+		{"template and noscript", `<p>a</p><template>t</template><noscript>n</noscript>`, "a"},
+		{"empty", `<p> </p>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NodeToText(mustParse(t, tt.doc), TextOptions{}); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package htmlutil
+
+import (
+	"io"
+	"regexp"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// GetHtmlNodesByAttrRegexp returns the HTML nodes found within the provided
+// node that have an attribute with the provided key whose value matches the
+// provided regular expression, up to the provided count. As with
+// regexp.MatchString, the expression matches anywhere in the value unless it
+// is anchored.
+//
+// The tag and attribute are optional. If they are empty, they will not be used
+// as search criteria, so an empty attribute matches any attribute whose value
+// matches.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesByAttrRegexp(n *html.Node, tag string, attr string, re *regexp.Regexp, count int) []*html.Node {
+	m := NewMatcher(tag, "", "")
+	return GetHtmlNodesFunc(n, func(n *html.Node) bool {
+		if !m.Match(n) {
+			return false
+		}
+
+		for _, a := range n.Attr {
+			if (attr == "" || a.Key == attr) && re.MatchString(a.Val) {
+				return true
+			}
+		}
+		return false
+	}, count)
+}
+
+// GetHtmlNodesByTextRegexp returns the HTML nodes found within the provided
+// node whose text, as returned by GetText(), matches the provided regular
+// expression, up to the provided count.
+//
+// The tag is optional. If it is empty, it will not be used as search criteria.
+// Note that an ancestor matches whenever one of its descendants does, so a tag
+// is usually provided.
+//
+// The text of each candidate node is read lazily through regexp.MatchReader
+// rather than built as a string, so matching large elements such as body does
+// not allocate a copy of their text.
+//
+// If the count is -1, all nodes will be returned.
+func GetHtmlNodesByTextRegexp(n *html.Node, tag string, re *regexp.Regexp, count int) []*html.Node {
+	m := NewMatcher(tag, "", "")
+	return GetHtmlNodesFunc(n, func(n *html.Node) bool {
+		if !m.Match(n) {
+			return false
+		}
+		return re.MatchReader(newTextRuneReader(n))
+	}, count)
+}
+
+// textRuneReader reads the text of the text nodes within root, in document
+// order, one rune at a time.
+type textRuneReader struct {
+	root *html.Node
+	node *html.Node
+	data string
+}
+
+func newTextRuneReader(root *html.Node) *textRuneReader {
+	return &textRuneReader{root: root, node: root}
+}
+
+func (r *textRuneReader) ReadRune() (rune, int, error) {
+	for r.data == "" {
+		if r.node == nil {
+			return 0, 0, io.EOF
+		}
+		if r.node.Type == html.TextNode {
+			r.data = r.node.Data
+		}
+		r.node = r.nextNode(r.node)
+	}
+
+	c, size := utf8.DecodeRuneInString(r.data)
+	r.data = r.data[size:]
+	return c, size, nil
+}
+
+// nextNode returns the node following n in document order within root, or nil
+// at the end of root. The content of template elements is skipped, as it is
+// by GetText().
+func (r *textRuneReader) nextNode(n *html.Node) *html.Node {
+	if n.FirstChild != nil && !isTemplate(n) {
+		return n.FirstChild
+	}
+
+	for ; n != r.root; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package htmlutil
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGetHtmlNodesByAttrRegexp(t *testing.T) {
+	doc := mustParse(t, `<a id="l1" href="/files/report.pdf">1</a>`+
+		`<a id="l2" href="/files/report.pdf?download=1">2</a>`+
+		`<a id="l3" href="/files/REPORT.PDF">3</a>`+
+		`<div id="comment-123" class="c"></div><div id="comment-x"></div>`+
+		`<span id="s" data-pdf="/a.pdf"></span>`)
+
+	tests := []struct {
+		name  string
+		tag   string
+		attr  string
+		re    string
+		count int
+		want  string
+	}{
+		{"suffix", "a", "href", `\.pdf$`, -1, "l1"},
+		{"unanchored", "a", "href", `\.pdf`, -1, "l1,l2"},
+		{"case-insensitive", "a", "href", `(?i)\.pdf$`, -1, "l1,l3"},
+		{"ids", "div", "id", `^comment-\d+$`, -1, "comment-123"},
+		{"any attribute", "", "", `\.pdf$`, -1, "l1,s"},
+		{"any tag", "", "data-pdf", `pdf`, -1, "s"},
+		{"count", "a", "href", `report`, 2, "l1,l2"},
+		{"no match", "a", "href", `\.docx$`, -1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeNames(GetHtmlNodesByAttrRegexp(doc, tt.tag, tt.attr, regexp.MustCompile(tt.re), tt.count))
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHtmlNodesByTextRegexp(t *testing.T) {
+	doc := mustParse(t, `<div id="d"><p id="p1">Price: <b>42</b> EUR</p><p id="p2">Price: 7 USD</p><p id="p3">Free</p></div>`)
+
+	tests := []struct {
+		name  string
+		tag   string
+		re    string
+		count int
+		want  string
+	}{
+		{"across inline elements", "p", `Price: \d+ EUR`, -1, "p1"},
+		{"several", "p", `^Price`, -1, "p1,p2"},
+		{"ancestors match too", "", `USD`, -1, "html,body,d,p2"},
+		{"count", "p", `Price`, 1, "p1"},
+		{"anchored to the whole text", "p", `^Free$`, -1, "p3"},
+		{"unicode", "p", `^F.ee$`, -1, "p3"},
+		{"no match", "p", `GBP`, -1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeNames(GetHtmlNodesByTextRegexp(doc, tt.tag, regexp.MustCompile(tt.re), tt.count))
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextRuneReader(t *testing.T) {
+	doc := mustParse(t, `<div id="d">a<b>ä<i>€</i></b><!-- no -->𝄞<p></p>z</div><p>after</p>`)
+	div := GetFirstHtmlNode(doc, "div", "", "")
+
+	var sb strings.Builder
+	r := newTextRuneReader(div)
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			break
+		}
+		sb.WriteRune(c)
+	}
+
+	if got, want := sb.String(), GetText(div); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func BenchmarkGetHtmlNodesByAttrRegexp(b *testing.B) {
+	doc := mustParse(b, largePage(500))
+	re := regexp.MustCompile(`^/wiki/Link_\d+_2$`)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		GetHtmlNodesByAttrRegexp(doc, "a", "href", re, -1)
+	}
+}
+
+func BenchmarkGetHtmlNodesByTextRegexp(b *testing.B) {
+	doc := mustParse(b, largePage(500))
+	re := regexp.MustCompile(`Paragraph 2 of section \d+3 `)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		GetHtmlNodesByTextRegexp(doc, "p", re, -1)
+	}
+}
+
+func BenchmarkGetHtmlNodesByTextRegexpBody(b *testing.B) {
+	doc := mustParse(b, largePage(500))
+	re := regexp.MustCompile(`Footer`)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		GetHtmlNodesByTextRegexp(doc, "body", re, -1)
+	}
+}
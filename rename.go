@@ -0,0 +1,90 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// voidElements lists the HTML elements that have no end tag and no content.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "keygen": true, "link": true,
+	"meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// RenameAllHtmlTags is a convenience function for RenameHtmlTag() that renames
+// all matching elements.
+func RenameAllHtmlTags(n *html.Node, fromTag string, toTag string) int {
+	return RenameHtmlTag(n, fromTag, toTag, -1)
+}
+
+// RenameHtmlTag is a convenience function for RenameHtmlTags() that renames
+// the elements with the provided tag regardless of their attributes.
+func RenameHtmlTag(n *html.Node, fromTag string, toTag string, count int) int {
+	return RenameHtmlTags(n, fromTag, "", "", toTag, count)
+}
+
+// RenameHtmlTags changes the tag of the HTML nodes found within the provided
+// node given a tag, attribute, and attribute value, up to the provided count,
+// to toTag, keeping their attributes and children. It returns the number of
+// elements renamed.
+//
+// Renames that would change how an element is rendered are refused, and the
+// element is left as is: renaming a void element such as img to a non-void
+// element such as div or the other way around, and renaming an element whose
+// text is rendered without escaping, such as script or style, to one whose
+// text is escaped or the other way around. Refused elements count toward the
+// provided count.
+//
+// The tag, attribute, and attribute value are all optional. If they are empty,
+// they will not be used as search criteria.
+//
+// If the count is -1, all matching elements will be renamed.
+func RenameHtmlTags(n *html.Node, tag string, attr string, attrValue string, toTag string, count int) int {
+	renamed := 0
+	for _, node := range GetHtmlNodes(n, tag, attr, attrValue, count, false) {
+		if renameHtmlNode(node, toTag) {
+			renamed++
+		}
+	}
+	return renamed
+}
+
+// RenameHtmlTagsMap renames the elements within the provided node whose tag
+// is a key of mapping to the associated tag, in a single pass over the tree.
+// Renames are refused like they are by RenameHtmlTags(). It returns the number
+// of elements renamed.
+func RenameHtmlTagsMap(n *html.Node, mapping map[string]string) int {
+	renamed := 0
+	Walk(n, func(n *html.Node) WalkAction {
+		if n.Type != html.ElementNode {
+			return WalkContinue
+		}
+		if toTag, ok := mapping[n.Data]; ok && renameHtmlNode(n, toTag) {
+			renamed++
+		}
+		return WalkContinue
+	})
+	return renamed
+}
+
+// renameHtmlNode changes the tag of the element n to toTag, and reports
+// whether it did so. The rename is refused if it would change how the element
+// is rendered.
+func renameHtmlNode(n *html.Node, toTag string) bool {
+	if toTag == "" || n.Data == toTag {
+		return false
+	}
+
+	renamed := &html.Node{Type: html.ElementNode, Data: toTag, Namespace: n.Namespace}
+	if n.Namespace == "" && voidElements[n.Data] != voidElements[toTag] {
+		return false
+	}
+	if childTextNodesAreLiteral(n) != childTextNodesAreLiteral(renamed) {
+		return false
+	}
+
+	n.Data = toTag
+	n.DataAtom = atom.Lookup([]byte(toTag))
+	return true
+}
@@ -0,0 +1,390 @@
+package htmlutil
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// HtmlNodeChildrenToString converts the children of an HTML node to a string,
+// the equivalent of the DOM's innerHTML. A node without children results in
+// an empty string.
+func HtmlNodeChildrenToString(n *html.Node) (string, error) {
+	buf := getRenderBuffer(n)
+	defer putRenderBuffer(buf)
+
+	if err := RenderChildren(buf, n); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// maxPooledBufferSize is the capacity above which render buffers are not
+// returned to the pool, so that rendering a large document once doesn't keep
+// its buffer alive.
+const maxPooledBufferSize = 64 << 10
+
+// renderBufferPool holds the buffers used by HtmlNodeToString() and
+// HtmlNodeChildrenToString().
+var renderBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getRenderBuffer returns an empty buffer from the pool, grown to the
+// estimated rendered size of n.
+func getRenderBuffer(n *html.Node) *bytes.Buffer {
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	buf.Grow(estimateRenderedSize(n))
+	return buf
+}
+
+func putRenderBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	renderBufferPool.Put(buf)
+}
+
+// estimateRenderedSize returns a cheap estimate of the number of bytes of the
+// rendering of n, from the length of its tags, attributes, and text.
+func estimateRenderedSize(n *html.Node) int {
+	size := 0
+
+	Walk(n, func(n *html.Node) WalkAction {
+		switch n.Type {
+		case html.ElementNode:
+			// The start and end tags
+			size += 2*len(n.Data) + 5
+			for _, a := range n.Attr {
+				size += len(a.Namespace) + len(a.Key) + len(a.Val) + 4
+			}
+		case html.CommentNode:
+			size += len(n.Data) + 7
+		default:
+			size += len(n.Data)
+		}
+		return WalkContinue
+	})
+
+	return size
+}
+
+// RenderChildren renders each of the children of an HTML node to w, in order.
+//
+// Text children of raw text elements such as script and style are written
+// literally, as they would be when rendering the element itself.
+func RenderChildren(w io.Writer, n *html.Node) error {
+	literal := childTextNodesAreLiteral(n)
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if literal && c.Type == html.TextNode {
+			if _, err := io.WriteString(w, c.Data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := html.Render(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// childTextNodesAreLiteral reports whether the text children of n are
+// rendered without escaping. This mirrors the logic used by html.Render.
+func childTextNodesAreLiteral(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+
+	switch n.Data {
+	case "iframe", "noembed", "noframes", "noscript", "plaintext", "script", "style", "xmp":
+		return true
+	}
+	return false
+}
+
+// RenderOptions controls the output of RenderHtmlNode(). The zero value
+// renders exactly what html.Render does.
+type RenderOptions struct {
+	// SelfClosingVoid renders void elements in the XHTML style, with a space
+	// before the slash as in "<br />", instead of "<br/>".
+	SelfClosingVoid bool
+
+	// OmitDoctype leaves out the doctype of a document.
+	OmitDoctype bool
+
+	// ChildrenOnly renders the children of the provided node but not the node
+	// itself, the equivalent of the DOM's innerHTML.
+	ChildrenOnly bool
+}
+
+// RenderHtmlNode renders an HTML node to w as specified by the provided
+// options. Unlike HtmlNodeToString(), the output is written as it is produced,
+// so it can be streamed to an http.ResponseWriter without being held in
+// memory.
+func RenderHtmlNode(w io.Writer, n *html.Node, opts RenderOptions) error {
+	bw := bufio.NewWriter(w)
+
+	nodes := []*html.Node{n}
+	if opts.ChildrenOnly || (opts.OmitDoctype && n.Type == html.DocumentNode) {
+		nodes = nil
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, c)
+		}
+	}
+
+	literal := opts.ChildrenOnly && childTextNodesAreLiteral(n)
+	for _, c := range nodes {
+		if opts.OmitDoctype && c.Type == html.DoctypeNode {
+			continue
+		}
+
+		var err error
+		switch {
+		case literal && c.Type == html.TextNode:
+			_, err = bw.WriteString(c.Data)
+		case opts.SelfClosingVoid:
+			err = renderXHTML(bw, c)
+		default:
+			err = html.Render(bw, c)
+		}
+		if err == errPlaintextAbort {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// errPlaintextAbort is returned by renderXHTML() once a plaintext element has
+// been rendered, after which nothing else can be, as with html.Render.
+var errPlaintextAbort = errors.New("htmlutil: plaintext element rendered")
+
+// renderXHTML renders n as html.Render does, except that void elements are
+// closed with " />".
+func renderXHTML(w *bufio.Writer, n *html.Node) error {
+	switch n.Type {
+	case html.ElementNode:
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := renderXHTML(w, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return html.Render(w, n)
+	}
+
+	tag := startTag(n)
+	if voidElements[n.Data] {
+		if n.FirstChild != nil {
+			return fmt.Errorf("htmlutil: void element <%s> has child nodes", n.Data)
+		}
+		_, err := w.WriteString(strings.TrimSuffix(tag, ">") + " />")
+		return err
+	}
+	w.WriteString(tag)
+
+	// Keep a leading newline from being dropped when the output is parsed
+	if c := n.FirstChild; c != nil && c.Type == html.TextNode && strings.HasPrefix(c.Data, "\n") && isHtmlElement(n, "pre", "listing", "textarea") {
+		w.WriteByte('\n')
+	}
+
+	literal := childTextNodesAreLiteral(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if literal && c.Type == html.TextNode {
+			w.WriteString(c.Data)
+			continue
+		}
+		if err := renderXHTML(w, c); err != nil {
+			return err
+		}
+	}
+	if literal && n.Data == "plaintext" {
+		return errPlaintextAbort
+	}
+
+	_, err := w.WriteString("</" + n.Data + ">")
+	return err
+}
+
+// IndentOptions controls the output of RenderIndented().
+type IndentOptions struct {
+	// Indent is written once per nesting level. If empty, two spaces are
+	// used.
+	Indent string
+
+	// InlineTags lists the elements treated as inline content, which are
+	// kept on the same line as their siblings. If nil, DefaultInlineTags is
+	// used.
+	InlineTags []string
+}
+
+// DefaultInlineTags lists the elements treated as inline content by
+// RenderIndented() when no other list is provided.
+var DefaultInlineTags = []string{
+	"a", "abbr", "b", "bdi", "bdo", "br", "button", "cite", "code", "data",
+	"del", "dfn", "em", "i", "img", "input", "ins", "kbd", "label", "mark",
+	"q", "s", "samp", "select", "small", "span", "strong", "sub", "sup",
+	"time", "u", "var", "wbr",
+}
+
+// HtmlNodeToPrettyString converts an HTML node to an indented string for
+// easier reading and diffing, using the default IndentOptions.
+func HtmlNodeToPrettyString(n *html.Node) (string, error) {
+	var buf bytes.Buffer
+
+	if err := RenderIndented(&buf, n, IndentOptions{}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderIndented renders an HTML node to w with each element on its own line,
+// indented by its nesting level.
+//
+// Only whitespace that is insignificant is changed: an element is broken over
+// several lines only if its children are all elements that aren't inline,
+// comments, or whitespace-only text. Other elements, including inline
+// elements and elements such as pre, textarea, and script whose whitespace
+// is significant, are rendered verbatim on a single line. The output parses
+// back to the same tree, apart from whitespace-only text nodes.
+func RenderIndented(w io.Writer, n *html.Node, opts IndentOptions) error {
+	p := prettyPrinter{
+		w:      w,
+		indent: opts.Indent,
+		inline: stringSet(opts.InlineTags),
+	}
+	if p.indent == "" {
+		p.indent = "  "
+	}
+	if opts.InlineTags == nil {
+		p.inline = stringSet(DefaultInlineTags)
+	}
+
+	return p.node(n, 0)
+}
+
+// prettyPrinter holds the state of a single RenderIndented() call.
+type prettyPrinter struct {
+	w      io.Writer
+	indent string
+	inline map[string]bool
+}
+
+func (p *prettyPrinter) node(n *html.Node, depth int) error {
+	switch n.Type {
+	case html.DocumentNode:
+		return p.children(n, depth)
+	case html.TextNode:
+		if strings.TrimFunc(n.Data, isHtmlSpace) == "" {
+			return nil
+		}
+	case html.ElementNode:
+		if p.canBreak(n) {
+			return p.brokenElement(n, depth)
+		}
+	}
+
+	if err := p.writeIndent(depth); err != nil {
+		return err
+	}
+	if err := html.Render(p.w, n); err != nil {
+		return err
+	}
+	_, err := io.WriteString(p.w, "\n")
+	return err
+}
+
+func (p *prettyPrinter) children(n *html.Node, depth int) error {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := p.node(c, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// brokenElement renders an element with its start tag, each of its children,
+// and its end tag on separate lines.
+func (p *prettyPrinter) brokenElement(n *html.Node, depth int) error {
+	if err := p.writeIndent(depth); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(p.w, startTag(n)+"\n"); err != nil {
+		return err
+	}
+
+	if err := p.children(n, depth+1); err != nil {
+		return err
+	}
+
+	if err := p.writeIndent(depth); err != nil {
+		return err
+	}
+	_, err := io.WriteString(p.w, "</"+n.Data+">\n")
+	return err
+}
+
+// canBreak reports whether whitespace can be added between the children of n
+// without changing their meaning.
+func (p *prettyPrinter) canBreak(n *html.Node) bool {
+	if n.FirstChild == nil || p.inline[n.Data] || childTextNodesAreLiteral(n) {
+		return false
+	}
+
+	switch n.Data {
+	case "pre", "listing", "textarea", "title":
+		return false
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.ElementNode:
+			if p.inline[c.Data] {
+				return false
+			}
+		case html.TextNode:
+			if strings.TrimFunc(c.Data, isHtmlSpace) != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p *prettyPrinter) writeIndent(depth int) error {
+	_, err := io.WriteString(p.w, strings.Repeat(p.indent, depth))
+	return err
+}
+
+// startTag returns the start tag of an element, with its attributes escaped
+// the same way html.Render escapes them.
+func startTag(n *html.Node) string {
+	var sb strings.Builder
+
+	sb.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		sb.WriteByte(' ')
+		if a.Namespace != "" {
+			sb.WriteString(a.Namespace + ":")
+		}
+		sb.WriteString(a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	sb.WriteByte('>')
+
+	return sb.String()
+}
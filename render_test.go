@@ -0,0 +1,486 @@
+package htmlutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestHtmlNodeChildrenToString(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		tag  string
+		want string
+	}{
+		{"mixed children", `<div>text <b>bold</b><!-- note --> &amp; more</div>`, "div", `text <b>bold</b><!-- note --> &amp; more`},
+		{"no children", `<div></div>`, "div", ""},
+		{"void child", `<p>a<br>b</p>`, "p", "a<br/>b"},
+		{"script", `<script>if (a < b && c) {}</script>`, "script", `if (a < b && c) {}`},
+		{"attributes", `<ul><li class="a" data-x='"q"'>1</li></ul>`, "ul", `<li class="a" data-x="&#34;q&#34;">1</li>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := GetFirstHtmlNode(mustParse(t, tt.doc), tt.tag, "", "")
+
+			got, err := HtmlNodeChildrenToString(n)
+			if err != nil || got != tt.want {
+				t.Errorf("HtmlNodeChildrenToString: got %q, %v; want %q", got, err, tt.want)
+			}
+
+			var sb strings.Builder
+			if err := RenderChildren(&sb, n); err != nil || sb.String() != tt.want {
+				t.Errorf("RenderChildren: got %q, %v; want %q", sb.String(), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHtmlNodeChildrenToStringRoundTrip(t *testing.T) {
+	doc := mustParse(t, `<div id="x">one <em>two</em><!--three--><a href="/?a=1&amp;b=2">four</a><br>five</div>`)
+	div := GetFirstHtmlNode(doc, "div", "id", "x")
+
+	inner, err := HtmlNodeChildrenToString(div)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := ParseFragmentString(inner, "div")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	for _, n := range nodes {
+		if err := html.Render(&sb, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sb.String() != inner {
+		t.Errorf("round trip: got %q, want %q", sb.String(), inner)
+	}
+
+	var types []html.NodeType
+	for _, n := range nodes {
+		types = append(types, n.Type)
+	}
+	want := []html.NodeType{html.TextNode, html.ElementNode, html.CommentNode, html.ElementNode, html.ElementNode, html.TextNode}
+	if len(types) != len(want) {
+		t.Fatalf("got %d nodes, want %d", len(types), len(want))
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("node %d: got type %v, want %v", i, types[i], want[i])
+		}
+	}
+}
+
+// treeStructure describes the tree rooted at n one node per line, leaving
+// out whitespace-only text nodes, so that trees can be compared structurally.
+func treeStructure(n *html.Node) string {
+	var sb strings.Builder
+
+	WalkWithDepth(n, func(n *html.Node, depth int) WalkAction {
+		if n.Type == html.TextNode && strings.TrimFunc(n.Data, isHtmlSpace) == "" {
+			return WalkContinue
+		}
+		sb.WriteString(strings.Repeat(" ", depth))
+		switch n.Type {
+		case html.ElementNode:
+			sb.WriteString(startTag(n))
+		case html.TextNode:
+			fmt.Fprintf(&sb, "text %q", n.Data)
+		case html.CommentNode:
+			fmt.Fprintf(&sb, "comment %q", n.Data)
+		case html.DoctypeNode:
+			sb.WriteString("doctype " + n.Data)
+		case html.DocumentNode:
+			sb.WriteString("document")
+		}
+		sb.WriteByte('\n')
+		return WalkContinue
+	})
+
+	return sb.String()
+}
+
+const prettyFixture = `<!DOCTYPE html><html><head><title>A  title</title>` +
+	"<script>if (a) {\n  b();\n}</script></head>" +
+	`<body><div class="c"><p>Some <b>bold</b> and <a href="/x">link</a>.</p>` +
+	`<ul><li>one</li><li><span>two</span></li></ul><!-- note -->` +
+	"<pre>  keep\n    this</pre><textarea>  text\n</textarea>" +
+	`<table><tr><td>1</td></tr></table></div></body></html>`
+
+func TestHtmlNodeToPrettyString(t *testing.T) {
+	doc := mustParse(t, prettyFixture)
+
+	got, err := HtmlNodeToPrettyString(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<!DOCTYPE html>\n" +
+		"<html>\n" +
+		"  <head>\n" +
+		"    <title>A  title</title>\n" +
+		"    <script>if (a) {\n  b();\n}</script>\n" +
+		"  </head>\n" +
+		"  <body>\n" +
+		"    <div class=\"c\">\n" +
+		"      <p>Some <b>bold</b> and <a href=\"/x\">link</a>.</p>\n" +
+		"      <ul>\n" +
+		"        <li>one</li>\n" +
+		"        <li><span>two</span></li>\n" +
+		"      </ul>\n" +
+		"      <!-- note -->\n" +
+		"      <pre>  keep\n    this</pre>\n" +
+		"      <textarea>  text\n</textarea>\n" +
+		"      <table>\n" +
+		"        <tbody>\n" +
+		"          <tr>\n" +
+		"            <td>1</td>\n" +
+		"          </tr>\n" +
+		"        </tbody>\n" +
+		"      </table>\n" +
+		"    </div>\n" +
+		"  </body>\n" +
+		"</html>\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderIndentedReparses(t *testing.T) {
+	fixtures := []string{
+		prettyFixture,
+		largePage(3),
+		`<div><div><div>deep</div></div> <p>a</p> </div><svg><g><circle r="1"></circle></g></svg>`,
+		`<pre>` + "\n" + `leading newline</pre><select><option>a</option><option>b</option></select>`,
+	}
+
+	for i, fixture := range fixtures {
+		doc := mustParse(t, fixture)
+
+		var sb strings.Builder
+		if err := RenderIndented(&sb, doc, IndentOptions{Indent: "\t"}); err != nil {
+			t.Fatal(err)
+		}
+		reparsed := mustParse(t, sb.String())
+
+		if got, want := treeStructure(reparsed), treeStructure(doc); got != want {
+			t.Errorf("fixture %d doesn't reparse to the same tree:\ngot:\n%s\nwant:\n%s", i, got, want)
+		}
+	}
+}
+
+func TestRenderIndentedInlineTags(t *testing.T) {
+	doc := mustParse(t, `<div><span><em>a</em></span><p><custom-tag>b</custom-tag></p></div>`)
+	div := GetFirstHtmlNode(doc, "div", "", "")
+
+	var sb strings.Builder
+	if err := RenderIndented(&sb, div, IndentOptions{Indent: "-", InlineTags: []string{"custom-tag"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// span is not inline with this list, so it is broken over several lines,
+	// while custom-tag stays on the line of its parent
+	want := "<div>\n-<span>\n--<em>a</em>\n-</span>\n-<p><custom-tag>b</custom-tag></p>\n</div>\n"
+	if sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
+
+// renderCorpus holds documents exercising the special cases of html.Render.
+func renderCorpus() []string {
+	return append(traversalCorpus(),
+		`<!DOCTYPE html><html><head><meta charset="utf-8"><style>p > a { x: "&" }</style></head><body><br><hr><img src="a&b.png" alt='"q"'></body></html>`,
+		"<pre>\nleading newline</pre><textarea>\n\nkept</textarea><listing>\nx</listing>",
+		`<script>if (a < b && c) {}</script><noscript><b>x</b></noscript><iframe><p></iframe><xmp><b></xmp>`,
+		`<svg><path d="M0 0"/><circle r="1"></circle><foreignObject><br></foreignObject></svg><math><mi>x</mi></math>`,
+		`<p>a &amp; b &lt; c &nbsp; "d"</p><!-- comment --><input value="x" disabled>`,
+		`<template><tr><td>x</td></tr></template><table><col span="2"><tr><td>1</td></tr></table>`,
+		`<plaintext><b>everything after this is text`,
+	)
+}
+
+func TestRenderHtmlNodeMatchesHtmlRender(t *testing.T) {
+	for i, markup := range renderCorpus() {
+		doc := mustParse(t, markup)
+
+		var want, got strings.Builder
+		if err := html.Render(&want, doc); err != nil {
+			t.Fatalf("%d: html.Render: %v", i, err)
+		}
+		if err := RenderHtmlNode(&got, doc, RenderOptions{}); err != nil {
+			t.Fatalf("%d: RenderHtmlNode: %v", i, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%d: output differs from html.Render:\ngot  %s\nwant %s", i, got.String(), want.String())
+		}
+	}
+}
+
+func TestRenderHtmlNodeOptions(t *testing.T) {
+	const markup = `<!DOCTYPE html><html><head></head><body><p>a<br>b</p><img src="x.png" alt=""><svg><path d="M0"></path></svg></body></html>`
+	const body = `<p>a<br/>b</p><img src="x.png" alt=""/><svg><path d="M0"></path></svg>`
+	const xhtmlBody = `<p>a<br />b</p><img src="x.png" alt="" /><svg><path d="M0"></path></svg>`
+
+	tests := []struct {
+		name string
+		node func(doc *html.Node) *html.Node
+		opts RenderOptions
+		want string
+	}{
+		{
+			name: "default",
+			opts: RenderOptions{},
+			want: `<!DOCTYPE html><html><head></head><body>` + body + `</body></html>`,
+		},
+		{
+			name: "xhtml",
+			opts: RenderOptions{SelfClosingVoid: true},
+			want: `<!DOCTYPE html><html><head></head><body>` + xhtmlBody + `</body></html>`,
+		},
+		{
+			name: "omit doctype",
+			opts: RenderOptions{OmitDoctype: true},
+			want: `<html><head></head><body>` + body + `</body></html>`,
+		},
+		{
+			name: "omit doctype of an element",
+			node: func(doc *html.Node) *html.Node { return GetFirstHtmlNode(doc, "p", "", "") },
+			opts: RenderOptions{OmitDoctype: true},
+			want: `<p>a<br/>b</p>`,
+		},
+		{
+			name: "fragment",
+			node: func(doc *html.Node) *html.Node { return GetFirstHtmlNode(doc, "body", "", "") },
+			opts: RenderOptions{ChildrenOnly: true},
+			want: body,
+		},
+		{
+			name: "fragment of a document",
+			opts: RenderOptions{ChildrenOnly: true, OmitDoctype: true, SelfClosingVoid: true},
+			want: `<html><head></head><body>` + xhtmlBody + `</body></html>`,
+		},
+		{
+			name: "xhtml fragment",
+			node: func(doc *html.Node) *html.Node { return GetFirstHtmlNode(doc, "body", "", "") },
+			opts: RenderOptions{ChildrenOnly: true, SelfClosingVoid: true},
+			want: xhtmlBody,
+		},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, markup)
+		n := doc
+		if tt.node != nil {
+			n = tt.node(doc)
+		}
+
+		var sb strings.Builder
+		if err := RenderHtmlNode(&sb, n, tt.opts); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHtmlNodeLiteralChildren(t *testing.T) {
+	doc := mustParse(t, `<script>a < b && "c"</script><pre>`+"\n\nx"+`</pre>`)
+
+	tests := []struct {
+		tag  string
+		opts RenderOptions
+		want string
+	}{
+		{"script", RenderOptions{ChildrenOnly: true}, `a < b && "c"`},
+		{"script", RenderOptions{SelfClosingVoid: true}, `<script>a < b && "c"</script>`},
+		{"pre", RenderOptions{SelfClosingVoid: true}, "<pre>\n\nx</pre>"},
+	}
+
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := RenderHtmlNode(&sb, GetFirstHtmlNode(doc, tt.tag, "", ""), tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s %+v: got %q, want %q", tt.tag, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHtmlNodeXHTMLReparses(t *testing.T) {
+	for i, markup := range renderCorpus() {
+		doc := mustParse(t, markup)
+
+		var sb strings.Builder
+		if err := RenderHtmlNode(&sb, doc, RenderOptions{SelfClosingVoid: true}); err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if got, want := treeStructure(mustParse(t, sb.String())), treeStructure(doc); got != want {
+			t.Errorf("%d: reparsed tree differs:\ngot  %s\nwant %s", i, got, want)
+		}
+	}
+}
+
+func TestRenderHtmlNodeErrors(t *testing.T) {
+	br := NewElement("br", nil, NewText("x"))
+	if err := RenderHtmlNode(&strings.Builder{}, br, RenderOptions{SelfClosingVoid: true}); err == nil {
+		t.Error("void element with children: got no error")
+	}
+}
+
+// BenchmarkRenderHtmlNode compares streaming a multi-megabyte document to a
+// writer with building the string first.
+func BenchmarkRenderHtmlNode(b *testing.B) {
+	doc := mustParse(b, largePage(5000))
+
+	b.Run("HtmlNodeToString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s, err := HtmlNodeToString(doc)
+			if err != nil {
+				b.Fatal(err)
+			}
+			io.WriteString(io.Discard, s)
+		}
+	})
+
+	b.Run("RenderHtmlNode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := RenderHtmlNode(io.Discard, doc, RenderOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("RenderHtmlNodeXHTML", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := RenderHtmlNode(io.Discard, doc, RenderOptions{SelfClosingVoid: true}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestAppendHtmlNode(t *testing.T) {
+	for i, markup := range renderCorpus() {
+		doc := mustParse(t, markup)
+		want := mustRender(t, doc)
+
+		got, err := AppendHtmlNode([]byte("prefix"), doc)
+		if err != nil {
+			t.Fatalf("%d: AppendHtmlNode: %v", i, err)
+		}
+		if string(got) != "prefix"+want {
+			t.Errorf("%d: got  %s\nwant prefix%s", i, got, want)
+		}
+	}
+}
+
+func TestAppendHtmlNodeReusesBuffer(t *testing.T) {
+	p := NewElement("p", nil, NewText("text"))
+
+	dst := make([]byte, 0, 64)
+	dst, err := AppendHtmlNode(dst, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := &dst[0]
+
+	dst, err = AppendHtmlNode(dst[:0], p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(dst) != "<p>text</p>" || &dst[0] != first {
+		t.Errorf("got %q, want the buffer reused", dst)
+	}
+}
+
+func TestHtmlNodeToStringPooledBuffers(t *testing.T) {
+	// Rendering after a larger node must not leave bytes of the previous
+	// rendering in the pooled buffer
+	large := mustParse(t, largePage(5))
+	small := NewElement("b", nil, NewText("x"))
+
+	for i := 0; i < 10; i++ {
+		mustRender(t, large)
+		if got := mustRender(t, small); got != "<b>x</b>" {
+			t.Fatalf("got %q", got)
+		}
+		if got, err := HtmlNodeChildrenToString(small); err != nil || got != "x" {
+			t.Fatalf("got %q, %v", got, err)
+		}
+	}
+}
+
+func TestEstimateRenderedSize(t *testing.T) {
+	for i, markup := range append(renderCorpus(), largePage(20)) {
+		doc := mustParse(t, markup)
+		size := len(mustRender(t, doc))
+
+		// The estimate needs to be close enough to avoid regrowing the buffer
+		// several times, without wasting much of it
+		if got := estimateRenderedSize(doc); got < size/2 || got > 2*size+16 {
+			t.Errorf("%d: got estimate %d for %d bytes", i, got, size)
+		}
+	}
+}
+
+// BenchmarkHtmlNodeToString compares rendering small and large subtrees with a
+// new buffer for every call, as HtmlNodeToString did before buffers were
+// pooled, with the pooled buffers and with a reused AppendHtmlNode buffer.
+func BenchmarkHtmlNodeToString(b *testing.B) {
+	doc := mustParse(b, largePage(50))
+	subtrees := []struct {
+		name string
+		n    *html.Node
+	}{
+		{"Small", GetFirstHtmlNode(doc, "p", "", "")},
+		{"Large", GetFirstHtmlNode(doc, "body", "", "")},
+	}
+
+	for _, st := range subtrees {
+		b.Run(st.name+"/Unpooled", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var buf bytes.Buffer
+				if err := html.Render(&buf, st.n); err != nil {
+					b.Fatal(err)
+				}
+				_ = buf.String()
+			}
+		})
+
+		b.Run(st.name+"/HtmlNodeToString", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := HtmlNodeToString(st.n); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(st.name+"/AppendHtmlNode", func(b *testing.B) {
+			var dst []byte
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var err error
+				if dst, err = AppendHtmlNode(dst[:0], st.n); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package htmlutil
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ReplaceText replaces the matches of the provided regular expression in the
+// text nodes within the provided node with replacement, which can refer to
+// submatches as described by regexp.Regexp.Expand. It returns the number of
+// text nodes modified.
+//
+// The text of script, style, textarea, and other elements whose content is
+// not markup is left untouched. The expression is matched against the text as
+// decoded by the parser, so "&amp;" in the source is matched by "&", and
+// replacements are escaped again when the tree is rendered.
+//
+// Each text node is matched on its own, so text split by inline elements, as
+// in "foo<b>bar</b>", is never matched as a whole. Text split into adjacent
+// text nodes by earlier modifications can be merged first with
+// MergeTextNodes().
+func ReplaceText(n *html.Node, re *regexp.Regexp, replacement string) int {
+	return replaceTextNodes(n, func(s string) string {
+		return re.ReplaceAllString(s, replacement)
+	})
+}
+
+// ReplaceTextFunc is like ReplaceText() but replaces each match with the
+// result of calling repl with it.
+func ReplaceTextFunc(n *html.Node, re *regexp.Regexp, repl func(match string) string) int {
+	return replaceTextNodes(n, func(s string) string {
+		return re.ReplaceAllStringFunc(s, repl)
+	})
+}
+
+// MergeTextNodes merges the adjacent text nodes within the provided node into
+// one text node, and returns the number of text nodes removed. The parser
+// never produces adjacent text nodes, but modifications of the tree can.
+func MergeTextNodes(n *html.Node) int {
+	merged := 0
+
+	// Merge the children of each node before the walk reads them, since the
+	// walk takes the next sibling of a node before visiting it
+	Walk(n, func(n *html.Node) WalkAction {
+		merged += mergeTextChildren(n, false)
+		return WalkContinue
+	})
+
+	return merged
+}
+
+// mergeTextChildren merges each run of adjacent text nodes among the children
+// of n into its first node, removing the resulting text nodes that are empty
+// if dropEmpty is true, and returns the number of text nodes removed.
+func mergeTextChildren(n *html.Node, dropEmpty bool) int {
+	removed := 0
+
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type != html.TextNode {
+			c = next
+			continue
+		}
+
+		// Build the text of the run at once, as concatenating the nodes one by
+		// one is quadratic on long runs
+		if next != nil && next.Type == html.TextNode {
+			var sb strings.Builder
+			sb.WriteString(c.Data)
+			for ; next != nil && next.Type == html.TextNode; next = c.NextSibling {
+				sb.WriteString(next.Data)
+				n.RemoveChild(next)
+				removed++
+			}
+			c.Data = sb.String()
+		}
+
+		if dropEmpty && c.Data == "" {
+			n.RemoveChild(c)
+			removed++
+		}
+		c = next
+	}
+
+	return removed
+}
+
+// replaceTextNodes replaces the data of the text nodes within n with the
+// result of calling replace with it, skipping the content of elements whose
+// text is not markup, and returns the number of text nodes changed.
+func replaceTextNodes(n *html.Node, replace func(string) string) int {
+	modified := 0
+
+	Walk(n, func(n *html.Node) WalkAction {
+		switch n.Type {
+		case html.ElementNode:
+			if childTextNodesAreLiteral(n) || (n.Namespace == "" && n.Data == "textarea") {
+				return WalkSkipChildren
+			}
+		case html.TextNode:
+			if data := replace(n.Data); data != n.Data {
+				n.Data = data
+				modified++
+			}
+		}
+		return WalkContinue
+	})
+
+	return modified
+}
+
+// HighlightText wraps each match of the provided regular expression in the
+// text nodes within the provided node in a new element with the provided tag
+// and attributes, splitting the text nodes around the matches, and returns the
+// number of matches wrapped. If the tag is empty, mark is used. Empty matches
+// are ignored.
+//
+// The content of script, style, title, textarea, and other elements whose
+// content is not markup is left untouched, and so is the content of elements
+// with the wrapping tag, so running HighlightText() again with the same
+// arguments doesn't wrap the matches twice. Like ReplaceText(), each text node
+// is matched on its own.
+func HighlightText(n *html.Node, re *regexp.Regexp, wrapTag string, wrapAttrs map[string]string) int {
+	if wrapTag == "" {
+		wrapTag = "mark"
+	}
+
+	wrapped := 0
+	Walk(n, func(n *html.Node) WalkAction {
+		switch n.Type {
+		case html.ElementNode:
+			if childTextNodesAreLiteral(n) || n.Data == wrapTag || isHtmlElement(n, "title", "textarea") {
+				return WalkSkipChildren
+			}
+		case html.TextNode:
+			if n.Parent != nil {
+				wrapped += highlightTextNode(n, re, wrapTag, wrapAttrs)
+			}
+		}
+		return WalkContinue
+	})
+
+	return wrapped
+}
+
+// highlightTextNode replaces the text node n with text nodes and wrapping
+// elements for the matches of re, and returns the number of matches wrapped.
+func highlightTextNode(n *html.Node, re *regexp.Regexp, wrapTag string, wrapAttrs map[string]string) int {
+	var pieces []*html.Node
+	last := 0
+
+	for _, loc := range re.FindAllStringIndex(n.Data, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		if loc[0] > last {
+			pieces = append(pieces, NewText(n.Data[last:loc[0]]))
+		}
+		pieces = append(pieces, NewElement(wrapTag, wrapAttrs, NewText(n.Data[loc[0]:loc[1]])))
+		last = loc[1]
+	}
+	if len(pieces) == 0 {
+		return 0
+	}
+	if last < len(n.Data) {
+		pieces = append(pieces, NewText(n.Data[last:]))
+	}
+
+	wrapped := 0
+	for _, piece := range pieces {
+		if piece.Type == html.ElementNode {
+			wrapped++
+		}
+		n.Parent.InsertBefore(piece, n)
+	}
+	n.Parent.RemoveChild(n)
+
+	return wrapped
+}
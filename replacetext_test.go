@@ -0,0 +1,245 @@
+package htmlutil
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestReplaceText(t *testing.T) {
+	tests := []struct {
+		name        string
+		markup      string
+		re          string
+		replacement string
+		modified    int
+		want        string
+	}{
+		{
+			name:        "text nodes",
+			markup:      `<p>colour and colours</p><p title="colour">no match</p><div>colour<b>colour</b></div>`,
+			re:          `colou?r`,
+			replacement: "color",
+			modified:    3,
+			want:        `<p>color and colors</p><p title="colour">no match</p><div>color<b>color</b></div>`,
+		},
+		{
+			name:        "submatches",
+			markup:      `<p>2026-10-14</p>`,
+			re:          `(\d+)-(\d+)-(\d+)`,
+			replacement: "$3/$2/$1",
+			modified:    1,
+			want:        `<p>14/10/2026</p>`,
+		},
+		{
+			name:        "literal content skipped",
+			markup:      `<p>foo</p><script>var foo = 1</script><style>.foo{}</style><textarea>foo</textarea><noscript>foo</noscript>`,
+			re:          `foo`,
+			replacement: "bar",
+			modified:    1,
+			want:        `<p>bar</p><script>var foo = 1</script><style>.foo{}</style><textarea>foo</textarea><noscript>foo</noscript>`,
+		},
+		{
+			name:        "decoded entities",
+			markup:      `<p>Tom &amp; Jerry &lt;3 &copy;</p>`,
+			re:          `&amp;|&`,
+			replacement: "<and>",
+			modified:    1,
+			want:        `<p>Tom &lt;and&gt; Jerry &lt;3 ©</p>`,
+		},
+		{
+			name:        "split by inline elements",
+			markup:      `<p>foo<b>bar</b> foobar</p>`,
+			re:          `foobar`,
+			replacement: "X",
+			modified:    1,
+			want:        `<p>foo<b>bar</b> X</p>`,
+		},
+		{
+			name:        "no match",
+			markup:      `<p>abc</p>`,
+			re:          `z`,
+			replacement: "y",
+			modified:    0,
+			want:        `<p>abc</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, tt.markup)
+		if got := ReplaceText(doc, regexp.MustCompile(tt.re), tt.replacement); got != tt.modified {
+			t.Errorf("%s: got %d modified, want %d", tt.name, got, tt.modified)
+		}
+		if got := bodyHtml(t, doc); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceTextFunc(t *testing.T) {
+	doc := mustParse(t, `<p>"Hello," she said. "Darn it."</p><pre>"code"</pre><script>"x"</script>`)
+	quotes := regexp.MustCompile(`"[^"]*"`)
+
+	got := ReplaceTextFunc(doc, quotes, func(match string) string {
+		return "“" + match[1:len(match)-1] + "”"
+	})
+	if got != 2 {
+		t.Errorf("got %d modified, want 2", got)
+	}
+
+	profanity := regexp.MustCompile(`(?i)\bdarn\b`)
+	ReplaceTextFunc(doc, profanity, func(match string) string {
+		return strings.Repeat("*", len(match))
+	})
+
+	want := `<p>“Hello,” she said. “**** it.”</p><pre>“code”</pre><script>"x"</script>`
+	if got := bodyHtml(t, doc); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMergeTextNodes(t *testing.T) {
+	doc := mustParse(t, `<p id="p">x</p><div id="d"><span>in</span></div>`)
+	p, d := GetElementById(doc, "p"), GetElementById(doc, "d")
+	p.AppendChild(NewText("foo"))
+	p.AppendChild(NewText("bar"))
+	d.InsertBefore(NewText("a"), d.FirstChild)
+	d.InsertBefore(NewText(""), d.FirstChild)
+	d.FirstChild.NextSibling.NextSibling.AppendChild(NewText("!"))
+
+	re := regexp.MustCompile(`xfoobar`)
+	if got := ReplaceText(doc, re, "merged"); got != 0 {
+		t.Errorf("before merging: got %d modified, want matches across text nodes unmatched", got)
+	}
+
+	if got := MergeTextNodes(doc); got != 4 {
+		t.Errorf("got %d text nodes removed, want 4", got)
+	}
+	checkSiblings(t, doc)
+
+	if got := ReplaceText(doc, re, "merged"); got != 1 {
+		t.Errorf("after merging: got %d modified, want 1", got)
+	}
+	if got, want := bodyHtml(t, doc), `<p id="p">merged</p><div id="d">a<span>in!</span></div>`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	var count int
+	for c := d.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("got %d text children, want 1", count)
+	}
+}
+
+func TestHighlightText(t *testing.T) {
+	tests := []struct {
+		name    string
+		markup  string
+		re      string
+		tag     string
+		attrs   map[string]string
+		wrapped int
+		want    string
+	}{
+		{
+			name:    "multiple matches",
+			markup:  `<p>go is fun, go!</p>`,
+			re:      `go`,
+			wrapped: 2,
+			want:    `<p><mark>go</mark> is fun, <mark>go</mark>!</p>`,
+		},
+		{
+			name:    "whole node",
+			markup:  `<p>go</p>`,
+			re:      `go`,
+			wrapped: 1,
+			want:    `<p><mark>go</mark></p>`,
+		},
+		{
+			name:    "start and end",
+			markup:  `<p>ab-cd-ab</p>`,
+			re:      `ab`,
+			wrapped: 2,
+			want:    `<p><mark>ab</mark>-cd-<mark>ab</mark></p>`,
+		},
+		{
+			name:    "adjacent matches",
+			markup:  `<p>aaa</p>`,
+			re:      `a`,
+			wrapped: 3,
+			want:    `<p><mark>a</mark><mark>a</mark><mark>a</mark></p>`,
+		},
+		{
+			name:    "custom tag and attributes",
+			markup:  `<p>Tom &amp; Jerry</p>`,
+			re:      `&`,
+			tag:     "span",
+			attrs:   map[string]string{"class": "hit", "data-n": "1"},
+			wrapped: 1,
+			want:    `<p>Tom <span class="hit" data-n="1">&amp;</span> Jerry</p>`,
+		},
+		{
+			name: "skipped elements",
+			markup: `<html><head><title>go</title><style>go{}</style></head><body>` +
+				`<script>go()</script><textarea>go</textarea><mark>go</mark><p>go <mark>go</mark> <em>go</em></p></body></html>`,
+			re:      `go`,
+			wrapped: 2,
+			want:    `<script>go()</script><textarea>go</textarea><mark>go</mark><p><mark>go</mark> <mark>go</mark> <em><mark>go</mark></em></p>`,
+		},
+		{
+			name:    "empty matches",
+			markup:  `<p>abc</p>`,
+			re:      `x*`,
+			wrapped: 0,
+			want:    `<p>abc</p>`,
+		},
+		{
+			name:    "split by inline elements",
+			markup:  `<p>se<b>arch</b></p>`,
+			re:      `search`,
+			wrapped: 0,
+			want:    `<p>se<b>arch</b></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		doc := mustParse(t, tt.markup)
+		re := regexp.MustCompile(tt.re)
+
+		if got := HighlightText(doc, re, tt.tag, tt.attrs); got != tt.wrapped {
+			t.Errorf("%s: got %d wrapped, want %d", tt.name, got, tt.wrapped)
+		}
+		if got := bodyHtml(t, doc); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+		checkSiblings(t, doc)
+
+		// Running it again doesn't wrap the matches twice
+		if got := HighlightText(doc, re, tt.tag, tt.attrs); got != 0 {
+			t.Errorf("%s: second run: got %d wrapped, want 0", tt.name, got)
+		}
+		if got := bodyHtml(t, doc); got != tt.want {
+			t.Errorf("%s: second run:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightTextRendered(t *testing.T) {
+	doc := mustParse(t, `<html><head><title>Results for go</title></head><body><ul><li>Learn Go</li><li>Go, go, GO</li></ul></body></html>`)
+
+	if got := HighlightText(doc, regexp.MustCompile(`(?i)\bgo\b`), "", nil); got != 4 {
+		t.Errorf("got %d wrapped, want 4", got)
+	}
+
+	want := `<html><head><title>Results for go</title></head><body><ul>` +
+		`<li>Learn <mark>Go</mark></li><li><mark>Go</mark>, <mark>go</mark>, <mark>GO</mark></li></ul></body></html>`
+	if got := mustRender(t, doc); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
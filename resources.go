@@ -0,0 +1,177 @@
+package htmlutil
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ScriptInfo describes a script element found by ExtractScripts().
+type ScriptInfo struct {
+	// Src is the src of an external script, resolved against the base URL.
+	// It is nil for inline scripts, and for external scripts whose src can't
+	// be parsed.
+	Src *url.URL
+
+	// Inline reports whether the script has no src attribute, in which case
+	// Text holds its content.
+	Inline bool
+	Text   string
+
+	// Type is the type attribute, trimmed and lowercased.
+	Type string
+
+	Async    bool
+	Defer    bool
+	Module   bool
+	NoModule bool
+
+	Integrity   string
+	Nonce       string
+	CrossOrigin string
+
+	Node *html.Node
+}
+
+// StylesheetInfo describes a stylesheet found by ExtractStylesheets(): either
+// a <link rel="stylesheet"> element or a style element.
+type StylesheetInfo struct {
+	// Href is the href of a linked stylesheet, resolved against the base URL.
+	// It is nil for style elements, and for links whose href can't be parsed.
+	Href *url.URL
+
+	// Inline reports whether the stylesheet is a style element, in which case
+	// Text holds its content.
+	Inline bool
+	Text   string
+
+	Media string
+
+	// Alternate reports whether the link is an alternate stylesheet, which is
+	// not applied by default.
+	Alternate bool
+
+	Integrity   string
+	Nonce       string
+	CrossOrigin string
+
+	Node *html.Node
+}
+
+// ExtractScripts returns the script elements of the provided document, in
+// document order, which is their execution order apart from async and
+// deferred scripts.
+//
+// Relative URLs are resolved against base, or against the href of the
+// document's <base> element if there is one.
+func ExtractScripts(doc *html.Node, base *url.URL) []ScriptInfo {
+	scripts := []ScriptInfo{}
+	base, _ = documentBaseURL(doc, base)
+
+	for _, n := range GetAllHtmlNodesNS(doc, "", "script", "", "") {
+		script := ScriptInfo{
+			Type:        strings.ToLower(strings.TrimSpace(GetAttrOr(n, "type", ""))),
+			Integrity:   strings.TrimSpace(GetAttrOr(n, "integrity", "")),
+			Nonce:       GetAttrOr(n, "nonce", ""),
+			CrossOrigin: GetAttrOr(n, "crossorigin", ""),
+			Node:        n,
+		}
+		_, script.Async = GetAttr(n, "async")
+		_, script.Defer = GetAttr(n, "defer")
+		_, script.NoModule = GetAttr(n, "nomodule")
+		script.Module = script.Type == "module"
+
+		if src, ok := GetAttr(n, "src"); ok {
+			script.Src, _ = resolveURL(base, strings.TrimSpace(src))
+		} else {
+			script.Inline = true
+			script.Text = GetText(n)
+		}
+
+		scripts = append(scripts, script)
+	}
+
+	return scripts
+}
+
+// ExtractStylesheets returns the stylesheets of the provided document, linked
+// with <link rel="stylesheet"> or inline in style elements, in document
+// order, which is the order in which they cascade.
+//
+// Relative URLs are resolved against base, or against the href of the
+// document's <base> element if there is one.
+func ExtractStylesheets(doc *html.Node, base *url.URL) []StylesheetInfo {
+	sheets := []StylesheetInfo{}
+	base, _ = documentBaseURL(doc, base)
+
+	for _, n := range GetAllHtmlNodesFunc(doc, isStylesheet) {
+		sheet := StylesheetInfo{
+			Media:       strings.TrimSpace(GetAttrOr(n, "media", "")),
+			Integrity:   strings.TrimSpace(GetAttrOr(n, "integrity", "")),
+			Nonce:       GetAttrOr(n, "nonce", ""),
+			CrossOrigin: GetAttrOr(n, "crossorigin", ""),
+			Node:        n,
+		}
+
+		if n.Data == "style" {
+			sheet.Inline = true
+			sheet.Text = GetText(n)
+		} else {
+			sheet.Href, _ = linkURL(n, base)
+			sheet.Alternate = hasRelToken(n, "alternate")
+		}
+
+		sheets = append(sheets, sheet)
+	}
+
+	return sheets
+}
+
+// CheckSubresourceIntegrity returns the external script and stylesheet link
+// elements of the provided document that lack an integrity attribute, in
+// document order.
+//
+// If base, the URL of the document, is provided, resources with the same
+// origin as base are not reported, since integrity checks protect against
+// compromised third-party hosts. Relative URLs are resolved against the
+// document's <base> element if there is one, but its href doesn't change the
+// origin of the document: a <base> pointing at a CDN doesn't exempt the CDN.
+// Resources inside template elements are not loaded and are not reported.
+func CheckSubresourceIntegrity(doc *html.Node, base *url.URL) []*html.Node {
+	missing := []*html.Node{}
+	resolveBase, _ := documentBaseURL(doc, base)
+
+	check := func(n *html.Node, u *url.URL, integrity string) {
+		if integrity != "" {
+			return
+		}
+		if base != nil && u != nil && strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host) {
+			return
+		}
+		missing = append(missing, n)
+	}
+
+	for _, n := range renderedHtmlNodesFunc(doc, func(n *html.Node) bool {
+		return isStylesheet(n) || isHtmlElement(n, "script")
+	}) {
+		switch n.Data {
+		case "script":
+			if src, ok := GetAttr(n, "src"); ok {
+				u, _ := resolveURL(resolveBase, strings.TrimSpace(src))
+				check(n, u, strings.TrimSpace(GetAttrOr(n, "integrity", "")))
+			}
+		case "link":
+			u, _ := linkURL(n, resolveBase)
+			check(n, u, strings.TrimSpace(GetAttrOr(n, "integrity", "")))
+		}
+	}
+
+	return missing
+}
+
+// isStylesheet reports whether n is a style element or a link element with a
+// rel of stylesheet.
+func isStylesheet(n *html.Node) bool {
+	return isHtmlElement(n, "style") || (isHtmlElement(n, "link") && hasRelToken(n, "stylesheet"))
+}
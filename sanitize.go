@@ -0,0 +1,218 @@
+package htmlutil
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Policy is an allowlist describing the markup kept by Sanitize().
+type Policy struct {
+	// AllowedTags lists the elements that are kept.
+	AllowedTags []string
+
+	// AllowedAttrs maps a tag to the attributes kept on elements with that
+	// tag. The attributes listed under "*" are kept on every allowed element.
+	// Namespaced attributes are written as "namespace:key", for example
+	// "xlink:href".
+	AllowedAttrs map[string][]string
+
+	// AllowedURLSchemes lists the schemes allowed in URL attributes such as
+	// href and src. Relative URLs are always allowed.
+	AllowedURLSchemes []string
+
+	// UnwrapDisallowed keeps the content of disallowed elements by replacing
+	// them with their sanitized children. If false, disallowed elements are
+	// removed along with their content.
+	UnwrapDisallowed bool
+}
+
+// DefaultPolicy returns a Policy allowing basic formatting, lists, quotes,
+// and links to http, https, and mailto URLs. Disallowed elements are
+// unwrapped.
+func DefaultPolicy() Policy {
+	return Policy{
+		AllowedTags: []string{
+			"a", "abbr", "b", "blockquote", "br", "cite", "code", "del", "em",
+			"h1", "h2", "h3", "h4", "h5", "h6", "hr", "i", "ins", "kbd", "li",
+			"mark", "ol", "p", "pre", "q", "s", "small", "span", "strong", "sub",
+			"sup", "u", "ul",
+		},
+		AllowedAttrs: map[string][]string{
+			"*":          {"title"},
+			"a":          {"href", "rel"},
+			"blockquote": {"cite"},
+			"del":        {"cite", "datetime"},
+			"ins":        {"cite", "datetime"},
+			"ol":         {"start", "reversed"},
+			"q":          {"cite"},
+		},
+		AllowedURLSchemes: []string{"http", "https", "mailto"},
+		UnwrapDisallowed:  true,
+	}
+}
+
+// Sanitize removes the markup not allowed by the provided policy from the
+// descendants of the provided node, in place. The node itself is left as is,
+// so it is typically a body element or the container of a parsed fragment.
+//
+// Regardless of the policy, comments, event handler attributes such as
+// onclick, and URL attributes using the javascript:, vbscript:, or data:
+// schemes are always removed. The content of disallowed script, style, and
+// other raw text elements, and of disallowed SVG and MathML elements, is
+// always removed rather than unwrapped, since it would not be inert outside
+// of them. The html, head, and body elements are kept so that whole documents
+// can be sanitized.
+func Sanitize(n *html.Node, policy Policy) {
+	s := newSanitizer(policy)
+	s.sanitizeChildren(n)
+}
+
+// sanitizer holds a policy converted to sets for fast lookups.
+type sanitizer struct {
+	tags    map[string]bool
+	attrs   map[string]map[string]bool
+	schemes map[string]bool
+	unwrap  bool
+}
+
+func newSanitizer(policy Policy) *sanitizer {
+	s := &sanitizer{
+		tags:    stringSet(policy.AllowedTags),
+		attrs:   make(map[string]map[string]bool, len(policy.AllowedAttrs)),
+		schemes: make(map[string]bool, len(policy.AllowedURLSchemes)),
+		unwrap:  policy.UnwrapDisallowed,
+	}
+
+	for tag, attrs := range policy.AllowedAttrs {
+		s.attrs[tag] = stringSet(attrs)
+	}
+	for _, scheme := range policy.AllowedURLSchemes {
+		s.schemes[strings.ToLower(scheme)] = true
+	}
+
+	return s
+}
+
+func (s *sanitizer) sanitizeChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		// Take the next sibling before c is possibly removed or unwrapped
+		next := c.NextSibling
+
+		switch c.Type {
+		case html.CommentNode:
+			n.RemoveChild(c)
+		case html.ElementNode:
+			s.sanitizeElement(c)
+		}
+
+		c = next
+	}
+}
+
+func (s *sanitizer) sanitizeElement(n *html.Node) {
+	if s.allowsTag(n) {
+		s.sanitizeAttrs(n)
+		s.sanitizeChildren(n)
+		return
+	}
+
+	if s.unwrap && n.Namespace == "" && !childTextNodesAreLiteral(n) && n.Data != "template" {
+		s.sanitizeChildren(n)
+		unwrapHtmlNode(n)
+		return
+	}
+
+	n.Parent.RemoveChild(n)
+}
+
+func (s *sanitizer) allowsTag(n *html.Node) bool {
+	if n.Namespace == "" {
+		switch n.Data {
+		case "html", "head", "body":
+			return true
+		}
+	}
+	return s.tags[n.Data]
+}
+
+func (s *sanitizer) sanitizeAttrs(n *html.Node) {
+	attrs := n.Attr[:0]
+
+	for _, a := range n.Attr {
+		key := qualifiedAttrKey(a)
+
+		if !s.attrs["*"][key] && !s.attrs[n.Data][key] {
+			continue
+		}
+		if isEventHandlerAttr(a.Key) {
+			continue
+		}
+		if isURLAttr(a.Key) && !s.allowsURLs(a.Key, a.Val) {
+			continue
+		}
+
+		attrs = append(attrs, a)
+	}
+
+	n.Attr = attrs
+}
+
+// allowsURLs reports whether the URLs in the value of a URL attribute are all
+// allowed.
+func (s *sanitizer) allowsURLs(key string, val string) bool {
+	urls := []string{val}
+	if key == "srcset" {
+		urls = nil
+		for _, candidate := range splitSrcset(val) {
+			urls = append(urls, candidate.URL)
+		}
+	}
+
+	for _, raw := range urls {
+		if !s.allowsURL(raw) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *sanitizer) allowsURL(raw string) bool {
+	for _, scheme := range []string{"javascript", "vbscript", "data"} {
+		if urlSchemeIs(raw, scheme) {
+			return false
+		}
+	}
+
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || s.schemes[strings.ToLower(u.Scheme)]
+}
+
+// isEventHandlerAttr reports whether key is the name of an event handler
+// attribute such as onclick.
+func isEventHandlerAttr(key string) bool {
+	return len(key) > 2 && strings.EqualFold(key[:2], "on")
+}
+
+// isURLAttr reports whether key is the name of an attribute holding a URL.
+func isURLAttr(key string) bool {
+	switch strings.ToLower(key) {
+	case "action", "background", "cite", "formaction", "href", "longdesc",
+		"manifest", "poster", "src", "srcset":
+		return true
+	}
+	return false
+}
+
+// stringSet returns a set containing the provided strings.
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,116 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// sanitizeFragment parses markup as the content of a body element, sanitizes
+// it with the provided policy, and renders the result.
+func sanitizeFragment(t testing.TB, markup string, policy Policy) string {
+	t.Helper()
+
+	doc := mustParse(t, "<body>"+markup)
+	Sanitize(GetFirstHtmlNode(doc, "body", "", ""), policy)
+	return bodyHtml(t, doc)
+}
+
+func TestSanitizeDefaultPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		want   string
+	}{
+		{"allowed", `<p title="t">a <b>b</b> <a href="https://example.com/" rel="nofollow">c</a></p>`, `<p title="t">a <b>b</b> <a href="https://example.com/" rel="nofollow">c</a></p>`},
+		{"disallowed unwrapped", `<div><font color="red">a</font><p class="x">b</p></div>`, `a<p>b</p>`},
+		{"script removed", `<p>a<script>alert(1)</script>b</p>`, `<p>ab</p>`},
+		{"style removed", `<style>p { color: red }</style><p>a</p>`, `<p>a</p>`},
+		{"comments removed", `<p>a<!-- secret -->b</p>`, `<p>ab</p>`},
+		{"event handlers", `<p onclick="alert(1)" title="t" ONMOUSEOVER="x()">a</p>`, `<p title="t">a</p>`},
+		{"javascript href", `<a href="javascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"obfuscated javascript href", `<a href=" java&#09;script:alert(1)">a</a>`, `<a>a</a>`},
+		{"javascript href uppercase", `<a href="JaVaScRiPt:alert(1)">a</a>`, `<a>a</a>`},
+		{"data href", `<a href="data:text/html;base64,PHNjcmlwdD4=">a</a>`, `<a>a</a>`},
+		{"disallowed scheme", `<a href="ftp://example.com/">a</a>`, `<a>a</a>`},
+		{"relative href", `<a href="/page?q=1">a</a>`, `<a href="/page?q=1">a</a>`},
+		{"mailto href", `<a href="mailto:a@example.com">a</a>`, `<a href="mailto:a@example.com">a</a>`},
+		{"quotes in attribute values", `<p title='"><script>alert(1)</script>'>a</p>`, `<p title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">a</p>`},
+		{"svg style", `<svg><style><img src="x" onerror="alert(1)"></style></svg><p>a</p>`, `<p>a</p>`},
+		{"svg foreignobject", `<svg><foreignObject><p onclick="x()">a</p></foreignObject></svg>`, ``},
+		{"math style", `<math><mtext><table><mglyph><style><img src="x" onerror="alert(1)">`, ``},
+		{"noscript", `<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`, `&#34;&gt;`},
+		{"template", `<template><img src="x" onerror="alert(1)"></template><p>a</p>`, `<p>a</p>`},
+		{"iframe", `<iframe src="https://evil.example/"></iframe>`, ``},
+		{"textarea", `<textarea></textarea><img src=x onerror=alert(1)></textarea>`, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeFragment(t, tt.markup, DefaultPolicy()); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeDropDisallowed(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.UnwrapDisallowed = false
+
+	got := sanitizeFragment(t, `<p>a <span>b</span> <div>c</div></p><section><p>d</p></section>`, policy)
+	if want := `<p>a <span>b</span> </p><p></p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeCustomPolicy(t *testing.T) {
+	policy := Policy{
+		AllowedTags: []string{"img", "svg", "use", "a"},
+		AllowedAttrs: map[string][]string{
+			"*":   {"id", "onclick"},
+			"img": {"src", "srcset", "alt"},
+			"use": {"xlink:href"},
+			"a":   {"href"},
+		},
+		AllowedURLSchemes: []string{"https", "data"},
+	}
+
+	tests := []struct {
+		name   string
+		markup string
+		want   string
+	}{
+		{"attributes per tag", `<img id="i" src="https://example.com/a.png" alt="A" width="10">`, `<img id="i" src="https://example.com/a.png" alt="A"/>`},
+		{"event handlers despite policy", `<a id="a" onclick="x()" href="https://example.com/">a</a>`, `<a id="a" href="https://example.com/">a</a>`},
+		{"data despite policy", `<img src="data:image/png;base64,AAAA">`, `<img/>`},
+		{"srcset", `<img srcset="https://example.com/a.png 1x, javascript:alert(1) 2x">`, `<img/>`},
+		{"allowed srcset", `<img srcset="/a.png 1x, https://example.com/b.png 2x">`, `<img srcset="/a.png 1x, https://example.com/b.png 2x"/>`},
+		{"namespaced attribute", `<svg><use xlink:href="#icon" href="#plain"></use></svg>`, `<svg><use xlink:href="#icon"></use></svg>`},
+		{"disallowed dropped", `<p>a</p><img src="/b.png">`, `<img src="/b.png"/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeFragment(t, tt.markup, policy); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeDocument(t *testing.T) {
+	doc := mustParse(t, `<html><head><title>T</title><script>x()</script></head><body onload="x()"><p>a</p></body></html>`)
+
+	Sanitize(doc, DefaultPolicy())
+
+	// The title is unwrapped like any other disallowed element, leaving its
+	// escaped text in place
+	if got, want := mustRender(t, doc), `<html><head>T</head><body><p>a</p></body></html>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The tree can still be queried afterwards
+	if got := GetAllHtmlNodes(doc, "p", "", ""); len(got) != 1 || !strings.Contains(GetText(got[0]), "a") {
+		t.Errorf("got %v", got)
+	}
+}
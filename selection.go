@@ -0,0 +1,129 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selection is a list of HTML nodes with chainable methods for querying and
+// modifying them. Methods are safe to call on an empty selection.
+type Selection struct {
+	Nodes []*html.Node
+}
+
+// Select returns a selection containing only the provided node, to start a
+// chain of queries.
+func Select(root *html.Node) Selection {
+	if root == nil {
+		return Selection{}
+	}
+	return Selection{Nodes: []*html.Node{root}}
+}
+
+// Len returns the number of nodes in the selection.
+func (s Selection) Len() int {
+	return len(s.Nodes)
+}
+
+// Find returns the descendants of the nodes in the selection matching the
+// provided tag, attribute, and attribute value, using the same criteria as
+// GetHtmlNodes(). Each node appears only once in the result.
+func (s Selection) Find(tag string, attr string, attrValue string) Selection {
+	var found []*html.Node
+	seen := make(map[*html.Node]bool)
+
+	for _, root := range s.Nodes {
+		for _, n := range GetAllHtmlNodes(root, tag, attr, attrValue) {
+			if n != root && !seen[n] {
+				seen[n] = true
+				found = append(found, n)
+			}
+		}
+	}
+
+	return Selection{Nodes: found}
+}
+
+// Filter returns the nodes in the selection for which keep returns true.
+func (s Selection) Filter(keep func(*html.Node) bool) Selection {
+	return Selection{Nodes: FilterNodes(s.Nodes, keep)}
+}
+
+// First returns a selection containing only the first node of the selection,
+// or an empty selection if it is empty.
+func (s Selection) First() Selection {
+	if len(s.Nodes) == 0 {
+		return Selection{}
+	}
+	return Selection{Nodes: s.Nodes[:1:1]}
+}
+
+// Each calls f for each node in the selection along with its index.
+func (s Selection) Each(f func(i int, n *html.Node)) Selection {
+	for i, n := range s.Nodes {
+		f(i, n)
+	}
+	return s
+}
+
+// Text returns the normalized text of each node in the selection, joined with
+// spaces.
+func (s Selection) Text() string {
+	texts := make([]string, 0, len(s.Nodes))
+
+	for _, n := range s.Nodes {
+		if text := GetNormalizedText(n); text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	return strings.Join(texts, " ")
+}
+
+// Attr returns the value of the provided attribute of the first node in the
+// selection. The boolean result reports whether the attribute was found.
+func (s Selection) Attr(key string) (string, bool) {
+	if len(s.Nodes) == 0 {
+		return "", false
+	}
+	return GetAttr(s.Nodes[0], key)
+}
+
+// SetAttr sets the provided attribute on every node in the selection.
+func (s Selection) SetAttr(key string, value string) Selection {
+	for _, n := range s.Nodes {
+		SetHtmlAttr(n, key, value)
+	}
+	return s
+}
+
+// AddClass adds the provided classes to every node in the selection.
+func (s Selection) AddClass(classes ...string) Selection {
+	for _, n := range s.Nodes {
+		AddClass(n, classes...)
+	}
+	return s
+}
+
+// Remove removes every node in the selection from its parent, as
+// RemoveNodes() does. Nodes without a parent are left as they are, and so are
+// nodes inside another node of the selection, which is removed with them.
+func (s Selection) Remove() Selection {
+	RemoveNodes(s.Nodes)
+	return s
+}
+
+// Render returns the rendered HTML of every node in the selection,
+// concatenated.
+func (s Selection) Render() (string, error) {
+	var sb strings.Builder
+
+	for _, n := range s.Nodes {
+		if err := html.Render(&sb, n); err != nil {
+			return "", err
+		}
+	}
+
+	return sb.String(), nil
+}
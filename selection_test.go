@@ -0,0 +1,21 @@
+package htmlutil
+
+import "testing"
+
+func TestSelectionRemoveNested(t *testing.T) {
+	doc := mustParse(t, `<div class="ad"><p class="ad">nested</p><span>kept</span></div><p>b</p>`)
+	ads := Select(doc).Find("", "class", "ad")
+	div := ads.Nodes[0]
+
+	ads.Remove()
+	checkSiblings(t, doc)
+
+	if got := bodyHtml(t, doc); got != `<p>b</p>` {
+		t.Errorf("got %q", got)
+	}
+
+	// The nested node is left within its removed ancestor
+	if got := mustRender(t, div); got != `<div class="ad"><p class="ad">nested</p><span>kept</span></div>` {
+		t.Errorf("got removed subtree %q", got)
+	}
+}
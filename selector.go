@@ -0,0 +1,542 @@
+package htmlutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Selector is a compiled CSS selector that can be matched against many
+// documents.
+//
+// The supported syntax is a subset of CSS Selectors Level 3: type selectors
+// (including *), #id, .class, attribute selectors ([attr], [attr=value],
+// [attr~=value], [attr|=value], [attr^=value], [attr$=value], [attr*=value],
+// optionally followed by an i flag for case-insensitive values), the
+// descendant, child (>), next-sibling (+), and subsequent-sibling (~)
+// combinators, and comma-separated selector lists. Pseudo-classes and
+// pseudo-elements are not supported.
+type Selector struct {
+	source string
+	groups []complexSelector
+}
+
+// complexSelector is a sequence of compound selectors joined by combinators.
+// combinators[i] sits between compounds[i] and compounds[i+1].
+type complexSelector struct {
+	compounds   []compoundSelector
+	combinators []byte
+}
+
+// compoundSelector is a sequence of simple selectors that must all match the
+// same element.
+type compoundSelector struct {
+	tag     string
+	ids     []string
+	classes []string
+	attrs   []attrSelector
+}
+
+// attrSelector is a single attribute selector such as [href^="https:"].
+type attrSelector struct {
+	key             string
+	op              string
+	value           string
+	caseInsensitive bool
+}
+
+// CompileSelector parses a CSS selector so it can be reused. An error
+// describing the problem and its position is returned if the selector is
+// invalid.
+func CompileSelector(selector string) (*Selector, error) {
+	p := selectorParser{s: selector}
+
+	groups, err := p.parseSelectorList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Selector{source: selector, groups: groups}, nil
+}
+
+// MustCompileSelector is like CompileSelector() but panics if the selector is
+// invalid. It simplifies safe initialization of global variables.
+func MustCompileSelector(selector string) *Selector {
+	s, err := CompileSelector(selector)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// QuerySelectorAll returns the descendants of the provided node that match the
+// provided CSS selector, in document order.
+func QuerySelectorAll(n *html.Node, selector string) ([]*html.Node, error) {
+	s, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return s.FindAll(n), nil
+}
+
+// QuerySelector returns the first descendant of the provided node that matches
+// the provided CSS selector, or ErrNodeNotFound if there is no match.
+func QuerySelector(n *html.Node, selector string) (*html.Node, error) {
+	s, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return s.FindFirst(n)
+}
+
+// String returns the source text of the selector.
+func (s *Selector) String() string {
+	return s.source
+}
+
+// Match reports whether the provided node matches the selector.
+func (s *Selector) Match(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+
+	for i := range s.groups {
+		if s.groups[i].match(n, len(s.groups[i].compounds)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAll returns the descendants of the provided node that match the
+// selector, in document order.
+func (s *Selector) FindAll(n *html.Node) []*html.Node {
+	return s.find(n, -1)
+}
+
+// FindFirst returns the first descendant of the provided node that matches the
+// selector, or ErrNodeNotFound if there is no match.
+func (s *Selector) FindFirst(n *html.Node) (*html.Node, error) {
+	return firstHtmlNode(s.find(n, 1))
+}
+
+func (s *Selector) find(root *html.Node, count int) []*html.Node {
+	return GetHtmlNodesFunc(root, func(n *html.Node) bool {
+		return n != root && s.Match(n)
+	}, count)
+}
+
+// match reports whether n matches the complex selector up to and including
+// the compound selector at index i, working from right to left.
+func (c *complexSelector) match(n *html.Node, i int) bool {
+	if !c.compounds[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	switch c.combinators[i-1] {
+	case ' ':
+		for p := parentElement(n); p != nil; p = parentElement(p) {
+			if c.match(p, i-1) {
+				return true
+			}
+		}
+	case '>':
+		if p := parentElement(n); p != nil {
+			return c.match(p, i-1)
+		}
+	case '+':
+		if p := PrevElementSibling(n); p != nil {
+			return c.match(p, i-1)
+		}
+	case '~':
+		for p := PrevElementSibling(n); p != nil; p = PrevElementSibling(p) {
+			if c.match(p, i-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *compoundSelector) match(n *html.Node) bool {
+	if c.tag != "" && !strings.EqualFold(n.Data, c.tag) {
+		return false
+	}
+
+	for _, id := range c.ids {
+		if val, ok := GetAttr(n, "id"); !ok || val != id {
+			return false
+		}
+	}
+
+	for _, class := range c.classes {
+		if !HasClass(n, class) {
+			return false
+		}
+	}
+
+	for i := range c.attrs {
+		if !c.attrs[i].match(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *attrSelector) match(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Namespace != "" || !strings.EqualFold(attr.Key, a.key) {
+			continue
+		}
+
+		val, want := attr.Val, a.value
+		if a.caseInsensitive {
+			val, want = strings.ToLower(val), strings.ToLower(want)
+		}
+
+		switch a.op {
+		case "":
+			return true
+		case "=":
+			return val == want
+		case "~=":
+			for _, token := range splitHtmlTokens(val) {
+				if token == want {
+					return true
+				}
+			}
+			return false
+		case "|=":
+			return val == want || strings.HasPrefix(val, want+"-")
+		case "^=":
+			return want != "" && strings.HasPrefix(val, want)
+		case "$=":
+			return want != "" && strings.HasSuffix(val, want)
+		case "*=":
+			return want != "" && strings.Contains(val, want)
+		}
+		return false
+	}
+	return false
+}
+
+// parentElement returns the parent of n if it is an element node.
+func parentElement(n *html.Node) *html.Node {
+	if p := n.Parent; p != nil && p.Type == html.ElementNode {
+		return p
+	}
+	return nil
+}
+
+// selectorParser is a recursive descent parser for CSS selectors.
+type selectorParser struct {
+	s   string
+	pos int
+}
+
+func (p *selectorParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("htmlutil: invalid selector %q at offset %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *selectorParser) parseSelectorList() ([]complexSelector, error) {
+	var groups []complexSelector
+
+	for {
+		p.skipWhitespace()
+
+		c, err := p.parseComplexSelector()
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, c)
+
+		p.skipWhitespace()
+		if p.pos >= len(p.s) {
+			return groups, nil
+		}
+		if p.s[p.pos] != ',' {
+			return nil, p.errorf("unexpected %q", p.s[p.pos])
+		}
+		p.pos++
+	}
+}
+
+func (p *selectorParser) parseComplexSelector() (complexSelector, error) {
+	var c complexSelector
+
+	compound, err := p.parseCompoundSelector()
+	if err != nil {
+		return c, err
+	}
+	c.compounds = append(c.compounds, compound)
+
+	for {
+		hadWhitespace := p.skipWhitespace()
+		if p.pos >= len(p.s) || p.s[p.pos] == ',' {
+			return c, nil
+		}
+
+		combinator := byte(' ')
+		switch p.s[p.pos] {
+		case '>', '+', '~':
+			combinator = p.s[p.pos]
+			p.pos++
+			p.skipWhitespace()
+		default:
+			if !hadWhitespace {
+				return c, p.errorf("unexpected %q", p.s[p.pos])
+			}
+		}
+
+		compound, err := p.parseCompoundSelector()
+		if err != nil {
+			return c, err
+		}
+		c.compounds = append(c.compounds, compound)
+		c.combinators = append(c.combinators, combinator)
+	}
+}
+
+func (p *selectorParser) parseCompoundSelector() (compoundSelector, error) {
+	var c compoundSelector
+	start := p.pos
+
+	if p.pos < len(p.s) && p.s[p.pos] == '*' {
+		p.pos++
+	} else if p.startsIdentifier() {
+		tag, err := p.parseIdentifier()
+		if err != nil {
+			return c, err
+		}
+		c.tag = strings.ToLower(tag)
+	}
+
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '#':
+			p.pos++
+			id, err := p.parseName()
+			if err != nil {
+				return c, err
+			}
+			c.ids = append(c.ids, id)
+		case '.':
+			p.pos++
+			class, err := p.parseIdentifier()
+			if err != nil {
+				return c, err
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			p.pos++
+			a, err := p.parseAttrSelector()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, a)
+		case ':':
+			return c, p.errorf("pseudo-classes are not supported")
+		default:
+			if p.pos == start {
+				return c, p.errorf("expected selector, found %q", p.s[p.pos])
+			}
+			return c, nil
+		}
+	}
+
+	if p.pos == start {
+		return c, p.errorf("expected selector, found end of input")
+	}
+	return c, nil
+}
+
+func (p *selectorParser) parseAttrSelector() (attrSelector, error) {
+	var a attrSelector
+
+	p.skipWhitespace()
+	key, err := p.parseIdentifier()
+	if err != nil {
+		return a, err
+	}
+	a.key = strings.ToLower(key)
+	p.skipWhitespace()
+
+	if p.pos >= len(p.s) {
+		return a, p.errorf("unterminated attribute selector")
+	}
+	if p.s[p.pos] == ']' {
+		p.pos++
+		return a, nil
+	}
+
+	switch {
+	case p.s[p.pos] == '=':
+		a.op = "="
+		p.pos++
+	case strings.IndexByte("~|^$*", p.s[p.pos]) >= 0 && p.pos+1 < len(p.s) && p.s[p.pos+1] == '=':
+		a.op = p.s[p.pos : p.pos+2]
+		p.pos += 2
+	default:
+		return a, p.errorf("unexpected %q in attribute selector", p.s[p.pos])
+	}
+	p.skipWhitespace()
+
+	if p.pos >= len(p.s) {
+		return a, p.errorf("missing attribute value")
+	}
+	if q := p.s[p.pos]; q == '"' || q == '\'' {
+		a.value, err = p.parseString()
+	} else {
+		a.value, err = p.parseIdentifier()
+	}
+	if err != nil {
+		return a, err
+	}
+	p.skipWhitespace()
+
+	if p.pos < len(p.s) && (p.s[p.pos] == 'i' || p.s[p.pos] == 'I' || p.s[p.pos] == 's' || p.s[p.pos] == 'S') {
+		a.caseInsensitive = p.s[p.pos] == 'i' || p.s[p.pos] == 'I'
+		p.pos++
+		p.skipWhitespace()
+	}
+
+	if p.pos >= len(p.s) || p.s[p.pos] != ']' {
+		return a, p.errorf("unterminated attribute selector")
+	}
+	p.pos++
+	return a, nil
+}
+
+// skipWhitespace advances past any whitespace and reports whether there was
+// any.
+func (p *selectorParser) skipWhitespace() bool {
+	start := p.pos
+	for p.pos < len(p.s) && isHtmlSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) startsIdentifier() bool {
+	if p.pos >= len(p.s) {
+		return false
+	}
+
+	c := p.s[p.pos]
+	if c == '-' && p.pos+1 < len(p.s) {
+		c = p.s[p.pos+1]
+	}
+	return isNameStart(c) || c == '\\'
+}
+
+// parseIdentifier parses a CSS identifier, which may not start with a digit.
+func (p *selectorParser) parseIdentifier() (string, error) {
+	if !p.startsIdentifier() {
+		if p.pos >= len(p.s) {
+			return "", p.errorf("expected identifier, found end of input")
+		}
+		return "", p.errorf("expected identifier, found %q", p.s[p.pos])
+	}
+	return p.parseName()
+}
+
+// parseName parses a sequence of CSS name characters, as used after #.
+func (p *selectorParser) parseName() (string, error) {
+	var sb strings.Builder
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(r)
+		case isNameStart(c) || c == '-' || (c >= '0' && c <= '9'):
+			sb.WriteByte(c)
+			p.pos++
+		default:
+			if sb.Len() == 0 {
+				return "", p.errorf("expected name, found %q", c)
+			}
+			return sb.String(), nil
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "", p.errorf("expected name, found end of input")
+	}
+	return sb.String(), nil
+}
+
+// parseEscape parses a backslash escape, either up to six hex digits followed
+// by optional whitespace or a single literal character.
+func (p *selectorParser) parseEscape() (rune, error) {
+	p.pos++
+	if p.pos >= len(p.s) {
+		return 0, p.errorf("incomplete escape sequence")
+	}
+
+	end := p.pos
+	for end < len(p.s) && end-p.pos < 6 && isHexDigit(p.s[end]) {
+		end++
+	}
+	if end > p.pos {
+		code, _ := strconv.ParseUint(p.s[p.pos:end], 16, 32)
+		p.pos = end
+		if p.pos < len(p.s) && isHtmlSpace(rune(p.s[p.pos])) {
+			p.pos++
+		}
+		if code == 0 || code > utf8.MaxRune {
+			return utf8.RuneError, nil
+		}
+		return rune(code), nil
+	}
+
+	r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+	p.pos += size
+	return r, nil
+}
+
+func (p *selectorParser) parseString() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch c {
+		case quote:
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(r)
+		case '\n':
+			return "", p.errorf("newline in string")
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= utf8.RuneSelf
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
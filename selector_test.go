@@ -0,0 +1,212 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// selectorDoc has elements to exercise every combinator and attribute
+// selector operator.
+const selectorDoc = `<div id="main" class="content wide">` +
+	`<h1 id="title">Title</h1>` +
+	`<p id="p1" class="intro lead" lang="en-US">One <a id="a1" href="https://example.com/a.pdf" rel="external nofollow">pdf</a></p>` +
+	`<p id="p2" lang="en">Two <span id="s1"><a id="a2" href="/local" title="Local Link">local</a></span></p>` +
+	`<ul id="list"><li id="l1" data-state="Open">1</li><li id="l2">2</li><li id="l3" data-state="">3</li></ul>` +
+	`</div>` +
+	`<p id="p3" class="outro">Three</p>` +
+	`<svg id="svg"><a id="svg-a" xlink:href="#x"></a><foreignObject id="fo"></foreignObject></svg>` +
+	`<div id="1x" class="2col a:b"></div>`
+
+// selectedIds returns the ids of the nodes matching selector in selectorDoc.
+func selectedIds(t *testing.T, selector string) string {
+	t.Helper()
+
+	nodes, err := QuerySelectorAll(mustParse(t, selectorDoc), selector)
+	if err != nil {
+		t.Fatalf("%q: %v", selector, err)
+	}
+
+	var ids []string
+	for _, n := range nodes {
+		ids = append(ids, GetAttrOr(n, "id", n.Data))
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestQuerySelectorAll(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		// Type, universal, id, and class selectors
+		{"h1", "title"},
+		{"H1", "title"},
+		{"p", "p1 p2 p3"},
+		{"#p2", "p2"},
+		{"p#p2", "p2"},
+		{"div#p2", ""},
+		{".intro", "p1"},
+		{".intro.lead", "p1"},
+		{"p.lead.intro#p1", "p1"},
+		{".missing", ""},
+		{"ul > *", "l1 l2 l3"},
+		{"foreignobject", "fo"},
+		{"#\\31 x", "1x"},
+		{".\\32 col", "1x"},
+		{".a\\:b", "1x"},
+
+		// Combinators
+		{"div a", "a1 a2"},
+		{"div > a", ""},
+		{"p > a", "a1"},
+		{"p a", "a1 a2"},
+		{"#main > p > span > a", "a2"},
+		{"h1 + p", "p1"},
+		{"h1 + ul", ""},
+		{"h1 ~ p", "p1 p2"},
+		{"h1 ~ *", "p1 p2 list"},
+		{"li + li", "l2 l3"},
+		{"li ~ li ~ li", "l3"},
+		{"#main ~ p", "p3"},
+		{"div  >  p+p   a", "a2"},
+		{"div\n\tp", "p1 p2"},
+
+		// Attribute selectors
+		{"[lang]", "p1 p2"},
+		{"[LANG]", "p1 p2"},
+		{"[lang=en]", "p2"},
+		{`[lang="en-US"]`, "p1"},
+		{"[lang|=en]", "p1 p2"},
+		{"[lang|=en-US]", "p1"},
+		{"[rel~=nofollow]", "a1"},
+		{"[rel~=follow]", ""},
+		{"[href^='https:']", "a1"},
+		{"[href$=\".pdf\"]", "a1"},
+		{"[href*=local]", "a2"},
+		{"[href^='']", ""},
+		{"[href$='']", ""},
+		{"[href*='']", ""},
+		{"[title='local link']", ""},
+		{"[title='local link' i]", "a2"},
+		{"[title='Local Link' s]", "a2"},
+		{"[ data-state = open i ]", "l1"},
+		{"[data-state]", "l1 l3"},
+		{"[data-state='']", "l3"},
+		{"li[data-state][id=l1]", "l1"},
+		{"[href='#x']", ""},
+
+		// Selector lists keep document order
+		{"#p3, h1", "title p3"},
+		{"p, .intro", "p1 p2 p3"},
+		{" li + li , #title ", "title l2 l3"},
+	}
+
+	for _, tt := range tests {
+		if got := selectedIds(t, tt.selector); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestCompileSelectorErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{"", "expected selector, found end of input"},
+		{"   ", "expected selector, found end of input"},
+		{"p,", "expected selector, found end of input"},
+		{",p", `expected selector, found ','`},
+		{"p,,a", `expected selector, found ','`},
+		{"p >", "expected selector, found end of input"},
+		{"p > > a", `expected selector, found '>'`},
+		{"> p", `expected selector, found '>'`},
+		{"p:hover", "pseudo-classes are not supported"},
+		{"li:not(.a)", "pseudo-classes are not supported"},
+		{"li:nth-child(2n+1)", "pseudo-classes are not supported"},
+		{":nth-of-type(2)", "pseudo-classes are not supported"},
+		{"p::before", "pseudo-classes are not supported"},
+		{"#", "expected name, found end of input"},
+		{"#.a", `expected name, found '.'`},
+		{".", "expected identifier, found end of input"},
+		{".1a", `expected identifier, found '1'`},
+		{"1p", `expected selector, found '1'`},
+		{"p!", `unexpected '!'`},
+		{"p)", `unexpected ')'`},
+		{"[", "expected identifier, found end of input"},
+		{"[href", "unterminated attribute selector"},
+		{"[href=", "missing attribute value"},
+		{"[href=a", "unterminated attribute selector"},
+		{"[href=a b]", "unterminated attribute selector"},
+		{"[href!=a]", `unexpected '!' in attribute selector`},
+		{"[href~a]", `unexpected '~' in attribute selector`},
+		{"[href=1]", `expected identifier, found '1'`},
+		{"[href='a]", "unterminated string"},
+		{"[href='a\nb']", "newline in string"},
+		{"[='a']", `expected identifier, found '='`},
+		{"p\\", "incomplete escape sequence"},
+	}
+
+	for _, tt := range tests {
+		s, err := CompileSelector(tt.selector)
+		if err == nil {
+			t.Errorf("%q: got %v, want an error", tt.selector, s)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got error %q, want %q", tt.selector, err, tt.want)
+		}
+		if _, err := QuerySelectorAll(mustParse(t, selectorDoc), tt.selector); err == nil {
+			t.Errorf("%q: QuerySelectorAll got no error", tt.selector)
+		}
+	}
+}
+
+func TestSelectorErrorOffset(t *testing.T) {
+	_, err := CompileSelector("div > p:first-child")
+	if err == nil || !strings.Contains(err.Error(), "at offset 7") {
+		t.Errorf("got %v, want the offset of the colon", err)
+	}
+}
+
+func TestSelector(t *testing.T) {
+	doc := mustParse(t, selectorDoc)
+	s := MustCompileSelector("div p > a")
+
+	if got := s.String(); got != "div p > a" {
+		t.Errorf("String: got %q", got)
+	}
+	if a1 := GetElementById(doc, "a1"); !s.Match(a1) {
+		t.Error("Match: got false for a1")
+	}
+	if s.Match(GetElementById(doc, "p1")) || s.Match(nil) || s.Match(doc) {
+		t.Error("Match: got true for a node that isn't a matching element")
+	}
+
+	first, err := s.FindFirst(doc)
+	if err != nil || GetAttrOr(first, "id", "") != "a1" {
+		t.Errorf("FindFirst: got %v, %v", first, err)
+	}
+	if _, err := MustCompileSelector("table").FindFirst(doc); err != ErrNodeNotFound {
+		t.Errorf("FindFirst: got %v, want ErrNodeNotFound", err)
+	}
+	if n, err := QuerySelector(doc, "li + li"); err != nil || GetAttrOr(n, "id", "") != "l2" {
+		t.Errorf("QuerySelector: got %v, %v", n, err)
+	}
+
+	// The root isn't part of the results, but its ancestors are considered
+	main := GetElementById(doc, "main")
+	if got := MustCompileSelector("div").FindAll(main); len(got) != 0 {
+		t.Errorf("FindAll: got the root in %v", nodeNames(got))
+	}
+	if got := MustCompileSelector("body div p").FindAll(main); len(got) != 2 {
+		t.Errorf("FindAll: got %d nodes, want 2", len(got))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompileSelector didn't panic for an invalid selector")
+		}
+	}()
+	MustCompileSelector("a[")
+}
@@ -0,0 +1,294 @@
+package htmlutil
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// SEORule identifies a check of AuditSEO().
+type SEORule string
+
+const (
+	// SEOTitleMissing flags documents without a title element, or with an
+	// empty one.
+	SEOTitleMissing SEORule = "title-missing"
+
+	// SEOTitleDuplicate flags title elements after the first one.
+	SEOTitleDuplicate SEORule = "title-duplicate"
+
+	// SEODescriptionMissing flags documents without a <meta name="description">
+	// element with non-empty content. An og:description alone doesn't count,
+	// since search engines don't use it as the page description.
+	SEODescriptionMissing SEORule = "description-missing"
+
+	// SEOMultipleH1 flags h1 elements after the first one.
+	SEOMultipleH1 SEORule = "multiple-h1"
+
+	// SEOHeadingSkip flags headings more than one level below the previous
+	// heading, such as an h4 following an h2.
+	SEOHeadingSkip SEORule = "heading-skip"
+
+	// SEOInternalNofollow flags rel="nofollow" links to the host of the page.
+	SEOInternalNofollow SEORule = "internal-nofollow"
+
+	// SEOImageAlt flags images at least SEOOptions.ImageAltMinSize pixels
+	// wide or high, 100 by default, according to their width and height
+	// attributes, without a descriptive alt attribute. An alt attribute is
+	// not descriptive if it is empty, a generic word such as "image", or the
+	// file name of the image.
+	SEOImageAlt SEORule = "image-alt"
+
+	// SEOCanonicalMismatch flags a canonical link that doesn't point to the
+	// page URL.
+	SEOCanonicalMismatch SEORule = "canonical-mismatch"
+)
+
+// SEOIssue is a problem found by AuditSEO(). Node is the element the issue is
+// about, or the document itself for issues about something missing.
+type SEOIssue struct {
+	Rule    SEORule
+	Message string
+	Node    *html.Node
+}
+
+// DefaultSEOImageAltMinSize is the size from which AuditSEO() flags images
+// without a descriptive alt attribute.
+const DefaultSEOImageAltMinSize = 100
+
+// SEOOptions controls the built-in checks of AuditSEOWithOptions().
+type SEOOptions struct {
+	// ImageAltMinSize is the width or height, in pixels, from which images
+	// without a descriptive alt attribute are flagged by SEOImageAlt. If 0,
+	// DefaultSEOImageAltMinSize is used.
+	ImageAltMinSize int
+}
+
+// SEOCheck is a check run by AuditSEO(). The page URL may be nil.
+type SEOCheck func(doc *html.Node, pageURL *url.URL) []SEOIssue
+
+// seoCheck is a registered check, which the built-in checks implement to
+// receive the options of the audit.
+type seoCheck func(doc *html.Node, pageURL *url.URL, opts SEOOptions) []SEOIssue
+
+var seoRegistry = struct {
+	sync.Mutex
+	rules  []SEORule
+	checks map[SEORule]seoCheck
+}{checks: make(map[SEORule]seoCheck)}
+
+func init() {
+	registerSEORule(SEOTitleMissing, withoutSEOOptions(checkSEOTitleMissing))
+	registerSEORule(SEOTitleDuplicate, withoutSEOOptions(checkSEOTitleDuplicate))
+	registerSEORule(SEODescriptionMissing, withoutSEOOptions(checkSEODescriptionMissing))
+	registerSEORule(SEOMultipleH1, withoutSEOOptions(checkSEOMultipleH1))
+	registerSEORule(SEOHeadingSkip, withoutSEOOptions(checkSEOHeadingSkip))
+	registerSEORule(SEOInternalNofollow, withoutSEOOptions(checkSEOInternalNofollow))
+	registerSEORule(SEOImageAlt, checkSEOImageAlt)
+	registerSEORule(SEOCanonicalMismatch, withoutSEOOptions(checkSEOCanonicalMismatch))
+}
+
+// RegisterSEORule adds a check to the ones run by AuditSEO(), after those
+// already registered. The rule constants of this package are registered in
+// their order of declaration. RegisterSEORule panics if the rule is already
+// registered or the check is nil.
+func RegisterSEORule(rule SEORule, check SEOCheck) {
+	if check == nil {
+		panic("htmlutil: nil check for SEO rule " + strconv.Quote(string(rule)))
+	}
+	registerSEORule(rule, withoutSEOOptions(check))
+}
+
+func registerSEORule(rule SEORule, check seoCheck) {
+	seoRegistry.Lock()
+	defer seoRegistry.Unlock()
+
+	if _, ok := seoRegistry.checks[rule]; ok {
+		panic("htmlutil: SEO rule " + strconv.Quote(string(rule)) + " registered twice")
+	}
+
+	seoRegistry.rules = append(seoRegistry.rules, rule)
+	seoRegistry.checks[rule] = check
+}
+
+// withoutSEOOptions adapts a check that doesn't use the options of the audit.
+func withoutSEOOptions(check SEOCheck) seoCheck {
+	return func(doc *html.Node, pageURL *url.URL, opts SEOOptions) []SEOIssue {
+		return check(doc, pageURL)
+	}
+}
+
+// SEORules returns the registered rules, in the order they are run.
+func SEORules() []SEORule {
+	seoRegistry.Lock()
+	defer seoRegistry.Unlock()
+
+	return append([]SEORule(nil), seoRegistry.rules...)
+}
+
+// AuditSEO runs the registered checks on the provided document and returns
+// the issues found, grouped by rule in registration order. The page URL is
+// used to tell internal links apart and to validate the canonical URL, and may
+// be nil, in which case only relative links are internal and the canonical URL
+// is not validated. The rules to ignore can be filtered out of the result.
+//
+// The built-in checks skip the content of template elements, which isn't
+// rendered.
+func AuditSEO(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	return AuditSEOWithOptions(doc, pageURL, SEOOptions{})
+}
+
+// AuditSEOWithOptions is like AuditSEO() but allows the built-in checks to be
+// tuned with SEOOptions.
+func AuditSEOWithOptions(doc *html.Node, pageURL *url.URL, opts SEOOptions) []SEOIssue {
+	if opts.ImageAltMinSize <= 0 {
+		opts.ImageAltMinSize = DefaultSEOImageAltMinSize
+	}
+
+	seoRegistry.Lock()
+	checks := make([]seoCheck, len(seoRegistry.rules))
+	for i, rule := range seoRegistry.rules {
+		checks[i] = seoRegistry.checks[rule]
+	}
+	seoRegistry.Unlock()
+
+	issues := []SEOIssue{}
+	for _, check := range checks {
+		issues = append(issues, check(doc, pageURL, opts)...)
+	}
+	return issues
+}
+
+func checkSEOTitleMissing(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	titles := renderedHtmlNodesFunc(doc, isHtmlTitle)
+	if len(titles) > 0 && GetNormalizedText(titles[0]) != "" {
+		return nil
+	}
+	return []SEOIssue{{SEOTitleMissing, "document has no title", doc}}
+}
+
+func checkSEOTitleDuplicate(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	var issues []SEOIssue
+	for i, title := range renderedHtmlNodesFunc(doc, isHtmlTitle) {
+		if i > 0 {
+			issues = append(issues, SEOIssue{SEOTitleDuplicate, "document has more than one title", title})
+		}
+	}
+	return issues
+}
+
+func checkSEODescriptionMissing(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	descriptions := renderedHtmlNodesFunc(doc, func(n *html.Node) bool {
+		return isHtmlElement(n, "meta") && strings.EqualFold(strings.TrimSpace(GetAttrOr(n, "name", "")), "description") &&
+			strings.TrimSpace(GetAttrOr(n, "content", "")) != ""
+	})
+	if len(descriptions) > 0 {
+		return nil
+	}
+	return []SEOIssue{{SEODescriptionMissing, "document has no meta description", doc}}
+}
+
+func checkSEOMultipleH1(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	var issues []SEOIssue
+	for i, h := range renderedHtmlNodesFunc(doc, func(n *html.Node) bool { return isHtmlElement(n, "h1") }) {
+		if i > 0 {
+			issues = append(issues, SEOIssue{SEOMultipleH1, "document has more than one h1", h})
+		}
+	}
+	return issues
+}
+
+func checkSEOHeadingSkip(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	var issues []SEOIssue
+	previous := 0
+	for _, h := range ExtractHeadings(doc) {
+		if IsInTemplateContent(h.Node) {
+			continue
+		}
+		if previous > 0 && h.Level > previous+1 {
+			message := fmt.Sprintf("h%d follows h%d", h.Level, previous)
+			issues = append(issues, SEOIssue{SEOHeadingSkip, message, h.Node})
+		}
+		previous = h.Level
+	}
+	return issues
+}
+
+func checkSEOInternalNofollow(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	base, _ := documentBaseURL(doc, pageURL)
+
+	var issues []SEOIssue
+	for _, a := range NewMatcherWithOptions("a", "href", "", MatchOptions{SkipTemplateContent: true}).FindAll(doc) {
+		if !hasRelToken(a, "nofollow") {
+			continue
+		}
+
+		u, err := resolveURL(base, strings.TrimSpace(GetAttrOr(a, "href", "")))
+		if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		if u.Host == "" || (pageURL != nil && strings.EqualFold(u.Hostname(), pageURL.Hostname())) {
+			issues = append(issues, SEOIssue{SEOInternalNofollow, "internal link has rel=\"nofollow\"", a})
+		}
+	}
+	return issues
+}
+
+func checkSEOImageAlt(doc *html.Node, pageURL *url.URL, opts SEOOptions) []SEOIssue {
+	var issues []SEOIssue
+	for _, img := range renderedHtmlNodesFunc(doc, func(n *html.Node) bool { return isHtmlElement(n, "img") }) {
+		width, _ := strconv.Atoi(strings.TrimSpace(GetAttrOr(img, "width", "")))
+		height, _ := strconv.Atoi(strings.TrimSpace(GetAttrOr(img, "height", "")))
+		if (width < opts.ImageAltMinSize && height < opts.ImageAltMinSize) || isDescriptiveAlt(img) {
+			continue
+		}
+		issues = append(issues, SEOIssue{SEOImageAlt, "image has no descriptive alt text", img})
+	}
+	return issues
+}
+
+// isDescriptiveAlt reports whether the alt attribute of an img element
+// describes it, rather than being empty, generic, or its file name.
+func isDescriptiveAlt(img *html.Node) bool {
+	alt := strings.ToLower(strings.TrimSpace(GetAttrOr(img, "alt", "")))
+	switch alt {
+	case "", "image", "img", "photo", "picture", "graphic", "logo", "icon":
+		return false
+	}
+
+	src := GetAttrOr(img, "src", "")
+	if u, err := url.Parse(strings.TrimSpace(src)); err == nil {
+		src = u.Path
+	}
+	file := strings.ToLower(path.Base(src))
+	return alt != file && alt != strings.TrimSuffix(file, path.Ext(file))
+}
+
+func checkSEOCanonicalMismatch(doc *html.Node, pageURL *url.URL) []SEOIssue {
+	if pageURL == nil {
+		return nil
+	}
+
+	for _, link := range NewMatcherWithOptions("link", "href", "", MatchOptions{SkipTemplateContent: true}).FindAll(doc) {
+		if !hasRelToken(link, "canonical") {
+			continue
+		}
+
+		canonical, err := resolveURL(pageURL, strings.TrimSpace(GetAttrOr(link, "href", "")))
+		page := *pageURL
+		page.Fragment = ""
+		if err == nil {
+			canonical.Fragment = ""
+		}
+		if err != nil || canonical.String() != page.String() {
+			return []SEOIssue{{SEOCanonicalMismatch, "canonical URL doesn't match the page URL", link}}
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package htmlutil
+
+import "testing"
+
+func TestAuditSEOImageAltMinSize(t *testing.T) {
+	doc := mustParse(t, `<img id="small" src="/s.png" width="40" height="40">`+
+		`<img id="medium" src="/m.png" width="80">`+
+		`<img id="large" src="/l.png" height="120" alt="l.png">`+
+		`<img id="described" src="/d.png" width="400" alt="A chart of sales">`)
+
+	tests := []struct {
+		name string
+		opts SEOOptions
+		want string
+	}{
+		{"default", SEOOptions{}, "large"},
+		{"smaller", SEOOptions{ImageAltMinSize: 50}, "medium large"},
+		{"exact size", SEOOptions{ImageAltMinSize: 40}, "small medium large"},
+		{"larger", SEOOptions{ImageAltMinSize: 200}, ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		for _, issue := range AuditSEOWithOptions(doc, nil, tt.opts) {
+			if issue.Rule == SEOImageAlt {
+				got += " " + GetAttrOr(issue.Node, "id", "")
+			}
+		}
+		if got != "" {
+			got = got[1:]
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for _, issue := range AuditSEO(doc, nil) {
+		if issue.Rule == SEOImageAlt && GetAttrOr(issue.Node, "id", "") != "large" {
+			t.Errorf("AuditSEO: got %+v", issue)
+		}
+	}
+}
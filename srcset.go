@@ -0,0 +1,177 @@
+package htmlutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SrcsetCandidate is an image candidate of a srcset attribute.
+type SrcsetCandidate struct {
+	URL string
+
+	// Descriptor is the width or pixel density descriptor of the candidate,
+	// such as "640w" or "2x", or empty if it has none.
+	Descriptor string
+}
+
+// Srcset is a list of image candidates, as found in a srcset attribute.
+type Srcset []SrcsetCandidate
+
+// String serializes the candidates as the value of a srcset attribute.
+func (c Srcset) String() string {
+	parts := make([]string, len(c))
+	for i, candidate := range c {
+		parts[i] = candidate.URL
+		if candidate.Descriptor != "" {
+			parts[i] += " " + candidate.Descriptor
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseSrcset parses the value of a srcset attribute following the parsing
+// rules of the HTML spec, so that URLs containing commas such as data: URLs are
+// kept whole. Whitespace around candidates and within descriptors is
+// normalized.
+//
+// Candidates whose descriptor is not a valid width ("640w") or pixel density
+// ("2x") descriptor are skipped, and an error identifying each of them is
+// returned joined together along with the valid candidates.
+func ParseSrcset(value string) (Srcset, error) {
+	var candidates Srcset
+	var errs []error
+
+	for _, c := range splitSrcset(value) {
+		if err := checkSrcsetDescriptor(c.Descriptor); err != nil {
+			errs = append(errs, fmt.Errorf("htmlutil: invalid srcset candidate %q: %w", Srcset{c}.String(), err))
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, errors.Join(errs...)
+}
+
+// GetImageCandidates returns the image candidates of an img element, or of the
+// img element of a picture element: the srcset candidates of the source
+// elements of the picture, in document order, followed by the srcset
+// candidates of the img and its src as a candidate without a descriptor. The
+// media and type attributes of source elements are not evaluated.
+//
+// Candidates that cannot be parsed are skipped, and the errors are returned
+// joined together along with the other candidates.
+func GetImageCandidates(img *html.Node) (Srcset, error) {
+	var candidates Srcset
+	var errs []error
+
+	if err := checkElement(img, "img", "picture"); err != nil {
+		return candidates, err
+	}
+
+	picture := img.Parent
+	if img.Data == "picture" {
+		picture = img
+		img = nil
+		if imgs := GetChildHtmlNodes(picture, "img", "", "", 1); len(imgs) > 0 {
+			img = imgs[0]
+		}
+	}
+
+	addSrcset := func(n *html.Node) {
+		if srcset, ok := GetAttr(n, "srcset"); ok {
+			parsed, err := ParseSrcset(srcset)
+			candidates = append(candidates, parsed...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if picture != nil && picture.Type == html.ElementNode && picture.Data == "picture" {
+		for _, source := range GetChildHtmlNodes(picture, "source", "", "", -1) {
+			addSrcset(source)
+		}
+	}
+
+	if img != nil {
+		addSrcset(img)
+		if src := strings.TrimSpace(GetAttrOr(img, "src", "")); src != "" {
+			candidates = append(candidates, SrcsetCandidate{URL: src})
+		}
+	}
+
+	return candidates, errors.Join(errs...)
+}
+
+// splitSrcset splits the value of a srcset attribute into its candidates like
+// ParseSrcset(), without validating the descriptors.
+func splitSrcset(s string) Srcset {
+	var candidates Srcset
+
+	for {
+		s = strings.TrimLeftFunc(s, func(r rune) bool { return isHtmlSpace(r) || r == ',' })
+		if s == "" {
+			return candidates
+		}
+
+		end := strings.IndexFunc(s, isHtmlSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		c := SrcsetCandidate{URL: s[:end]}
+		s = s[end:]
+
+		// A URL ending with a comma has no descriptor
+		if strings.HasSuffix(c.URL, ",") {
+			c.URL = strings.TrimRight(c.URL, ",")
+			candidates = append(candidates, c)
+			continue
+		}
+
+		// The descriptor runs up to the next comma outside of parentheses
+		depth := 0
+		end = len(s)
+		for i, r := range s {
+			if r == '(' {
+				depth++
+			} else if r == ')' && depth > 0 {
+				depth--
+			} else if r == ',' && depth == 0 {
+				end = i
+				break
+			}
+		}
+		c.Descriptor = strings.Join(strings.FieldsFunc(s[:end], isHtmlSpace), " ")
+		s = s[end:]
+
+		candidates = append(candidates, c)
+	}
+}
+
+// checkSrcsetDescriptor returns an error if descriptor is neither empty, a
+// width descriptor, nor a pixel density descriptor.
+func checkSrcsetDescriptor(descriptor string) error {
+	if descriptor == "" {
+		return nil
+	}
+	if strings.ContainsFunc(descriptor, isHtmlSpace) {
+		return errors.New("more than one descriptor")
+	}
+
+	value, unit := descriptor[:len(descriptor)-1], descriptor[len(descriptor)-1]
+	switch unit {
+	case 'w':
+		if w, err := strconv.Atoi(value); err == nil && w > 0 && value[0] != '+' {
+			return nil
+		}
+	case 'x':
+		if x, err := strconv.ParseFloat(value, 64); err == nil && x >= 0 && value[0] != '+' {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid descriptor %q", descriptor)
+}
@@ -0,0 +1,199 @@
+package htmlutil
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Default reading speeds of TextStatsWithOptions().
+const (
+	DefaultWordsPerMinute    = 200
+	DefaultCJKCharsPerMinute = 500
+)
+
+// StatsOptions controls the reading time estimated by TextStatsWithOptions().
+type StatsOptions struct {
+	// WordsPerMinute is the reading speed for text written with spaces
+	// between words. If 0, DefaultWordsPerMinute is used.
+	WordsPerMinute int
+
+	// CJKCharsPerMinute is the reading speed for Chinese and Japanese text,
+	// which has no spaces between words. If 0, DefaultCJKCharsPerMinute is
+	// used.
+	CJKCharsPerMinute int
+}
+
+// Stats holds statistics about the visible text of an HTML node, computed by
+// TextStats().
+type Stats struct {
+	// Words is the number of words. Each Chinese or Japanese character counts
+	// as a word, since that text has no spaces between words; CJKChars is the
+	// number of such characters.
+	Words    int
+	CJKChars int
+
+	// Characters is the number of runes, not counting whitespace.
+	Characters int
+
+	// Sentences is an estimate of the number of sentences, based on
+	// terminal punctuation and on the ends of blocks such as paragraphs.
+	Sentences int
+
+	// Paragraphs is the number of p elements with visible text.
+	Paragraphs int
+
+	// Images is the number of visible img elements.
+	Images int
+
+	// ReadingTime is the estimated time needed to read the text.
+	ReadingTime time.Duration
+}
+
+// ReadingMinutes returns the reading time rounded up to a whole number of
+// minutes, as in "5 min read". It is at least 1 if there are any words.
+func (s Stats) ReadingMinutes() int {
+	if s.Words == 0 {
+		return 0
+	}
+	return max(int(math.Ceil(s.ReadingTime.Minutes())), 1)
+}
+
+// TextStats is a convenience function for TextStatsWithOptions() using the
+// default reading speeds.
+func TextStats(n *html.Node) Stats {
+	return TextStatsWithOptions(n, StatsOptions{})
+}
+
+// TextStatsWithOptions returns statistics about the visible text of the
+// provided node, as rendered by NodeToText() with hidden content skipped but
+// without list markers, so that markup, scripts, styles, templates, hidden
+// elements, and the numbers of list items are not counted.
+//
+// Words are separated by whitespace, and must hold at least a letter or a
+// digit. Chinese and Japanese characters are counted one by one instead, and
+// are read at their own speed when estimating the reading time.
+func TextStatsWithOptions(n *html.Node, opts StatsOptions) Stats {
+	wpm := opts.WordsPerMinute
+	if wpm <= 0 {
+		wpm = DefaultWordsPerMinute
+	}
+	cpm := opts.CJKCharsPerMinute
+	if cpm <= 0 {
+		cpm = DefaultCJKCharsPerMinute
+	}
+
+	var s Stats
+	if n == nil {
+		return s
+	}
+
+	s.countText(statsText(n))
+
+	Walk(n, func(n *html.Node) WalkAction {
+		switch {
+		case n.Type != html.ElementNode:
+		case isNonRenderedTextElement(n) || isHiddenElement(n):
+			return WalkSkipChildren
+		case isHtmlElement(n, "p"):
+			if strings.TrimSpace(statsText(n)) != "" {
+				s.Paragraphs++
+			}
+		case isHtmlElement(n, "img"):
+			s.Images++
+		}
+		return WalkContinue
+	})
+
+	minutes := float64(s.Words-s.CJKChars)/float64(wpm) + float64(s.CJKChars)/float64(cpm)
+	s.ReadingTime = time.Duration(minutes * float64(time.Minute))
+
+	return s
+}
+
+// statsText returns the text of n counted by TextStatsWithOptions(): the
+// output of NodeToText() with hidden content skipped and no list markers.
+func statsText(n *html.Node) string {
+	if IsHiddenNode(n) {
+		return ""
+	}
+
+	r := textRenderer{opts: TextOptions{SkipHidden: true}, noMarkers: true}
+	r.render(n)
+	r.endLine()
+	return r.out.String()
+}
+
+// countText counts the words, characters, and sentences of text.
+func (s *Stats) countText(text string) {
+	inWord, wordHasLetter := false, false
+	inTerminator, sentencePending := false, false
+
+	endWord := func() {
+		if inWord && wordHasLetter {
+			s.Words++
+		}
+		inWord, wordHasLetter = false, false
+	}
+
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			endWord()
+
+			// Lines hold blocks such as paragraphs and headings, which end
+			// sentences even without punctuation
+			if r == '\n' && sentencePending {
+				s.Sentences++
+				sentencePending = false
+			}
+			inTerminator = false
+			continue
+		}
+		s.Characters++
+
+		switch {
+		case isCJKChar(r):
+			endWord()
+			s.Words++
+			s.CJKChars++
+			sentencePending = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			inWord, wordHasLetter = true, true
+			sentencePending = true
+		default:
+			inWord = true
+		}
+
+		// A run of terminal punctuation such as "?!" or "..." ends a single
+		// sentence
+		terminator := isSentenceTerminator(r)
+		if terminator && !inTerminator && sentencePending {
+			s.Sentences++
+			sentencePending = false
+		}
+		inTerminator = terminator
+	}
+	endWord()
+
+	if sentencePending {
+		s.Sentences++
+	}
+}
+
+// isCJKChar reports whether r is a Chinese or Japanese character, written
+// without spaces between words.
+func isCJKChar(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// isSentenceTerminator reports whether r is punctuation ending a sentence.
+func isSentenceTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', '。', '！', '？', '…':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const englishStatsDoc = `<article><h1>Reading time</h1>` +
+	`<p>The quick brown fox jumps over the lazy dog. It was not amused!</p>` +
+	`<p>Prices rose 5% in <b>2024</b> — a record?</p>` +
+	`<ol><li>First item</li><li>Second item</li></ol><img src="a.png" alt="A figure">` +
+	`<script>var words = "not counted";</script><style>p { margin: 0 }</style>` +
+	`<p hidden>Hidden words here.</p><p style="display: none">Also hidden <img src="b.png"></p><p> </p>` +
+	`<template><p>Template text</p></template></article>`
+
+// japaneseStatsDoc is the opening of "I Am a Cat" by Natsume Sōseki.
+const japaneseStatsDoc = `<article><h1>吾輩は猫である</h1>` +
+	`<p>吾輩は猫である。名前はまだ無い。</p>` +
+	`<p>どこで生れたかとんと見当がつかぬ。</p></article>`
+
+func TestTextStats(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want Stats
+	}{
+		{
+			name: "english",
+			doc:  englishStatsDoc,
+			want: Stats{Words: 26, Characters: 108, Sentences: 6, Paragraphs: 2, Images: 1, ReadingTime: 7800 * time.Millisecond},
+		},
+		{
+			name: "japanese",
+			doc:  japaneseStatsDoc,
+			want: Stats{Words: 37, CJKChars: 37, Characters: 40, Sentences: 4, Paragraphs: 2, ReadingTime: 4440 * time.Millisecond},
+		},
+		{
+			name: "mixed",
+			doc:  `<p>Go言語 is fun. コーヒーを飲む。</p>`,
+			want: Stats{Words: 12, CJKChars: 9, Characters: 18, Sentences: 2, Paragraphs: 1, ReadingTime: 1980 * time.Millisecond},
+		},
+		{
+			name: "punctuation runs",
+			doc:  `<p>Really?! Yes... No — "quoted." Done</p>`,
+			want: Stats{Words: 5, Characters: 30, Sentences: 4, Paragraphs: 1, ReadingTime: 1500 * time.Millisecond},
+		},
+		{
+			name: "empty",
+			doc:  `<div> <p></p><script>x()</script></div>`,
+			want: Stats{},
+		},
+	}
+
+	for _, tt := range tests {
+		got := TextStats(GetFirstHtmlNode(mustParse(t, tt.doc), "body", "", ""))
+		if got != tt.want {
+			t.Errorf("%s: got  %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTextStatsListMarkers(t *testing.T) {
+	// The numbers NodeToText() renders for ordered lists are not words
+	doc := mustParse(t, `<ol start="9"><li>one</li><li>two</li><li>three</li></ol>`)
+
+	if text := NodeToText(doc, TextOptions{}); !strings.Contains(text, "10.") {
+		t.Fatalf("got %q, want numbered items", text)
+	}
+	got := TextStats(doc)
+	if got.Words != 3 || got.Characters != 11 || got.Sentences != 3 {
+		t.Errorf("got %+v, want the items counted without their numbers", got)
+	}
+}
+
+func TestTextStatsHiddenRoot(t *testing.T) {
+	doc := mustParse(t, `<div hidden><p>Hidden text.</p><img src="a.png"></div>`)
+
+	if got := TextStats(GetFirstHtmlNode(doc, "div", "", "")); got != (Stats{}) {
+		t.Errorf("got %+v", got)
+	}
+	if got := TextStats(nil); got != (Stats{}) {
+		t.Errorf("nil node: got %+v", got)
+	}
+}
+
+func TestTextStatsReadingTime(t *testing.T) {
+	doc := mustParse(t, englishStatsDoc+japaneseStatsDoc)
+
+	got := TextStatsWithOptions(doc, StatsOptions{WordsPerMinute: 100, CJKCharsPerMinute: 300})
+	if want := 15600*time.Millisecond + 7400*time.Millisecond; got.ReadingTime != want {
+		t.Errorf("got %v, want %v", got.ReadingTime, want)
+	}
+
+	tests := []struct {
+		words int
+		want  int
+	}{
+		{0, 0},
+		{1, 1},
+		{200, 1},
+		{1000, 5},
+		{1001, 6},
+	}
+	for _, tt := range tests {
+		doc := mustParse(t, "<p>"+strings.Repeat("word ", tt.words)+"</p>")
+		stats := TextStats(doc)
+		if stats.Words != tt.words {
+			t.Errorf("%d words: got %d words", tt.words, stats.Words)
+		}
+		if got := stats.ReadingMinutes(); got != tt.want {
+			t.Errorf("%d words: got %d min read, want %d", tt.words, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package htmlutil
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// StreamQuery describes the elements searched for by StreamExtract().
+type StreamQuery struct {
+	// Name is the key under which the matches are returned.
+	Name string
+
+	// Tag, Attr, AttrValue, and AllowAttrSubstring match elements with the
+	// same semantics as GetHtmlNodes().
+	Tag                string
+	Attr               string
+	AttrValue          string
+	AllowAttrSubstring bool
+
+	// Count is the number of matches after which the query is satisfied. If
+	// -1, all matches are returned. A count of 0 is rejected, since the query
+	// could never match.
+	Count int
+
+	// CaptureAttr is the attribute whose value is captured. If empty, the
+	// text content of the element is captured.
+	CaptureAttr string
+}
+
+// StreamMatch is an element found by StreamExtract().
+type StreamMatch struct {
+	Tag   string
+	Attrs []html.Attribute
+
+	// Value is the captured attribute value or text content.
+	Value string
+
+	// Offset is the byte offset of the start tag of the element within the
+	// input, and EndOffset the byte offset just past its end tag, or past its
+	// start tag if it has no content.
+	Offset    int64
+	EndOffset int64
+}
+
+// StreamOptions controls when StreamExtractWithOptions() stops reading.
+type StreamOptions struct {
+	// StopWhenSatisfied stops reading as soon as all queries with a count
+	// have their count of matches. Queries with a count of -1 are left out,
+	// and only get the matches found until then, unless every query has a
+	// count of -1, in which case the whole document is read.
+	StopWhenSatisfied bool
+
+	// StopAfterTag stops reading after the end tag of the first element with
+	// this tag, such as "head".
+	StopAfterTag string
+}
+
+// StreamExtract is a convenience function for StreamExtractWithOptions() that
+// stops reading as soon as all queries are satisfied.
+func StreamExtract(r io.Reader, wants []StreamQuery) (map[string][]StreamMatch, error) {
+	return StreamExtractWithOptions(r, wants, StreamOptions{StopWhenSatisfied: true})
+}
+
+// StreamExtractWithOptions searches an HTML document read from r for the
+// elements matching the provided queries without building a tree, and
+// returns the matches of each query keyed by its name.
+//
+// The document is tokenized rather than parsed, so the end of an element's
+// text content is found by counting start and end tags. This is exact for
+// well-formed markup, but content whose end tags are implied, such as "<p>a
+// <p>b", is captured up to the end of the enclosing element.
+func StreamExtractWithOptions(r io.Reader, wants []StreamQuery, opts StreamOptions) (map[string][]StreamMatch, error) {
+	results := make(map[string][]StreamMatch, len(wants))
+	matchers := make([]*Matcher, len(wants))
+	for i, q := range wants {
+		if q.Name == "" {
+			return nil, fmt.Errorf("htmlutil: stream query %d has no name", i)
+		}
+		if _, ok := results[q.Name]; ok {
+			return nil, fmt.Errorf("htmlutil: duplicate stream query name %q", q.Name)
+		}
+		if q.Count == 0 {
+			return nil, fmt.Errorf("htmlutil: stream query %q has a count of 0", q.Name)
+		}
+		results[q.Name] = nil
+		matchers[i] = NewMatcherWithOptions(q.Tag, q.Attr, q.AttrValue, MatchOptions{AllowAttrSubstring: q.AllowAttrSubstring})
+	}
+
+	// open holds the matches whose text content is being captured, along with
+	// the depth of their element
+	type openMatch struct {
+		query string
+		match StreamMatch
+		text  strings.Builder
+		depth int
+	}
+	var open []*openMatch
+
+	z := html.NewTokenizer(r)
+	depth := 0
+	var offset int64
+
+	satisfied := func() bool {
+		bounded := false
+		for _, q := range wants {
+			if q.Count < 0 {
+				continue
+			}
+			if len(results[q.Name]) < q.Count {
+				return false
+			}
+			bounded = true
+		}
+		return bounded
+	}
+	// Nested matches are finished innermost first, so restore document order
+	// before returning
+	done := func(err error) (map[string][]StreamMatch, error) {
+		for _, matches := range results {
+			slices.SortStableFunc(matches, func(a StreamMatch, b StreamMatch) int {
+				return cmp.Compare(a.Offset, b.Offset)
+			})
+		}
+		return results, err
+	}
+	finish := func(m *openMatch, end int64) {
+		m.match.Value = m.text.String()
+		m.match.EndOffset = end
+		results[m.query] = append(results[m.query], m.match)
+	}
+
+	for {
+		tt := z.Next()
+		start := offset
+		offset += int64(len(z.Raw()))
+
+		switch tt {
+		case html.ErrorToken:
+			for _, m := range open {
+				finish(m, offset)
+			}
+			if err := z.Err(); err != io.EOF {
+				return done(err)
+			}
+			return done(nil)
+
+		case html.TextToken:
+			text := z.Text()
+			for _, m := range open {
+				m.text.Write(text)
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			n := &html.Node{Type: html.ElementNode, DataAtom: t.DataAtom, Data: t.Data, Attr: t.Attr}
+			empty := tt == html.SelfClosingTagToken || voidElements[t.Data]
+
+			for i, q := range wants {
+				pending := 0
+				for _, m := range open {
+					if m.query == q.Name {
+						pending++
+					}
+				}
+				if q.Count >= 0 && len(results[q.Name])+pending >= q.Count {
+					continue
+				}
+				if !matchers[i].Match(n) {
+					continue
+				}
+
+				match := StreamMatch{Tag: t.Data, Attrs: t.Attr, Offset: start, EndOffset: offset}
+				if q.CaptureAttr != "" || empty {
+					match.Value = GetAttrOr(n, q.CaptureAttr, "")
+					results[q.Name] = append(results[q.Name], match)
+					continue
+				}
+				open = append(open, &openMatch{query: q.Name, match: match, depth: depth})
+			}
+
+			if !empty {
+				depth++
+			}
+
+		case html.EndTagToken:
+			tag := z.Token().Data
+			if voidElements[tag] {
+				break
+			}
+			depth = max(depth-1, 0)
+
+			kept := open[:0]
+			for _, m := range open {
+				if m.depth >= depth {
+					finish(m, offset)
+				} else {
+					kept = append(kept, m)
+				}
+			}
+			open = kept
+
+			if opts.StopAfterTag != "" && tag == opts.StopAfterTag {
+				for _, m := range open {
+					finish(m, offset)
+				}
+				return done(nil)
+			}
+		}
+
+		if opts.StopWhenSatisfied && len(open) == 0 && satisfied() {
+			return done(nil)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package htmlutil
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// pageQueries are the queries for the title, canonical link, and description
+// of a page.
+var pageQueries = []StreamQuery{
+	{Name: "title", Tag: "title", Count: 1},
+	{Name: "canonical", Tag: "link", Attr: "rel", AttrValue: "canonical", Count: 1, CaptureAttr: "href"},
+	{Name: "description", Tag: "meta", Attr: "name", AttrValue: "description", Count: 1, CaptureAttr: "content"},
+}
+
+// failingReader returns the provided data, then an error, so that tests fail
+// if the stream is read past the data.
+type failingReader struct {
+	r io.Reader
+}
+
+var errReadTooFar = errors.New("read past the expected end")
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errReadTooFar
+	}
+	return n, err
+}
+
+func TestStreamExtract(t *testing.T) {
+	const markup = `<!DOCTYPE html><html><head><title>Tom &amp; Jerry</title>` +
+		`<link rel="canonical" href="https://example.com/a"><meta name="description" content="About cats.">` +
+		`</head><body><div class="card"><p>One <b>bold</b></p></div><p>Two</p><div class="card big">Three</div>` +
+		`<img src="x.png"><br/></body></html>`
+
+	queries := append(slices.Clone(pageQueries),
+		StreamQuery{Name: "paragraphs", Tag: "p", Count: -1},
+		StreamQuery{Name: "cards", Tag: "div", Attr: "class", AttrValue: "card", Count: -1, AllowAttrSubstring: true},
+		StreamQuery{Name: "exact cards", Tag: "div", Attr: "class", AttrValue: "card", Count: -1},
+		StreamQuery{Name: "images", Tag: "img", Count: -1, CaptureAttr: "src"},
+		StreamQuery{Name: "breaks", Tag: "br", Count: -1},
+	)
+
+	results, err := StreamExtractWithOptions(strings.NewReader(markup), queries, StreamOptions{})
+	if err != nil {
+		t.Fatalf("StreamExtract: %v", err)
+	}
+
+	want := map[string][]string{
+		"title":       {"Tom & Jerry"},
+		"canonical":   {"https://example.com/a"},
+		"description": {"About cats."},
+		"paragraphs":  {"One bold", "Two"},
+		"cards":       {"One bold", "Three"},
+		"exact cards": {"One bold"},
+		"images":      {"x.png"},
+		"breaks":      {""},
+	}
+	for name, values := range want {
+		matches := results[name]
+		if len(matches) != len(values) {
+			t.Errorf("%s: got %d matches, want %d", name, len(matches), len(values))
+			continue
+		}
+		for i, m := range matches {
+			if m.Value != values[i] {
+				t.Errorf("%s %d: got %q, want %q", name, i, m.Value, values[i])
+			}
+		}
+	}
+
+	if got := results["cards"]; len(got) == 2 && (got[1].Tag != "div" || len(got[1].Attrs) != 1 || got[1].Attrs[0].Val != "card big") {
+		t.Errorf("got match %+v", got[1])
+	}
+}
+
+func TestStreamExtractOffsets(t *testing.T) {
+	const markup = `<html><head><title>T</title></head><body>` +
+		`<ul><li id="a">one <em>1</em></li><li id="b">two</li></ul><img id="c" src="x.png"><hr id="d"/></body></html>`
+
+	results, err := StreamExtractWithOptions(strings.NewReader(markup), []StreamQuery{
+		{Name: "ids", Attr: "id", Count: -1},
+	}, StreamOptions{})
+	if err != nil {
+		t.Fatalf("StreamExtract: %v", err)
+	}
+
+	want := []string{
+		`<li id="a">one <em>1</em></li>`,
+		`<li id="b">two</li>`,
+		`<img id="c" src="x.png">`,
+		`<hr id="d"/>`,
+	}
+	matches := results["ids"]
+	if len(matches) != len(want) {
+		t.Fatalf("got %d matches, want %d", len(matches), len(want))
+	}
+	for i, m := range matches {
+		if got := markup[m.Offset:m.EndOffset]; got != want[i] {
+			t.Errorf("%d: offsets %d-%d cover %q, want %q", i, m.Offset, m.EndOffset, got, want[i])
+		}
+	}
+}
+
+func TestStreamExtractNested(t *testing.T) {
+	const markup = `<div id="outer">a<div id="inner">b</div>c</div><div id="last">d</div>`
+
+	results, err := StreamExtract(strings.NewReader(markup), []StreamQuery{{Name: "divs", Tag: "div", Count: -1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range results["divs"] {
+		got = append(got, m.Value)
+	}
+	if strings.Join(got, ",") != "abc,b,d" {
+		t.Errorf("got %q, want the matches in document order", got)
+	}
+}
+
+func TestStreamExtractStops(t *testing.T) {
+	const head = `<html><head><title>Page</title><link rel="canonical" href="/a"><meta name="description" content="D">`
+
+	// The reader fails past the head, so reading it would return an error
+	results, err := StreamExtract(&failingReader{strings.NewReader(head)}, pageQueries)
+	if err != nil {
+		t.Fatalf("StreamExtract read past the satisfied queries: %v", err)
+	}
+	if got := results["description"]; len(got) != 1 || got[0].Value != "D" {
+		t.Errorf("got %+v", got)
+	}
+
+	// An unbounded query doesn't keep a bounded query from stopping the read,
+	// and only gets the matches found until then
+	queries := []StreamQuery{
+		{Name: "title", Tag: "title", Count: 1},
+		{Name: "links", Tag: "link", Count: -1},
+	}
+	results, err = StreamExtract(&failingReader{strings.NewReader(`<title>T</title>`)}, queries)
+	if err != nil {
+		t.Fatalf("unbounded query: %v", err)
+	}
+	if len(results["links"]) != 0 {
+		t.Errorf("got links %+v", results["links"])
+	}
+
+	// Unbounded queries alone read the whole document
+	_, err = StreamExtract(&failingReader{strings.NewReader(head)}, []StreamQuery{{Name: "links", Tag: "link", Count: -1}})
+	if !errors.Is(err, errReadTooFar) {
+		t.Errorf("got %v, want the whole document read", err)
+	}
+
+	// Unsatisfied queries read the whole document
+	_, err = StreamExtract(&failingReader{strings.NewReader(head)}, []StreamQuery{{Name: "h1", Tag: "h1", Count: 1}})
+	if !errors.Is(err, errReadTooFar) {
+		t.Errorf("got %v, want the whole document read", err)
+	}
+}
+
+func TestStreamExtractStopAfterTag(t *testing.T) {
+	const markup = `<html><head><title>T</title></head><body><title>not in head</title><p>x</p></body></html>`
+
+	results, err := StreamExtractWithOptions(&failingReader{strings.NewReader(markup)}, []StreamQuery{
+		{Name: "titles", Tag: "title", Count: -1},
+		{Name: "p", Tag: "p", Count: -1},
+	}, StreamOptions{StopAfterTag: "head"})
+	if err != nil {
+		t.Fatalf("StreamExtract: %v", err)
+	}
+	if got := results["titles"]; len(got) != 1 || got[0].Value != "T" {
+		t.Errorf("got %+v, want only the title in head", got)
+	}
+	if _, ok := results["p"]; !ok {
+		t.Error("queries without matches are missing from the results")
+	}
+}
+
+func TestStreamExtractCount(t *testing.T) {
+	results, err := StreamExtractWithOptions(strings.NewReader(`<p>1</p><p>2<p>3</p></p><p>4</p>`), []StreamQuery{
+		{Name: "two", Tag: "p", Count: 2},
+	}, StreamOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := results["two"]; len(got) != 2 || got[0].Value != "1" || got[1].Value != "23" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestStreamExtractErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []StreamQuery
+		want    string
+	}{
+		{"count of 0", []StreamQuery{{Name: "a", Tag: "a"}}, "count of 0"},
+		{"no name", []StreamQuery{{Tag: "a", Count: 1}}, "no name"},
+		{"duplicate name", []StreamQuery{{Name: "a", Tag: "a", Count: 1}, {Name: "a", Tag: "b", Count: 1}}, "duplicate"},
+	}
+
+	for _, tt := range tests {
+		_, err := StreamExtract(strings.NewReader(`<a>x</a>`), tt.queries)
+		if err == nil || !strings.HasPrefix(err.Error(), "htmlutil: ") || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error about %q", tt.name, err, tt.want)
+		}
+	}
+
+	_, err := StreamExtract(&failingReader{strings.NewReader(`<p>x`)}, []StreamQuery{{Name: "h1", Tag: "h1", Count: 1}})
+	if !errors.Is(err, errReadTooFar) {
+		t.Errorf("got %v, want the read error", err)
+	}
+}
+
+// BenchmarkStreamExtract compares extracting the title, canonical link, and
+// description of a large page by streaming with parsing the whole page.
+func BenchmarkStreamExtract(b *testing.B) {
+	page := largePage(5000)
+
+	b.Run("StreamExtract", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			results, err := StreamExtract(strings.NewReader(page), pageQueries)
+			if err != nil || len(results["description"]) != 1 {
+				b.Fatal(results, err)
+			}
+		}
+	})
+
+	b.Run("ParseAndGetHtmlNodes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			doc, err := ParseString(page)
+			if err != nil {
+				b.Fatal(err)
+			}
+			GetText(GetFirstHtmlNode(doc, "title", "", ""))
+			GetAttrOr(GetFirstHtmlNode(doc, "link", "rel", "canonical"), "href", "")
+			if GetFirstHtmlNode(doc, "meta", "name", "description") == nil {
+				b.Fatal("no description")
+			}
+		}
+	})
+
+	b.Run("StreamExtractWholePage", func(b *testing.B) {
+		queries := []StreamQuery{{Name: "links", Tag: "a", Count: -1, CaptureAttr: "href"}}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := StreamExtract(strings.NewReader(page), queries); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
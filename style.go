@@ -0,0 +1,250 @@
+package htmlutil
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// StyleDecl is a declaration of an inline style attribute, such as
+// "color: red !important".
+type StyleDecl struct {
+	// Property is the name of the property, lowercased unless it is a custom
+	// property such as --main-color, whose names are case-sensitive.
+	Property string
+
+	// Value is the value of the declaration, without the !important flag.
+	Value string
+
+	Important bool
+}
+
+// String serializes the declaration as it would appear in a style attribute.
+func (d StyleDecl) String() string {
+	s := d.Property + ": " + d.Value
+	if d.Important {
+		s += " !important"
+	}
+	return s
+}
+
+// ParseStyleAttr parses the value of a style attribute into its declarations,
+// in order. Semicolons and colons within quoted strings, parentheses, and
+// comments, as in url("a;b.png"), don't split declarations, and comments are
+// removed.
+//
+// Declarations without a colon or property name are skipped, and an error
+// identifying each of them is returned joined together along with the valid
+// declarations.
+func ParseStyleAttr(value string) ([]StyleDecl, error) {
+	var decls []StyleDecl
+	var errs []error
+
+	for _, raw := range splitStyleDecls(value) {
+		if strings.TrimFunc(raw, isHtmlSpace) == "" {
+			continue
+		}
+
+		colon := indexStyleColon(raw)
+		if colon < 0 {
+			errs = append(errs, fmt.Errorf("htmlutil: invalid style declaration %q: missing colon", strings.TrimSpace(raw)))
+			continue
+		}
+
+		property := strings.TrimFunc(raw[:colon], isHtmlSpace)
+		if property == "" {
+			errs = append(errs, fmt.Errorf("htmlutil: invalid style declaration %q: missing property", strings.TrimSpace(raw)))
+			continue
+		}
+		if !strings.HasPrefix(property, "--") {
+			property = strings.ToLower(property)
+		}
+
+		decl := StyleDecl{Property: property, Value: strings.TrimFunc(raw[colon+1:], isHtmlSpace)}
+		if i := strings.LastIndexByte(decl.Value, '!'); i >= 0 && strings.EqualFold(strings.TrimFunc(decl.Value[i+1:], isHtmlSpace), "important") {
+			decl.Value = strings.TrimFunc(decl.Value[:i], isHtmlSpace)
+			decl.Important = true
+		}
+		decls = append(decls, decl)
+	}
+
+	return decls, errors.Join(errs...)
+}
+
+// GetStyleProperty returns the value of the provided property in the style
+// attribute of the provided node, without its !important flag. As in CSS, the
+// last declaration of the property applies, unless an earlier one is
+// important and the last one isn't. The boolean result reports whether the
+// property was found.
+func GetStyleProperty(n *html.Node, prop string) (string, bool) {
+	found := false
+	var applied StyleDecl
+
+	for _, decl := range styleDecls(n) {
+		if styleProperty(decl.Property, prop) && (!found || decl.Important || !applied.Important) {
+			applied = decl
+			found = true
+		}
+	}
+
+	return applied.Value, found
+}
+
+// SetStyleProperty sets the provided property in the style attribute of the
+// provided node to value, which may end with "!important". The first existing
+// declaration of the property is updated in place and further ones are
+// removed. Otherwise a declaration is appended. Other declarations are kept in
+// order.
+func SetStyleProperty(n *html.Node, prop string, value string) {
+	decls, _ := ParseStyleAttr(prop + ":" + value)
+	if len(decls) != 1 {
+		return
+	}
+	decl := decls[0]
+
+	var updated []StyleDecl
+	set := false
+	for _, d := range styleDecls(n) {
+		switch {
+		case !styleProperty(d.Property, decl.Property):
+			updated = append(updated, d)
+		case !set:
+			updated = append(updated, decl)
+			set = true
+		}
+	}
+	if !set {
+		updated = append(updated, decl)
+	}
+
+	setStyleDecls(n, updated)
+}
+
+// RemoveStyleProperty removes the declarations of the provided property from
+// the style attribute of the provided node, removing the attribute if no
+// declarations are left. It reports whether any declaration was removed.
+func RemoveStyleProperty(n *html.Node, prop string) bool {
+	decls := styleDecls(n)
+	kept := slices.DeleteFunc(slices.Clone(decls), func(d StyleDecl) bool {
+		return styleProperty(d.Property, prop)
+	})
+	if len(kept) == len(decls) {
+		return false
+	}
+
+	setStyleDecls(n, kept)
+	return true
+}
+
+// styleDecls returns the valid declarations of the style attribute of n.
+func styleDecls(n *html.Node) []StyleDecl {
+	style, _ := GetAttr(n, "style")
+	decls, _ := ParseStyleAttr(style)
+	return decls
+}
+
+// setStyleDecls rewrites the style attribute of n with the provided
+// declarations, removing it if there are none.
+func setStyleDecls(n *html.Node, decls []StyleDecl) {
+	if len(decls) == 0 {
+		n.Attr = slices.DeleteFunc(n.Attr, func(a html.Attribute) bool {
+			return a.Namespace == "" && a.Key == "style"
+		})
+		return
+	}
+
+	parts := make([]string, len(decls))
+	for i, d := range decls {
+		parts[i] = d.String()
+	}
+	SetHtmlAttr(n, "style", strings.Join(parts, "; "))
+}
+
+// styleProperty reports whether the property of a declaration is the provided
+// property, which is compared case-insensitively unless it is a custom
+// property.
+func styleProperty(property string, prop string) bool {
+	if strings.HasPrefix(prop, "--") {
+		return property == prop
+	}
+	return property == strings.ToLower(strings.TrimSpace(prop))
+}
+
+// splitStyleDecls splits the value of a style attribute on the semicolons that
+// are outside of strings, parentheses, and comments, removing comments.
+func splitStyleDecls(value string) []string {
+	var decls []string
+	var sb strings.Builder
+	var quote byte
+	depth := 0
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(value) {
+				sb.WriteByte(c)
+				i++
+				c = value[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '/' && strings.HasPrefix(value[i:], "/*"):
+			end := strings.Index(value[i+2:], "*/")
+			if end < 0 {
+				i = len(value)
+			} else {
+				i += end + 3
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ';' && depth == 0:
+			decls = append(decls, sb.String())
+			sb.Reset()
+			continue
+		}
+
+		sb.WriteByte(c)
+	}
+
+	return append(decls, sb.String())
+}
+
+// indexStyleColon returns the index of the first colon of a declaration that
+// is outside of strings and parentheses, or -1.
+func indexStyleColon(decl string) int {
+	var quote byte
+	depth := 0
+
+	for i := 0; i < len(decl); i++ {
+		c := decl[i]
+
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ':' && depth == 0:
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,202 @@
+package htmlutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Limits from the HTML spec for the colspan and rowspan attributes.
+const (
+	maxColspan = 1000
+	maxRowspan = 65534
+)
+
+// TableToRows returns the normalized text of each cell of the provided table
+// element, row by row, in document order.
+//
+// Cells spanning several columns or rows through colspan and rowspan are
+// repeated in every position they cover, and rows are padded with empty
+// strings so that every row has the same width. Neither rows nor text of
+// tables nested inside cells are included.
+//
+// An error is returned if the provided node is not a table element.
+func TableToRows(table *html.Node) ([][]string, error) {
+	if err := checkElement(table, "table"); err != nil {
+		return nil, err
+	}
+
+	trs := tableRows(table)
+	rows := make([][]string, len(trs))
+	filled := make([][]bool, len(trs))
+
+	width := 0
+	for i, tr := range trs {
+		col := 0
+		for _, cell := range childElementsByTag(tr, "td", "th") {
+			// Skip the positions already filled by cells spanning from rows above
+			for col < len(filled[i]) && filled[i][col] {
+				col++
+			}
+
+			text := cellText(cell)
+			colspan := spanAttr(cell, "colspan", 1, maxColspan)
+			rowspan := spanAttr(cell, "rowspan", len(trs)-i, maxRowspan)
+
+			for r := i; r < i+rowspan && r < len(trs); r++ {
+				for c := col; c < col+colspan; c++ {
+					for len(rows[r]) <= c {
+						rows[r] = append(rows[r], "")
+						filled[r] = append(filled[r], false)
+					}
+					if !filled[r][c] {
+						rows[r][c] = text
+						filled[r][c] = true
+					}
+				}
+			}
+			col += colspan
+		}
+
+		width = max(width, len(rows[i]))
+	}
+
+	for i := range rows {
+		for len(rows[i]) < width {
+			rows[i] = append(rows[i], "")
+		}
+	}
+
+	return rows, nil
+}
+
+// TableToRecords returns the rows of the provided table element as records
+// keyed by the text of the cells in the first row, which is taken to be the
+// header row. If several header cells have the same text, the value of the
+// last of these columns is kept.
+//
+// An error is returned if the provided node is not a table element.
+func TableToRecords(table *html.Node) ([]map[string]string, error) {
+	rows, err := TableToRows(table)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	header := rows[0]
+	records := make([]map[string]string, 0, len(rows)-1)
+
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(header))
+		for i, key := range header {
+			record[key] = row[i]
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// cellText returns the normalized text of a table cell, excluding the text of
+// any tables nested inside it.
+func cellText(cell *html.Node) string {
+	t := textNormalizer{skip: func(n *html.Node) bool {
+		return n.Data == "table"
+	}}
+	for c := cell.FirstChild; c != nil; c = c.NextSibling {
+		t.writeNode(c)
+	}
+	return t.sb.String()
+}
+
+// tableRows returns the rows of a table element: its tr children and those of
+// its thead, tbody, and tfoot children, in document order.
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+
+	for _, c := range childElementsByTag(table, "tr", "thead", "tbody", "tfoot") {
+		if c.Data == "tr" {
+			rows = append(rows, c)
+		} else {
+			rows = append(rows, childElementsByTag(c, "tr")...)
+		}
+	}
+
+	return rows
+}
+
+// spanAttr returns the value of the colspan or rowspan attribute of a cell,
+// clamped to the limits of the HTML spec. zeroValue is used if the attribute
+// is 0, which for rowspan means spanning all remaining rows.
+func spanAttr(cell *html.Node, key string, zeroValue int, limit int) int {
+	val, ok := GetAttr(cell, key)
+	if !ok {
+		return 1
+	}
+
+	span, err := strconv.Atoi(strings.TrimSpace(val))
+	switch {
+	case err != nil || span < 0:
+		return 1
+	case span == 0:
+		if key == "colspan" {
+			return 1
+		}
+		return max(zeroValue, 1)
+	}
+	return min(span, limit)
+}
+
+// childElementsByTag returns the children of the provided node that are
+// elements with one of the provided tags, in document order.
+func childElementsByTag(n *html.Node, tags ...string) []*html.Node {
+	var children []*html.Node
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		for _, tag := range tags {
+			if c.Data == tag {
+				children = append(children, c)
+				break
+			}
+		}
+	}
+
+	return children
+}
+
+// checkElement returns an error if n is not an element with one of the
+// provided tags.
+func checkElement(n *html.Node, tags ...string) error {
+	if n != nil && n.Type == html.ElementNode {
+		for _, tag := range tags {
+			if n.Data == tag {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("htmlutil: expected %s element, got %s", strings.Join(tags, " or "), describeHtmlNode(n))
+}
+
+// describeHtmlNode returns a short description of n for use in error messages.
+func describeHtmlNode(n *html.Node) string {
+	switch {
+	case n == nil:
+		return "nil node"
+	case n.Type == html.ElementNode:
+		return n.Data + " element"
+	case n.Type == html.TextNode:
+		return "text node"
+	case n.Type == html.DocumentNode:
+		return "document node"
+	case n.Type == html.CommentNode:
+		return "comment node"
+	case n.Type == html.DoctypeNode:
+		return "doctype node"
+	}
+	return "node"
+}
@@ -0,0 +1,141 @@
+package htmlutil
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestTableToRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		want  [][]string
+	}{
+		{
+			name:  "thead and tbody",
+			table: `<table><thead><tr><th>Name</th><th>Age</th></tr></thead><tbody><tr><td> Ann </td><td>30</td></tr></tbody></table>`,
+			want:  [][]string{{"Name", "Age"}, {"Ann", "30"}},
+		},
+		{
+			name:  "missing tbody",
+			table: `<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+			want:  [][]string{{"A", "B"}, {"1", "2"}},
+		},
+		{
+			name:  "th in body rows",
+			table: `<table><tr><th></th><th>Q1</th></tr><tr><th>Sales</th><td>10</td></tr><tr><th>Costs</th><td>4</td></tr></table>`,
+			want:  [][]string{{"", "Q1"}, {"Sales", "10"}, {"Costs", "4"}},
+		},
+		{
+			name:  "colspan",
+			table: `<table><tr><td colspan="2">wide</td><td>c</td></tr><tr><td>a</td><td>b</td><td>c</td></tr></table>`,
+			want:  [][]string{{"wide", "wide", "c"}, {"a", "b", "c"}},
+		},
+		{
+			name:  "rowspan",
+			table: `<table><tr><td rowspan="2">tall</td><td>1</td></tr><tr><td>2</td></tr><tr><td>x</td><td>3</td></tr></table>`,
+			want:  [][]string{{"tall", "1"}, {"tall", "2"}, {"x", "3"}},
+		},
+		{
+			name:  "rowspan and colspan",
+			table: `<table><tr><td rowspan="2" colspan="2">big</td><td>1</td></tr><tr><td>2</td></tr></table>`,
+			want:  [][]string{{"big", "big", "1"}, {"big", "big", "2"}},
+		},
+		{
+			name:  "rowspan zero",
+			table: `<table><tr><td rowspan="0">all</td><td>1</td></tr><tr><td>2</td></tr><tr><td>3</td></tr></table>`,
+			want:  [][]string{{"all", "1"}, {"all", "2"}, {"all", "3"}},
+		},
+		{
+			name:  "invalid spans",
+			table: `<table><tr><td colspan="x">a</td><td colspan="-1">b</td></tr></table>`,
+			want:  [][]string{{"a", "b"}},
+		},
+		{
+			name:  "ragged rows",
+			table: `<table><tr><td>a</td></tr><tr><td>b</td><td>c</td><td>d</td></tr></table>`,
+			want:  [][]string{{"a", "", ""}, {"b", "c", "d"}},
+		},
+		{
+			name:  "nested table",
+			table: `<table><tr><td>outer <table><tr><td>inner</td></tr></table></td><td>2</td></tr></table>`,
+			want:  [][]string{{"outer", "2"}},
+		},
+		{
+			name:  "tfoot",
+			table: `<table><tbody><tr><td>1</td></tr></tbody><tfoot><tr><td>total</td></tr></tfoot></table>`,
+			want:  [][]string{{"1"}, {"total"}},
+		},
+		{
+			name:  "caption and markup",
+			table: `<table><caption>Cap</caption><tr><td><a href="/">link</a> <b>text</b></td></tr></table>`,
+			want:  [][]string{{"link text"}},
+		},
+		{
+			name:  "empty",
+			table: `<table></table>`,
+			want:  [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := GetFirstHtmlNode(mustParse(t, tt.table), "table", "", "")
+
+			got, err := TableToRows(table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableToRowsNotATable(t *testing.T) {
+	doc := mustParse(t, `<div><table><tr><td>1</td></tr></table></div>`)
+
+	for _, n := range []*html.Node{nil, doc, GetFirstHtmlNode(doc, "div", "", ""), GetFirstHtmlNode(doc, "td", "", "")} {
+		if _, err := TableToRows(n); err == nil {
+			t.Errorf("no error for %s", describeHtmlNode(n))
+		}
+		if _, err := TableToRecords(n); err == nil {
+			t.Errorf("TableToRecords: no error for %s", describeHtmlNode(n))
+		}
+	}
+}
+
+func TestTableToRecords(t *testing.T) {
+	table := GetFirstHtmlNode(mustParse(t, `<table>`+
+		`<tr><th>Name</th><th>City</th></tr>`+
+		`<tr><td>Ann</td><td>Oslo</td></tr>`+
+		`<tr><td colspan="2">Bob</td></tr>`+
+		`<tr><td>Cy</td></tr>`+
+		`</table>`), "table", "", "")
+
+	got, err := TableToRecords(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]string{
+		{"Name": "Ann", "City": "Oslo"},
+		{"Name": "Bob", "City": "Bob"},
+		{"Name": "Cy", "City": ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTableToRecordsHeaderOnly(t *testing.T) {
+	table := GetFirstHtmlNode(mustParse(t, `<table><tr><th>A</th></tr></table>`), "table", "", "")
+
+	got, err := TableToRecords(table)
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v; want no records", got, err)
+	}
+}
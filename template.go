@@ -0,0 +1,53 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// GetTemplateContent returns the node holding the content of the provided
+// template element, or nil if tpl is not a template element.
+//
+// In the DOM, the content of a template element is a separate document
+// fragment. The parser of golang.org/x/net/html keeps it as the children of
+// the template element instead, so the returned node is tpl itself: its
+// children are the content, and rendering them with
+// HtmlNodeChildrenToString() gives the markup of the template. Content that
+// the parser would relocate elsewhere, such as rows inside a template within
+// a table, is kept inside the template.
+//
+// Since the content is part of the tree, searches such as GetHtmlNodes()
+// find nodes inside templates unless MatchOptions.SkipTemplateContent is
+// set. The text and audit functions of this package always skip it, as it
+// isn't rendered.
+func GetTemplateContent(tpl *html.Node) *html.Node {
+	if !isTemplate(tpl) {
+		return nil
+	}
+	return tpl
+}
+
+// IsInTemplateContent reports whether the provided node is inside the content
+// of a template element.
+func IsInTemplateContent(n *html.Node) bool {
+	if n == nil {
+		return false
+	}
+
+	for p := n.Parent; p != nil; p = p.Parent {
+		if isTemplate(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderedHtmlNodesFunc is like GetAllHtmlNodesFunc() but skips the content of
+// template elements.
+func renderedHtmlNodesFunc(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	return findHtmlNodesFunc(n, match, -1, true)
+}
+
+// isTemplate reports whether n is a template element.
+func isTemplate(n *html.Node) bool {
+	return isHtmlElement(n, "template")
+}
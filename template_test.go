@@ -0,0 +1,168 @@
+package htmlutil
+
+import (
+	"regexp"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// templateDoc has templates nested inside tables, whose rows and cells the
+// parser keeps inside the templates rather than relocating them.
+const templateDoc = `<!DOCTYPE html><html><head><title>T</title><template id="head"><title>in head</title></template></head><body>` +
+	`<table id="t"><template id="rows"><tr><td>row template</td></tr></template>` +
+	`<tr><td>visible<template id="cell"><td>cell template</td><img src="x.png"></template></td></tr></table>` +
+	`<template id="outer"><p>outer<template id="inner"><b>inner</b></template></p><table><tr><td>table in template</td></tr></table></template>` +
+	`<p>after</p></body></html>`
+
+func TestGetTemplateContent(t *testing.T) {
+	doc := mustParse(t, templateDoc)
+
+	want := map[string]string{
+		"head":  `<title>in head</title>`,
+		"rows":  `<tr><td>row template</td></tr>`,
+		"cell":  `<td>cell template</td><img src="x.png"/>`,
+		"outer": `<p>outer<template id="inner"><b>inner</b></template></p><table><tbody><tr><td>table in template</td></tr></tbody></table>`,
+		"inner": `<b>inner</b>`,
+	}
+	for id, markup := range want {
+		tpl := GetFirstHtmlNode(doc, "template", "id", id)
+		content := GetTemplateContent(tpl)
+		if content == nil {
+			t.Errorf("%s: got no content", id)
+			continue
+		}
+		got, err := HtmlNodeChildrenToString(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != markup {
+			t.Errorf("%s: got  %s\nwant %s", id, got, markup)
+		}
+	}
+
+	for _, n := range []*html.Node{nil, doc, GetFirstHtmlNode(doc, "table", "", ""), NewText("template")} {
+		if got := GetTemplateContent(n); got != nil {
+			t.Errorf("%v: got %v, want nil", n, got)
+		}
+	}
+}
+
+func TestIsInTemplateContent(t *testing.T) {
+	doc := mustParse(t, templateDoc)
+
+	tests := []struct {
+		n    *html.Node
+		want bool
+	}{
+		{GetFirstHtmlNode(doc, "template", "id", "rows"), false},
+		{GetFirstHtmlNode(doc, "template", "id", "inner"), true},
+		{GetFirstHtmlNode(doc, "tr", "", ""), true},
+		{GetFirstHtmlNode(doc, "b", "", ""), true},
+		{GetFirstHtmlNode(doc, "img", "", ""), true},
+		{GetFirstHtmlNode(doc, "table", "id", "t"), false},
+		{GetFirstHtmlNode(doc, "p", "", ""), true},
+		{GetFirstHtmlNodeFunc(doc, func(n *html.Node) bool { return n.Type == html.TextNode && n.Data == "after" }), false},
+		{nil, false},
+	}
+
+	for i, tt := range tests {
+		if got := IsInTemplateContent(tt.n); got != tt.want {
+			t.Errorf("%d: got %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestSkipTemplateContent(t *testing.T) {
+	doc := mustParse(t, templateDoc)
+
+	tests := []struct {
+		tag  string
+		all  int
+		skip int
+	}{
+		{"td", 4, 1},
+		{"tr", 3, 1},
+		{"template", 5, 4},
+		{"title", 2, 1},
+		{"img", 1, 0},
+		{"p", 2, 1},
+	}
+
+	for _, tt := range tests {
+		if got := len(GetAllHtmlNodes(doc, tt.tag, "", "")); got != tt.all {
+			t.Errorf("%s: got %d nodes, want %d", tt.tag, got, tt.all)
+		}
+		got := GetHtmlNodesWithOptions(doc, tt.tag, "", "", -1, MatchOptions{SkipTemplateContent: true})
+		if len(got) != tt.skip {
+			t.Errorf("%s: got %d nodes with template content skipped, want %d", tt.tag, len(got), tt.skip)
+		}
+		for _, n := range got {
+			if IsInTemplateContent(n) {
+				t.Errorf("%s: got a node in template content", tt.tag)
+			}
+		}
+	}
+
+	// A template provided as the root is searched
+	tpl := GetFirstHtmlNode(doc, "template", "id", "rows")
+	if got := GetHtmlNodesWithOptions(tpl, "td", "", "", -1, MatchOptions{SkipTemplateContent: true}); len(got) != 1 {
+		t.Errorf("got %d nodes in the root template, want 1", len(got))
+	}
+}
+
+func TestTextSkipsTemplateContent(t *testing.T) {
+	doc := mustParse(t, templateDoc)
+	body := GetFirstHtmlNode(doc, "body", "", "")
+
+	if got := GetText(body); got != "visibleafter" {
+		t.Errorf("GetText: got %q", got)
+	}
+	if got := GetNormalizedText(body); got != "visibleafter" {
+		t.Errorf("GetNormalizedText: got %q", got)
+	}
+	if got := GetTextWithOptions(body, TextOptions{}); got != "visibleafter" {
+		t.Errorf("GetTextWithOptions: got %q", got)
+	}
+	if got := NodeToText(doc, TextOptions{}); got != "visible\nafter" {
+		t.Errorf("NodeToText: got %q", got)
+	}
+
+	// Like other searches, the regexp search looks into template content, but
+	// the text of the templates isn't part of the text of their ancestors
+	for _, re := range []string{"template", "inner", "row"} {
+		got := GetHtmlNodesByTextRegexp(doc, "", regexp.MustCompile(re), -1)
+		if len(got) == 0 {
+			t.Errorf("GetHtmlNodesByTextRegexp %q: got no nodes", re)
+		}
+		for _, n := range got {
+			if !IsInTemplateContent(n) {
+				t.Errorf("GetHtmlNodesByTextRegexp %q: got %s outside template content", re, n.Data)
+			}
+		}
+	}
+	if got := GetHtmlNodesByTextRegexp(doc, "td", regexp.MustCompile("^visible$"), -1); len(got) != 1 {
+		t.Errorf("GetHtmlNodesByTextRegexp: got %d cells, want 1", len(got))
+	}
+
+	// The text of a template provided as the root is skipped as well
+	if got := GetText(GetFirstHtmlNode(doc, "template", "id", "rows")); got != "" {
+		t.Errorf("GetText of a template: got %q", got)
+	}
+}
+
+func TestAuditsSkipTemplateContent(t *testing.T) {
+	doc := mustParse(t, templateDoc)
+
+	if got := ImagesMissingAlt(doc); len(got) != 0 {
+		t.Errorf("ImagesMissingAlt: got %d images", len(got))
+	}
+	if got := TablesMissingHeaders(doc); len(got) != 1 || GetAttrOr(got[0], "id", "") != "t" {
+		t.Errorf("TablesMissingHeaders: got %v", nodeNames(got))
+	}
+	for _, issue := range AuditSEO(doc, nil) {
+		if issue.Rule == SEOTitleDuplicate || issue.Rule == SEOImageAlt {
+			t.Errorf("AuditSEO: got %+v", issue)
+		}
+	}
+}
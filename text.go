@@ -0,0 +1,137 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GetText returns the concatenated data of all text nodes within the provided
+// node, in document order. Comment nodes and the content of template
+// elements, which isn't rendered, are not included.
+func GetText(n *html.Node) string {
+	var sb strings.Builder
+
+	Walk(n, func(n *html.Node) WalkAction {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case isTemplate(n):
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+
+	return sb.String()
+}
+
+// GetTextWithOptions is like GetText() but skips hidden content if
+// opts.SkipHidden is set. Other options only apply to NodeToText().
+func GetTextWithOptions(n *html.Node, opts TextOptions) string {
+	if !opts.SkipHidden {
+		return GetText(n)
+	}
+	if IsHiddenNode(n) {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	Walk(n, func(n *html.Node) WalkAction {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case isHiddenElement(n), isTemplate(n):
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+
+	return sb.String()
+}
+
+// GetNormalizedText returns the text within the provided node with runs of
+// whitespace collapsed to a single space and leading and trailing whitespace
+// removed. The contents of script, style, and template elements are skipped.
+//
+// Only ASCII whitespace is collapsed. Non-breaking spaces (U+00A0), which the
+// parser decodes from &nbsp;, are preserved as is.
+func GetNormalizedText(n *html.Node) string {
+	t := textNormalizer{}
+	t.writeNode(n)
+	return t.sb.String()
+}
+
+// textNormalizer accumulates text with whitespace collapsed as it is written.
+// If skip is set, the elements for which it returns true are skipped along
+// with their descendants.
+type textNormalizer struct {
+	sb           strings.Builder
+	pendingSpace bool
+	skip         func(*html.Node) bool
+}
+
+func (t *textNormalizer) writeNode(root *html.Node) {
+	Walk(root, func(n *html.Node) WalkAction {
+		switch n.Type {
+		case html.TextNode:
+			t.writeString(n.Data)
+		case html.ElementNode:
+			if isNonRenderedTextElement(n) || (t.skip != nil && t.skip(n)) {
+				return WalkSkipChildren
+			}
+		case html.CommentNode, html.DoctypeNode:
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+}
+
+func (t *textNormalizer) writeString(s string) {
+	for _, r := range s {
+		if isHtmlSpace(r) {
+			t.pendingSpace = true
+			continue
+		}
+
+		// Leading whitespace is dropped, trailing whitespace is never flushed
+		if t.pendingSpace && t.sb.Len() > 0 {
+			t.sb.WriteByte(' ')
+		}
+		t.pendingSpace = false
+		t.sb.WriteRune(r)
+	}
+}
+
+// isNonRenderedTextElement reports whether the text content of n is never
+// rendered as visible text.
+func isNonRenderedTextElement(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	switch n.Data {
+	case "script", "style", "template":
+		return true
+	}
+	return false
+}
+
+// blockElementTags lists the elements that start a new block of content when
+// converting HTML to text or Markdown.
+var blockElementTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"body": true, "dd": true, "details": true, "dialog": true, "div": true,
+	"dl": true, "dt": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hgroup": true, "hr": true, "html": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"summary": true, "table": true, "ul": true,
+}
+
+// isBlockElement reports whether n is an element that starts a new block of
+// content.
+func isBlockElement(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.Namespace == "" && blockElementTags[n.Data]
+}
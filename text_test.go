@@ -0,0 +1,105 @@
+package htmlutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largePage returns a synthetic article page in the style of a Wikipedia
+// article, with the provided number of sections of headings, paragraphs with
+// inline markup and links, lists, tables, and scripts.
+func largePage(sections int) string {
+	var sb strings.Builder
+
+	sb.WriteString(`<!DOCTYPE html><html lang="en"><head><meta charset="utf-8">`)
+	sb.WriteString(`<title>Large page</title><link rel="canonical" href="https://example.com/wiki/Large">`)
+	sb.WriteString(`<meta name="description" content="A large page.">`)
+	sb.WriteString(`<style>body { margin: 0 }</style><script>var config = {};</script></head>`)
+	sb.WriteString(`<body><div id="content" class="mw-body"><h1 id="title">Large page</h1>`)
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&sb, `<div class="section" id="s%d"><h2><span class="mw-headline">Section %d</span></h2>`, i, i)
+		for j := 0; j < 3; j++ {
+			fmt.Fprintf(&sb, `<p>Paragraph %d of section %d has <b>bold</b>, <i>italic</i> and `, j, i)
+			fmt.Fprintf(&sb, `<a href="/wiki/Link_%d_%d" title="Link">linked</a> text&nbsp;with an entity.`, i, j)
+			sb.WriteString(`<sup class="reference"><a href="#cite">[1]</a></sup></p>`)
+		}
+		fmt.Fprintf(&sb, `<ul><li>Item one</li><li>Item <a href="/wiki/Two_%d">two</a></li><li>Item three</li></ul>`, i)
+		sb.WriteString(`<table class="wikitable"><tr><th>Key</th><th>Value</th></tr>`)
+		fmt.Fprintf(&sb, `<tr><td>Alpha</td><td>%d</td></tr><tr><td>Beta</td><td>%d</td></tr></table>`, i, i*2)
+		fmt.Fprintf(&sb, `<img src="/img/%d.png" alt="Figure %d" width="200"><!-- comment %d -->`, i, i, i)
+		sb.WriteString(`<script>track("section");</script></div>`)
+	}
+	sb.WriteString(`</div><div id="footer"><p>Footer</p></div></body></html>`)
+
+	return sb.String()
+}
+
+func TestGetText(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"nested inline", `<p>a <b>b <i>c</i></b> d</p>`, "a b c d"},
+		{"comments", `<p>a<!-- hidden -->b</p>`, "ab"},
+		{"nbsp", `<p>a&nbsp;b</p>`, "a b"},
+		{"whitespace kept", "<p> a\n\tb </p>", " a\n\tb "},
+		{"template skipped", `<p>a</p><template><p>b</p></template>`, "a"},
+		{"script kept", `<p>a</p><script>b</script>`, "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := GetFirstHtmlNode(mustParse(t, tt.doc), "body", "", "")
+			if got := GetText(body); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNormalizedText(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"nested inline", `<p>a <b>b <i>c</i></b> d</p>`, "a b c d"},
+		{"inline without spaces", `<p>a<b>b</b>c</p>`, "abc"},
+		{"collapse and trim", "<p>\n  a \t\n b  </p>  <p> c </p>", "a b c"},
+		{"comments", `<p>a <!-- hidden --> b</p>`, "a b"},
+		{"nbsp preserved", `<p> a&nbsp;&nbsp;b </p>`, "a  b"},
+		{"script, style, and template skipped", `<p>a</p> <script>x()</script><style>p{}</style><template>t</template><p>b</p>`, "a b"},
+		{"empty", `<p> </p>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := GetFirstHtmlNode(mustParse(t, tt.doc), "body", "", "")
+			if got := GetNormalizedText(body); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkGetText(b *testing.B) {
+	doc := mustParse(b, largePage(500))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		GetText(doc)
+	}
+}
+
+func BenchmarkGetNormalizedText(b *testing.B) {
+	doc := mustParse(b, largePage(500))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		GetNormalizedText(doc)
+	}
+}
@@ -0,0 +1,173 @@
+package htmlutil
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultTrackerHosts lists the domains of common analytics and advertising
+// trackers, used by StripTracking() when TrackingOptions.Hosts is nil.
+var DefaultTrackerHosts = []string{
+	"analytics.twitter.com", "bat.bing.com", "cdn.segment.com", "clarity.ms",
+	"connect.facebook.net", "doubleclick.net", "facebook.com",
+	"google-analytics.com", "googletagmanager.com", "hotjar.com",
+	"matomo.cloud", "mixpanel.com", "pixel.wp.com", "px.ads.linkedin.com",
+	"quantserve.com", "scorecardresearch.com", "segment.io", "snap.licdn.com",
+	"static.ads-twitter.com", "stats.wp.com",
+}
+
+// DefaultTrackingScriptPatterns lists the snippets identifying the inline
+// scripts of common analytics providers, used by StripTracking() when
+// TrackingOptions.ScriptPatterns is nil.
+var DefaultTrackingScriptPatterns = []string{
+	"google-analytics.com/", "googletagmanager.com/", "gtag(", "ga('create'",
+	"fbq(", "_paq.push(", "hotjar.com", "_hjSettings", "mixpanel.init(",
+	"analytics.load(", "window.clarity", "_qevents", "_comscore",
+}
+
+// TrackingOptions controls what StripTracking() removes.
+type TrackingOptions struct {
+	// Hosts lists the tracker domains. A URL matches a domain if its host is
+	// the domain or one of its subdomains, so "google-analytics.com" matches
+	// "www.google-analytics.com" but not "notgoogle-analytics.com". If nil,
+	// DefaultTrackerHosts is used. To extend the defaults, append to a copy of
+	// them.
+	Hosts []string
+
+	// ScriptPatterns lists snippets whose presence in the content of an
+	// inline script identifies it as a tracker. If nil,
+	// DefaultTrackingScriptPatterns is used.
+	ScriptPatterns []string
+
+	// Aggressive removes 1x1 images from any host, not only from tracker
+	// hosts.
+	Aggressive bool
+}
+
+// StripTracking is like StripTrackingNodes() but returns the number of nodes
+// removed.
+func StripTracking(doc *html.Node, opts TrackingOptions) int {
+	return len(StripTrackingNodes(doc, opts))
+}
+
+// StripTrackingNodes removes tracking pixels and analytics scripts from the
+// provided document, and returns the removed nodes in document order so that
+// they can be audited. The removed nodes are:
+//
+//   - img elements whose width and height attributes are both 0 or 1, and
+//     whose src is on a tracker host, or on any host in aggressive mode
+//   - script elements whose src is on a tracker host, or whose content
+//     contains one of the script patterns
+//   - noscript elements whose content consists only of such images, as used
+//     for pixel fallbacks
+func StripTrackingNodes(doc *html.Node, opts TrackingOptions) []*html.Node {
+	if opts.Hosts == nil {
+		opts.Hosts = DefaultTrackerHosts
+	}
+	if opts.ScriptPatterns == nil {
+		opts.ScriptPatterns = DefaultTrackingScriptPatterns
+	}
+
+	var removed []*html.Node
+	Walk(doc, func(n *html.Node) WalkAction {
+		if n != doc && isTrackingNode(n, opts) {
+			removed = append(removed, n)
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+
+	RemoveNodes(removed)
+	return removed
+}
+
+// isTrackingNode reports whether n is an element removed by
+// StripTrackingNodes().
+func isTrackingNode(n *html.Node, opts TrackingOptions) bool {
+	switch {
+	case isHtmlElement(n, "img"):
+		return isTrackingPixel(n, opts)
+	case isHtmlElement(n, "script"):
+		if src, ok := GetAttr(n, "src"); ok {
+			return isTrackerURL(src, opts.Hosts)
+		}
+		text := GetText(n)
+		for _, pattern := range opts.ScriptPatterns {
+			if pattern != "" && strings.Contains(text, pattern) {
+				return true
+			}
+		}
+	case isHtmlElement(n, "noscript"):
+		return isPixelFallback(n, opts)
+	}
+	return false
+}
+
+// isTrackingPixel reports whether img is a 1x1 image removed as specified by
+// opts.
+func isTrackingPixel(img *html.Node, opts TrackingOptions) bool {
+	for _, key := range []string{"width", "height"} {
+		switch strings.TrimSpace(strings.TrimSuffix(GetAttrOr(img, key, ""), "px")) {
+		case "0", "1":
+		default:
+			return false
+		}
+	}
+
+	return opts.Aggressive || isTrackerURL(GetAttrOr(img, "src", ""), opts.Hosts)
+}
+
+// isPixelFallback reports whether a noscript element contains tracking
+// pixels and nothing else but whitespace.
+func isPixelFallback(noscript *html.Node, opts TrackingOptions) bool {
+	nodes, err := noscriptContent(noscript)
+	if err != nil {
+		return false
+	}
+
+	pixels := 0
+	for _, n := range nodes {
+		pixel := true
+		Walk(n, func(c *html.Node) WalkAction {
+			switch {
+			case c.Type == html.TextNode && strings.TrimFunc(c.Data, isHtmlSpace) != "":
+				pixel = false
+			case c.Type == html.ElementNode && isHtmlElement(c, "img"):
+				if !isTrackingPixel(c, opts) {
+					pixel = false
+				}
+				pixels++
+			}
+			return WalkContinue
+		})
+		if !pixel {
+			return false
+		}
+	}
+
+	return pixels > 0
+}
+
+// isTrackerURL reports whether the host of the provided URL is one of the
+// provided domains or a subdomain of one. Relative URLs never match.
+func isTrackerURL(rawURL string, hosts []string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return false
+	}
+
+	for _, domain := range hosts {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
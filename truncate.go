@@ -0,0 +1,207 @@
+package htmlutil
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// TruncateOptions controls the output of TruncateHtml().
+type TruncateOptions struct {
+	// MaxWords and MaxRunes are the budgets of visible text. The text is cut
+	// before the first word exceeding either of them. Runes are counted with
+	// whitespace between words counted as a single space. If 0, the budget
+	// is not limited.
+	MaxWords int
+	MaxRunes int
+
+	// BlockSlack is the amount of text that may be dropped to cut at the
+	// start of a block element, such as a paragraph, rather than in the
+	// middle of it. It is measured in words if MaxWords is set, and in runes
+	// otherwise.
+	BlockSlack int
+
+	// Ellipsis is appended to the last text kept when the content is
+	// truncated, for example "…".
+	Ellipsis string
+
+	// ReadMoreURL, if not empty, adds a link to it at the end of the
+	// truncated content, with ReadMoreText as its text, or "Read more" if
+	// ReadMoreText is empty.
+	ReadMoreURL  string
+	ReadMoreText string
+}
+
+// TruncateHtml returns a detached clone of the provided node holding only as
+// much of its content as fits the budgets of the provided options, and
+// reports whether the content was truncated. The provided node is left
+// untouched.
+//
+// Only visible text is counted: the content of script, style, and template
+// elements is skipped, as are attributes such as alt. Words are never split,
+// and the elements that are kept are closed properly since the result is a
+// tree. Void elements such as img count as no words and are kept if they
+// appear before the cut.
+func TruncateHtml(n *html.Node, opts TruncateOptions) (*html.Node, bool) {
+	clone := CloneHtmlNode(n)
+	if clone == nil || (opts.MaxWords <= 0 && opts.MaxRunes <= 0) {
+		return clone, false
+	}
+
+	t := truncator{opts: opts}
+	Walk(clone, t.visit)
+	if t.cut == nil {
+		return clone, false
+	}
+
+	// Cut at the start of the last block if it doesn't lose too much text
+	if t.block != nil && t.lost() <= opts.BlockSlack {
+		t.cut, t.keepCut = t.block, false
+	}
+	removeFollowing(t.cut, clone, t.keepCut)
+
+	if opts.Ellipsis != "" {
+		if last := lastVisibleText(clone); last != nil {
+			last.Data = strings.TrimRightFunc(last.Data, isHtmlSpace) + opts.Ellipsis
+		}
+	}
+
+	if opts.ReadMoreURL != "" {
+		text := opts.ReadMoreText
+		if text == "" {
+			text = "Read more"
+		}
+
+		container := clone
+		if clone.Type == html.DocumentNode {
+			if body, err := FindFirstHtmlNode(clone, "body", "", ""); err == nil {
+				container = body
+			}
+		}
+		container.AppendChild(NewElement("a", map[string]string{"href": opts.ReadMoreURL}, NewText(text)))
+	}
+
+	return clone, true
+}
+
+// truncator finds where TruncateHtml() cuts the content.
+type truncator struct {
+	opts TruncateOptions
+
+	words int
+	runes int
+
+	// cut is the node at which the content is cut, and keepCut reports
+	// whether the node itself is kept, which is the case for text nodes cut
+	// after their first words.
+	cut     *html.Node
+	keepCut bool
+
+	// block is the last block element started before the cut, with the
+	// counts at its start.
+	block      *html.Node
+	blockWords int
+	blockRunes int
+}
+
+func (t *truncator) visit(n *html.Node) WalkAction {
+	switch n.Type {
+	case html.ElementNode:
+		if isNonRenderedTextElement(n) {
+			return WalkSkipChildren
+		}
+		if isBlockElement(n) && (t.words > 0 || t.runes > 0) {
+			t.block, t.blockWords, t.blockRunes = n, t.words, t.runes
+		}
+	case html.TextNode:
+		if t.truncateText(n) {
+			return WalkStop
+		}
+	}
+	return WalkContinue
+}
+
+// truncateText counts the words of a text node, and cuts it before the first
+// word exceeding the budgets. It reports whether the node was cut.
+func (t *truncator) truncateText(n *html.Node) bool {
+	s := n.Data
+	offset := 0
+
+	for {
+		start := strings.IndexFunc(s[offset:], func(r rune) bool { return !isHtmlSpace(r) })
+		if start < 0 {
+			return false
+		}
+		start += offset
+
+		end := strings.IndexFunc(s[start:], isHtmlSpace)
+		if end < 0 {
+			end = len(s)
+		} else {
+			end += start
+		}
+
+		runes := utf8.RuneCountInString(s[start:end])
+		if t.runes > 0 {
+			runes++
+		}
+
+		if (t.opts.MaxWords > 0 && t.words+1 > t.opts.MaxWords) || (t.opts.MaxRunes > 0 && t.runes+runes > t.opts.MaxRunes) {
+			n.Data = strings.TrimRightFunc(s[:start], isHtmlSpace)
+			t.cut, t.keepCut = n, n.Data != ""
+			return true
+		}
+
+		t.words++
+		t.runes += runes
+		offset = end
+	}
+}
+
+// lost returns the amount of text between the start of the last block and
+// the cut, in the unit of BlockSlack.
+func (t *truncator) lost() int {
+	if t.opts.MaxWords > 0 {
+		return t.words - t.blockWords
+	}
+	return t.runes - t.blockRunes
+}
+
+// removeFollowing removes the nodes following n in document order within
+// root, as well as n itself unless keep is true. The ancestors of n are kept,
+// unless removing n leaves them empty.
+func removeFollowing(n *html.Node, root *html.Node, keep bool) {
+	for c := n; c != nil && c != root; c = c.Parent {
+		for next := c.NextSibling; next != nil; next = c.NextSibling {
+			c.Parent.RemoveChild(next)
+		}
+	}
+	if keep || n == root {
+		return
+	}
+
+	parent := n.Parent
+	parent.RemoveChild(n)
+	for parent != root && parent.FirstChild == nil && parent.Type == html.ElementNode {
+		empty := parent
+		parent = parent.Parent
+		parent.RemoveChild(empty)
+	}
+}
+
+// lastVisibleText returns the last text node within n with non-whitespace
+// text that is not inside a script, style, or template element.
+func lastVisibleText(n *html.Node) *html.Node {
+	var last *html.Node
+	Walk(n, func(n *html.Node) WalkAction {
+		if isNonRenderedTextElement(n) {
+			return WalkSkipChildren
+		}
+		if n.Type == html.TextNode && strings.TrimFunc(n.Data, isHtmlSpace) != "" {
+			last = n
+		}
+		return WalkContinue
+	})
+	return last
+}
@@ -0,0 +1,102 @@
+package htmlutil
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// AbsolutizeURLs rewrites the relative URLs in the href, src, srcset, poster,
+// action, and data-src attributes within the provided node to absolute URLs
+// resolved against base, or against the href of the document's <base> element
+// if there is one. Each candidate of a srcset attribute is resolved on its own
+// and its descriptors are kept.
+//
+// Fragment-only URLs such as "#top" are left untouched, as are data:,
+// mailto:, and javascript: URLs. URLs that cannot be parsed are left as they
+// are, and the parse errors are returned joined together once the whole tree
+// has been processed.
+func AbsolutizeURLs(n *html.Node, base *url.URL) error {
+	var errs []error
+
+	base, err := documentBaseURL(n, base)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if base == nil {
+		return errors.Join(errs...)
+	}
+
+	RewriteURLs(n, func(tag string, attr string, raw string) (string, bool) {
+		ref := strings.TrimSpace(raw)
+		if ref == "" || strings.HasPrefix(ref, "#") {
+			return raw, false
+		}
+		for _, scheme := range []string{"data", "mailto", "javascript"} {
+			if urlSchemeIs(ref, scheme) {
+				return raw, false
+			}
+		}
+
+		u, err := resolveURL(base, ref)
+		if err != nil {
+			errs = append(errs, err)
+			return raw, false
+		}
+		return u.String(), true
+	})
+
+	return errors.Join(errs...)
+}
+
+// RewriteURLs calls rewrite for each URL held by an href, src, srcset, poster,
+// action, or data-src attribute of the elements within the provided node,
+// passing the tag of the element, the key of the attribute, and the URL. When
+// rewrite returns true, the URL is replaced with the returned value, and when
+// it returns false, the URL is left unchanged. It returns the number of
+// attributes rewritten.
+//
+// Each candidate of a srcset attribute is passed separately, and the attribute
+// is recomposed with the descriptors of the candidates, such as "2x" or
+// "640w", preserved.
+func RewriteURLs(n *html.Node, rewrite func(tag string, attr string, url string) (string, bool)) int {
+	rewritten := 0
+
+	Walk(n, func(n *html.Node) WalkAction {
+		if n.Type != html.ElementNode {
+			return WalkContinue
+		}
+
+		for i, a := range n.Attr {
+			if a.Namespace != "" {
+				continue
+			}
+
+			switch a.Key {
+			case "href", "src", "poster", "action", "data-src":
+				if val, ok := rewrite(n.Data, a.Key, a.Val); ok && val != a.Val {
+					n.Attr[i].Val = val
+					rewritten++
+				}
+			case "srcset":
+				changed := false
+				candidates := splitSrcset(a.Val)
+				for j, c := range candidates {
+					if val, ok := rewrite(n.Data, a.Key, c.URL); ok && val != c.URL {
+						candidates[j].URL = val
+						changed = true
+					}
+				}
+				if changed {
+					n.Attr[i].Val = candidates.String()
+					rewritten++
+				}
+			}
+		}
+		return WalkContinue
+	})
+
+	return rewritten
+}
@@ -0,0 +1,168 @@
+package htmlutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const urlsFixture = `<html><head><base href="/blog/"><link id="css" href="style.css"></head><body>` +
+	`<a id="rel" href="post.html">post</a>` +
+	`<a id="up" href="../about">about</a>` +
+	`<a id="root" href="/contact">contact</a>` +
+	`<a id="abs" href="https://other.example/x">other</a>` +
+	`<a id="frag" href="#top">top</a>` +
+	`<a id="mail" href="mailto:ann@example.com">mail</a>` +
+	`<a id="js" href=" JavaScript:void(0)">js</a>` +
+	`<img id="img" src="a.png" srcset="a.png 1x, img/a@2x.png 2x, data:image/png;base64,AA 3x" data-src="lazy.png">` +
+	`<img id="data" src="data:image/gif;base64,R0lGOD">` +
+	`<video id="video" poster="poster.jpg" src="//cdn.example/v.mp4"></video>` +
+	`<form id="form" action="submit"></form>` +
+	`<a id="empty" href="">empty</a>` +
+	`</body></html>`
+
+func TestAbsolutizeURLs(t *testing.T) {
+	doc := mustParse(t, urlsFixture)
+	if err := AbsolutizeURLs(doc, mustParseURL(t, "https://example.com/index.html")); err != nil {
+		t.Fatalf("AbsolutizeURLs: %v", err)
+	}
+
+	tests := []struct {
+		id   string
+		attr string
+		want string
+	}{
+		{"css", "href", "https://example.com/blog/style.css"},
+		{"rel", "href", "https://example.com/blog/post.html"},
+		{"up", "href", "https://example.com/about"},
+		{"root", "href", "https://example.com/contact"},
+		{"abs", "href", "https://other.example/x"},
+		{"frag", "href", "#top"},
+		{"mail", "href", "mailto:ann@example.com"},
+		{"js", "href", " JavaScript:void(0)"},
+		{"img", "src", "https://example.com/blog/a.png"},
+		{"img", "srcset", "https://example.com/blog/a.png 1x, https://example.com/blog/img/a@2x.png 2x, data:image/png;base64,AA 3x"},
+		{"img", "data-src", "https://example.com/blog/lazy.png"},
+		{"data", "src", "data:image/gif;base64,R0lGOD"},
+		{"video", "poster", "https://example.com/blog/poster.jpg"},
+		{"video", "src", "https://cdn.example/v.mp4"},
+		{"form", "action", "https://example.com/blog/submit"},
+		{"empty", "href", ""},
+	}
+
+	for _, tt := range tests {
+		n := GetElementById(doc, tt.id)
+		if got := GetAttrOr(n, tt.attr, "<none>"); got != tt.want {
+			t.Errorf("%s %s: got %q, want %q", tt.id, tt.attr, got, tt.want)
+		}
+	}
+
+	if got := GetAttrOr(GetFirstHtmlNode(doc, "base", "", ""), "href", ""); got != "https://example.com/blog/" {
+		t.Errorf("base: got %q, want it resolved against the provided URL only", got)
+	}
+}
+
+func TestAbsolutizeURLsWithoutBase(t *testing.T) {
+	doc := mustParse(t, `<a href="b/c">x</a>`)
+
+	if err := AbsolutizeURLs(doc, nil); err != nil {
+		t.Fatalf("nil base: %v", err)
+	}
+	if got := mustRender(t, GetFirstHtmlNode(doc, "a", "", "")); got != `<a href="b/c">x</a>` {
+		t.Errorf("nil base: got %s", got)
+	}
+
+	if err := AbsolutizeURLs(doc, mustParseURL(t, "http://example.com/a/")); err != nil {
+		t.Fatalf("AbsolutizeURLs: %v", err)
+	}
+	if got := GetAttrOr(GetFirstHtmlNode(doc, "a", "", ""), "href", ""); got != "http://example.com/a/b/c" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestAbsolutizeURLsSubtree(t *testing.T) {
+	doc := mustParse(t, `<head><base href="https://cdn.example/"></head><article><img src="x.png"></article><img id="out" src="y.png">`)
+	article := GetFirstHtmlNode(doc, "article", "", "")
+
+	if err := AbsolutizeURLs(article, mustParseURL(t, "https://example.com/")); err != nil {
+		t.Fatalf("AbsolutizeURLs: %v", err)
+	}
+	if got := GetAttrOr(GetFirstHtmlNode(article, "img", "", ""), "src", ""); got != "https://cdn.example/x.png" {
+		t.Errorf("got %q, want the document's base to be honored", got)
+	}
+	if got := GetAttrOr(GetElementById(doc, "out"), "src", ""); got != "y.png" {
+		t.Errorf("got %q, want elements outside the subtree unchanged", got)
+	}
+}
+
+func TestAbsolutizeURLsMalformed(t *testing.T) {
+	doc := mustParse(t, `<a id="bad1" href="http://[::1">x</a><a id="good1" href="a">a</a>`+
+		`<img id="bad2" src="%zz"><a id="good2" href="b">b</a>`)
+
+	err := AbsolutizeURLs(doc, mustParseURL(t, "https://example.com/"))
+	if err == nil {
+		t.Fatal("got no error")
+	}
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Errorf("got %v, want two joined errors", err)
+	}
+	for _, s := range []string{"htmlutil: invalid URL", "http://[::1", "%zz"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error %q does not mention %q", err, s)
+		}
+	}
+
+	for id, want := range map[string]string{
+		"bad1":  "http://[::1",
+		"bad2":  "%zz",
+		"good1": "https://example.com/a",
+		"good2": "https://example.com/b",
+	} {
+		n := GetElementById(doc, id)
+		attr := "href"
+		if n.Data == "img" {
+			attr = "src"
+		}
+		if got := GetAttrOr(n, attr, ""); got != want {
+			t.Errorf("%s: got %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestAbsolutizeURLsMalformedBase(t *testing.T) {
+	doc := mustParse(t, `<head><base href="http://[::1"></head><a href="a">a</a>`)
+
+	err := AbsolutizeURLs(doc, mustParseURL(t, "https://example.com/"))
+	if err == nil || !strings.Contains(err.Error(), "http://[::1") {
+		t.Errorf("got %v, want the base href error", err)
+	}
+	if got := GetAttrOr(GetFirstHtmlNode(doc, "a", "", ""), "href", ""); got != "https://example.com/a" {
+		t.Errorf("got %q, want the provided base to be used", got)
+	}
+}
+
+func TestRewriteURLs(t *testing.T) {
+	doc := mustParse(t, `<a href="a">a</a><img src="b" srcset="c 1x, d 2x"><svg><a xlink:href="e"></a></svg><p title="f"></p>`)
+
+	var seen []string
+	n := RewriteURLs(doc, func(tag, attr, url string) (string, bool) {
+		seen = append(seen, tag+" "+attr+" "+url)
+		if url == "d" {
+			return "D", true
+		}
+		return url + "!", url == "a"
+	})
+
+	if n != 2 {
+		t.Errorf("got %d attributes rewritten, want 2", n)
+	}
+	if got, want := strings.Join(seen, ","), "a href a,img src b,img srcset c,img srcset d"; got != want {
+		t.Errorf("got calls %q, want %q", got, want)
+	}
+	want := `<a href="a!">a</a><img src="b" srcset="c 1x, D 2x"/><svg><a xlink:href="e"></a></svg><p title="f"></p>`
+	if got := bodyHtml(t, doc); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
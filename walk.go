@@ -0,0 +1,76 @@
+package htmlutil
+
+import (
+	"golang.org/x/net/html"
+)
+
+// WalkAction tells Walk() how to proceed after visiting a node.
+type WalkAction int
+
+const (
+	// WalkContinue continues the walk with the children of the current node.
+	WalkContinue WalkAction = iota
+
+	// WalkSkipChildren continues the walk, skipping the descendants of the
+	// current node.
+	WalkSkipChildren
+
+	// WalkStop ends the walk.
+	WalkStop
+)
+
+// Walk calls fn for the provided node and each of its descendants in document
+// order (a pre-order traversal). The return value of fn controls whether the
+// walk descends into the children of the current node, skips them, or stops.
+// Walk reports whether the walk ran to completion without being stopped.
+//
+// The next sibling of a node is taken before fn is called for it, and its
+// children after, so fn may safely remove the current node or replace its
+// children. The walk is iterative rather than recursive, so arbitrarily deep
+// trees can't overflow the stack.
+func Walk(n *html.Node, fn func(*html.Node) WalkAction) bool {
+	return WalkWithDepth(n, func(n *html.Node, depth int) WalkAction {
+		return fn(n)
+	})
+}
+
+// WalkWithDepth is like Walk() but also passes fn the depth of the current
+// node relative to the provided node, which has a depth of 0.
+func WalkWithDepth(n *html.Node, fn func(n *html.Node, depth int) WalkAction) bool {
+	if n == nil {
+		return true
+	}
+
+	// Each frame holds the next node to visit at a given depth, so the stack
+	// never grows beyond the depth of the tree
+	type frame struct {
+		node  *html.Node
+		depth int
+	}
+	stack := []frame{{n, 0}}
+	root := n
+
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		var next *html.Node
+		if f.node != root {
+			next = f.node.NextSibling
+		}
+
+		action := fn(f.node, f.depth)
+		if action == WalkStop {
+			return false
+		}
+
+		if next != nil {
+			stack = append(stack, frame{next, f.depth})
+		}
+		if action != WalkSkipChildren && f.node.FirstChild != nil {
+			stack = append(stack, frame{f.node.FirstChild, f.depth + 1})
+		}
+	}
+
+	return true
+}